@@ -199,7 +199,7 @@ func run(ctx context.Context, configFilePath string) {
 		}
 	}
 
-	db, err := mysql.OpenImportTunedDB(*config.Database)
+	db, err := mysql.OpenImportTunedDB(*config.Database, mysql.PoolConf{}, 0)
 	if err != nil {
 		log.Error().Err(err).Msg("Error opening database connection")
 		return
@@ -59,7 +59,7 @@ func runIjpImport(args importArgs) {
 	defer stop()
 
 	conf := cnf.LoadConfig(args.configPath)
-	db, err := mysql.OpenDB(*conf.LiveAttrs.DB)
+	db, err := mysql.OpenDB(*conf.LiveAttrs.DB, conf.LiveAttrs.DBPool)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to import data")
 	}
@@ -103,7 +103,7 @@ func runAsscImport(args importArgs) {
 	defer stop()
 
 	conf := cnf.LoadConfig(args.configPath)
-	db, err := mysql.OpenDB(*conf.LiveAttrs.DB)
+	db, err := mysql.OpenDB(*conf.LiveAttrs.DB, conf.LiveAttrs.DBPool)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to import data")
 	}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/gob"
 	"flag"
 	"fmt"
@@ -25,6 +26,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -38,6 +40,7 @@ import (
 
 	"frodo/cnf"
 	"frodo/db/mysql"
+	"frodo/db/tables"
 	"frodo/debug"
 	dictActions "frodo/dictionary/actions"
 	"frodo/docs"
@@ -46,10 +49,13 @@ import (
 	"frodo/keywords"
 	"frodo/liveattrs"
 	laActions "frodo/liveattrs/actions"
+	laDb "frodo/liveattrs/db"
 	"frodo/liveattrs/db/freqdb"
 	"frodo/liveattrs/laconf"
+	"frodo/liveattrs/request/response"
 	"frodo/ltsearch"
 	"frodo/metadb"
+	"frodo/middleware"
 	"frodo/root"
 	"frodo/ujc/lex"
 	"frodo/ujc/ssjc"
@@ -66,6 +72,11 @@ var (
 func init() {
 	gob.Register(&liveattrs.LiveAttrsJobInfo{})
 	gob.Register(&freqdb.NgramJobInfo{})
+	gob.Register(&laDb.IntegrityJobInfo{})
+	gob.Register(&laDb.ReindexJobInfo{})
+	gob.Register(jobs.PersistedError{})
+	gob.Register([]*response.ListedValue{})
+	gob.Register(response.SummarizedValue{})
 }
 
 // @title           FRODO - Frequency Registry Of Dictionary Objects
@@ -79,6 +90,45 @@ func init() {
 
 // @externalDocs.description  OpenAPI
 // @externalDocs.url          https://swagger.io/resources/open-api/
+// reloadLiveAttrsDBConfOnSIGHUP re-reads the liveattrs database
+// configuration from the main config file each time the process
+// receives SIGHUP (e.g. after a credentials rotation) and applies
+// it to all the liveattrs build configurations.
+func reloadLiveAttrsDBConfOnSIGHUP(
+	ctx context.Context,
+	confPath string,
+	laConfRegistry *laconf.LiveAttrsBuildConfProvider,
+) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigs:
+			newConf, err := cnf.ReadConfig(confPath)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("path", confPath).
+					Msg("failed to reload liveattrs database configuration, keeping the current one")
+				continue
+			}
+			if newConf.LiveAttrs == nil || newConf.LiveAttrs.DB == nil {
+				log.Error().
+					Str("path", confPath).
+					Msg("reloaded configuration contains no liveattrs database, keeping the current one")
+				continue
+			}
+			laConfRegistry.SetGlobalDBConf(newConf.LiveAttrs.DB)
+			log.Info().
+				Str("path", confPath).
+				Msg("applied reloaded liveattrs database configuration")
+		}
+	}
+}
+
 func main() {
 	version := general.VersionInfo{
 		Version:   version,
@@ -86,9 +136,11 @@ func main() {
 		GitCommit: gitCommit,
 	}
 
+	jobArgs := flag.String("jobArgs", "", "runjob: path to a JSON file with job arguments (the same as the respective HTTP action accepts)")
+	jobQuery := flag.String("jobQuery", "", "runjob: URL query arguments of the respective HTTP action (e.g. append=1&ngramSize=2)")
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "FRODO - Frequency Registry of Dictionary Objects\n\nUsage:\n\t%s [options] start [config.json]\n\t%s [options] version\n",
-			filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "FRODO - Frequency Registry of Dictionary Objects\n\nUsage:\n\t%s [options] start [config.json]\n\t%s [options] runjob [config.json] [%s] [corpusId]\n\t%s [options] version\n",
+			filepath.Base(os.Args[0]), filepath.Base(os.Args[0]), strings.Join(syncJobNames(), "|"), filepath.Base(os.Args[0]))
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -97,7 +149,7 @@ func main() {
 		fmt.Printf("frodo %s\nbuild date: %s\nlast commit: %s\n", version.Version, version.BuildDate, version.GitCommit)
 		return
 
-	} else if action != "start" {
+	} else if action != "start" && action != "runjob" {
 		log.Fatal().Msgf("Unknown action %s", action)
 	}
 	conf := cnf.LoadConfig(flag.Arg(1))
@@ -115,6 +167,9 @@ func main() {
 	}
 	log.Info().Msg("Starting FRODO")
 	cnf.ApplyDefaults(conf)
+	if err := tables.Configure(conf.TableNameTemplate); err != nil {
+		log.Fatal().Err(err).Msg("failed to configure database table names")
+	}
 
 	docs.SwaggerInfo.Version = version.Version
 	docs.SwaggerInfo.Host = fmt.Sprintf("%s:%d", conf.ListenAddress, conf.ListenPort)
@@ -128,6 +183,7 @@ func main() {
 	var corpusMeta metadb.Provider
 	var corpusMetaW metadb.SQLUpdater
 	var corpusMetaErr error
+	var cncDB *sql.DB
 
 	if conf.CNCDB != nil {
 		cTableName := "corpora"
@@ -157,6 +213,8 @@ func main() {
 		} else {
 			corpusMeta = tmp
 			corpusMetaW = tmp
+			cncDB = tmp.Conn()
+			conf.CNCDB.Pool.Apply(cncDB)
 		}
 		log.Info().Msgf("using CNC corpus info SQL database: %s@%s", conf.CNCDB.Name, conf.CNCDB.Host)
 
@@ -170,7 +228,7 @@ func main() {
 		log.Fatal().Err(corpusMetaErr)
 	}
 
-	laDB, err := mysql.OpenDB(*conf.LiveAttrs.DB)
+	laDB, err := mysql.OpenDB(*conf.LiveAttrs.DB, conf.LiveAttrs.DBPool)
 	if err != nil {
 		log.Fatal().Err(err).Send()
 	}
@@ -191,18 +249,45 @@ func main() {
 	engine.Use(gin.Recovery())
 	engine.Use(logging.GinMiddleware())
 	engine.Use(uniresp.AlwaysJSONContentType())
+	if bodyLimit := middleware.MaxRequestBodySize(conf.MaxRequestBodySize); bodyLimit != nil {
+		engine.Use(bodyLimit)
+	}
+	if reqLogging := middleware.RequestLogging(conf.RequestLogging); reqLogging != nil {
+		engine.Use(reqLogging)
+	}
+	engine.Use(middleware.ErrorContentNegotiation())
 	engine.NoMethod(uniresp.NoMethodHandler)
 	engine.NoRoute(uniresp.NotFoundHandler)
 
-	rootActions := root.Actions{Version: version, Conf: conf}
+	// readRoutes contains synchronous read actions touching the database
+	readRoutes := engine.Group("")
+	if readLimit := middleware.ConcurrencyLimit(
+		conf.MaxNumConcurrentReads, conf.ReadsRetryAfterSecs); readLimit != nil {
+		readRoutes.Use(readLimit)
+	}
 
+	if action == "runjob" {
+		// a synchronously run job must not touch the status data of a running server
+		conf.Jobs.StatusDataPath = ""
+	}
 	jobStopChannel := make(chan string)
 	jobActions := jobs.NewActions(conf.Jobs, conf.Language, ctx, jobStopChannel)
+	engine.Use(jobActions.RecordJobRequests)
+
+	rootActions := root.Actions{
+		Version: version,
+		Conf:    conf,
+		LADB:    laDB.DB(),
+		CNCDB:   cncDB,
+		Jobs:    jobActions,
+	}
 
 	laConfRegistry := laconf.NewLiveAttrsBuildConfProvider(
 		conf.LiveAttrs.ConfDirPath,
 		conf.LiveAttrs.DB,
+		conf.CorporaSetup,
 	)
+	go reloadLiveAttrsDBConfOnSIGHUP(ctx, conf.GetSourcePath(), laConfRegistry)
 
 	liveattrsActions := laActions.NewActions(
 		laActions.LAConf{
@@ -219,6 +304,8 @@ func main() {
 		version,
 	)
 
+	jobActions.RegisterJobResumer(laDb.ReindexJobType, liveattrsActions.ResumeReindexJob)
+
 	for _, dj := range jobActions.GetDetachedJobs() {
 		if dj.IsFinished() {
 			continue
@@ -237,52 +324,78 @@ func main() {
 
 	engine.GET(
 		"/", rootActions.RootAction)
+	engine.GET(
+		"/health", rootActions.Health)
 	engine.GET(
 		"/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	engine.POST(
 		"/liveAttributes/:corpusId/data", liveattrsActions.Create)
 	engine.DELETE(
 		"/liveAttributes/:corpusId/data", liveattrsActions.Delete)
+	engine.PATCH(
+		"/liveAttributes/:corpusId/data", liveattrsActions.UpdateData)
 	engine.POST(
 		"/liveAttributes/:corpusId/cleanTmpTables", liveattrsActions.CleanTmpTables)
+	engine.POST(
+		"/liveAttributes/:corpusId/validateIntegrity", liveattrsActions.ValidateIntegrity)
+	engine.POST(
+		"/liveAttributes/:corpusId/reindex", liveattrsActions.Reindex)
 	engine.GET(
 		"/liveAttributes/:corpusId/conf", liveattrsActions.ViewConf)
 	engine.PUT(
 		"/liveAttributes/:corpusId/conf", liveattrsActions.CreateConf)
 	engine.PATCH(
 		"/liveAttributes/:corpusId/conf", liveattrsActions.PatchConfig)
+	engine.GET(
+		"/liveAttributes/:corpusId/conf/yaml", liveattrsActions.ViewConfYAML)
+	engine.GET(
+		"/liveAttributes/:corpusId/conf/version", liveattrsActions.ConfVersion)
+	engine.POST(
+		"/liveAttributes/:corpusId/conf/validate", liveattrsActions.ValidateConf)
+	engine.POST(
+		"/liveAttributes/:corpusId/conf/diff", liveattrsActions.DiffConf)
+	engine.GET(
+		"/liveAttributes/:corpusId/conf/bibViews", liveattrsActions.BibViews)
+	engine.POST(
+		"/liveAttributes/:corpusId/conf/restore", liveattrsActions.RestoreConf)
+	engine.POST(
+		"/liveAttributes/conf/bulk", liveattrsActions.BulkCreateConf)
 	engine.GET(
 		"/liveAttributes/:corpusId/qsDefaults", liveattrsActions.QSDefaults)
 	engine.DELETE(
 		"/liveAttributes/:corpusId/confCache", liveattrsActions.FlushCache)
-	engine.POST(
+	readRoutes.POST(
 		"/liveAttributes/:corpusId/query", liveattrsActions.Query)
-	engine.POST(
+	readRoutes.POST(
+		"/liveAttributes/:corpusId/query/stream", liveattrsActions.QueryStream)
+	readRoutes.POST(
 		"/liveAttributes/:corpusId/fillAttrs", liveattrsActions.FillAttrs)
-	engine.POST(
+	readRoutes.POST(
 		"/liveAttributes/:corpusId/selectionSubcSize",
 		liveattrsActions.GetAdhocSubcSize)
-	engine.POST(
+	readRoutes.POST(
 		"/liveAttributes/:corpusId/attrValAutocomplete",
 		liveattrsActions.AttrValAutocomplete)
-	engine.POST(
+	readRoutes.POST(
 		"/liveAttributes/:corpusId/getBibliography",
 		liveattrsActions.GetBibliography)
-	engine.POST(
+	readRoutes.POST(
 		"/liveAttributes/:corpusId/findBibTitles",
 		liveattrsActions.FindBibTitles)
 	engine.GET(
 		"/liveAttributes/:corpusId/stats", liveattrsActions.Stats)
+	engine.GET(
+		"/liveAttributes/cacheStats", liveattrsActions.CacheStats)
 	engine.POST(
 		"/liveAttributes/:corpusId/mixSubcorpus",
 		liveattrsActions.MixSubcorpus)
 	engine.GET(
 		"/liveAttributes/:corpusId/inferredAtomStructure",
 		liveattrsActions.InferredAtomStructure)
-	engine.POST(
+	readRoutes.POST(
 		"/liveAttributes/:corpusId/documentList",
 		liveattrsActions.DocumentList)
-	engine.POST(
+	readRoutes.POST(
 		"/liveAttributes/:corpusId/numMatchingDocuments",
 		liveattrsActions.NumMatchingDocuments)
 
@@ -295,6 +408,7 @@ func main() {
 		corpusMetaW,
 		laDB,
 		conf.LiveAttrs.CustomNgramTablesDataDir,
+		conf.LiveAttrs.ImportQueryTimeout(),
 		laConfRegistry,
 		version,
 	)
@@ -304,38 +418,41 @@ func main() {
 	engine.POST(
 		"/dictionary/:corpusId/ngrams",
 		dictActionsHandler.GenerateNgrams)
+	engine.DELETE(
+		"/dictionary/:corpusId/ngrams",
+		dictActionsHandler.DeleteNgrams)
 	engine.POST(
 		"/dictionary/:corpusId/querySuggestions",
 		dictActionsHandler.CreateQuerySuggestions)
 
-	engine.GET(
+	readRoutes.GET(
 		"/dictionary/SSJC/search/:term",
 		ujcActionsHandler.SearchSSJC,
 	)
-	engine.GET(
+	readRoutes.GET(
 		"/dictionary/SJC/search/:term",
 		ujcActionsHandler.SearchSJC,
 	)
 
 	lexActionsHandler := lex.NewHandler(laDB, dictActionsHandler)
-	engine.GET(
+	readRoutes.GET(
 		"/dictionary/lex/:corpusId/search/:term",
 		lexActionsHandler.SearchWord,
 	)
 
-	engine.GET(
+	readRoutes.GET(
 		"/dictionary/:corpusId/querySuggestions/:term",
 		dictActionsHandler.GetQuerySuggestions)
-	engine.GET(
+	readRoutes.GET(
 		"/dictionary/:corpusId/search/:term",
 		dictActionsHandler.GetQuerySuggestions)
-	engine.GET(
+	readRoutes.GET(
 		"/dictionary/:corpusId/similarARFWords/:term",
 		dictActionsHandler.SimilarARFWords)
 
-	ltSearchActions := ltsearch.NewActions(laDB, laConfRegistry, conf.CorporaSetup.RegistryDirPaths[0])
+	ltSearchActions := ltsearch.NewActions(laDB, laConfRegistry, conf.CorporaSetup.RegistryDirPaths)
 
-	engine.POST(
+	readRoutes.POST(
 		"/liveTokens/:corpusId/query", ltSearchActions.Query)
 
 	engine.GET(
@@ -368,6 +485,20 @@ func main() {
 		"/jobs", jobActions.JobList)
 	engine.GET(
 		"/jobs/utilization", jobActions.Utilization)
+	engine.GET(
+		"/jobs/templates", jobActions.ListTemplates)
+	engine.GET(
+		"/jobs/templates/:name", jobActions.GetTemplate)
+	engine.PUT(
+		"/jobs/templates/:name", jobActions.SaveTemplate)
+	engine.DELETE(
+		"/jobs/templates/:name", jobActions.DeleteTemplate)
+	engine.POST(
+		"/jobs/from-template/:name", jobActions.CreateFromTemplate)
+	engine.POST(
+		"/jobs/tableUpdateConsumer/restart", jobActions.RestartTableUpdateConsumer)
+	engine.POST(
+		"/jobs/clearFinished", jobActions.ClearAllFinished)
 	engine.GET(
 		"/jobs/:jobId", jobActions.JobInfo)
 	engine.DELETE(
@@ -385,6 +516,28 @@ func main() {
 	engine.DELETE(
 		"/jobs/:jobId/emailNotification/:address",
 		jobActions.RemoveNotification)
+	engine.GET(
+		"/jobs/:jobId/events", jobActions.JobEvents)
+	engine.GET(
+		"/jobs/:jobId/webhook/*url", jobActions.GetWebhooks)
+	engine.PUT(
+		"/jobs/:jobId/webhook/*url", jobActions.AddWebhook)
+	engine.DELETE(
+		"/jobs/:jobId/webhook/*url", jobActions.RemoveWebhook)
+
+	if conf.Jobs.MetricsPath != "" {
+		engine.GET(conf.Jobs.MetricsPath, jobActions.Metrics)
+	}
+
+	jobActions.SetJobCreators(
+		map[string]jobs.JobCreator{
+			"liveattrs": liveattrsActions.CreateDataJob,
+			"ngrams":    dictActionsHandler.CreateNgramsJob,
+			"integrity": liveattrsActions.CreateIntegrityJob,
+			"reindex":   liveattrsActions.CreateReindexJob,
+		},
+		syncJobs,
+	)
 
 	if conf.Logging.Level.IsDebugMode() {
 		debugActions := debug.NewActions(jobActions)
@@ -392,6 +545,15 @@ func main() {
 		engine.POST("/debug/finishJob/:jobId", debugActions.FinishDummyJob)
 	}
 
+	if action == "runjob" {
+		err := runJobSync(ctx, jobActions, flag.Arg(2), flag.Arg(3), *jobArgs, *jobQuery)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to run job")
+			os.Exit(1)
+		}
+		return
+	}
+
 	log.Info().Msgf("starting to listen at %s:%d", conf.ListenAddress, conf.ListenPort)
 	srv := &http.Server{
 		Handler:      engine,
@@ -416,4 +578,6 @@ func main() {
 	if err := srv.Shutdown(ctxShutDown); err != nil {
 		log.Fatal().Err(err).Msg("Server forced to shutdown")
 	}
+	<-jobActions.Exited()
+	<-liveattrsActions.Exited()
 }
@@ -0,0 +1,115 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"frodo/jobs"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	syncJobPollInterval = 2 * time.Second
+)
+
+// syncJobs maps job names usable with the `runjob` action
+// to URL paths of respective job-creating actions
+var syncJobs = map[string]string{
+	"liveattrs": "/liveAttributes/%s/data",
+	"ngrams":    "/dictionary/%s/ngrams",
+	"integrity": "/liveAttributes/%s/validateIntegrity",
+	"reindex":   "/liveAttributes/%s/reindex",
+}
+
+func syncJobNames() []string {
+	ans := make([]string, 0, len(syncJobs))
+	for k := range syncJobs {
+		ans = append(ans, k)
+	}
+	slices.Sort(ans)
+	return ans
+}
+
+// runJobSync starts a job using the same function as the respective HTTP
+// action does (which means all the arguments processing and validation
+// are the same) and waits for the job to finish. The job status is written
+// to stdout each time it changes. In case no job is created (e.g. a dry run
+// of n-gram generation), the result is written to stdout instead.
+func runJobSync(
+	ctx context.Context,
+	jobActions *jobs.Actions,
+	jobName string,
+	corpusID string,
+	argsPath string,
+	query string,
+) error {
+	if _, ok := syncJobs[jobName]; !ok {
+		return fmt.Errorf(
+			"unknown job %s (available: %s)", jobName, strings.Join(syncJobNames(), ", "))
+	}
+	if corpusID == "" {
+		return fmt.Errorf("missing corpus ID")
+	}
+	req := jobs.JobRequest{CorpusID: corpusID}
+	if argsPath != "" {
+		args, err := os.ReadFile(argsPath)
+		if err != nil {
+			return fmt.Errorf("failed to read job arguments: %w", err)
+		}
+		req.Args = args
+	}
+	var err error
+	req.Query, err = url.ParseQuery(query)
+	if err != nil {
+		return fmt.Errorf("failed to parse job query: %w", err)
+	}
+	created, err := jobActions.CreateJob(ctx, jobName, req)
+	if err != nil {
+		return fmt.Errorf("failed to start job (status %d): %w", jobs.JobRequestErrorStatus(err), err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	if created.JobID == "" {
+		return enc.Encode(created.Body)
+	}
+	jobID := created.JobID
+	log.Info().Str("jobId", jobID).Str("corpusId", corpusID).Msgf("started %s job", jobName)
+	job, err := jobActions.WaitForJob(
+		ctx,
+		jobID,
+		syncJobPollInterval,
+		func(info jobs.GeneralJobInfo) {
+			if err := enc.Encode(info.FullInfo()); err != nil {
+				log.Error().Err(err).Msg("failed to write job status")
+			}
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if job.GetError() != nil {
+		return fmt.Errorf("job %s failed: %w", jobID, job.GetError())
+	}
+	return nil
+}
@@ -68,7 +68,7 @@ func runImport(args importArgs) {
 	defer stop()
 
 	conf := cnf.LoadConfig(args.configPath)
-	db, err := mysql.OpenDB(*conf.LiveAttrs.DB)
+	db, err := mysql.OpenDB(*conf.LiveAttrs.DB, conf.LiveAttrs.DBPool)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to import data")
 	}
@@ -21,6 +21,7 @@ import (
 	"frodo/corpus"
 	"frodo/jobs"
 	"frodo/liveattrs"
+	"frodo/middleware"
 	"frodo/ujc"
 	"os"
 	"path/filepath"
@@ -36,6 +37,17 @@ const (
 	dfltLanguage               = "en"
 	dfltMaxNumConcurrentJobs   = 4
 	dfltVertMaxNumErrors       = 100
+
+	dfltJobsTableUpdateStallTimeoutSecs = 120
+	dfltJobsClearedJobsMemorySize       = 1000
+	dfltJobsClearedJobsRetentionHours   = 168
+	dfltJobsWebhookTimeoutSecs          = 10
+	dfltJobsHistoryRetentionHours       = 720
+
+	dfltMaxRequestBodySize = 10 * 1024 * 1024
+
+	dfltMaxNumConcurrentReads = 64
+	dfltReadsRetryAfterSecs   = 2
 )
 
 // Conf is a global configuration of the app
@@ -51,7 +63,35 @@ type Conf struct {
 	Jobs                   *jobs.Conf            `json:"jobs"`
 	UJC                    ujc.Conf              `json:"ujc"`
 	Language               string                `json:"language"`
-	srcPath                string
+
+	// RequestLogging is an optional debugging log of requests
+	// and responses (off by default)
+	RequestLogging *middleware.RequestLoggingConf `json:"requestLogging"`
+
+	// MaxRequestBodySize specifies max. size (in bytes) of request bodies.
+	// Larger requests are rejected with 413. A negative value disables
+	// the limit.
+	MaxRequestBodySize int64 `json:"maxRequestBodySize"`
+
+	// TableNameTemplate specifies how names of corpus-related database tables
+	// (n-grams and other auxiliary tables) are created. The template must contain
+	// both `{corpus}` and `{table}` placeholders. Default is `{corpus}_{table}`.
+	// Tables created by vert-tagextract (liveattrs_entry, colcounts) always
+	// use the default naming.
+	TableNameTemplate string `json:"tableNameTemplate"`
+
+	// MaxNumConcurrentReads limits the number of concurrently processed
+	// synchronous read requests touching the database (liveattrs queries,
+	// dictionary search etc.). Requests exceeding the limit are rejected
+	// with 503. The limit is independent of jobs.maxNumConcurrentJobs.
+	// A negative value disables the limit.
+	MaxNumConcurrentReads int `json:"maxNumConcurrentReads"`
+
+	// ReadsRetryAfterSecs is a value of the `Retry-After` header sent along
+	// with requests rejected due to MaxNumConcurrentReads
+	ReadsRetryAfterSecs int `json:"readsRetryAfterSecs"`
+
+	srcPath string
 }
 
 func (conf *Conf) GetLocation() *time.Location { // TODO
@@ -80,17 +120,27 @@ func LoadConfig(path string) *Conf {
 	if path == "" {
 		log.Fatal().Msg("Cannot load config - path not specified")
 	}
-	rawData, err := os.ReadFile(path)
+	conf, err := ReadConfig(path)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Cannot load config")
 	}
+	return conf
+}
+
+// ReadConfig reads and parses a configuration file. Unlike LoadConfig,
+// it reports problems via the returned error so it can be used to
+// reload configuration of a running service.
+func ReadConfig(path string) (*Conf, error) {
+	rawData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 	var conf Conf
 	conf.srcPath = path
-	err = json.Unmarshal(rawData, &conf)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Cannot load config")
+	if err := json.Unmarshal(rawData, &conf); err != nil {
+		return nil, err
 	}
-	return &conf
+	return &conf, nil
 }
 
 func ApplyDefaults(conf *Conf) {
@@ -108,6 +158,27 @@ func ApplyDefaults(conf *Conf) {
 			dfltVertMaxNumErrors,
 		)
 	}
+	if conf.MaxRequestBodySize == 0 {
+		conf.MaxRequestBodySize = dfltMaxRequestBodySize
+		log.Warn().Msgf(
+			"maxRequestBodySize not specified, using default: %d",
+			dfltMaxRequestBodySize,
+		)
+	}
+	if conf.MaxNumConcurrentReads == 0 {
+		conf.MaxNumConcurrentReads = dfltMaxNumConcurrentReads
+		log.Warn().Msgf(
+			"maxNumConcurrentReads not specified, using default: %d",
+			dfltMaxNumConcurrentReads,
+		)
+	}
+	if conf.ReadsRetryAfterSecs <= 0 {
+		conf.ReadsRetryAfterSecs = dfltReadsRetryAfterSecs
+		log.Warn().Msgf(
+			"readsRetryAfterSecs not specified, using default: %d",
+			dfltReadsRetryAfterSecs,
+		)
+	}
 	if conf.Language == "" {
 		conf.Language = dfltLanguage
 		log.Warn().Msgf("language not specified, using default: %s", conf.Language)
@@ -117,6 +188,43 @@ func ApplyDefaults(conf *Conf) {
 		conf.Jobs.MaxNumConcurrentJobs = v
 		log.Warn().Msgf("jobs.maxNumConcurrentJobs not specified, using default %d", v)
 	}
+	if conf.Jobs.TableUpdateStallTimeoutSecs == 0 {
+		conf.Jobs.TableUpdateStallTimeoutSecs = dfltJobsTableUpdateStallTimeoutSecs
+		log.Warn().Msgf(
+			"jobs.tableUpdateStallTimeoutSecs not specified, using default %d",
+			dfltJobsTableUpdateStallTimeoutSecs,
+		)
+	}
+	if conf.Jobs.ClearedJobsMemorySize == 0 {
+		conf.Jobs.ClearedJobsMemorySize = dfltJobsClearedJobsMemorySize
+		log.Warn().Msgf(
+			"jobs.clearedJobsMemorySize not specified, using default %d",
+			dfltJobsClearedJobsMemorySize,
+		)
+	}
+	if conf.Jobs.WebhookTimeoutSecs <= 0 {
+		conf.Jobs.WebhookTimeoutSecs = dfltJobsWebhookTimeoutSecs
+		log.Warn().Msgf(
+			"jobs.webhookTimeoutSecs not specified, using default %d",
+			dfltJobsWebhookTimeoutSecs,
+		)
+	}
+	if conf.Jobs.HistoryRetentionHours == 0 {
+		conf.Jobs.HistoryRetentionHours = dfltJobsHistoryRetentionHours
+		if conf.Jobs.PersistFinishedJobs {
+			log.Warn().Msgf(
+				"jobs.historyRetentionHours not specified, using default %d",
+				dfltJobsHistoryRetentionHours,
+			)
+		}
+	}
+	if conf.Jobs.ClearedJobsRetentionHours == 0 {
+		conf.Jobs.ClearedJobsRetentionHours = dfltJobsClearedJobsRetentionHours
+		log.Warn().Msgf(
+			"jobs.clearedJobsRetentionHours not specified, using default %d",
+			dfltJobsClearedJobsRetentionHours,
+		)
+	}
 }
 
 // ------- live attributes and stuff
@@ -18,7 +18,9 @@ package corpus
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"frodo/db/mysql"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -34,6 +36,10 @@ const (
 	CorpusVariantLimited CorpusVariant = "omezeni"
 )
 
+var (
+	ErrorRegistryNotFound = errors.New("corpus registry file not found")
+)
+
 type CorpusVariant string
 
 func (cv CorpusVariant) SubDir() string {
@@ -78,6 +84,41 @@ type CorporaSetup struct {
 	CorporaConfDir     string   `json:"confFilesDir"`
 	corpora            []corp.CorpusSetup
 	MonitoringDatasets MonitoringDatasets `json:"monitoringDatasets"`
+
+	// TagsetPreference is a ranked list of tagsets used when a corpus
+	// supports more than one tagset. If empty, the first supported
+	// tagset of the corpus is used.
+	TagsetPreference []corp.SupportedTagset `json:"tagsetPreference"`
+}
+
+// GetPreferredTagset returns the highest ranked (see TagsetPreference)
+// supported tagset from the provided list. In case there is no match,
+// the first supported tagset is returned.
+func (cs *CorporaSetup) GetPreferredTagset(values []corp.SupportedTagset) corp.SupportedTagset {
+	return GetPreferredSupportedTagset(values, cs.TagsetPreference)
+}
+
+// FindRegistryFile searches the provided registry directories (in
+// the provided order) for the registry file of a corpus and returns
+// the first match. In case there is no match, ErrorRegistryNotFound
+// is returned.
+func FindRegistryFile(registryDirs []string, corpusID string) (string, error) {
+	for _, dir := range registryDirs {
+		regPath := filepath.Join(dir, corpusID)
+		if isFile, _ := fs.IsFile(regPath); isFile {
+			return regPath, nil
+		}
+	}
+	return "", fmt.Errorf(
+		"%w: %s (searched in: %s)",
+		ErrorRegistryNotFound, corpusID, strings.Join(registryDirs, ", "),
+	)
+}
+
+// FindRegistryFile searches all the configured registry directories
+// for the registry file of a corpus. See FindRegistryFile.
+func (cs *CorporaSetup) FindRegistryFile(corpusID string) (string, error) {
+	return FindRegistryFile(cs.RegistryDirPaths, corpusID)
 }
 
 func (cs *CorporaSetup) GetFirstValidRegistry(corpusID, subDir string) string {
@@ -175,4 +216,8 @@ type DatabaseSetup struct {
 	Name                     string `json:"db"`
 	OverrideCorporaTableName string `json:"overrideCorporaTableName"`
 	OverridePCTableName      string `json:"overridePcTableName"`
+
+	// Pool configures connection pool of the database
+	// (see mysql.PoolConf for defaults)
+	Pool mysql.PoolConf `json:"pool"`
 }
@@ -0,0 +1,51 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRegistryFileMultipleDirs(t *testing.T) {
+	regDir1 := t.TempDir()
+	regDir2 := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(regDir1, "syn2020"), []byte("NAME \"SYN2020\"\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(regDir2, "syn2020"), []byte("NAME \"SYN2020\"\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(regDir2, "intercorp_v16ud_cs"), []byte("NAME \"IC\"\n"), 0644))
+	// a directory with the corpus name must not be confused with a registry file
+	assert.NoError(t, os.Mkdir(filepath.Join(regDir1, "intercorp_v16ud_cs"), 0755))
+
+	setup := &CorporaSetup{RegistryDirPaths: []string{regDir1, regDir2}}
+
+	regPath, err := setup.FindRegistryFile("syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(regDir1, "syn2020"), regPath)
+
+	regPath, err = setup.FindRegistryFile("intercorp_v16ud_cs")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(regDir2, "intercorp_v16ud_cs"), regPath)
+}
+
+func TestFindRegistryFileNotFound(t *testing.T) {
+	setup := &CorporaSetup{RegistryDirPaths: []string{t.TempDir(), t.TempDir()}}
+	_, err := setup.FindRegistryFile("syn2020")
+	assert.ErrorIs(t, err, ErrorRegistryNotFound)
+}
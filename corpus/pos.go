@@ -18,6 +18,7 @@ package corpus
 
 import (
 	"errors"
+	"slices"
 
 	"github.com/czcorpus/mquery-common/corp"
 	vteCnf "github.com/czcorpus/vert-tagextract/v3/cnf"
@@ -68,6 +69,21 @@ func ApplyPosProperties(
 	return modders.NewStringTransformerChain(""), ErrorPosNotDefined
 }
 
+// GetPreferredSupportedTagset returns the first tagset from the preference
+// list which is both valid and contained in values. If none matches,
+// the first supported tagset of values is returned.
+func GetPreferredSupportedTagset(
+	values []corp.SupportedTagset,
+	preference []corp.SupportedTagset,
+) corp.SupportedTagset {
+	for _, pref := range preference {
+		if pref.Validate() == nil && slices.Contains(values, pref) {
+			return pref
+		}
+	}
+	return GetFirstSupportedTagset(values)
+}
+
 func GetFirstSupportedTagset(values []corp.SupportedTagset) corp.SupportedTagset {
 	for _, v := range values {
 		if v.Validate() == nil {
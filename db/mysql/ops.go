@@ -18,15 +18,29 @@ package mysql
 
 import (
 	"database/sql"
+	"errors"
+	"strconv"
 	"time"
 
 	db "github.com/czcorpus/vert-tagextract/v3/db"
 	"github.com/go-sql-driver/mysql"
 )
 
+const (
+	maxExecTimeExceededErrNo = 3024
+)
+
+// IsMaxExecTimeError tests whether the err has been caused
+// by a query aborted due to exceeded `max_execution_time`
+func IsMaxExecTimeError(err error) bool {
+	var mErr *mysql.MySQLError
+	return errors.As(err, &mErr) && mErr.Number == maxExecTimeExceededErrNo
+}
+
 type Adapter struct {
 	db      *sql.DB
 	conf    db.Conf
+	pool    PoolConf
 	dbName  string
 	isAdHoc bool
 }
@@ -43,6 +57,12 @@ func (a *Adapter) Conf() db.Conf {
 	return a.conf
 }
 
+// PoolConf returns the connection pool configuration
+// the adapter has been opened with
+func (a *Adapter) PoolConf() PoolConf {
+	return a.pool
+}
+
 // Close closes the wrapped database connection.
 // Only connections which are not "ad-hoc" can
 // be closed this way. This applies e.g. for
@@ -58,7 +78,23 @@ func (a *Adapter) Close() error {
 	return a.db.Close()
 }
 
-func OpenDB(conf db.Conf) (*Adapter, error) {
+// NewAdapter wraps an already opened database handle
+// (e.g. a handle created via sql.OpenDB with a custom connector).
+// The resulting adapter is not "ad-hoc" and cannot be closed via Close.
+func NewAdapter(database *sql.DB, dbName string) *Adapter {
+	return &Adapter{db: database, dbName: dbName}
+}
+
+// OpenDB opens a database handle with connection pool
+// configured according to the pool argument.
+func OpenDB(conf db.Conf, pool PoolConf) (*Adapter, error) {
+	return openDB(conf, pool, nil)
+}
+
+// openDB opens a database connection with optional additional
+// session variables (these are set for each new connection
+// of the pool)
+func openDB(conf db.Conf, pool PoolConf, sessionParams map[string]string) (*Adapter, error) {
 	mconf := mysql.NewConfig()
 	mconf.Net = "tcp"
 	mconf.Addr = conf.Host
@@ -68,30 +104,37 @@ func OpenDB(conf db.Conf) (*Adapter, error) {
 	mconf.ParseTime = true
 	mconf.Loc = time.Local
 	mconf.Params = map[string]string{"autocommit": "true"}
+	for k, v := range sessionParams {
+		mconf.Params[k] = v
+	}
 	db, err := sql.Open("mysql", mconf.FormatDSN())
 	if err != nil {
 		return nil, err
 	}
-	return &Adapter{db: db, dbName: mconf.DBName, conf: conf}, nil
+	pool.Apply(db)
+	return &Adapter{db: db, dbName: mconf.DBName, conf: conf, pool: pool}, nil
 }
 
 // OpenImportTunedDB creates an Adapter instance with
 // undrelying connection session having slightly modified
 // parameters suitable for faster data import (unique checks disabled,
-// foreign checks disabled).
-func OpenImportTunedDB(conf db.Conf) (*Adapter, error) {
-	a, err := OpenDB(conf)
+// foreign checks disabled). In case maxExecTime is positive, it is
+// applied as the `max_execution_time` of all the SELECT statements
+// (rounded to milliseconds; the server aborts exceeding queries).
+// The session parameters are set for each connection of the pool
+// so they survive connection recycling (see PoolConf).
+func OpenImportTunedDB(conf db.Conf, pool PoolConf, maxExecTime time.Duration) (*Adapter, error) {
+	params := map[string]string{
+		"unique_checks":      "0",
+		"foreign_key_checks": "0",
+	}
+	if maxExecTime > 0 {
+		params["max_execution_time"] = strconv.FormatInt(max(maxExecTime.Milliseconds(), 1), 10)
+	}
+	a, err := openDB(conf, pool, params)
 	if err != nil {
 		return nil, err
 	}
 	a.isAdHoc = true
-	for _, q := range []string{
-		"SET SESSION unique_checks = 0",
-		"SET SESSION foreign_key_checks = 0",
-	} {
-		if _, err = a.db.Exec(q); err != nil {
-			return nil, err
-		}
-	}
 	return a, nil
 }
@@ -0,0 +1,85 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	dfltMaxOpenConns       = 100
+	dfltMaxIdleConns       = 10
+	dfltConnMaxLifetimeSec = 300
+)
+
+// PoolConf configures a connection pool of a database handle.
+// Zero values mean defaults which are reasonable for a single
+// Frodo instance sharing a database server with other services:
+//
+//   - MaxOpenConns: 100 (a negative value means "no limit")
+//   - MaxIdleConns: 10 (never more than MaxOpenConns; a negative value
+//     means "no idle connections")
+//   - ConnMaxLifetimeSecs: 300 (a negative value means "no limit")
+//
+// Please make sure MaxOpenConns of all the services using the database
+// together do not exceed the server's `max_connections`.
+type PoolConf struct {
+	MaxOpenConns        int `json:"maxOpenConns"`
+	MaxIdleConns        int `json:"maxIdleConns"`
+	ConnMaxLifetimeSecs int `json:"connMaxLifetimeSecs"`
+}
+
+// GetMaxOpenConns returns MaxOpenConns or the default value
+// if not configured (0 = no limit, as understood by sql.DB)
+func (pc PoolConf) GetMaxOpenConns() int {
+	if pc.MaxOpenConns == 0 {
+		return dfltMaxOpenConns
+	}
+	return max(pc.MaxOpenConns, 0)
+}
+
+// GetMaxIdleConns returns MaxIdleConns or the default value
+// if not configured. The value never exceeds GetMaxOpenConns
+// (in case it is limited).
+func (pc PoolConf) GetMaxIdleConns() int {
+	ans := pc.MaxIdleConns
+	if ans == 0 {
+		ans = dfltMaxIdleConns
+	}
+	if maxOpen := pc.GetMaxOpenConns(); maxOpen > 0 {
+		ans = min(ans, maxOpen)
+	}
+	return max(ans, 0)
+}
+
+// ConnMaxLifetime returns ConnMaxLifetimeSecs as time.Duration
+// or the default value if not configured (0 = no limit, as understood
+// by sql.DB)
+func (pc PoolConf) ConnMaxLifetime() time.Duration {
+	if pc.ConnMaxLifetimeSecs == 0 {
+		return dfltConnMaxLifetimeSec * time.Second
+	}
+	return time.Duration(max(pc.ConnMaxLifetimeSecs, 0)) * time.Second
+}
+
+// Apply sets the pool parameters to the provided database handle
+func (pc PoolConf) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(pc.GetMaxOpenConns())
+	db.SetMaxIdleConns(pc.GetMaxIdleConns())
+	db.SetConnMaxLifetime(pc.ConnMaxLifetime())
+}
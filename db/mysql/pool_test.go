@@ -0,0 +1,57 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	db "github.com/czcorpus/vert-tagextract/v3/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolConfDefaults(t *testing.T) {
+	var pc PoolConf
+	assert.Equal(t, dfltMaxOpenConns, pc.GetMaxOpenConns())
+	assert.Equal(t, dfltMaxIdleConns, pc.GetMaxIdleConns())
+	assert.Equal(t, dfltConnMaxLifetimeSec*time.Second, pc.ConnMaxLifetime())
+
+	pc = PoolConf{MaxOpenConns: -1, MaxIdleConns: -1, ConnMaxLifetimeSecs: -1}
+	assert.Equal(t, 0, pc.GetMaxOpenConns())
+	assert.Equal(t, 0, pc.GetMaxIdleConns())
+	assert.Equal(t, time.Duration(0), pc.ConnMaxLifetime())
+
+	pc = PoolConf{MaxOpenConns: 5, MaxIdleConns: 20}
+	assert.Equal(t, 5, pc.GetMaxIdleConns())
+}
+
+func TestOpenDBAppliesPoolConf(t *testing.T) {
+	// the connection is opened lazily so no server is needed here
+	a, err := OpenDB(
+		db.Conf{Host: "127.0.0.1:3306", User: "test", Name: "test"},
+		PoolConf{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetimeSecs: 60},
+	)
+	assert.NoError(t, err)
+	defer a.DB().Close()
+	assert.Equal(t, 7, a.DB().Stats().MaxOpenConnections)
+	assert.Equal(t, 7, a.PoolConf().MaxOpenConns)
+
+	a2, err := OpenImportTunedDB(a.Conf(), a.PoolConf(), time.Minute)
+	assert.NoError(t, err)
+	defer a2.Close()
+	assert.Equal(t, 7, a2.DB().Stats().MaxOpenConnections)
+}
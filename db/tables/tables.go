@@ -0,0 +1,117 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tables
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultTemplate produces the original table names
+	// (e.g. `intercorp_v13_liveattrs_entry`)
+	DefaultTemplate = "{corpus}_{table}"
+
+	placeholderCorpus = "{corpus}"
+	placeholderTable  = "{table}"
+)
+
+// Table types (i.e. table name suffixes in the default template)
+const (
+	LiveAttrsEntry    = "liveattrs_entry"
+	LiveAttrsEntryNew = "liveattrs_entry_new"
+	ColCounts         = "colcounts"
+	ColCountsNew      = "colcounts_new"
+	Word              = "word"
+	TermSearch        = "term_search"
+	LemmaStats        = "lemma_stats"
+)
+
+// importerTables contains tables created by vert-tagextract
+// which always uses the default naming
+var importerTables = map[string]bool{
+	LiveAttrsEntry:    true,
+	LiveAttrsEntryNew: true,
+	ColCounts:         true,
+	ColCountsNew:      true,
+}
+
+var (
+	defaultNamer     = &TableNamer{template: DefaultTemplate}
+	defaultNamerLock sync.RWMutex
+)
+
+// TableNamer creates database table names based on a template
+// containing the `{corpus}` and `{table}` placeholders. The corpus
+// part is typically a grouped corpus name (see corpus.DBInfo.GroupedName()).
+//
+// Please note that liveattrs tables (liveattrs_entry, colcounts) are created
+// by vert-tagextract which always uses the default naming. The template
+// is therefore not applied to them.
+type TableNamer struct {
+	template string
+}
+
+// Name returns a table name for a corpus and a table type
+// (see table type constants, e.g. LiveAttrsEntry)
+func (tn *TableNamer) Name(corpus, table string) string {
+	template := tn.template
+	if importerTables[table] {
+		template = DefaultTemplate
+	}
+	return strings.NewReplacer(
+		placeholderCorpus, corpus,
+		placeholderTable, table,
+	).Replace(template)
+}
+
+// NewTableNamer creates a table namer based on a template.
+// An empty template means DefaultTemplate.
+func NewTableNamer(template string) (*TableNamer, error) {
+	if template == "" {
+		template = DefaultTemplate
+	}
+	if !strings.Contains(template, placeholderCorpus) || !strings.Contains(template, placeholderTable) {
+		return nil, fmt.Errorf(
+			"invalid table name template %s: both %s and %s must be present",
+			template, placeholderCorpus, placeholderTable,
+		)
+	}
+	return &TableNamer{template: template}, nil
+}
+
+// Configure sets a table name template used by Name.
+// It is expected to be called once during the service startup.
+func Configure(template string) error {
+	namer, err := NewTableNamer(template)
+	if err != nil {
+		return err
+	}
+	defaultNamerLock.Lock()
+	defaultNamer = namer
+	defaultNamerLock.Unlock()
+	return nil
+}
+
+// Name returns a table name for a corpus and a table type
+// using the configured template (see Configure).
+func Name(corpus, table string) string {
+	defaultNamerLock.RLock()
+	defer defaultNamerLock.RUnlock()
+	return defaultNamer.Name(corpus, table)
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultTableName(t *testing.T) {
+	namer, err := NewTableNamer("")
+	assert.NoError(t, err)
+	assert.Equal(t, "intercorp_v13_liveattrs_entry", namer.Name("intercorp_v13", LiveAttrsEntry))
+}
+
+func TestCustomTableName(t *testing.T) {
+	namer, err := NewTableNamer("frodo_{table}__{corpus}")
+	assert.NoError(t, err)
+	assert.Equal(t, "frodo_word__syn2020", namer.Name("syn2020", Word))
+}
+
+func TestCustomTemplateNotAppliedToImporterTables(t *testing.T) {
+	namer, err := NewTableNamer("frodo_{table}__{corpus}")
+	assert.NoError(t, err)
+	assert.Equal(t, "syn2020_liveattrs_entry", namer.Name("syn2020", LiveAttrsEntry))
+	assert.Equal(t, "syn2020_liveattrs_entry_new", namer.Name("syn2020", LiveAttrsEntryNew))
+	assert.Equal(t, "syn2020_colcounts", namer.Name("syn2020", ColCounts))
+	assert.Equal(t, "syn2020_colcounts_new", namer.Name("syn2020", ColCountsNew))
+}
+
+func TestInvalidTableNameTemplate(t *testing.T) {
+	_, err := NewTableNamer("frodo_{table}")
+	assert.Error(t, err)
+}
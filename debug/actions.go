@@ -19,6 +19,7 @@ package debug
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"frodo/jobs"
 
@@ -56,6 +57,15 @@ func (a *Actions) CreateDummyJob(ctx *gin.Context) {
 	if ctx.Request.URL.Query().Get("error") == "1" {
 		jobInfo.Error = fmt.Errorf("dummy error")
 	}
+	priority := jobs.DefaultJobPriority
+	if sp := ctx.Request.URL.Query().Get("priority"); sp != "" {
+		priority, err = strconv.Atoi(sp)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer, uniresp.NewActionError("invalid priority: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
 	finishSignal := make(chan bool)
 	fn := func(upds chan<- jobs.GeneralJobInfo) {
 		defer close(upds)
@@ -63,9 +73,13 @@ func (a *Actions) CreateDummyJob(ctx *gin.Context) {
 		jobInfo.Result = &jobs.DummyJobResult{Payload: "Job Done!"}
 		upds <- jobInfo.AsFinished()
 	}
-	a.jobActions.EnqueueJob(&fn, jobInfo)
+	if err := a.jobActions.EnqueueJobWithPriority(&fn, jobInfo, priority); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionErrorFrom(err), jobs.EnqueueErrorStatus(err))
+		return
+	}
 	a.finishSignals[jobID.String()] = finishSignal
-	uniresp.WriteJSONResponse(ctx.Writer, jobInfo)
+	jobs.WriteJobAcceptedResponse(ctx, jobInfo.ID, jobInfo)
 }
 
 func (a *Actions) FinishDummyJob(ctx *gin.Context) {
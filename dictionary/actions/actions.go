@@ -18,6 +18,8 @@ package actions
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"frodo/corpus"
 	"frodo/db/mysql"
 	"frodo/general"
@@ -25,6 +27,7 @@ import (
 	"frodo/liveattrs/laconf"
 	"frodo/metadb"
 	"sync"
+	"time"
 )
 
 type Actions struct {
@@ -46,6 +49,10 @@ type Actions struct {
 
 	laCustomNgramDataDirPath string
 
+	// importQueryTimeout limits long running queries of n-gram
+	// generators (zero means no limit)
+	importQueryTimeout time.Duration
+
 	corpusMeta metadb.Provider
 
 	corpusMetaW metadb.SQLUpdater
@@ -74,6 +81,22 @@ func (a *Actions) clearDatasetSizes() {
 	a.datasetSizesCache = make(map[string]int64)
 }
 
+// resolveGroupedName finds a name under which dictionary
+// tables of a corpus are stored. For members of parallel corpora,
+// this is the name of the whole group (see corpus.DBInfo.GroupedName).
+// For corpora/datasets without corpus metadata, the original name
+// is returned.
+func (a *Actions) resolveGroupedName(corpusID string) (string, error) {
+	corpusDBInfo, err := a.corpusMeta.LoadInfo(corpusID)
+	if err == sql.ErrNoRows {
+		return corpusID, nil
+
+	} else if err != nil {
+		return "", fmt.Errorf("failed to resolve grouped name of %s: %w", corpusID, err)
+	}
+	return corpusDBInfo.GroupedName(), nil
+}
+
 // NewActions is the default factory for Actions
 func NewActions(
 	ctx context.Context,
@@ -84,6 +107,7 @@ func NewActions(
 	corpusMetaW metadb.SQLUpdater,
 	laDB *mysql.Adapter,
 	laCustomNgramDataDirPath string,
+	importQueryTimeout time.Duration,
 	laConfRegistry *laconf.LiveAttrsBuildConfProvider,
 	version general.VersionInfo,
 ) *Actions {
@@ -97,6 +121,7 @@ func NewActions(
 		corpusMetaW:              corpusMetaW,
 		laDB:                     laDB,
 		laCustomNgramDataDirPath: laCustomNgramDataDirPath,
+		importQueryTimeout:       importQueryTimeout,
 		datasetSizesCache:        make(map[string]int64),
 	}
 	return actions
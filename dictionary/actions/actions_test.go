@@ -0,0 +1,292 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"frodo/corpus"
+	"frodo/db/mysql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/mquery-common/corp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type dummyCorpusMeta struct {
+	data    map[string]*corpus.DBInfo
+	tagsets map[string][]corp.SupportedTagset
+	err     error
+}
+
+func (dcm *dummyCorpusMeta) LoadInfo(corpusID string) (*corpus.DBInfo, error) {
+	if dcm.err != nil {
+		return nil, dcm.err
+	}
+	v, ok := dcm.data[corpusID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return v, nil
+}
+
+func (dcm *dummyCorpusMeta) GetCorpusTagsets(corpusID string) ([]corp.SupportedTagset, error) {
+	if dcm.err != nil {
+		return nil, dcm.err
+	}
+	return dcm.tagsets[corpusID], nil
+}
+
+func (dcm *dummyCorpusMeta) LoadAliasedInfo(corpusID, aliasOf string) (*corpus.DBInfo, error) {
+	if aliasOf == "" {
+		return dcm.LoadInfo(corpusID)
+	}
+	ans, err := dcm.LoadInfo(aliasOf)
+	if err != nil {
+		return nil, err
+	}
+	tmp := *ans
+	tmp.Name = corpusID
+	return &tmp, nil
+}
+
+func newTestingActions(meta *dummyCorpusMeta) *Actions {
+	return &Actions{
+		corpusMeta:        meta,
+		datasetSizesCache: make(map[string]int64),
+	}
+}
+
+func TestResolveGroupedNameParallelMember(t *testing.T) {
+	a := newTestingActions(&dummyCorpusMeta{
+		data: map[string]*corpus.DBInfo{
+			"intercorp_v16ud_cs": {Name: "intercorp_v16ud_cs", ParallelCorpus: "intercorp_v16ud"},
+			"intercorp_v16ud_en": {Name: "intercorp_v16ud_en", ParallelCorpus: "intercorp_v16ud"},
+		},
+	})
+	name, err := a.resolveGroupedName("intercorp_v16ud_cs")
+	assert.NoError(t, err)
+	assert.Equal(t, "intercorp_v16ud", name)
+	name, err = a.resolveGroupedName("intercorp_v16ud_en")
+	assert.NoError(t, err)
+	assert.Equal(t, "intercorp_v16ud", name)
+}
+
+func TestResolveGroupedNameSingleCorpus(t *testing.T) {
+	a := newTestingActions(&dummyCorpusMeta{
+		data: map[string]*corpus.DBInfo{
+			"syn2020": {Name: "syn2020"},
+		},
+	})
+	name, err := a.resolveGroupedName("syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, "syn2020", name)
+}
+
+func TestResolveGroupedNameUnknownDataset(t *testing.T) {
+	a := newTestingActions(&dummyCorpusMeta{data: map[string]*corpus.DBInfo{}})
+	name, err := a.resolveGroupedName("custom_dataset")
+	assert.NoError(t, err)
+	assert.Equal(t, "custom_dataset", name)
+}
+
+func TestResolveGroupedNameDBError(t *testing.T) {
+	a := newTestingActions(&dummyCorpusMeta{err: errors.New("connection refused")})
+	_, err := a.resolveGroupedName("syn2020")
+	assert.Error(t, err)
+}
+
+func TestParseNgramSizes(t *testing.T) {
+	sizes, err := parseNgramSizes("1, 2,3")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, sizes)
+
+	sizes, err = parseNgramSizes("")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, sizes)
+
+	_, err = parseNgramSizes("1,x")
+	assert.Error(t, err)
+	_, err = parseNgramSizes("0")
+	assert.Error(t, err)
+	_, err = parseNgramSizes("2,2")
+	assert.Error(t, err)
+}
+
+// searchDriver is a fake database driver returning a single
+// lemma with two word forms for any dictionary query. Table existence
+// checks (information_schema) always report a missing table.
+// All the queries (including the existence checks) are recorded along
+// with their string arguments (which may contain table names).
+type searchDriver struct {
+	queries []string
+}
+
+func (d *searchDriver) Open(name string) (driver.Conn, error) {
+	return &searchConn{drv: d}, nil
+}
+
+func (d *searchDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *searchDriver) Driver() driver.Driver {
+	return d
+}
+
+type searchConn struct {
+	drv *searchDriver
+}
+
+func (c *searchConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements not supported")
+}
+
+func (c *searchConn) Close() error {
+	return nil
+}
+
+func (c *searchConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *searchConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	recorded := query
+	for _, arg := range args {
+		if v, ok := arg.Value.(string); ok {
+			recorded += " " + v
+		}
+	}
+	c.drv.queries = append(c.drv.queries, recorded)
+	if strings.Contains(query, "information_schema") {
+		return &searchRows{cols: []string{"cnt"}, data: [][]driver.Value{{int64(0)}}}, nil
+	}
+	if strings.HasPrefix(query, "SELECT DISTINCT w.lemma, w.pos") {
+		return &searchRows{cols: []string{"lemma", "pos"}, data: [][]driver.Value{{"dům", "N"}}}, nil
+	}
+	return &searchRows{
+		cols: []string{
+			"value", "lemma", "sublemma", "count", "pos",
+			"arf", "ngram", "sim_freqs_score", "initial_cap",
+		},
+		data: [][]driver.Value{
+			{"dům", "dům", "dům", int64(10), "N", float64(8), int64(1), float64(8), false},
+			{"domu", "dům", "dům", int64(5), "N", float64(4), int64(1), float64(4), false},
+		},
+	}, nil
+}
+
+type searchRows struct {
+	cols   []string
+	data   [][]driver.Value
+	served int
+}
+
+func (r *searchRows) Columns() []string {
+	return r.cols
+}
+
+func (r *searchRows) Close() error {
+	return nil
+}
+
+func (r *searchRows) Next(dest []driver.Value) error {
+	if r.served >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.served])
+	r.served++
+	return nil
+}
+
+func newSearchTestingActions(drv *searchDriver) *Actions {
+	a := newTestingActions(&dummyCorpusMeta{
+		data: map[string]*corpus.DBInfo{
+			"intercorp_v16ud_cs": {Name: "intercorp_v16ud_cs", ParallelCorpus: "intercorp_v16ud"},
+		},
+	})
+	a.laDB = mysql.NewAdapter(sql.OpenDB(drv), "frodo")
+	a.setDatasetSize("intercorp_v16ud_cs", 1000000)
+	return a
+}
+
+// assertGroupedTablesQueried tests that the dictionary of the parallel
+// corpus member has been searched via the tables of the whole parallel corpus
+func assertGroupedTablesQueried(t *testing.T, queries []string) {
+	if assert.NotEmpty(t, queries) {
+		for _, q := range queries {
+			assert.Contains(t, q, "intercorp_v16ud_")
+			assert.NotContains(t, q, "intercorp_v16ud_cs_")
+		}
+	}
+}
+
+func TestGetQuerySuggestionsParallelMember(t *testing.T) {
+	drv := &searchDriver{}
+	a := newSearchTestingActions(drv)
+	defer a.laDB.DB().Close()
+	engine := gin.New()
+	engine.GET("/dictionary/:corpusId/querySuggestions/:term", a.GetQuerySuggestions)
+
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(
+		http.MethodGet, "/dictionary/intercorp_v16ud_cs/querySuggestions/dům", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var ans struct {
+		Matches []struct {
+			Lemma string `json:"lemma"`
+		} `json:"matches"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &ans))
+	if assert.Len(t, ans.Matches, 1) {
+		assert.Equal(t, "dům", ans.Matches[0].Lemma)
+	}
+	assertGroupedTablesQueried(t, drv.queries)
+}
+
+func TestSimilarARFWordsParallelMember(t *testing.T) {
+	drv := &searchDriver{}
+	a := newSearchTestingActions(drv)
+	defer a.laDB.DB().Close()
+	engine := gin.New()
+	engine.GET("/dictionary/:corpusId/similarARFWords/:term", a.SimilarARFWords)
+
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(
+		http.MethodGet, "/dictionary/intercorp_v16ud_cs/similarARFWords/dům", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var ans struct {
+		Matches []struct {
+			Lemma string `json:"lemma"`
+		} `json:"matches"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &ans))
+	assert.NotEmpty(t, ans.Matches)
+	assertGroupedTablesQueried(t, drv.queries)
+}
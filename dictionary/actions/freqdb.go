@@ -18,14 +18,18 @@ package actions
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"frodo/dictionary"
+	"frodo/middleware"
 	"net/http"
 	"strings"
 
 	"github.com/czcorpus/cnc-gokit/unireq"
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 const (
@@ -50,8 +54,9 @@ func (a *Actions) CreateQuerySuggestions(ctx *gin.Context) {
 	uniresp.WriteJSONResponse(ctx.Writer, corpusID)
 }
 
-func (a *Actions) attachMatchTypes(term string, result []dictionary.Lemma, caseSens bool) []searchedLemma {
-	ans := make([]searchedLemma, len(result))
+// matchTypeAttacher returns a function adding information about
+// where (word, sublemma) the term has been found in a lemma
+func matchTypeAttacher(term string, caseSens bool) func(lemma dictionary.Lemma) searchedLemma {
 	var lcMod func(string) string
 	if caseSens {
 		lcMod = func(s string) string { return s }
@@ -61,8 +66,8 @@ func (a *Actions) attachMatchTypes(term string, result []dictionary.Lemma, caseS
 	}
 	term = lcMod(term)
 
-	for i, lemma := range result {
-		ans[i] = searchedLemma{
+	return func(lemma dictionary.Lemma) searchedLemma {
+		ans := searchedLemma{
 			Lemma: dictionary.Lemma{
 				ID:          lemma.ID,
 				Lemma:       lemma.Lemma,
@@ -78,29 +83,94 @@ func (a *Actions) attachMatchTypes(term string, result []dictionary.Lemma, caseS
 		}
 		for _, form := range lemma.Forms {
 			if term == lcMod(form.Value) {
-				ans[i].FoundIn = "word"
+				ans.FoundIn = "word"
 				break
 			}
 		}
-		if ans[i].FoundIn == "" {
+		if ans.FoundIn == "" {
 			for _, subl := range lemma.Sublemmas {
 				if term == lcMod(subl.Value) {
-					ans[i].FoundIn = "sublemma"
+					ans.FoundIn = "sublemma"
 					break
 				}
 			}
 		}
+		return ans
+	}
+}
+
+func (a *Actions) attachMatchTypes(term string, result []dictionary.Lemma, caseSens bool) []searchedLemma {
+	attach := matchTypeAttacher(term, caseSens)
+	ans := make([]searchedLemma, len(result))
+	for i, lemma := range result {
+		ans[i] = attach(lemma)
 	}
 	return ans
 }
 
+// streamQuerySuggestions writes matching lemmas one per line (NDJSON)
+// as they are read from the database. Once the response has started,
+// errors cannot be reported via HTTP status so they are written as
+// a final `{"error": "..."}` line.
+func (a *Actions) streamQuerySuggestions(
+	ctx *gin.Context,
+	groupedName string,
+	term string,
+	caseSensitive bool,
+	opts ...dictionary.SearchOption,
+) {
+	middleware.DisableWriteDeadline(ctx)
+	attach := matchTypeAttacher(term, caseSensitive)
+	enc := json.NewEncoder(ctx.Writer)
+	var headerWritten bool
+	err := dictionary.SearchStream(
+		ctx,
+		a.laDB,
+		groupedName,
+		func(lemma dictionary.Lemma) error {
+			if !headerWritten {
+				ctx.Writer.Header().Set("Content-Type", middleware.NDJSONContentType)
+				ctx.Writer.WriteHeader(http.StatusOK)
+				headerWritten = true
+			}
+			if err := enc.Encode(attach(lemma)); err != nil {
+				return err
+			}
+			ctx.Writer.Flush()
+			return nil
+		},
+		opts...,
+	)
+	if err != nil {
+		log.Error().Err(err).Str("term", term).Msg("failed to stream dictionary search results")
+		if !headerWritten {
+			uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+			return
+		}
+		if err := enc.Encode(map[string]string{"error": err.Error()}); err != nil {
+			log.Error().Err(err).Msg("failed to write streamed error")
+		}
+		return
+	}
+	if !headerWritten {
+		ctx.Writer.Header().Set("Content-Type", middleware.NDJSONContentType)
+		ctx.Writer.WriteHeader(http.StatusOK)
+	}
+}
+
 // GetQuerySuggestions godoc
 // @Summary      Get query suggestions for a specified corpus
+// @Description  Matching lemmas can be also streamed as NDJSON (one lemma per line) by setting `Accept: application/x-ndjson` or `format=ndjson`. In such case, a possible error occurring after the response has started is reported as a final line with the `error` key.
 // @Produce      json
+// @Produce      application/x-ndjson
 // @Param        corpusId path string true "Used corpus"
 // @Param        term path string true "Search term"
 // @Param        no-multivalues query int false "Forbid multivalues" default(0)
 // @Param        pos query string false "Search part of speach"
+// @Param        format query string false "Output format (ndjson)"
+// @Param        match query string false "Matching mode (any, prefix, suffix)" default(any)
+// @Param        offset query int false "Number of matching lemmas to skip" default(0)
+// @Param        limit query int false "Maximum number of returned lemmas (0 = no limit); the JSON response contains the hasMore flag" default(0)
 // @Success      200 {object} map[string]any
 // @Router       /dictionary/{corpusId}/querySuggestions/{term} [get]
 // @Router       /dictionary/{corpusId}/search/{term} [get]
@@ -121,29 +191,75 @@ func (a *Actions) GetQuerySuggestions(ctx *gin.Context) {
 		posOpts = dictionary.SearchWithPoS(pos)
 	}
 
+	var termOpts dictionary.SearchOption
+	switch ctx.DefaultQuery("match", dictionary.MatchAny) {
+	case dictionary.MatchAny:
+		termOpts = dictionary.SearchWithAnyValue(term)
+	case dictionary.MatchPrefix:
+		termOpts = dictionary.SearchWithPrefix(term)
+	case dictionary.MatchSuffix:
+		termOpts = dictionary.SearchWithSuffix(term)
+	default:
+		uniresp.RespondWithErrorJSON(
+			ctx, fmt.Errorf("invalid match mode %s", ctx.Query("match")), http.StatusBadRequest)
+		return
+	}
+
+	offset, ok := unireq.GetURLIntArgOrFail(ctx, "offset", 0)
+	if !ok {
+		return
+	}
+	limit, ok := unireq.GetURLIntArgOrFail(ctx, "limit", 0)
+	if !ok {
+		return
+	}
+	if offset < 0 || limit < 0 {
+		uniresp.RespondWithErrorJSON(
+			ctx, fmt.Errorf("offset and limit must be non-negative"), http.StatusBadRequest)
+		return
+	}
+
 	datasetSize, err := a.GetDatasetSize(corpusID)
 	if err != nil {
 		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
 		return
 	}
+	groupedName, err := a.resolveGroupedName(corpusID)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		return
+	}
 
-	items, err := dictionary.Search(
-		ctx,
-		a.laDB,
-		corpusID,
-		dictionary.SearchWithAnyValue(term),
+	srchOpts := []dictionary.SearchOption{
+		termOpts,
 		dictionary.SearchWithAnyValueCS(caseSensitive),
 		dictionary.SearchWithDatasetSizeForIPM(int(datasetSize)),
 		mvOpts,
 		posOpts,
-	)
+		dictionary.SearchWithOffset(offset),
+	}
+	if middleware.WantsNDJSON(ctx) {
+		srchOpts = append(srchOpts, dictionary.SearchWithLimit(limit))
+		a.streamQuerySuggestions(ctx, groupedName, term, caseSensitive, srchOpts...)
+		return
+	}
+	if limit > 0 {
+		// we read one more lemma to find out whether there are more results
+		srchOpts = append(srchOpts, dictionary.SearchWithLimit(limit+1))
+	}
+	items, err := dictionary.Search(ctx, a.laDB, groupedName, srchOpts...)
 
 	if err != nil {
 		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
 		return
 	}
+	hasMore := limit > 0 && len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
 	ans := map[string]any{
 		"matches": a.attachMatchTypes(term, items, caseSensitive),
+		"hasMore": hasMore,
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
@@ -179,7 +295,10 @@ func (a *Actions) GetDatasetSize(datasetName string) (int64, error) {
 // @Param        pos query string false "Search part of speach"
 // @Param        rangeCoeff query float64 false "Search range coefficient" default(0.2) minimum(0) maximum(1)
 // @Param        maxkItems query int false "Maximum number of items" default(20)
+// @Param        compact query int false "Return only word, count and ipm for each match" default(0)
+// @Param        level query string false "Search similar words or lemmas (by their aggregate ARF)" Enums(word, lemma) default(word)
 // @Success      200 {object} map[string]any
+// @Failure      409 {object} uniresp.ActionError "Lemma stats must be regenerated (level=lemma only)"
 // @Router       /dictionary/{corpusId}/similarARFWords/{term} [get]
 func (a *Actions) SimilarARFWords(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
@@ -199,11 +318,22 @@ func (a *Actions) SimilarARFWords(ctx *gin.Context) {
 	if !ok {
 		return
 	}
+	level := ctx.DefaultQuery("level", dictionary.SimFreqLevelWord)
+	if level != dictionary.SimFreqLevelWord && level != dictionary.SimFreqLevelLemma {
+		uniresp.RespondWithErrorJSON(
+			ctx, fmt.Errorf("invalid level %s", level), http.StatusBadRequest)
+		return
+	}
+	groupedName, err := a.resolveGroupedName(corpusID)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		return
+	}
 
 	termSrch, err := dictionary.Search(
 		ctx,
 		a.laDB,
-		corpusID,
+		groupedName,
 		dictionary.SearchWithWord(word),
 		dictionary.SearchWithLemma(lemma),
 		dictionary.SearchWithPoS(pos),
@@ -214,14 +344,35 @@ func (a *Actions) SimilarARFWords(ctx *gin.Context) {
 		return
 	}
 	if len(termSrch) > 0 {
-		items, err := dictionary.SimilarARFWords(
-			ctx,
-			a.laDB,
-			corpusID,
-			termSrch[0],
-			rangeCoeff,
-			maxNumItems,
-		)
+		var items []dictionary.Lemma
+		if level == dictionary.SimFreqLevelLemma {
+			items, err = dictionary.SimilarARFLemmas(
+				ctx,
+				a.laDB,
+				groupedName,
+				termSrch[0],
+				rangeCoeff,
+				maxNumItems,
+			)
+
+		} else {
+			items, err = dictionary.SimilarARFWords(
+				ctx,
+				a.laDB,
+				groupedName,
+				termSrch[0],
+				rangeCoeff,
+				maxNumItems,
+			)
+		}
+		if errors.Is(err, dictionary.ErrLemmaStatsOutdated) {
+			uniresp.RespondWithErrorJSON(ctx, err, http.StatusConflict)
+			return
+
+		} else if err != nil {
+			uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+			return
+		}
 		datasetSize, err := a.GetDatasetSize(corpusID)
 		if err != nil {
 			uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
@@ -230,8 +381,20 @@ func (a *Actions) SimilarARFWords(ctx *gin.Context) {
 		for i := range items {
 			items[i].IPM = float64(items[i].Count) / float64(datasetSize) * 1000000
 		}
-		ans := map[string]any{
-			"matches": items,
+		var ans map[string]any
+		if ctx.Request.URL.Query().Get("compact") == "1" {
+			compactItems := make([]dictionary.LemmaCompact, len(items))
+			for i, item := range items {
+				compactItems[i] = item.CompactVersion()
+			}
+			ans = map[string]any{
+				"matches": compactItems,
+			}
+
+		} else {
+			ans = map[string]any{
+				"matches": items,
+			}
 		}
 		uniresp.WriteJSONResponse(ctx.Writer, ans)
 
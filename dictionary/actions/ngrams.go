@@ -17,25 +17,28 @@
 package actions
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"frodo/corpus"
 	"frodo/db/mysql"
+	"frodo/jobs"
+	"frodo/liveattrs"
 	"frodo/liveattrs/db/freqdb"
 	"frodo/liveattrs/laconf"
-	"io"
+	"frodo/middleware"
 	"net/http"
-	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/czcorpus/cnc-gokit/strutil"
-	"github.com/czcorpus/cnc-gokit/unireq"
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/czcorpus/mquery-common/corp"
 	"github.com/czcorpus/vert-tagextract/v3/cnf"
+	"github.com/rs/zerolog/log"
 )
 
 func ShowErrorChain(err error) string {
@@ -87,6 +90,34 @@ func mergeAliasedConfig(orig, aliased *cnf.VTEConf) *cnf.VTEConf {
 	return &ans
 }
 
+// parseNgramSizes parses the `ngramSize` URL argument which may contain
+// either a single n-gram size or a comma-separated list of sizes
+// (e.g. `1,2,3`). An empty value means the default size 1.
+func parseNgramSizes(v string) ([]int, error) {
+	if v == "" {
+		return []int{1}, nil
+	}
+	items := strings.Split(v, ",")
+	ans := make([]int, 0, len(items))
+	for _, item := range items {
+		size, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid n-gram size: %s", item)
+		}
+		if slices.Contains(ans, size) {
+			return nil, fmt.Errorf("duplicate n-gram size: %d", size)
+		}
+		ans = append(ans, size)
+	}
+	return ans, nil
+}
+
+var (
+	// ErrorNoSupportedTagset means that a tagset for n-gram
+	// generation cannot be determined
+	ErrorNoSupportedTagset = errors.New("no supported tagset")
+)
+
 type NGramsReqArgs struct {
 	ColMapping            *corpus.QSAttributes `json:"colMapping,omitempty"`
 	PosTagset             corp.SupportedTagset `json:"posTagset"`
@@ -96,8 +127,8 @@ type NGramsReqArgs struct {
 }
 
 func (args NGramsReqArgs) Validate() error {
-	if args.MinFreq <= 0 {
-		args.MinFreq = 1
+	if args.MinFreq < 0 {
+		return fmt.Errorf("invalid minFreq: %d", args.MinFreq)
 	}
 	if err := args.PosTagset.Validate(); err != nil {
 		return fmt.Errorf("failed to validate tagset: %w", err)
@@ -118,13 +149,45 @@ func (args NGramsReqArgs) Validate() error {
 	return nil
 }
 
-func (a *Actions) getNgramArgs(req *http.Request) (NGramsReqArgs, error) {
-	var jsonArgs NGramsReqArgs
-	err := json.NewDecoder(req.Body).Decode(&jsonArgs)
-	if err == io.EOF {
-		err = nil
+// resolveNgramTagset determines a tagset used for n-gram generation.
+// An explicitly requested tagset is always used as is (i.e. tagsets
+// declared for the corpus are ignored). Otherwise, in case the tagset
+// is required (i.e. column mapping is inferred from the corpus registry),
+// the preferred one of the corpus-declared tagsets is used and
+// ErrorNoSupportedTagset is returned if none of them is supported.
+// In case the tagset is not required and not requested, an empty
+// tagset is returned.
+func (a *Actions) resolveNgramTagset(
+	corpusID, aliasOf string,
+	requested corp.SupportedTagset,
+	required bool,
+) (corp.SupportedTagset, error) {
+	if requested != "" {
+		if err := requested.Validate(); err != nil {
+			return "", fmt.Errorf("%w: %w", ErrorNoSupportedTagset, err)
+		}
+		return requested, nil
+	}
+	if !required {
+		return "", nil
+	}
+	tagsetCorpusID := corpusID
+	if aliasOf != "" {
+		tagsetCorpusID = aliasOf
+	}
+	corpTagsets, err := a.corpusMeta.GetCorpusTagsets(tagsetCorpusID)
+	if err != nil {
+		return "", err
+	}
+	tagset := a.corpConf.GetPreferredTagset(corpTagsets)
+	if tagset == "" {
+		avail := strutil.JoinAny(corpTagsets, func(v corp.SupportedTagset) string { return v.String() }, ", ")
+		return "", fmt.Errorf(
+			"%w: cannot find a suitable default tagset for %s (found: %s)",
+			ErrorNoSupportedTagset, corpusID, avail,
+		)
 	}
-	return jsonArgs, err
+	return tagset, nil
 }
 
 // GenerateNgrams godoc
@@ -132,28 +195,47 @@ func (a *Actions) getNgramArgs(req *http.Request) (NGramsReqArgs, error) {
 // @Produce      json
 // @Param        corpusId path string true "Used corpus"
 // @Param        append query int false "Append mode" default(0)
-// @Param        ngramSize query int false "N-gram size" default(1)
-// @Success      200 {object} any
+// @Param        minFreq query int false "Minimum absolute frequency of an n-gram to be stored (overrides the minFreq body argument)" default(1)
+// @Param        dryRun query int false "Only resolve and validate the settings and return them along with a size estimation; no job is created" default(0)
+// @Param        ngramSize query string false "N-gram size or a comma-separated list of sizes (e.g. 1,2,3) to be generated as chained jobs" default(1)
+// @Param        force query int false "Create the job even if another n-gram job for the corpus is unfinished" default(0)
+// @Success      202 {object} any "Job info or a list of job infos in case multiple sizes are requested"
+// @Header       202 {string} Location "URL path of the created job"
+// @Failure      409 {object} jobs.JobConflictResponse "An n-gram job for the corpus is unfinished"
+// @Failure      429 {object} uniresp.ActionError "Job queue is full"
 // @Router       /dictionary/{corpusId}/ngrams [post]
 func (a *Actions) GenerateNgrams(ctx *gin.Context) {
-	corpusID := ctx.Param("corpusId")
-	aliasOf := ctx.Query("aliasOf")
-	appendMode := ctx.Request.URL.Query().Get("append") == "1"
-	ngramSize, ok := unireq.GetURLIntArgOrFail(ctx, "ngramSize", 1)
-	if !ok {
+	req, err := jobs.NewJobRequest(ctx)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, middleware.RequestBodyErrorStatus(err))
 		return
 	}
+	job, err := a.CreateNgramsJob(ctx, req)
+	jobs.WriteCreatedJobResponse(ctx, job, err)
+}
+
+// CreateNgramsJob is a jobs.JobCreator behind the GenerateNgrams action.
+// In case of a dry run, no job is created.
+func (a *Actions) CreateNgramsJob(ctx context.Context, req jobs.JobRequest) (jobs.CreatedJob, error) {
+	corpusID := req.CorpusID
+	aliasOf := req.Query.Get("aliasOf")
+	appendMode := req.Query.Get("append") == "1"
+	ngramSizes, err := parseNgramSizes(req.Query.Get("ngramSize"))
+	if err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusBadRequest)
+	}
 	var laConf *cnf.VTEConf
-	var err error
+	// we capture the config version to be able to detect
+	// a config change during the build
+	confVersion := a.laConfCache.Version(corpusID)
+	aliasConfVersion := a.laConfCache.Version(aliasOf)
 	if aliasOf != "" {
 		laConf, err = a.laConfCache.Get(aliasOf)
 		if err == laconf.ErrorNoSuchConfig {
-			uniresp.RespondWithErrorJSON(ctx, fmt.Errorf("aliased corpus not found"), http.StatusNotFound)
-			return
+			return jobs.CreatedJob{}, jobs.NewJobRequestError(fmt.Errorf("aliased corpus not found"), http.StatusNotFound)
 
 		} else if err != nil {
-			uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
-			return
+			return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusInternalServerError)
 		}
 		laConf.Corpus = corpusID
 
@@ -171,41 +253,42 @@ func (a *Actions) GenerateNgrams(ctx *gin.Context) {
 			laConf = mergeAliasedConfig(laConf, laAlias)
 
 		} else if err != laconf.ErrorNoSuchConfig {
-			uniresp.RespondWithErrorJSON(
-				ctx,
-				err,
-				http.StatusInternalServerError,
-			)
-			return
+			return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusInternalServerError)
 		}
 
 	} else {
 		laConf, err = a.laConfCache.Get(corpusID)
 	}
 	if err == laconf.ErrorNoSuchConfig {
-		uniresp.RespondWithErrorJSON(
-			ctx,
-			err,
-			http.StatusNotFound,
-		)
-		return
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusNotFound)
 
 	} else if err != nil {
-		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
-		return
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusInternalServerError)
 	}
 
-	args, err := a.getNgramArgs(ctx.Request)
+	var args NGramsReqArgs
+	if err := req.DecodeArgs(&args); err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusBadRequest)
+	}
+	minFreq, err := req.IntArg("minFreq", max(args.MinFreq, 1))
 	if err != nil {
-		uniresp.RespondWithErrorJSON(ctx, err, http.StatusBadRequest)
-		return
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusBadRequest)
 	}
+	args.MinFreq = minFreq
 	if err = args.Validate(); err != nil {
-		uniresp.RespondWithErrorJSON(ctx, err, http.StatusUnprocessableEntity)
-		return
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusUnprocessableEntity)
 	}
 
-	var tagset corp.SupportedTagset
+	// the tagset is resolved independently of the column mapping source
+	// so an explicitly requested tagset is always used
+	inferMapping := args.ColMapping == nil && len(laConf.Ngrams.VertColumns) == 0
+	tagset, err := a.resolveNgramTagset(corpusID, aliasOf, args.PosTagset, inferMapping)
+	if errors.Is(err, ErrorNoSupportedTagset) {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusUnprocessableEntity)
+
+	} else if err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusInternalServerError)
+	}
 
 	if args.ColMapping == nil {
 
@@ -225,65 +308,27 @@ func (a *Actions) GenerateNgrams(ctx *gin.Context) {
 					args.ColMapping.Sublemma = v.Idx
 				}
 			}
-			tagset = args.PosTagset
 
 		} else {
 
-			var regPath string
+			regCorpusID := corpusID
 			if aliasOf != "" {
-				regPath = filepath.Join(a.corpConf.RegistryDirPaths[0], aliasOf) // TODO the [0]
-
-			} else {
-				regPath = filepath.Join(a.corpConf.RegistryDirPaths[0], corpusID) // TODO the [0]
+				regCorpusID = aliasOf
 			}
-
-			var corpTagsets []corp.SupportedTagset
-			var err error
-
-			if args.PosTagset != "" {
-				corpTagsets = []corp.SupportedTagset{args.PosTagset}
-
-			} else if aliasOf != "" {
-				corpTagsets, err = a.corpusMeta.GetCorpusTagsets(aliasOf)
-				if err != nil {
-					uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
-					return
-				}
-
-			} else {
-				corpTagsets, err = a.corpusMeta.GetCorpusTagsets(corpusID)
-				if err != nil {
-					uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
-					return
-				}
-			}
-			tagset = corpus.GetFirstSupportedTagset(corpTagsets)
-			if tagset == "" {
-				avail := strutil.JoinAny(corpTagsets, func(v corp.SupportedTagset) string { return v.String() }, ", ")
-				uniresp.RespondWithErrorJSON(
-					ctx, fmt.Errorf(
-						"cannot find a suitable default tagset for %s (found: %s)",
-						corpusID, avail,
-					),
-					http.StatusUnprocessableEntity,
-				)
-				return
+			regPath, err := a.corpConf.FindRegistryFile(regCorpusID)
+			if err != nil {
+				return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusUnprocessableEntity)
 			}
 			attrMapping, err := corpus.InferQSAttrMapping(regPath, tagset)
 			if err != nil {
-				uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
-				return
+				return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusInternalServerError)
 			}
 			args.ColMapping = &attrMapping
 			// now we need to revalidate to make sure the inference provided correct setup
 			if err = args.Validate(); err != nil {
-				uniresp.RespondWithErrorJSON(ctx, err, http.StatusUnprocessableEntity)
-				return
+				return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusUnprocessableEntity)
 			}
 		}
-
-	} else {
-		tagset = args.PosTagset
 	}
 
 	// the args.ColMapping.Tag arg below is likely OK,
@@ -293,59 +338,220 @@ func (a *Actions) GenerateNgrams(ctx *gin.Context) {
 	// ([corpus]_colcounts table)
 	posFn, err := corpus.ApplyPosProperties(&laConf.Ngrams, args.ColMapping.Tag, tagset)
 	if err == corpus.ErrorPosNotDefined {
-		uniresp.RespondWithErrorJSON(ctx, err, http.StatusUnprocessableEntity)
-		return
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusUnprocessableEntity)
 
 	} else if err != nil {
-		uniresp.RespondWithErrorJSON(
-			ctx,
-			err,
-			http.StatusInternalServerError,
-		)
-		return
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusInternalServerError)
 	}
 
 	groupedName := corpusID
 	if !args.SkipGroupedNameSearch {
 		corpusDBInfo, err := a.corpusMeta.LoadAliasedInfo(corpusID, aliasOf)
 		if err != nil {
-			uniresp.RespondWithErrorJSON(
-				ctx,
-				err,
-				http.StatusInternalServerError,
-			)
-			return
+			return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusInternalServerError)
 		}
 		corpusDBInfo.Name = corpusID
 		groupedName = corpusDBInfo.GroupedName()
 	}
 
-	tunedDb, err := mysql.OpenImportTunedDB(a.laDB.Conf())
-	if err != nil {
+	configGuard := func() error {
+		if v := a.laConfCache.Version(corpusID); v != confVersion {
+			return fmt.Errorf("%w (%s: version %d -> %d)", freqdb.ErrorConfigChanged, corpusID, confVersion, v)
+		}
+		if aliasOf != "" {
+			if v := a.laConfCache.Version(aliasOf); v != aliasConfVersion {
+				return fmt.Errorf("%w (%s: version %d -> %d)", freqdb.ErrorConfigChanged, aliasOf, aliasConfVersion, v)
+			}
+		}
+		return nil
+	}
+
+	if req.Query.Get("dryRun") == "1" {
+		previews := make([]freqdb.NgramPreview, 0, len(ngramSizes))
+		for i, ngramSize := range ngramSizes {
+			generator := freqdb.NewNgramFreqGenerator(
+				a.laDB,
+				a.jobActions,
+				groupedName,
+				corpusID,
+				a.laCustomNgramDataDirPath,
+				args.UsePartitionedTable,
+				appendMode || i > 0,
+				ngramSize,
+				posFn,
+				*args.ColMapping,
+				args.MinFreq,
+			)
+			preview, err := generator.Preview()
+			if err != nil {
+				return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusInternalServerError)
+			}
+			previews = append(previews, preview)
+		}
+		return jobs.CreatedJob{
+			Body: map[string]any{
+				"dryRun":              true,
+				"corpusId":            corpusID,
+				"aliasOf":             aliasOf,
+				"groupedName":         groupedName,
+				"tagset":              tagset,
+				"colMapping":          args.ColMapping,
+				"appendMode":          appendMode,
+				"minFreq":             args.MinFreq,
+				"usePartitionedTable": args.UsePartitionedTable,
+				"ngrams":              previews,
+			},
+		}, nil
+	}
+
+	// Please note that a client-provided parent job does not prevent
+	// overlapping jobs (it can be any job), only the jobs chained below do.
+	parentJobID := req.Query.Get("parentJobId")
+
+	enqueueNgramJob := func(i int, parentJobID string) (jobs.GeneralJobInfo, error) {
+		ngramSize := ngramSizes[i]
+		tunedDb, err := mysql.OpenImportTunedDB(a.laDB.Conf(), a.laDB.PoolConf(), a.importQueryTimeout)
+		if err != nil {
+			return nil, err
+		}
+		generator := freqdb.NewNgramFreqGenerator(
+			tunedDb,
+			a.jobActions,
+			groupedName,
+			corpusID,
+			a.laCustomNgramDataDirPath,
+			args.UsePartitionedTable,
+			appendMode || i > 0,
+			ngramSize,
+			posFn,
+			*args.ColMapping,
+			args.MinFreq,
+		)
+		generator.SetConfigGuard(configGuard)
+		generator.SetQueryTimeout(a.importQueryTimeout)
+		jobInfo, err := generator.GenerateAfter(parentJobID)
+		if err != nil {
+			if err := tunedDb.Close(); err != nil {
+				log.Error().Err(err).Msg("failed to close import-tuned connection")
+			}
+			return nil, err
+		}
+		return jobInfo, nil
+	}
+
+	// In case multiple sizes are requested, we chain the jobs so each one
+	// starts once the previous one finishes. All the jobs but the first one
+	// append to the tables. A failed job makes all its dependents fail too
+	// (see jobs.Actions.EqueueJobAfter).
+	var chain []jobs.GeneralJobInfo
+	err = a.jobActions.EnqueueIfNoConflict(
+		req.Query.Get("force") == "1",
+		corpusID,
+		[]string{freqdb.NgramJobType},
+		func() (err error) {
+			chain, err = a.enqueueJobChain(len(ngramSizes), parentJobID, enqueueNgramJob)
+			return err
+		},
+	)
+	var confErr jobs.ConflictingJobError
+	if errors.As(err, &confErr) {
+		return jobs.CreatedJob{}, err
+
+	} else if err != nil {
+		log.Error().
+			Err(err).
+			Str("corpusId", corpusID).
+			Int("numWithdrawnJobs", len(chain)).
+			Msg("failed to enqueue n-gram jobs")
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, jobs.EnqueueErrorStatus(err))
+	}
+	lastJobID := chain[len(chain)-1].GetID()
+	if len(chain) == 1 {
+		return jobs.CreatedJob{JobID: lastJobID, Body: chain[0].FullInfo()}, nil
+	}
+	jobInfos := make([]any, len(chain))
+	for i, jobInfo := range chain {
+		jobInfos[i] = jobInfo.FullInfo()
+	}
+	return jobs.CreatedJob{JobID: lastJobID, Body: jobInfos}, nil
+}
+
+// enqueueJobChain creates numJobs jobs via enqueueFn where each job
+// waits for the previous one (the first one waits for parentJobID,
+// if not empty). In case any of the jobs cannot be enqueued, all
+// the already enqueued jobs of the chain are withdrawn so no orphaned
+// part of the chain remains. In such case, the withdrawn jobs are
+// returned along with the error.
+func (a *Actions) enqueueJobChain(
+	numJobs int,
+	parentJobID string,
+	enqueueFn func(i int, parentJobID string) (jobs.GeneralJobInfo, error),
+) ([]jobs.GeneralJobInfo, error) {
+	chain := make([]jobs.GeneralJobInfo, 0, numJobs)
+	for i := 0; i < numJobs; i++ {
+		jobInfo, err := enqueueFn(i, parentJobID)
+		if err != nil {
+			for j := len(chain) - 1; j >= 0; j-- {
+				if !a.jobActions.WithdrawJob(chain[j].GetID()) {
+					log.Warn().Str("jobId", chain[j].GetID()).Msg("failed to withdraw chained job")
+				}
+			}
+			return chain, err
+		}
+		chain = append(chain, jobInfo)
+		parentJobID = jobInfo.GetID()
+	}
+	return chain, nil
+}
+
+// DeleteNgrams godoc
+// @Summary      Remove generated n-gram tables of a specified corpus
+// @Description  Can be used to clean up after a failed n-gram generation. The action is refused in case an n-gram generating job (or a liveattrs job if colCounts=1) for the corpus is running or waiting in the queue.
+// @Produce      json
+// @Param        corpusId path string true "Used corpus"
+// @Param        colCounts query int false "Remove also the colcounts table (n-gram generation will then require liveattrs to be rebuilt)" default(0)
+// @Success      200 {object} any
+// @Failure      409 {object} uniresp.ActionError "An unfinished job for the corpus exists"
+// @Router       /dictionary/{corpusId}/ngrams [delete]
+func (a *Actions) DeleteNgrams(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	withColCounts := ctx.Query("colCounts") == "1"
+	jobTypes := []string{freqdb.NgramJobType}
+	if withColCounts {
+		jobTypes = append(jobTypes, liveattrs.JobType)
+	}
+	if prevJob, ok := a.jobActions.ConflictingJob(corpusID, jobTypes...); ok {
 		uniresp.RespondWithErrorJSON(
 			ctx,
-			err,
-			http.StatusInternalServerError,
+			fmt.Errorf("%s job %s not finished yet", prevJob.GetType(), prevJob.GetID()),
+			http.StatusConflict,
 		)
 		return
 	}
-	generator := freqdb.NewNgramFreqGenerator(
-		tunedDb,
-		a.jobActions,
-		groupedName,
-		corpusID,
-		a.laCustomNgramDataDirPath,
-		args.UsePartitionedTable,
-		appendMode,
-		ngramSize,
-		posFn,
-		*args.ColMapping,
-		args.MinFreq,
-	)
-	jobInfo, err := generator.GenerateAfter(ctx.Request.URL.Query().Get("parentJobId"))
+	groupedName, err := a.resolveGroupedName(corpusID)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		return
+	}
+	removed, err := freqdb.DeleteNgramTables(a.laDB, groupedName, withColCounts)
 	if err != nil {
+		log.Error().
+			Err(err).
+			Str("corpusId", corpusID).
+			Strs("removedTables", removed).
+			Msg("failed to delete n-gram tables")
 		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
 		return
 	}
-	uniresp.WriteJSONResponse(ctx.Writer, jobInfo.FullInfo())
+	log.Info().
+		Str("corpusId", corpusID).
+		Strs("removedTables", removed).
+		Msg("deleted n-gram tables")
+	uniresp.WriteJSONResponse(
+		ctx.Writer,
+		map[string]any{
+			"ok":            true,
+			"removedTables": removed,
+		},
+	)
 }
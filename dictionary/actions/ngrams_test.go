@@ -0,0 +1,294 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"frodo/corpus"
+	"frodo/db/mysql"
+	"frodo/jobs"
+	"frodo/liveattrs/db/freqdb"
+	"frodo/liveattrs/laconf"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/mquery-common/corp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTagsetTestingActions(tagsets map[string][]corp.SupportedTagset) *Actions {
+	a := newTestingActions(&dummyCorpusMeta{tagsets: tagsets})
+	a.corpConf = &corpus.CorporaSetup{}
+	return a
+}
+
+func TestResolveNgramTagsetOverrideWithMapping(t *testing.T) {
+	// the corpus metadata must not be consulted at all
+	a := newTestingActions(&dummyCorpusMeta{err: errors.New("metadata not available")})
+	tagset, err := a.resolveNgramTagset("syn2020", "", corp.TagsetUD, false)
+	assert.NoError(t, err)
+	assert.Equal(t, corp.TagsetUD, tagset)
+}
+
+func TestResolveNgramTagsetOverrideWithInferredMapping(t *testing.T) {
+	a := newTagsetTestingActions(map[string][]corp.SupportedTagset{
+		"syn2020": {"foo_tagset", "bar_tagset"},
+	})
+	tagset, err := a.resolveNgramTagset("syn2020", "", corp.TagsetCSCNC2020, true)
+	assert.NoError(t, err)
+	assert.Equal(t, corp.TagsetCSCNC2020, tagset)
+}
+
+func TestResolveNgramTagsetInvalidOverride(t *testing.T) {
+	a := newTagsetTestingActions(map[string][]corp.SupportedTagset{
+		"syn2020": {corp.TagsetUD},
+	})
+	for _, required := range []bool{true, false} {
+		_, err := a.resolveNgramTagset("syn2020", "", "foo_tagset", required)
+		assert.ErrorIs(t, err, ErrorNoSupportedTagset)
+	}
+}
+
+func TestResolveNgramTagsetFromCorpus(t *testing.T) {
+	a := newTagsetTestingActions(map[string][]corp.SupportedTagset{
+		"syn2020":   {"foo_tagset", corp.TagsetUD},
+		"intercorp": {"foo_tagset", "bar_tagset"},
+	})
+	tagset, err := a.resolveNgramTagset("syn2020", "", "", true)
+	assert.NoError(t, err)
+	assert.Equal(t, corp.TagsetUD, tagset)
+
+	tagset, err = a.resolveNgramTagset("syn2020_alias", "syn2020", "", true)
+	assert.NoError(t, err)
+	assert.Equal(t, corp.TagsetUD, tagset)
+
+	_, err = a.resolveNgramTagset("intercorp", "", "", true)
+	assert.ErrorIs(t, err, ErrorNoSupportedTagset)
+	assert.ErrorContains(t, err, "foo_tagset, bar_tagset")
+
+	// column mapping provided - no tagset needed
+	tagset, err = a.resolveNgramTagset("intercorp", "", "", false)
+	assert.NoError(t, err)
+	assert.Equal(t, corp.SupportedTagset(""), tagset)
+}
+
+func TestDeleteNgramsRefusedForQueuedJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := newTestingActions(&dummyCorpusMeta{})
+	// no concurrent jobs allowed so the enqueued job stays in the queue
+	a.jobActions = jobs.NewActions(
+		&jobs.Conf{StatusDataPath: filepath.Join(t.TempDir(), "jobs.gob")},
+		"en",
+		ctx,
+		make(chan string, 10),
+	)
+	fn := jobs.QueuedFunc(func(updateJobChan chan<- jobs.GeneralJobInfo) {})
+	assert.NoError(t, a.jobActions.EnqueueJob(
+		&fn,
+		jobs.DummyJobInfo{ID: "queued", Type: freqdb.NgramJobType, CorpusID: "syn2020"},
+	))
+
+	w := httptest.NewRecorder()
+	gctx, _ := gin.CreateTestContext(w)
+	gctx.Request = httptest.NewRequest(http.MethodDelete, "/dictionary/syn2020/ngrams", nil)
+	gctx.Params = gin.Params{{Key: "corpusId", Value: "syn2020"}}
+	// the request must be refused before any database access (there is no database)
+	a.DeleteNgrams(gctx)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "queued")
+}
+
+func TestEnqueueJobChainWithdrawsOnFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := newTestingActions(&dummyCorpusMeta{})
+	// no concurrent jobs allowed so the enqueued jobs stay in the queue
+	a.jobActions = jobs.NewActions(
+		&jobs.Conf{StatusDataPath: filepath.Join(t.TempDir(), "jobs.gob")},
+		"en",
+		ctx,
+		make(chan string, 10),
+	)
+	errEnqueue := errors.New("failed to enqueue")
+	chain, err := a.enqueueJobChain(3, "", func(i int, parentJobID string) (jobs.GeneralJobInfo, error) {
+		if i == 2 {
+			return nil, errEnqueue
+		}
+		jobID := fmt.Sprintf("ngrams-%d", i)
+		fn := jobs.QueuedFunc(func(updateJobChan chan<- jobs.GeneralJobInfo) {})
+		jobInfo := jobs.DummyJobInfo{ID: jobID, Type: freqdb.NgramJobType, CorpusID: "syn2020"}
+		if parentJobID == "" {
+			return jobInfo, a.jobActions.EnqueueJob(&fn, jobInfo)
+		}
+		return jobInfo, a.jobActions.EqueueJobAfter(&fn, jobInfo, parentJobID)
+	})
+	assert.ErrorIs(t, err, errEnqueue)
+	assert.Len(t, chain, 2)
+	_, conflict := a.jobActions.ConflictingJob("syn2020", freqdb.NgramJobType)
+	assert.False(t, conflict)
+}
+
+// previewDriver is a fake database driver answering queries
+// of n-gram generation preview (freqdb.NgramFreqGenerator.Preview).
+// The n-gram tables are reported as existing, no processing time
+// estimation is available.
+type previewDriver struct {
+	numColcountsRows int64
+	queries          []string
+}
+
+func (d *previewDriver) Open(name string) (driver.Conn, error) {
+	return &previewConn{drv: d}, nil
+}
+
+func (d *previewDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *previewDriver) Driver() driver.Driver {
+	return d
+}
+
+type previewConn struct {
+	drv *previewDriver
+}
+
+func (c *previewConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements not supported")
+}
+
+func (c *previewConn) Close() error {
+	return nil
+}
+
+func (c *previewConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *previewConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	c.drv.queries = append(c.drv.queries, query)
+	switch {
+	case strings.Contains(query, "information_schema.TABLES"):
+		return &searchRows{cols: []string{"exists"}, data: [][]driver.Value{{int64(1)}}}, nil
+	case strings.Contains(query, "proc_times"):
+		return &searchRows{cols: []string{"total_items", "total_time"}, data: [][]driver.Value{{nil, nil}}}, nil
+	case strings.Contains(query, "_colcounts"):
+		return &searchRows{cols: []string{"cnt"}, data: [][]driver.Value{{c.drv.numColcountsRows}}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+func newNgramsPreviewTestingActions(t *testing.T, drv *previewDriver) *Actions {
+	confDir := t.TempDir()
+	err := os.WriteFile(
+		filepath.Join(confDir, "syn2020.json"),
+		[]byte(`{
+			"corpus": "syn2020",
+			"ngrams": {
+				"vertColumns": [
+					{"idx": 0, "role": "word"},
+					{"idx": 1, "role": "lemma"},
+					{"idx": 2, "role": "sublemma"},
+					{"idx": 3, "role": "tag"}
+				]
+			}
+		}`),
+		0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	a := newTestingActions(&dummyCorpusMeta{
+		data: map[string]*corpus.DBInfo{"syn2020": {Name: "syn2020"}},
+	})
+	a.laConfCache = laconf.NewLiveAttrsBuildConfProvider(confDir, nil, nil)
+	a.laDB = mysql.NewAdapter(sql.OpenDB(drv), "frodo")
+	a.jobActions = jobs.NewActions(
+		&jobs.Conf{StatusDataPath: filepath.Join(t.TempDir(), "jobs.gob")},
+		"en",
+		ctx,
+		make(chan string, 10),
+	)
+	return a
+}
+
+func TestCreateNgramsJobDryRun(t *testing.T) {
+	drv := &previewDriver{numColcountsRows: 1500}
+	a := newNgramsPreviewTestingActions(t, drv)
+	job, err := a.CreateNgramsJob(
+		context.Background(),
+		jobs.JobRequest{
+			CorpusID: "syn2020",
+			Query:    url.Values{"dryRun": {"1"}, "ngramSize": {"1,2"}, "minFreq": {"3"}},
+		},
+	)
+	assert.NoError(t, err)
+	// no job has been created
+	assert.Equal(t, "", job.JobID)
+	_, conflict := a.jobActions.ConflictingJob("syn2020", freqdb.NgramJobType)
+	assert.False(t, conflict)
+
+	body, ok := job.Body.(map[string]any)
+	assert.True(t, ok)
+	assert.Equal(t, true, body["dryRun"])
+	assert.Equal(t, "syn2020", body["groupedName"])
+	assert.Equal(t, 3, body["minFreq"])
+	assert.Equal(t, &corpus.QSAttributes{Word: 0, Lemma: 1, Sublemma: 2, Tag: 3}, body["colMapping"])
+	assert.Equal(
+		t,
+		[]freqdb.NgramPreview{
+			{NgramSize: 1, NumSourceRows: 1500, TimeEstimationSecs: -1, TablesExist: true},
+			{NgramSize: 2, NumSourceRows: 1500, TimeEstimationSecs: -1, TablesExist: true},
+		},
+		body["ngrams"],
+	)
+	for _, q := range drv.queries {
+		assert.True(t, strings.HasPrefix(q, "SELECT"), q)
+	}
+}
+
+func TestCreateNgramsJobDryRunInvalidArgs(t *testing.T) {
+	drv := &previewDriver{}
+	a := newNgramsPreviewTestingActions(t, drv)
+	_, err := a.CreateNgramsJob(
+		context.Background(),
+		jobs.JobRequest{
+			CorpusID: "syn2020",
+			Query:    url.Values{"dryRun": {"1"}},
+			Args:     []byte(`{"colMapping": {"word": 0, "lemma": 0, "sublemma": 2, "tag": 3}}`),
+		},
+	)
+	assert.Equal(t, http.StatusUnprocessableEntity, jobs.JobRequestErrorStatus(err))
+	assert.Empty(t, drv.queries)
+}
@@ -21,23 +21,47 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"frodo/common"
 	"frodo/db/mysql"
+	"frodo/db/tables"
 	"frodo/jobs"
 	"regexp"
+	"slices"
 	"strings"
 	"unicode"
+
+	"github.com/agnivade/levenshtein"
 )
 
 const (
 	maxExpectedNumMatchingLemmas = 30
+
+	// fuzzyPrefixLength is a number of leading characters
+	// a fuzzy match must share with the searched term
+	fuzzyPrefixLength = 1
+
+	// maxFuzzyCandidates is a maximum number of lemmas (the most
+	// frequent ones) read as candidates for fuzzy matching
+	maxFuzzyCandidates = 5000
+)
+
+// Supported modes of matching AnyValue
+const (
+	MatchAny    = "any"
+	MatchPrefix = "prefix"
+	MatchSuffix = "suffix"
 )
 
 var (
 	keyAlphabet       = []byte{'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z'}
 	validMVWordRegexp = regexp.MustCompile(`^[\sA-Za-z0-9áÁéÉěĚšŠčČřŘžŽýÝíÍúÚůťŤďĎňŇóÓ\-\|]+$`)
 	validWordRegexp   = regexp.MustCompile(`^[\sA-Za-z0-9áÁéÉěĚšŠčČřŘžŽýÝíÍúÚůťŤďĎňŇóÓ\-]+$`)
+
+	// errPageFilled stops processing of rows once
+	// all the requested lemmas are read
+	errPageFilled = errors.New("page filled")
 )
 
 func mkID(x int) string {
@@ -126,6 +150,23 @@ func (lemma *Lemma) ToJSON() ([]byte, error) {
 	return json.Marshal(lemma)
 }
 
+// LemmaCompact is a lean projection of Lemma
+// suitable e.g. for small UI widgets
+type LemmaCompact struct {
+	Word  string  `json:"word"`
+	Count int     `json:"count"`
+	IPM   float64 `json:"ipm"`
+}
+
+// CompactVersion produces a compact projection of the lemma
+func (lemma *Lemma) CompactVersion() LemmaCompact {
+	return LemmaCompact{
+		Word:  lemma.Lemma,
+		Count: lemma.Count,
+		IPM:   lemma.IPM,
+	}
+}
+
 type Exporter struct {
 	db                 *sql.DB
 	groupedName        string
@@ -185,10 +226,38 @@ func mergeEqualFormsLC(lemma *Lemma) {
 	lemma.Forms = forms
 }
 
-func processRowsSync(rows *sql.Rows, datasetSizeForIPM int, enableMultivalues bool) ([]Lemma, error) {
+// finalizeLemma calculates lemma totals once all its forms are read
+func finalizeLemma(lemma *Lemma, sublemmas map[string]int, datasetSizeForIPM int) {
+	for sValue, sCount := range sublemmas {
+		lemma.Sublemmas = append(
+			lemma.Sublemmas,
+			Sublemma{Value: sValue, Count: sCount},
+		)
+	}
+	for _, v := range lemma.Forms {
+		lemma.Count += v.Count
+	}
+	if datasetSizeForIPM > 0 {
+		lemma.DatasetSize = datasetSizeForIPM
+		lemma.IPM = float64(lemma.Count) / float64(datasetSizeForIPM) * 1e6
+	}
+	mergeEqualFormsLC(lemma)
+}
+
+// processRows reads all the dictionary rows, groups them into lemmas
+// and passes each complete lemma to the onLemma function. The rows
+// are always closed. Only a single lemma is kept in memory at a time.
+// In case onLemma returns an error, the processing stops
+// and the error is returned.
+func processRows(
+	rows *sql.Rows,
+	datasetSizeForIPM int,
+	enableMultivalues bool,
+	onLemma func(Lemma) error,
+) error {
+	defer rows.Close()
 
-	var idBase, procRecords int
-	matchingLemmas := make([]Lemma, 0, maxExpectedNumMatchingLemmas)
+	var idBase int
 	var currLemma *Lemma
 	sublemmas := make(map[string]int)
 
@@ -202,28 +271,17 @@ func processRowsSync(rows *sql.Rows, datasetSizeForIPM int, enableMultivalues bo
 			&wordValue, &lemmaValue, &sublemmaValue, &wordCount,
 			&wordPos, &wordArf, &ngramSize, &simFreqScore, &isPname)
 		if err != nil {
-			return []Lemma{}, fmt.Errorf("failed to process dictionary rows: %w", err)
+			return fmt.Errorf("failed to process dictionary rows: %w", err)
 		}
 		if isValidWord(lemmaValue, enableMultivalues) {
 			newLemma := lemmaValue
 			newPos := wordPos
 			if currLemma == nil || newLemma != currLemma.Lemma || newPos != currLemma.PoS {
 				if currLemma != nil {
-					for sValue, sCount := range sublemmas {
-						currLemma.Sublemmas = append(
-							currLemma.Sublemmas,
-							Sublemma{Value: sValue, Count: sCount},
-						)
-					}
-					for _, v := range currLemma.Forms {
-						currLemma.Count += v.Count
+					finalizeLemma(currLemma, sublemmas, datasetSizeForIPM)
+					if err := onLemma(*currLemma); err != nil {
+						return err
 					}
-					if datasetSizeForIPM > 0 {
-						currLemma.DatasetSize = datasetSizeForIPM
-						currLemma.IPM = float64(currLemma.Count) / float64(datasetSizeForIPM) * 1e6
-					}
-					mergeEqualFormsLC(currLemma)
-					matchingLemmas = append(matchingLemmas, *currLemma)
 				}
 				sublemmas = make(map[string]int)
 				currLemma = &Lemma{
@@ -252,29 +310,43 @@ func processRowsSync(rows *sql.Rows, datasetSizeForIPM int, enableMultivalues bo
 			}
 			currLemma.Forms = append(currLemma.Forms, form)
 			sublemmas[sublemmaValue] += wordCount
-
 		}
-		procRecords++
 	}
-	if procRecords == 0 {
-		return []Lemma{}, nil
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to process dictionary rows: %w", err)
 	}
 	if currLemma != nil {
-		for sValue, sCount := range sublemmas {
-			currLemma.Sublemmas = append(
-				currLemma.Sublemmas,
-				Sublemma{Value: sValue, Count: sCount},
-			)
-		}
-		for _, v := range currLemma.Forms {
-			currLemma.Count += v.Count
-		}
-		if datasetSizeForIPM > 0 {
-			currLemma.DatasetSize = datasetSizeForIPM
-			currLemma.IPM = float64(currLemma.Count) / float64(datasetSizeForIPM) * 1e6
-		}
-		mergeEqualFormsLC(currLemma)
-		matchingLemmas = append(matchingLemmas, *currLemma)
+		finalizeLemma(currLemma, sublemmas, datasetSizeForIPM)
+		return onLemma(*currLemma)
+	}
+	return nil
+}
+
+// processRowsSync reads all the dictionary rows and groups them into lemmas.
+// The rows are always closed. In case the query has been cancelled (e.g. via
+// its context), the function returns the respective error instead of partial
+// results. The offset and limit (zero means "no limit") are applied to lemmas.
+func processRowsSync(
+	rows *sql.Rows,
+	datasetSizeForIPM int,
+	enableMultivalues bool,
+	offset, limit int,
+) ([]Lemma, error) {
+	matchingLemmas := make([]Lemma, 0, maxExpectedNumMatchingLemmas)
+	err := processRows(
+		rows,
+		datasetSizeForIPM,
+		enableMultivalues,
+		paginate(offset, limit, func(lemma Lemma) error {
+			matchingLemmas = append(matchingLemmas, lemma)
+			return nil
+		}),
+	)
+	if errors.Is(err, errPageFilled) {
+		err = nil
+	}
+	if err != nil {
+		return []Lemma{}, err
 	}
 	return matchingLemmas, nil
 }
@@ -286,10 +358,16 @@ type SearchOptions struct {
 	PoS                         string
 	AnyValue                    string
 	AnyValueCS                  bool
+	AnyValueMatch               string
 	AllowMultivalues            bool
 	Limit                       int
+	Offset                      int
 	NgramSize                   int
 	SearchWithDatasetSizeForIPM int
+
+	// FuzzyMaxDistance, if positive, enables fuzzy matching of Lemma,
+	// Word or AnyValue (see SearchWithFuzzy)
+	FuzzyMaxDistance int
 }
 
 func (so SearchOptions) InferNgramSize() int {
@@ -329,6 +407,25 @@ func SearchWithAnyValue(v string) SearchOption {
 	}
 }
 
+// SearchWithPrefix works like SearchWithAnyValue but it matches
+// all the values starting with v. Unlike the suffix search,
+// this can use database indexes.
+func SearchWithPrefix(v string) SearchOption {
+	return func(c *SearchOptions) {
+		c.AnyValue = v
+		c.AnyValueMatch = MatchPrefix
+	}
+}
+
+// SearchWithSuffix works like SearchWithAnyValue but it matches
+// all the values ending with v.
+func SearchWithSuffix(v string) SearchOption {
+	return func(c *SearchOptions) {
+		c.AnyValue = v
+		c.AnyValueMatch = MatchSuffix
+	}
+}
+
 func SearchWithAnyValueCS(caseSensitive bool) SearchOption {
 	return func(c *SearchOptions) {
 		c.AnyValueCS = caseSensitive
@@ -341,18 +438,41 @@ func SearchWithMultivalues() SearchOption {
 	}
 }
 
+// SearchWithLimit sets a maximum number of returned lemmas
 func SearchWithLimit(lim int) SearchOption {
 	return func(c *SearchOptions) {
 		c.Limit = lim
 	}
 }
 
+// SearchWithOffset sets a number of (ordered) lemmas to be skipped.
+// Along with SearchWithLimit, this can be used for paging.
+func SearchWithOffset(n int) SearchOption {
+	return func(c *SearchOptions) {
+		c.Offset = n
+	}
+}
+
 func SearchWithNgramSize(size int) SearchOption {
 	return func(c *SearchOptions) {
 		c.NgramSize = size
 	}
 }
 
+// SearchWithFuzzy enables matching of lemmas (SearchWithLemma), words
+// (SearchWithWord) or any of the two (SearchWithAnyValue) within the
+// specified Levenshtein distance from the searched term. Matching lemmas
+// are ordered by the distance and then by their frequency.
+// Please note that candidates are required to share the first character
+// with the term and only the most frequent candidate lemmas are considered
+// (see maxFuzzyCandidates).
+// The limit (SearchWithLimit) is applied to the ordered lemmas.
+func SearchWithFuzzy(maxDistance int) SearchOption {
+	return func(c *SearchOptions) {
+		c.FuzzyMaxDistance = maxDistance
+	}
+}
+
 func SearchWithNoOp() SearchOption {
 	return func(c *SearchOptions) {}
 }
@@ -392,32 +512,176 @@ func (srch *ttlSearch) IsEmpty() bool {
 
 // ---------
 
+// fuzzyPrefilterSQL creates an SQL condition selecting candidates for fuzzy
+// matching of the term in any of the provided columns. The candidates must share
+// a prefix with the term and their length must be within the max. distance.
+func fuzzyPrefilterSQL(columns []string, term string, maxDistance int) (string, []any) {
+	runes := []rune(term)
+	prefix := escapeLikeValue(string(runes[:min(fuzzyPrefixLength, len(runes))]))
+	exprTmp := make([]string, len(columns))
+	args := make([]any, 0, 3*len(columns))
+	for i, col := range columns {
+		exprTmp[i] = fmt.Sprintf("(%s LIKE ? AND CHAR_LENGTH(%s) BETWEEN ? AND ?)", col, col)
+		args = append(args, prefix+"%", max(len(runes)-maxDistance, 1), len(runes)+maxDistance)
+	}
+	return "(" + strings.Join(exprTmp, " OR ") + ")", args
+}
+
+// fuzzyCandidatesCond creates an SQL condition restricting the search
+// to the most frequent lemmas matching the provided conditions (see
+// maxFuzzyCandidates). The limit applies to whole lemmas so all their
+// matching rows are always read. The conditions are expected to refer
+// to the word table via the `w` alias.
+func fuzzyCandidatesCond(groupedName string, whereSQL []string) string {
+	return fmt.Sprintf(
+		"(w.lemma, w.pos) IN (SELECT c.lemma, c.pos FROM ("+
+			"SELECT w.lemma, w.pos FROM %s AS w WHERE %s "+
+			"GROUP BY w.lemma, w.pos ORDER BY SUM(w.count) DESC LIMIT %d) AS c)",
+		tables.Name(groupedName, tables.Word),
+		strings.Join(whereSQL, " AND "),
+		maxFuzzyCandidates,
+	)
+}
+
+// fuzzyDistance returns the Levenshtein distance between the term
+// and the lemma. For word search, the closest form is used.
+func fuzzyDistance(lemma Lemma, term string, byLemma, byWord bool) int {
+	term = strings.ToLower(term)
+	ans := -1
+	if byLemma {
+		ans = levenshtein.ComputeDistance(term, strings.ToLower(lemma.Lemma))
+	}
+	if byWord {
+		for _, form := range lemma.Forms {
+			dist := levenshtein.ComputeDistance(term, strings.ToLower(form.Value))
+			if ans < 0 || dist < ans {
+				ans = dist
+			}
+		}
+	}
+	return ans
+}
+
+// rankFuzzyMatches removes lemmas too distant from the searched term
+// and sorts the remaining ones by their distance and frequency.
+func rankFuzzyMatches(lemmas []Lemma, srchOpts SearchOptions) []Lemma {
+	term := cmp.Or(srchOpts.Lemma, srchOpts.Word, srchOpts.AnyValue)
+	byLemma := srchOpts.Lemma != "" || srchOpts.AnyValue != ""
+	byWord := srchOpts.Word != "" || srchOpts.AnyValue != ""
+	distances := make(map[string]int, len(lemmas))
+	ans := make([]Lemma, 0, len(lemmas))
+	for _, lemma := range lemmas {
+		dist := fuzzyDistance(lemma, term, byLemma, byWord)
+		if dist >= 0 && dist <= srchOpts.FuzzyMaxDistance {
+			distances[lemma.ID] = dist
+			ans = append(ans, lemma)
+		}
+	}
+	slices.SortStableFunc(ans, func(a, b Lemma) int {
+		return cmp.Or(
+			cmp.Compare(distances[a.ID], distances[b.ID]),
+			cmp.Compare(b.Count, a.Count),
+		)
+	})
+	return lemmasPage(ans, srchOpts.Offset, srchOpts.Limit)
+}
+
+// lemmasPage returns lemmas after skipping offset ones
+// and limited to the limit (zero means "no limit")
+func lemmasPage(lemmas []Lemma, offset, limit int) []Lemma {
+	lemmas = lemmas[min(offset, len(lemmas)):]
+	if limit > 0 && len(lemmas) > limit {
+		lemmas = lemmas[:limit]
+	}
+	return lemmas
+}
+
+// paginate wraps onLemma so that the first offset lemmas are skipped
+// and the processing is stopped (via errPageFilled) once limit lemmas
+// are passed (zero limit means "no limit").
+func paginate(offset, limit int, onLemma func(Lemma) error) func(Lemma) error {
+	var numLemmas int
+	return func(lemma Lemma) error {
+		numLemmas++
+		if numLemmas <= offset {
+			return nil
+		}
+		if limit > 0 && numLemmas > offset+limit {
+			return errPageFilled
+		}
+		return onLemma(lemma)
+	}
+}
+
+// escapeLikeValue escapes characters with a special
+// meaning in SQL LIKE patterns
+func escapeLikeValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(v)
+}
+
+// termSearchCond creates an SQL condition (and its argument) matching
+// the term in the valColumn of the term search table according to
+// the match mode (MatchAny, MatchPrefix, MatchSuffix)
+func termSearchCond(valColumn, term, matchMode string) (string, string) {
+	switch matchMode {
+	case MatchPrefix:
+		return fmt.Sprintf("s.%s LIKE ?", valColumn), escapeLikeValue(term) + "%"
+	case MatchSuffix:
+		return fmt.Sprintf("s.%s LIKE ?", valColumn), "%" + escapeLikeValue(term)
+	default:
+		return fmt.Sprintf("s.%s = ?", valColumn), term
+	}
+}
+
+// termSearchValue returns a term search table column and a (possibly
+// lowercased) term value for a case (in)sensitive search
+func termSearchValue(term string, caseSensitive bool) (string, string) {
+	if caseSensitive {
+		return "value", term
+	}
+	return "value_lc", strings.ToLower(term)
+}
+
+// affixLemmasCond creates an SQL condition matching all the words
+// of lemmas (i.e. lemma + PoS combinations) having a value with
+// the term as a prefix or a suffix. Unlike termToLemma, the lemmas
+// are resolved by a subquery so their number is not limited and
+// the paging (applied to the ordered main query) remains correct.
+func affixLemmasCond(groupedName, term string, caseSensitive bool, matchMode string) (string, any) {
+	valColumn, term := termSearchValue(term, caseSensitive)
+	cond, arg := termSearchCond(valColumn, term, matchMode)
+	return fmt.Sprintf(
+		"(w.lemma, w.pos) IN ("+
+			"SELECT w2.lemma, w2.pos FROM %s AS s "+
+			"JOIN %s AS w2 ON w2.id = s.word_id "+
+			"WHERE %s)",
+		tables.Name(groupedName, tables.TermSearch),
+		tables.Name(groupedName, tables.Word),
+		cond,
+	), arg
+}
+
 func termToLemma(
 	ctx context.Context,
-	db *mysql.Adapter,
+	db *sql.DB,
 	groupedName string,
 	term string,
 	caseSensitive bool,
 ) (ans ttlSearch) {
-	val_column := "value_lc"
-	if caseSensitive {
-		val_column = "value"
-
-	} else {
-		term = strings.ToLower(term)
-	}
-	rows, err := db.DB().QueryContext(
+	valColumn, term := termSearchValue(term, caseSensitive)
+	cond, arg := termSearchCond(valColumn, term, MatchAny)
+	rows, err := db.QueryContext(
 		ctx,
 		fmt.Sprintf(
 			"SELECT DISTINCT w.lemma, w.pos "+
-				"FROM %s_term_search AS s "+
-				"JOIN %s_word AS w ON w.id = s.word_id "+
-				"WHERE s.%s = ?",
-			groupedName,
-			groupedName,
-			val_column,
+				"FROM %s AS s "+
+				"JOIN %s AS w ON w.id = s.word_id "+
+				"WHERE %s",
+			tables.Name(groupedName, tables.TermSearch),
+			tables.Name(groupedName, tables.Word),
+			cond,
 		),
-		term,
+		arg,
 	)
 	if err != nil {
 		ans.error = fmt.Errorf("failed to find term lemma: %w", err)
@@ -432,31 +696,129 @@ func termToLemma(
 		}
 		ans.items = append(ans.items, item)
 	}
+	if err := rows.Err(); err != nil {
+		ans.error = fmt.Errorf("failed to find term lemma: %w", err)
+	}
 	return
 }
 
+// Search searches for dictionary lemmas matching provided options.
+// All the database queries are bound to the provided context so
+// once the context is cancelled (e.g. a client abandons its request),
+// the running query is cancelled too.
 func Search(
 	ctx context.Context,
 	db *mysql.Adapter,
 	groupedName string,
 	opts ...SearchOption,
 ) ([]Lemma, error) {
+	return search(ctx, db.DB(), groupedName, opts...)
+}
+
+func search(
+	ctx context.Context,
+	db *sql.DB,
+	groupedName string,
+	opts ...SearchOption,
+) ([]Lemma, error) {
+	rows, srchOpts, err := searchRows(ctx, db, groupedName, opts...)
+	if err != nil || rows == nil {
+		return []Lemma{}, err
+	}
+	if srchOpts.FuzzyMaxDistance <= 0 {
+		return processRowsSync(
+			rows, srchOpts.SearchWithDatasetSizeForIPM, srchOpts.AllowMultivalues,
+			srchOpts.Offset, srchOpts.Limit)
+	}
+	ans, err := processRowsSync(rows, srchOpts.SearchWithDatasetSizeForIPM, srchOpts.AllowMultivalues, 0, 0)
+	if err != nil {
+		return ans, err
+	}
+	return rankFuzzyMatches(ans, srchOpts), nil
+}
+
+// SearchStream works like Search but instead of collecting all the
+// matching lemmas, it passes them one by one to the onLemma function
+// as they are read from the database. This keeps memory usage bounded
+// even for large results. In case onLemma returns an error, the search
+// stops and the error is returned. Fuzzy search results must be ranked
+// so they are collected first (their number is limited anyway).
+func SearchStream(
+	ctx context.Context,
+	db *mysql.Adapter,
+	groupedName string,
+	onLemma func(Lemma) error,
+	opts ...SearchOption,
+) error {
+	return searchStream(ctx, db.DB(), groupedName, onLemma, opts...)
+}
+
+func searchStream(
+	ctx context.Context,
+	db *sql.DB,
+	groupedName string,
+	onLemma func(Lemma) error,
+	opts ...SearchOption,
+) error {
+	rows, srchOpts, err := searchRows(ctx, db, groupedName, opts...)
+	if err != nil || rows == nil {
+		return err
+	}
+	if srchOpts.FuzzyMaxDistance > 0 {
+		lemmas, err := processRowsSync(
+			rows, srchOpts.SearchWithDatasetSizeForIPM, srchOpts.AllowMultivalues, 0, 0)
+		if err != nil {
+			return err
+		}
+		for _, lemma := range rankFuzzyMatches(lemmas, srchOpts) {
+			if err := onLemma(lemma); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = processRows(
+		rows,
+		srchOpts.SearchWithDatasetSizeForIPM,
+		srchOpts.AllowMultivalues,
+		paginate(srchOpts.Offset, srchOpts.Limit, onLemma),
+	)
+	if errors.Is(err, errPageFilled) {
+		return nil
+	}
+	return err
+}
+
+// searchRows runs a dictionary query based on the provided options.
+// In case it is already clear that nothing can match the query,
+// nil rows (and nil error) are returned.
+func searchRows(
+	ctx context.Context,
+	db *sql.DB,
+	groupedName string,
+	opts ...SearchOption,
+) (*sql.Rows, SearchOptions, error) {
 
 	whereSQL := make([]string, 0, 5)
 	whereArgs := make([]any, 0, 5)
-	limitSQL := ""
 	var srchOpts SearchOptions
 	for _, opt := range opts {
 		opt(&srchOpts)
 	}
 	ngramSize := srchOpts.InferNgramSize()
 	if ngramSize <= 0 {
-		return []Lemma{}, fmt.Errorf("failed to determine n-gram size in the query")
+		return nil, srchOpts, fmt.Errorf("failed to determine n-gram size in the query")
 	}
 	whereSQL = append(whereSQL, "w.ngram = ?")
 	whereArgs = append(whereArgs, ngramSize)
+	fuzzy := srchOpts.FuzzyMaxDistance > 0
 
-	if srchOpts.Lemma != "" {
+	if srchOpts.Lemma != "" && fuzzy {
+		sql, args := fuzzyPrefilterSQL([]string{"w.lemma"}, srchOpts.Lemma, srchOpts.FuzzyMaxDistance)
+		whereSQL = append(whereSQL, sql)
+		whereArgs = append(whereArgs, args...)
+
+	} else if srchOpts.Lemma != "" {
 		whereSQL = append(whereSQL, "w.lemma = ?")
 		whereArgs = append(whereArgs, srchOpts.Lemma)
 	}
@@ -464,7 +826,12 @@ func Search(
 		whereSQL = append(whereSQL, "w.sublemma = ?")
 		whereArgs = append(whereArgs, srchOpts.Sublemma)
 	}
-	if srchOpts.Word != "" {
+	if srchOpts.Word != "" && fuzzy {
+		sql, args := fuzzyPrefilterSQL([]string{"w.value"}, srchOpts.Word, srchOpts.FuzzyMaxDistance)
+		whereSQL = append(whereSQL, sql)
+		whereArgs = append(whereArgs, args...)
+
+	} else if srchOpts.Word != "" {
 		whereSQL = append(whereSQL, "w.value = ?")
 		whereArgs = append(whereArgs, srchOpts.Word)
 	}
@@ -472,13 +839,29 @@ func Search(
 	// two SQL queries:
 	// 1) identify matching lemma+pos entries
 	// 2) search all the variants matching (1)
-	if srchOpts.AnyValue != "" {
-		lemmaSrch := termToLemma(ctx, db, groupedName, srchOpts.AnyValue, srchOpts.AnyValueCS)
+	// (fuzzy search goes directly to lemmas and words, prefix and suffix
+	// search resolves (1) via a subquery as the number of lemmas can be large)
+	if srchOpts.AnyValue != "" && fuzzy {
+		sql, args := fuzzyPrefilterSQL(
+			[]string{"w.lemma", "w.value"}, srchOpts.AnyValue, srchOpts.FuzzyMaxDistance)
+		whereSQL = append(whereSQL, sql)
+		whereArgs = append(whereArgs, args...)
+
+	} else if srchOpts.AnyValue != "" &&
+		(srchOpts.AnyValueMatch == MatchPrefix || srchOpts.AnyValueMatch == MatchSuffix) {
+		sql, arg := affixLemmasCond(
+			groupedName, srchOpts.AnyValue, srchOpts.AnyValueCS, srchOpts.AnyValueMatch)
+		whereSQL = append(whereSQL, sql)
+		whereArgs = append(whereArgs, arg)
+
+	} else if srchOpts.AnyValue != "" {
+		lemmaSrch := termToLemma(
+			ctx, db, groupedName, srchOpts.AnyValue, srchOpts.AnyValueCS)
 		if lemmaSrch.error != nil {
-			return []Lemma{}, fmt.Errorf("failed to search dict. values: %w", lemmaSrch.error)
+			return nil, srchOpts, fmt.Errorf("failed to search dict. values: %w", lemmaSrch.error)
 		}
 		if lemmaSrch.IsEmpty() {
-			return []Lemma{}, nil
+			return nil, srchOpts, nil
 		}
 		sql, args := lemmaSrch.toSQL("w")
 		whereSQL = append(whereSQL, sql)
@@ -492,26 +875,27 @@ func Search(
 		whereSQL = append(whereSQL, "w.ngram = ?")
 		whereArgs = append(whereArgs, srchOpts.NgramSize)
 	}
-	if srchOpts.Limit > 0 {
-		limitSQL = fmt.Sprintf("LIMIT %d", srchOpts.Limit)
+	if fuzzy {
+		whereSQL = append(whereSQL, fuzzyCandidatesCond(groupedName, whereSQL))
+		whereArgs = append(whereArgs, whereArgs...)
 	}
-	rows, err := db.DB().QueryContext(
+	// Please note that the limit and offset apply to lemmas (not to rows)
+	// so they are applied while processing the rows.
+	rows, err := db.QueryContext(
 		ctx,
 		fmt.Sprintf(
 			"SELECT w.value, w.lemma, w.sublemma, w.count, "+
 				"w.pos, w.arf, w.ngram, w.sim_freqs_score, w.initial_cap "+
-				"FROM %s_word AS w "+
+				"FROM %s AS w "+
 				"WHERE %s "+
-				"ORDER BY w.lemma, w.pos, w.sublemma, w.value "+
-				"%s",
-			groupedName,
+				"ORDER BY w.lemma, w.pos, w.sublemma, w.value, w.id",
+			tables.Name(groupedName, tables.Word),
 			strings.Join(whereSQL, " AND "),
-			limitSQL,
 		),
 		whereArgs...,
 	)
 	if err != nil {
-		return []Lemma{}, fmt.Errorf("failed to search dict. values: %w", err)
+		return nil, srchOpts, fmt.Errorf("failed to search dict. values: %w", err)
 	}
-	return processRowsSync(rows, srchOpts.SearchWithDatasetSizeForIPM, srchOpts.AllowMultivalues)
+	return rows, srchOpts, nil
 }
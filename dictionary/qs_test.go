@@ -0,0 +1,462 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dictionary
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	slowDriverMaxRows = 100000
+)
+
+// slowDriver is a fake database driver producing a long stream
+// of dictionary rows. It calls onRow after each produced row
+// so a test can e.g. cancel a context in the middle of a query.
+type slowDriver struct {
+	onRow      func(numRows int)
+	servedRows atomic.Int64
+	closed     atomic.Bool
+}
+
+func (d *slowDriver) Open(name string) (driver.Conn, error) {
+	return &slowConn{drv: d}, nil
+}
+
+func (d *slowDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *slowDriver) Driver() driver.Driver {
+	return d
+}
+
+type slowConn struct {
+	drv *slowDriver
+}
+
+func (c *slowConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements not supported")
+}
+
+func (c *slowConn) Close() error {
+	return nil
+}
+
+func (c *slowConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *slowConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	return &slowRows{drv: c.drv}, nil
+}
+
+type slowRows struct {
+	drv *slowDriver
+}
+
+func (r *slowRows) Columns() []string {
+	return []string{
+		"value", "lemma", "sublemma", "count", "pos",
+		"arf", "ngram", "sim_freqs_score", "initial_cap",
+	}
+}
+
+func (r *slowRows) Close() error {
+	r.drv.closed.Store(true)
+	return nil
+}
+
+func (r *slowRows) Next(dest []driver.Value) error {
+	n := int(r.drv.servedRows.Add(1))
+	if n > slowDriverMaxRows {
+		return io.EOF
+	}
+	time.Sleep(100 * time.Microsecond)
+	dest[0] = "foo"
+	dest[1] = "foo"
+	dest[2] = "foo"
+	dest[3] = int64(1)
+	dest[4] = "N"
+	dest[5] = float64(1)
+	dest[6] = int64(1)
+	dest[7] = float64(1)
+	dest[8] = false
+	if r.drv.onRow != nil {
+		r.drv.onRow(n)
+	}
+	return nil
+}
+
+func TestSearchStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	drv := &slowDriver{
+		onRow: func(numRows int) {
+			if numRows == 10 {
+				cancel()
+			}
+		},
+	}
+	db := sql.OpenDB(drv)
+	defer db.Close()
+
+	ans, err := search(ctx, db, "test", SearchWithWord("foo"))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, ans)
+	assert.Less(t, drv.servedRows.Load(), int64(slowDriverMaxRows))
+	assert.Eventually(
+		t,
+		func() bool { return drv.closed.Load() },
+		time.Second,
+		10*time.Millisecond,
+	)
+}
+
+func TestSearchStreamStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	drv := &slowDriver{
+		onRow: func(numRows int) {
+			if numRows == 10 {
+				cancel()
+			}
+		},
+	}
+	db := sql.OpenDB(drv)
+	defer db.Close()
+
+	var numLemmas int
+	err := searchStream(
+		ctx,
+		db,
+		"test",
+		func(lemma Lemma) error {
+			numLemmas++
+			return nil
+		},
+		SearchWithWord("foo"),
+	)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, numLemmas)
+	assert.Less(t, drv.servedRows.Load(), int64(slowDriverMaxRows))
+}
+
+func TestFuzzyPrefilterSQL(t *testing.T) {
+	sql, args := fuzzyPrefilterSQL([]string{"w.lemma", "w.value"}, "dům", 1)
+	assert.Equal(
+		t,
+		"((w.lemma LIKE ? AND CHAR_LENGTH(w.lemma) BETWEEN ? AND ?) OR "+
+			"(w.value LIKE ? AND CHAR_LENGTH(w.value) BETWEEN ? AND ?))",
+		sql,
+	)
+	assert.Equal(t, []any{"d%", 2, 4, "d%", 2, 4}, args)
+
+	_, args = fuzzyPrefilterSQL([]string{"w.lemma"}, "%a", 3)
+	assert.Equal(t, []any{`\%%`, 1, 5}, args)
+}
+
+func fuzzyLemmaValues(lemmas []Lemma) []string {
+	ans := make([]string, len(lemmas))
+	for i, lemma := range lemmas {
+		ans[i] = lemma.Lemma
+	}
+	return ans
+}
+
+func TestRankFuzzyMatches(t *testing.T) {
+	lemmas := []Lemma{
+		{ID: "1", Lemma: "house", Count: 100, Forms: []Form{{Value: "houses"}}},
+		{ID: "2", Lemma: "horse", Count: 50, Forms: []Form{{Value: "horses"}}},
+		{ID: "3", Lemma: "hose", Count: 200, Forms: []Form{{Value: "hose"}}},
+		{ID: "4", Lemma: "hours", Count: 500, Forms: []Form{{Value: "hours"}}},
+		{ID: "5", Lemma: "Houston", Count: 10, Forms: []Form{{Value: "Houston"}}},
+	}
+	ans := rankFuzzyMatches(lemmas, SearchOptions{Lemma: "hous", FuzzyMaxDistance: 1})
+	assert.Equal(t, []string{"hours", "house"}, fuzzyLemmaValues(ans))
+
+	ans = rankFuzzyMatches(lemmas, SearchOptions{Lemma: "hous", FuzzyMaxDistance: 2, Limit: 3})
+	assert.Equal(t, []string{"hours", "house", "hose"}, fuzzyLemmaValues(ans))
+
+	ans = rankFuzzyMatches(lemmas, SearchOptions{Word: "horsus", FuzzyMaxDistance: 1})
+	assert.Equal(t, []string{"horse"}, fuzzyLemmaValues(ans))
+
+	ans = rankFuzzyMatches(lemmas, SearchOptions{AnyValue: "houstn", FuzzyMaxDistance: 1})
+	assert.Equal(t, []string{"Houston"}, fuzzyLemmaValues(ans))
+
+	ans = rankFuzzyMatches(lemmas, SearchOptions{Lemma: "garden", FuzzyMaxDistance: 2})
+	assert.Empty(t, ans)
+}
+
+func TestTermSearchCond(t *testing.T) {
+	cond, arg := termSearchCond("value_lc", "dům", MatchAny)
+	assert.Equal(t, "s.value_lc = ?", cond)
+	assert.Equal(t, "dům", arg)
+
+	cond, arg = termSearchCond("value_lc", "dů", MatchPrefix)
+	assert.Equal(t, "s.value_lc LIKE ?", cond)
+	assert.Equal(t, "dů%", arg)
+
+	cond, arg = termSearchCond("value", "m_%", MatchSuffix)
+	assert.Equal(t, "s.value LIKE ?", cond)
+	assert.Equal(t, `%m\_\%`, arg)
+}
+
+func TestPaginate(t *testing.T) {
+	lemmas := []string{"a", "b", "c", "d", "e"}
+	read := func(offset, limit int) ([]string, int) {
+		ans := make([]string, 0, len(lemmas))
+		onLemma := paginate(offset, limit, func(lemma Lemma) error {
+			ans = append(ans, lemma.Lemma)
+			return nil
+		})
+		var numRead int
+		for _, v := range lemmas {
+			numRead++
+			if err := onLemma(Lemma{Lemma: v}); err != nil {
+				assert.ErrorIs(t, err, errPageFilled)
+				break
+			}
+		}
+		return ans, numRead
+	}
+	page, numRead := read(0, 2)
+	assert.Equal(t, []string{"a", "b"}, page)
+	assert.Equal(t, 3, numRead)
+
+	page, _ = read(2, 2)
+	assert.Equal(t, []string{"c", "d"}, page)
+
+	page, numRead = read(3, 0)
+	assert.Equal(t, []string{"d", "e"}, page)
+	assert.Equal(t, 5, numRead)
+
+	page, _ = read(10, 2)
+	assert.Empty(t, page)
+}
+
+func TestLemmasPage(t *testing.T) {
+	lemmas := []Lemma{{Lemma: "a"}, {Lemma: "b"}, {Lemma: "c"}}
+	assert.Equal(t, []string{"b", "c"}, fuzzyLemmaValues(lemmasPage(lemmas, 1, 0)))
+	assert.Equal(t, []string{"b"}, fuzzyLemmaValues(lemmasPage(lemmas, 1, 1)))
+	assert.Empty(t, lemmasPage(lemmas, 5, 1))
+}
+
+func TestAffixLemmasCond(t *testing.T) {
+	cond, arg := affixLemmasCond("syn2020", "Dů", false, MatchPrefix)
+	assert.Equal(
+		t,
+		"(w.lemma, w.pos) IN (SELECT w2.lemma, w2.pos FROM syn2020_term_search AS s "+
+			"JOIN syn2020_word AS w2 ON w2.id = s.word_id WHERE s.value_lc LIKE ?)",
+		cond,
+	)
+	assert.Equal(t, "dů%", arg)
+
+	cond, arg = affixLemmasCond("syn2020", "Dům", true, MatchSuffix)
+	assert.Contains(t, cond, "WHERE s.value LIKE ?)")
+	assert.NotContains(t, cond, "LIMIT")
+	assert.Equal(t, "%Dům", arg)
+}
+
+// dictDriver is a fake database driver producing numLemmas
+// lemmas (each with two forms) ordered the same way as
+// the dictionary search query orders them. All the queries
+// are recorded.
+type dictDriver struct {
+	numLemmas int
+	queries   []string
+}
+
+func (d *dictDriver) Open(name string) (driver.Conn, error) {
+	return &dictConn{drv: d}, nil
+}
+
+func (d *dictDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *dictDriver) Driver() driver.Driver {
+	return d
+}
+
+type dictConn struct {
+	drv *dictDriver
+}
+
+func (c *dictConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements not supported")
+}
+
+func (c *dictConn) Close() error {
+	return nil
+}
+
+func (c *dictConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *dictConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	c.drv.queries = append(c.drv.queries, query)
+	return &dictRows{drv: c.drv}, nil
+}
+
+type dictRows struct {
+	drv    *dictDriver
+	served int
+}
+
+func (r *dictRows) Columns() []string {
+	return (&slowRows{}).Columns()
+}
+
+func (r *dictRows) Close() error {
+	return nil
+}
+
+func (r *dictRows) Next(dest []driver.Value) error {
+	if r.served >= 2*r.drv.numLemmas {
+		return io.EOF
+	}
+	lemma := fmt.Sprintf("dům%03d", r.served/2)
+	dest[0] = fmt.Sprintf("%s-%d", lemma, r.served%2)
+	dest[1] = lemma
+	dest[2] = lemma
+	dest[3] = int64(1)
+	dest[4] = "N"
+	dest[5] = float64(1)
+	dest[6] = int64(1)
+	dest[7] = float64(1)
+	dest[8] = false
+	r.served++
+	return nil
+}
+
+func TestPrefixSearchPagingBeyondHundredLemmas(t *testing.T) {
+	drv := &dictDriver{numLemmas: 150}
+	db := sql.OpenDB(drv)
+	defer db.Close()
+
+	ans, err := search(
+		context.Background(), db, "syn2020",
+		SearchWithPrefix("dům"), SearchWithOffset(120), SearchWithLimit(10),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 10)
+	assert.Equal(t, "dům120", ans[0].Lemma)
+	assert.Equal(t, "dům129", ans[9].Lemma)
+	// the matching lemmas are resolved within the main query (no capped lookup)
+	assert.Len(t, drv.queries, 1)
+	assert.Contains(t, drv.queries[0], "(w.lemma, w.pos) IN (SELECT")
+	assert.NotContains(t, drv.queries[0], "LIMIT")
+
+	var streamed []string
+	err = searchStream(
+		context.Background(), db, "syn2020",
+		func(lemma Lemma) error {
+			streamed = append(streamed, lemma.Lemma)
+			return nil
+		},
+		SearchWithSuffix("dům"), SearchWithOffset(140), SearchWithLimit(20),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, streamed, 10)
+	assert.Equal(t, "dům140", streamed[0])
+	assert.Equal(t, "dům149", streamed[9])
+}
+
+func TestFuzzySearchLimitsLemmasInsteadOfRows(t *testing.T) {
+	drv := &dictDriver{numLemmas: 3}
+	db := sql.OpenDB(drv)
+	defer db.Close()
+
+	ans, err := search(
+		context.Background(), db, "syn2020",
+		SearchWithLemma("dům001"), SearchWithFuzzy(1),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, ans, 3)
+	assert.Len(t, ans[0].Forms, 2)
+	assert.Len(t, drv.queries, 1)
+	assert.Contains(
+		t,
+		drv.queries[0],
+		"(w.lemma, w.pos) IN (SELECT c.lemma, c.pos FROM (SELECT w.lemma, w.pos FROM syn2020_word AS w "+
+			"WHERE w.ngram = ? AND ((w.lemma LIKE ? AND CHAR_LENGTH(w.lemma) BETWEEN ? AND ?)) "+
+			"GROUP BY w.lemma, w.pos ORDER BY SUM(w.count) DESC LIMIT 5000) AS c)",
+	)
+	assert.True(t, strings.HasSuffix(drv.queries[0], "ORDER BY w.lemma, w.pos, w.sublemma, w.value, w.id"))
+}
+
+// BenchmarkAnyValueVsPrefix compares the "any" and the "prefix" search
+// modes on a real dictionary. It requires the FRODO_BENCH_DSN (MySQL DSN)
+// and FRODO_BENCH_CORPUS (grouped corpus name) environment variables.
+// The FRODO_BENCH_TERM variable can be used to specify a searched term.
+func BenchmarkAnyValueVsPrefix(b *testing.B) {
+	dsn := os.Getenv("FRODO_BENCH_DSN")
+	groupedName := os.Getenv("FRODO_BENCH_CORPUS")
+	if dsn == "" || groupedName == "" {
+		b.Skip("FRODO_BENCH_DSN and FRODO_BENCH_CORPUS must be set")
+	}
+	term := os.Getenv("FRODO_BENCH_TERM")
+	if term == "" {
+		term = "pro"
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	modes := []struct {
+		name string
+		opt  SearchOption
+	}{
+		{name: "any", opt: SearchWithAnyValue(term)},
+		{name: "prefix", opt: SearchWithPrefix(term)},
+	}
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := search(
+					context.Background(), db, groupedName, mode.opt, SearchWithLimit(20),
+				); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -19,17 +19,32 @@ package dictionary
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"frodo/db/mysql"
+	"frodo/db/tables"
 )
 
+// Supported levels of the similar frequency search
+const (
+	SimFreqLevelWord  = "word"
+	SimFreqLevelLemma = "lemma"
+)
+
+// ErrLemmaStatsOutdated is returned by SimilarARFLemmas in case
+// the lemma stats table is missing or it has been created by an older
+// version without the aggregate ARF column. The n-gram data (including
+// the lemma stats table) must be regenerated to fix the problem.
+var ErrLemmaStatsOutdated = errors.New(
+	"lemma stats table missing or without aggregate ARF - n-grams must be regenerated")
+
 type rowsAndErr struct {
 	Rows []Lemma
 	Err  error
 }
 
 func lemmaStatsTableExists(ctx context.Context, db *mysql.Adapter, groupedName string) (bool, error) {
-	statsTable := groupedName + "_lemma_stats"
+	statsTable := tables.Name(groupedName, tables.LemmaStats)
 	row := db.DB().QueryRowContext(
 		ctx,
 		"SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
@@ -80,19 +95,19 @@ func SimilarARFWords(
 		rows, err = db.DB().QueryContext(
 			ctx,
 			fmt.Sprintf(
-				"(SELECT '-', lemma, '-', sum_count, pos, 0, 1, avg_sim_freqs_score "+
-					"FROM %s_lemma_stats "+
+				"(SELECT '-', lemma, '-', sum_count, pos, 0, 1, avg_sim_freqs_score, 0 "+
+					"FROM %s "+
 					"WHERE ngram = 1 AND avg_sim_freqs_score BETWEEN ? AND ? "+
 					"ORDER BY avg_sim_freqs_score ASC "+
 					"LIMIT ?) "+
 					"UNION "+
-					"(SELECT '-', lemma, '-', sum_count, pos, 0, 1, avg_sim_freqs_score "+
-					"FROM %s_lemma_stats "+
+					"(SELECT '-', lemma, '-', sum_count, pos, 0, 1, avg_sim_freqs_score, 0 "+
+					"FROM %s "+
 					"WHERE ngram = 1 AND avg_sim_freqs_score BETWEEN ? AND ? "+
 					"ORDER BY avg_sim_freqs_score DESC "+
 					"LIMIT ?)",
-				groupedName,
-				groupedName,
+				tables.Name(groupedName, tables.LemmaStats),
+				tables.Name(groupedName, tables.LemmaStats),
 			),
 			lemma.SimFreqScore, upperScoreLim, halfl,
 			lowerScoreLim, lemma.SimFreqScore, halfl,
@@ -105,22 +120,22 @@ func SimilarARFWords(
 			ctx,
 			fmt.Sprintf(
 				"(SELECT '-', w.lemma, '-', SUM(w.count), "+
-					"w.pos, 0, 1, AVG(w.sim_freqs_score) "+
-					"FROM %s_word AS w "+
+					"w.pos, 0, 1, AVG(w.sim_freqs_score), 0 "+
+					"FROM %s AS w "+
 					"WHERE w.sim_freqs_score BETWEEN ? AND ? AND w.ngram = 1 "+
 					"GROUP BY w.lemma, w.pos "+
 					"ORDER BY w.sim_freqs_score ASC, w.lemma, w.pos, w.sublemma, w.value "+
 					"LIMIT ?) "+
 					"UNION "+
 					"(SELECT '-', w.lemma, '-', SUM(w.count), "+
-					"w.pos, 0, 1, AVG(w.sim_freqs_score) "+
-					"FROM %s_word AS w "+
+					"w.pos, 0, 1, AVG(w.sim_freqs_score), 0 "+
+					"FROM %s AS w "+
 					"WHERE w.sim_freqs_score BETWEEN ? AND ? AND w.ngram = 1 "+
 					"GROUP BY w.lemma, w.pos "+
 					"ORDER BY w.sim_freqs_score DESC, w.lemma, w.pos, w.sublemma, w.value "+
 					"LIMIT ? )",
-				groupedName,
-				groupedName,
+				tables.Name(groupedName, tables.Word),
+				tables.Name(groupedName, tables.Word),
 			),
 			lemma.SimFreqScore, upperScoreLim, halfl,
 			lowerScoreLim, lemma.SimFreqScore, halfl,
@@ -130,10 +145,119 @@ func SimilarARFWords(
 	if err != nil {
 		return []Lemma{}, fmt.Errorf("failed to get similar freq. words: %w", err)
 	}
-	defer rows.Close()
-	ans, err := processRowsSync(rows, 0, false)
+	ans, err := processRowsSync(rows, 0, false, 0, 0)
 	if err != nil {
 		return []Lemma{}, fmt.Errorf("failed to get similar freq. words: %w", err)
 	}
 	return ans, nil
 }
+
+// SimilarARFLemmas works like SimilarARFWords but instead of word-level scores,
+// it compares lemmas by their aggregate ARF (i.e. a sum of ARF of all their forms).
+// The searched range is determined the same way as in SimilarARFWords
+// and the nearest maxValues lemmas are returned (ordered by their distance
+// from the lemma). The SimFreqScore of returned lemmas contains their aggregate ARF.
+// The function uses precomputed aggregate ARF values from the auxiliary
+// `{groupedName}_lemma_stats` table so the table must be (re)built first
+// (see freqdb.NgramFreqGenerator.BuildLemmaStats). Tables built by older
+// versions of Frodo do not contain the aggregate ARF - in such case
+// ErrLemmaStatsOutdated is returned and n-grams must be regenerated.
+func SimilarARFLemmas(
+	ctx context.Context,
+	db *mysql.Adapter,
+	groupedName string,
+	lemma Lemma,
+	searchRangeCoeff float64,
+	maxValues int,
+) ([]Lemma, error) {
+	return similarARFLemmas(ctx, db.DB(), groupedName, lemma, searchRangeCoeff, maxValues)
+}
+
+// lemmaStatsHasARF tests whether the lemma stats table exists
+// and contains the `sum_arf` column
+func lemmaStatsHasARF(ctx context.Context, db *sql.DB, statsTable string) (bool, error) {
+	row := db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) FROM information_schema.COLUMNS "+
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = 'sum_arf'",
+		statsTable,
+	)
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check for lemma stats columns: %w", err)
+	}
+	return count > 0, nil
+}
+
+func similarARFLemmas(
+	ctx context.Context,
+	db *sql.DB,
+	groupedName string,
+	lemma Lemma,
+	searchRangeCoeff float64,
+	maxValues int,
+) ([]Lemma, error) {
+	if lemma.NgramSize != 1 {
+		return []Lemma{}, nil
+	}
+	if searchRangeCoeff <= 0 || searchRangeCoeff >= 1 {
+		panic("SimilarARFLemmas - searchRangeCoeff must be from interval (0, 1)")
+	}
+	statsTable := tables.Name(groupedName, tables.LemmaStats)
+	hasARF, err := lemmaStatsHasARF(ctx, db, statsTable)
+	if err != nil {
+		return []Lemma{}, err
+	}
+	if !hasARF {
+		return []Lemma{}, ErrLemmaStatsOutdated
+	}
+	var lemmaARF sql.NullFloat64
+	row := db.QueryRowContext(
+		ctx,
+		fmt.Sprintf(
+			"SELECT sum_arf FROM %s WHERE lemma = ? AND pos = ? AND ngram = 1",
+			statsTable,
+		),
+		lemma.Lemma, lemma.PoS,
+	)
+	if err := row.Scan(&lemmaARF); err == sql.ErrNoRows {
+		return []Lemma{}, nil
+
+	} else if err != nil {
+		return []Lemma{}, fmt.Errorf("failed to get similar freq. lemmas: %w", err)
+	}
+	if !lemmaARF.Valid || lemmaARF.Float64 <= 0 {
+		return []Lemma{}, nil
+	}
+	limitSQL := ""
+	args := []any{
+		lemmaARF.Float64 * (1.0 - searchRangeCoeff),
+		lemmaARF.Float64 * (1.0 + searchRangeCoeff),
+		lemmaARF.Float64,
+	}
+	if maxValues > 0 {
+		limitSQL = "LIMIT ?"
+		args = append(args, maxValues)
+	}
+	rows, err := db.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			"SELECT '-', lemma, '-', sum_count, pos, 0, 1, sum_arf, 0 "+
+				"FROM %s "+
+				"WHERE ngram = 1 AND sum_arf BETWEEN ? AND ? "+
+				"ORDER BY ABS(sum_arf - ?), lemma, pos "+
+				"%s",
+			statsTable,
+			limitSQL,
+		),
+		args...,
+	)
+	if err != nil {
+		return []Lemma{}, fmt.Errorf("failed to get similar freq. lemmas: %w", err)
+	}
+	ans, err := processRowsSync(rows, 0, false, 0, 0)
+	if err != nil {
+		return []Lemma{}, fmt.Errorf("failed to get similar freq. lemmas: %w", err)
+	}
+	return ans, nil
+}
@@ -0,0 +1,240 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dictionary
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fixtureWord struct {
+	value string
+	lemma string
+	pos   string
+	count int64
+	arf   float64
+}
+
+// simFreqFixture is a small corpus dictionary where lemmas
+// have quite different ARF than their individual forms
+var simFreqFixture = []fixtureWord{
+	{"dům", "dům", "N", 100, 40},
+	{"domu", "dům", "N", 80, 30},
+	{"domy", "dům", "N", 30, 10},
+	{"hrad", "hrad", "N", 90, 75},
+	{"hradu", "hrad", "N", 10, 5},
+	{"les", "les", "N", 70, 70},
+	{"stůl", "stůl", "N", 60, 55},
+	{"stolu", "stůl", "N", 50, 36},
+	{"běžet", "běžet", "V", 20, 15},
+	{"okno", "okno", "N", 150, 120},
+}
+
+// fixtureDriver is a fake database driver evaluating lemma-level
+// ARF queries on simFreqFixture aggregated the same way as in
+// the lemma_stats table. All the queries are recorded.
+type fixtureDriver struct {
+	queries []string
+
+	// noSumARF simulates a lemma stats table created
+	// without the sum_arf column
+	noSumARF bool
+}
+
+func (d *fixtureDriver) Open(name string) (driver.Conn, error) {
+	return &fixtureConn{drv: d}, nil
+}
+
+func (d *fixtureDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *fixtureDriver) Driver() driver.Driver {
+	return d
+}
+
+type fixtureConn struct {
+	drv *fixtureDriver
+}
+
+func (c *fixtureConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements not supported")
+}
+
+func (c *fixtureConn) Close() error {
+	return nil
+}
+
+func (c *fixtureConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *fixtureConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	c.drv.queries = append(c.drv.queries, query)
+	if strings.Contains(query, "information_schema.COLUMNS") {
+		ans := &fixtureRows{cols: []string{"count"}, data: [][]driver.Value{{int64(1)}}}
+		if c.drv.noSumARF {
+			ans.data[0][0] = int64(0)
+		}
+		return ans, nil
+	}
+	if strings.HasPrefix(query, "SELECT sum_arf") {
+		ans := &fixtureRows{cols: []string{"sum_arf"}}
+		var sum float64
+		for _, w := range simFreqFixture {
+			if w.lemma == args[0].Value && w.pos == args[1].Value {
+				sum += w.arf
+			}
+		}
+		if sum > 0 {
+			ans.data = [][]driver.Value{{sum}}
+		}
+		return ans, nil
+	}
+	type lemmaPos struct {
+		lemma string
+		pos   string
+	}
+	counts := make(map[lemmaPos]int64)
+	arfs := make(map[lemmaPos]float64)
+	for _, w := range simFreqFixture {
+		counts[lemmaPos{w.lemma, w.pos}] += w.count
+		arfs[lemmaPos{w.lemma, w.pos}] += w.arf
+	}
+	keys := make([]lemmaPos, 0, len(counts))
+	for k := range counts {
+		if arfs[k] >= args[0].Value.(float64) && arfs[k] <= args[1].Value.(float64) {
+			keys = append(keys, k)
+		}
+	}
+	center := args[2].Value.(float64)
+	slices.SortFunc(keys, func(a, b lemmaPos) int {
+		return cmp.Or(
+			cmp.Compare(math.Abs(arfs[a]-center), math.Abs(arfs[b]-center)),
+			strings.Compare(a.lemma+"|"+a.pos, b.lemma+"|"+b.pos),
+		)
+	})
+	if len(args) > 3 && int(args[3].Value.(int64)) < len(keys) {
+		keys = keys[:args[3].Value.(int64)]
+	}
+	ans := &fixtureRows{
+		cols: []string{
+			"value", "lemma", "sublemma", "count", "pos",
+			"arf", "ngram", "sum_arf", "initial_cap",
+		},
+	}
+	for _, k := range keys {
+		ans.data = append(
+			ans.data,
+			[]driver.Value{"-", k.lemma, "-", counts[k], k.pos, int64(0), int64(1), arfs[k], int64(0)},
+		)
+	}
+	return ans, nil
+}
+
+type fixtureRows struct {
+	cols []string
+	data [][]driver.Value
+	pos  int
+}
+
+func (r *fixtureRows) Columns() []string {
+	return r.cols
+}
+
+func (r *fixtureRows) Close() error {
+	return nil
+}
+
+func (r *fixtureRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.pos])
+	r.pos++
+	return nil
+}
+
+func TestSimilarARFLemmas(t *testing.T) {
+	drv := &fixtureDriver{}
+	db := sql.OpenDB(drv)
+	defer db.Close()
+
+	// dům has aggregate ARF 80 => searched range is [64, 96]
+	ans, err := similarARFLemmas(
+		context.Background(), db, "test", Lemma{Lemma: "dům", PoS: "N", NgramSize: 1}, 0.2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dům", "hrad", "les", "stůl"}, fuzzyLemmaValues(ans))
+	assert.Equal(t, 210, ans[0].Count)
+	assert.Equal(t, 80.0, ans[0].SimFreqScore)
+	assert.Equal(t, 100, ans[1].Count)
+	assert.Equal(t, 91.0, ans[3].SimFreqScore)
+
+	ans, err = similarARFLemmas(
+		context.Background(), db, "test", Lemma{Lemma: "dům", PoS: "N", NgramSize: 1}, 0.2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dům", "hrad"}, fuzzyLemmaValues(ans))
+	// only the precomputed lemma stats are queried
+	for _, q := range drv.queries {
+		if strings.Contains(q, "information_schema") {
+			continue
+		}
+		assert.Contains(t, q, "test_lemma_stats")
+		assert.NotContains(t, q, "GROUP BY")
+	}
+}
+
+func TestSimilarARFLemmasUnknownLemma(t *testing.T) {
+	db := sql.OpenDB(&fixtureDriver{})
+	defer db.Close()
+
+	ans, err := similarARFLemmas(
+		context.Background(), db, "test", Lemma{Lemma: "strom", PoS: "N", NgramSize: 1}, 0.2, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, ans)
+
+	ans, err = similarARFLemmas(
+		context.Background(), db, "test", Lemma{Lemma: "dům", PoS: "N", NgramSize: 2}, 0.2, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, ans)
+}
+
+func TestSimilarARFLemmasOutdatedStats(t *testing.T) {
+	drv := &fixtureDriver{noSumARF: true}
+	db := sql.OpenDB(drv)
+	defer db.Close()
+
+	ans, err := similarARFLemmas(
+		context.Background(), db, "test", Lemma{Lemma: "dům", PoS: "N", NgramSize: 1}, 0.2, 10)
+	assert.ErrorIs(t, err, ErrLemmaStatsOutdated)
+	assert.Empty(t, ans)
+	assert.Len(t, drv.queries, 1)
+}
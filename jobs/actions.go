@@ -19,14 +19,18 @@ package jobs
 import (
 	"context"
 	"fmt"
+	"frodo/mail"
+	"frodo/middleware"
 	"net/http"
 	"reflect"
 	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	cncmail "github.com/czcorpus/cnc-gokit/mail"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/text/message"
@@ -41,6 +45,10 @@ const (
 	tableActionClearOldJobs
 )
 
+const (
+	shutdownDrainCheckInterval = 200 * time.Millisecond
+)
+
 // TableUpdate is a job table queue element specifying
 // required operation on the table
 type TableUpdate struct {
@@ -62,12 +70,83 @@ type Actions struct {
 	jobDeps          JobsDeps
 	jobStop          chan<- string
 	msgPrinter       *message.Printer
+	lang             string
+
+	// jobConflictLock makes checks for conflicting jobs atomic
+	// along with enqueuing new jobs (see EnqueueIfNoConflict)
+	jobConflictLock sync.Mutex
 
 	// tableUpdate represents a single "point" through which jobs
 	// are updated
 	tableUpdate chan TableUpdate
 
-	notificationRecipients map[string][]string
+	// tuWatchdog watches the tableUpdate consumer
+	tuWatchdog tableUpdateWatchdog
+
+	// schedulerHeartbeat is a time (in UnixNano) of the last
+	// activity of the job scheduler (see SchedulerAlive)
+	schedulerHeartbeat atomic.Int64
+
+	// clearedJobs remembers recently cleared jobs
+	clearedJobs *clearedJobs
+
+	// jobSubscribers contains channels of clients interested
+	// in job status changes (see JobEvents)
+	jobSubscribers     map[string][]chan JobInfoCompact
+	jobSubscribersLock sync.Mutex
+
+	// metrics collects job subsystem metrics (see Metrics)
+	metrics *Collector
+
+	// jobDurations collects durations of finished jobs
+	// for queued jobs ETA estimation
+	jobDurations *jobDurations
+
+	// history contains finished jobs from previous runs
+	// and jobs removed by the regular cleanup
+	// (see Conf.PersistFinishedJobs)
+	history *jobHistory
+
+	notificationRecipients     map[string][]notificationRecipient
+	notificationRecipientsLock sync.Mutex
+
+	// notificationDigest buffers notifications in case
+	// Conf.NotificationDigestWindowSecs is set
+	notificationDigest *notificationDigest
+
+	// notificationWebhooks contains URLs called once a job finishes
+	notificationWebhooks     map[string][]string
+	notificationWebhooksLock sync.Mutex
+
+	// jobContexts contains contexts of cancellable jobs
+	// (see WithJobContext)
+	jobContexts     map[string]*jobContext
+	jobContextsLock sync.Mutex
+
+	// templates contains stored job definitions
+	templates *templateStore
+
+	// jobCreators are used to create jobs from templates
+	// (see SetJobCreators)
+	jobCreators map[string]jobCreator
+
+	// jobRequests contains requests of created jobs
+	// (see RecordJobRequests)
+	jobRequests *jobRequests
+
+	// jobsCtx is a parent context of all job contexts (see WithJobContext).
+	// Unlike ctx, it is cancelled only after running jobs are drained
+	// and the job list is saved on shutdown (see goWaitExit).
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+
+	// exited is closed once the shutdown procedure finishes
+	exited chan struct{}
+
+	// resumers reconstruct detached jobs of respective types
+	// (see RegisterJobResumer)
+	resumers     map[string]JobResumer
+	resumersLock sync.Mutex
 }
 
 func (a *Actions) TestAllowsJobRestart(jinfo GeneralJobInfo) error {
@@ -77,12 +156,12 @@ func (a *Actions) TestAllowsJobRestart(jinfo GeneralJobInfo) error {
 	return nil
 }
 
-func (a *Actions) createJobList(unfinishedOnly bool) JobInfoList {
+func (a *Actions) createJobList(filter jobListFilter) JobInfoList {
 	a.jobListLock.RLock()
 	defer a.jobListLock.RUnlock()
 	ans := make(JobInfoList, 0, len(a.jobList))
 	for _, v := range a.jobList {
-		if !unfinishedOnly || !v.IsFinished() {
+		if filter.matches(v) {
 			ans = append(ans, v)
 		}
 	}
@@ -101,19 +180,87 @@ func (a *Actions) HasRunningJobs() bool {
 	return false
 }
 
-func (a *Actions) EnqueueJob(fn *QueuedFunc, initialStatus GeneralJobInfo) {
+// EnqueueJob adds a job with DefaultJobPriority to the queue
+func (a *Actions) EnqueueJob(fn *QueuedFunc, initialStatus GeneralJobInfo) error {
+	return a.EnqueueJobWithPriority(fn, initialStatus, DefaultJobPriority)
+}
+
+// EnqueueJobWithPriority adds a job to the queue. Jobs with higher
+// priority are started before the ones with lower priority, jobs
+// with the same priority are started in the order of enqueuing.
+// In case the queue is full, ErrorQueueFull is returned.
+func (a *Actions) EnqueueJobWithPriority(fn *QueuedFunc, initialStatus GeneralJobInfo, priority int) error {
 	a.jobQueueLock.Lock()
-	a.jobQueue.Enqueue(fn, initialStatus)
+	if a.queueIsFull() {
+		a.jobQueueLock.Unlock()
+		a.rejectJob(initialStatus)
+		return ErrorQueueFull
+	}
+	a.jobQueue.EnqueueWithPriority(fn, initialStatus, priority)
 	a.jobQueueLock.Unlock()
-	log.Info().Msgf("Enqueued job %s", initialStatus.GetID())
+	log.Info().Int("priority", priority).Msgf("Enqueued job %s", initialStatus.GetID())
+	return nil
 }
 
-func (a *Actions) EqueueJobAfter(fn *QueuedFunc, initialStatus GeneralJobInfo, parentJobID string) {
+// EqueueJobAfter adds a job to the queue. The job is started once all
+// the parent jobs finish. In case any of the parents fails, the job fails
+// too. In case the queue is full, ErrorQueueFull is returned. In case
+// the dependencies cannot be added (e.g. ErrorCircularJobDependency),
+// the job is not enqueued and the error is returned.
+func (a *Actions) EqueueJobAfter(fn *QueuedFunc, initialStatus GeneralJobInfo, parentJobIDs ...string) error {
 	a.jobQueueLock.Lock()
+	if a.queueIsFull() {
+		a.jobQueueLock.Unlock()
+		a.rejectJob(initialStatus)
+		return ErrorQueueFull
+	}
+	if err := a.jobDeps.Add(initialStatus.GetID(), parentJobIDs...); err != nil {
+		a.jobQueueLock.Unlock()
+		a.releaseJobContext(initialStatus.GetID())
+		log.Error().Err(err).Str("jobId", initialStatus.GetID()).Msg("failed to add job dependencies")
+		return fmt.Errorf("failed to add job dependencies: %w", err)
+	}
 	a.jobQueue.Enqueue(fn, initialStatus)
 	a.jobQueueLock.Unlock()
-	a.jobDeps.Add(initialStatus.GetID(), parentJobID)
-	log.Info().Msgf("Enqueued job %s with parent %s", initialStatus.GetID(), parentJobID)
+	log.Info().Strs("parents", parentJobIDs).Msgf("Enqueued job %s with parents", initialStatus.GetID())
+	return nil
+}
+
+// WithdrawJob removes a job from the queue or, in case the job
+// is already running, requests its cancellation. It is intended for
+// jobs enqueued as a part of a larger operation which failed
+// (e.g. a partially enqueued chain of jobs). In case the job
+// is not found, false is returned.
+func (a *Actions) WithdrawJob(jobID string) bool {
+	a.jobQueueLock.Lock()
+	_, err := a.jobQueue.Remove(jobID)
+	a.jobQueueLock.Unlock()
+	if err == nil {
+		a.releaseJobContext(jobID)
+		log.Warn().Str("jobId", jobID).Msg("withdrawn queued job")
+		return true
+	}
+	if a.cancelJob(jobID) {
+		log.Warn().Str("jobId", jobID).Msg("cancelling withdrawn job")
+		return true
+	}
+	return false
+}
+
+// queueIsFull tests whether the job queue reached its configured
+// capacity. The jobQueueLock must be held by the caller.
+func (a *Actions) queueIsFull() bool {
+	return a.conf.MaxQueueLength > 0 && a.jobQueue.Size() >= a.conf.MaxQueueLength
+}
+
+// rejectJob releases resources of a job which has not been accepted
+// to the queue
+func (a *Actions) rejectJob(initialStatus GeneralJobInfo) {
+	a.releaseJobContext(initialStatus.GetID())
+	log.Warn().
+		Str("jobId", initialStatus.GetID()).
+		Int("maxQueueLength", a.conf.MaxQueueLength).
+		Msg("job queue is full, rejecting job")
 }
 
 func (a *Actions) dequeueAndRunJob() {
@@ -128,6 +275,7 @@ func (a *Actions) dequeueAndRunJob() {
 			Str("jobType", initState.GetType()).
 			Str("corpus", initState.GetCorpus()).
 			Msgf("Dequeued a new job")
+		a.metrics.jobStarted()
 		updateJobChan := a.registerJob(initState)
 		go func() {
 			(*fn)(updateJobChan)
@@ -183,47 +331,82 @@ func (a *Actions) registerJob(j GeneralJobInfo) chan GeneralJobInfo {
 
 // JobList godoc
 // @Summary      Returns a list of currently processed jobs
-// @Description
+// @Description  Jobs can be also streamed as NDJSON (one job per line) by setting `Accept: application/x-ndjson` or `format=ndjson`
 // @Produce      json
+// @Produce      application/x-ndjson
 // @Param        unfinishedOnly query int false "Get only unfinished jobs" default(0)
 // @Param        compact query int false "Get jobs in compact and unified format without job type-specific details" default(0)
-// @Success      200 {array} any "JobInfoListCompact or a custom type based on job type"
+// @Param        format query string false "Output format (ndjson)"
+// @Param        history query int false "Include finished jobs from the history (see persistFinishedJobs config); ignored with unfinishedOnly" default(0)
+// @Param        corpus query string false "Get only jobs related to the corpus"
+// @Param        type query string false "Get only jobs of the type (e.g. liveattrs-create)"
+// @Param        offset query int false "Number of jobs to skip" default(0)
+// @Param        limit query int false "Max. number of jobs to return (0 = no limit)" default(0)
+// @Success      200 {array} any "JobInfoListCompact or a custom type based on job type; the total number of matching jobs is in the X-Total-Count header"
+// @Failure      400 {object} uniresp.ActionError
 // @Router       /jobs [get]
 func (a *Actions) JobList(ctx *gin.Context) {
-	unOnly := ctx.Request.URL.Query().Get("unfinishedOnly") == "1"
-	if ctx.Request.URL.Query().Get("compact") == "1" {
+	page, err := parsePagination(ctx)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusBadRequest)
+		return
+	}
+	filter := jobListFilter{
+		unfinishedOnly: ctx.Request.URL.Query().Get("unfinishedOnly") == "1",
+		corpusID:       ctx.Request.URL.Query().Get("corpus"),
+		jobType:        ctx.Request.URL.Query().Get("type"),
+	}
+	var history JobInfoList
+	if !filter.unfinishedOnly && ctx.Request.URL.Query().Get("history") == "1" {
+		history = filter.apply(a.history.list())
+	}
+	if middleware.WantsNDJSON(ctx) {
+		a.streamJobList(ctx, filter, ctx.Request.URL.Query().Get("compact") == "1", history, page)
+
+	} else if ctx.Request.URL.Query().Get("compact") == "1" {
 		ans := func() JobInfoListCompact {
 			a.jobListLock.RLock()
 			defer a.jobListLock.RUnlock()
 			ans := make(JobInfoListCompact, 0, len(a.jobList))
 			for _, v := range a.jobList {
-				if !unOnly || !v.IsFinished() {
-					item := v.CompactVersion()
+				if filter.matches(v) {
+					item := compactVersion(v)
 					ans = append(ans, &item)
 				}
 			}
 			return ans
 		}()
+		for _, v := range history {
+			item := compactVersion(v)
+			ans = append(ans, &item)
+		}
 		sort.Sort(sort.Reverse(ans))
-		uniresp.WriteJSONResponse(ctx.Writer, ans)
+		from, to := page.bounds(len(ans))
+		ctx.Writer.Header().Set(totalCountHeader, strconv.Itoa(len(ans)))
+		uniresp.WriteJSONResponse(ctx.Writer, ans[from:to])
 
 	} else {
-		tmp := a.createJobList(unOnly)
+		tmp := append(a.createJobList(filter), history...)
 		sort.Sort(sort.Reverse(tmp))
-		ans := make([]any, len(tmp))
-		for i, item := range tmp {
+		from, to := page.bounds(len(tmp))
+		ans := make([]any, to-from)
+		for i, item := range tmp[from:to] {
 			ans[i] = item.FullInfo()
 		}
+		ctx.Writer.Header().Set(totalCountHeader, strconv.Itoa(len(tmp)))
 		uniresp.WriteJSONResponse(ctx.Writer, ans)
 	}
 }
 
 // JobInfo godoc
 // @Summary      Gives an information about a specific data sync job
+// @Description  For jobs waiting in the queue, their queue position and (if available) an estimated time to finish are provided.
 // @Produce      json
 // @Param        jobId path string true "Job ID"
 // @Param        compact query int false "Get compact info" default(0)
 // @Success      200 {object} any
+// @Failure      404 {object} uniresp.ActionError "Job not found"
+// @Failure      410 {object} uniresp.ActionError "Job existed but has been cleared"
 // @Router       /jobs/{jobId} [get]
 func (a *Actions) JobInfo(ctx *gin.Context) {
 	job := func() GeneralJobInfo {
@@ -233,12 +416,21 @@ func (a *Actions) JobInfo(ctx *gin.Context) {
 	}()
 	if job != nil {
 		if ctx.Request.URL.Query().Get("compact") == "1" {
-			uniresp.WriteJSONResponse(ctx.Writer, job.CompactVersion())
+			uniresp.WriteJSONResponse(ctx.Writer, compactVersion(job))
 
 		} else {
 			uniresp.WriteJSONResponse(ctx.Writer, job.FullInfo())
 		}
 
+	} else if queued, ahead, ok := a.findQueuedJob(ctx.Param("jobId")); ok {
+		uniresp.WriteJSONResponse(
+			ctx.Writer,
+			a.queuedJobInfo(queued, ahead, ctx.Request.URL.Query().Get("compact") == "1"),
+		)
+
+	} else if a.clearedJobs.contains(ctx.Param("jobId")) {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError("job expired and has been cleared"), http.StatusGone)
+
 	} else {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError("job not found"), http.StatusNotFound)
 	}
@@ -246,6 +438,7 @@ func (a *Actions) JobInfo(ctx *gin.Context) {
 
 // Delete godoc
 // @Summary      Delete existing job
+// @Description  Stop a running job. Jobs supporting cancellation are stopped and finished with the `cancelled` flag set (see the compact job info).
 // @Produce      json
 // @Param        jobId path string true "Job ID"
 // @Param        compact query int false "Get compact info" default(0)
@@ -259,6 +452,9 @@ func (a *Actions) Delete(ctx *gin.Context) {
 		return FindJob(a.jobList, ctx.Param("jobId"))
 	}()
 	if job != nil {
+		if a.cancelJob(job.GetID()) {
+			log.Info().Str("jobId", job.GetID()).Msg("cancelling job on user request")
+		}
 		a.jobStop <- job.GetID()
 		uniresp.WriteJSONResponse(ctx.Writer, job)
 
@@ -288,12 +484,86 @@ func (a *Actions) ClearIfFinished(ctx *gin.Context) {
 	}
 }
 
+// ClearAllFinished godoc
+// @Summary      Clear all finished jobs
+// @Produce      json
+// @Success      200 {object} map[string]any
+// @Router       /jobs/clearFinished [post]
+func (a *Actions) ClearAllFinished(ctx *gin.Context) {
+	removed := func() []string {
+		a.jobListLock.Lock()
+		defer a.jobListLock.Unlock()
+		return ClearFinishedJobs(a.jobList)
+	}()
+	func() {
+		a.notificationRecipientsLock.Lock()
+		defer a.notificationRecipientsLock.Unlock()
+		for _, jobID := range removed {
+			delete(a.notificationRecipients, jobID)
+		}
+	}()
+	func() {
+		a.notificationWebhooksLock.Lock()
+		defer a.notificationWebhooksLock.Unlock()
+		for _, jobID := range removed {
+			delete(a.notificationWebhooks, jobID)
+		}
+	}()
+	a.clearedJobs.add(removed...)
+	sort.Strings(removed)
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"removed": removed})
+}
+
+// drainRunningJobs waits (up to the timeout) for running jobs
+// to finish. It returns true if there are no unfinished jobs left.
+func (a *Actions) drainRunningJobs(timeout time.Duration) bool {
+	if a.numOfUnfinishedJobs() == 0 {
+		return true
+	}
+	if timeout <= 0 {
+		return false
+	}
+	log.Info().
+		Int("numRunning", a.numOfUnfinishedJobs()).
+		Dur("timeout", timeout).
+		Msg("waiting for running jobs to finish")
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(shutdownDrainCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if a.numOfUnfinishedJobs() == 0 {
+				return true
+			}
+		case <-deadline.C:
+			log.Warn().
+				Int("numRunning", a.numOfUnfinishedJobs()).
+				Msg("running jobs did not finish in time, they will be saved as unfinished")
+			return false
+		}
+	}
+}
+
 func (a *Actions) goWaitExit() {
 	go func() {
 		<-a.ctx.Done()
+		defer close(a.exited)
+		a.drainRunningJobs(a.conf.ShutdownDrainTimeout())
+		// jobs still running are saved as unfinished (= detached after
+		// restart) and only then we stop them
+		defer a.cancelJobs()
+		a.flushAllNotificationDigests()
 		if a.conf.StatusDataPath != "" {
 			log.Info().Msgf("saving state to %s", a.conf.StatusDataPath)
-			jobList := a.createJobList(true)
+			jobList := a.createJobList(jobListFilter{unfinishedOnly: !a.conf.PersistFinishedJobs})
+			if a.conf.PersistFinishedJobs {
+				for i, job := range jobList {
+					jobList[i] = persistableJob(job)
+				}
+				jobList = append(jobList, a.history.list()...)
+			}
 			err := jobList.Serialize(a.conf.StatusDataPath)
 			if err != nil {
 				log.Error().Err(err)
@@ -305,6 +575,21 @@ func (a *Actions) goWaitExit() {
 	}()
 }
 
+// Exited returns a channel which is closed once the job subsystem
+// finishes its shutdown (i.e. running jobs are drained and the job
+// list is saved)
+func (a *Actions) Exited() <-chan struct{} {
+	return a.exited
+}
+
+// jobsContext returns a parent context for job contexts
+func (a *Actions) jobsContext() context.Context {
+	if a.jobsCtx != nil {
+		return a.jobsCtx
+	}
+	return a.ctx
+}
+
 func (a *Actions) GetDetachedJobs() []GeneralJobInfo {
 	ans := make([]GeneralJobInfo, len(a.detachedJobs))
 	i := 0
@@ -341,11 +626,21 @@ func (a *Actions) LastUnfinishedJobOfType(datasetID string, jobType string) (Gen
 	defer a.jobListLock.RUnlock()
 	for _, v := range a.jobList {
 		if v.GetDatasetID() == datasetID && v.GetType() == jobType && !v.IsFinished() &&
-			(tmp == nil || reflect.ValueOf(tmp).IsNil() || v.GetStartDT().Before(tmp.GetStartDT())) {
+			(isNilJob(tmp) || v.GetStartDT().Before(tmp.GetStartDT())) {
 			tmp = v
 		}
 	}
-	return tmp, tmp != nil && !reflect.ValueOf(tmp).IsNil()
+	return tmp, !isNilJob(tmp)
+}
+
+// isNilJob tests whether a job is nil including a typed nil
+// pointer (job infos are stored both as values and pointers)
+func isNilJob(job GeneralJobInfo) bool {
+	if job == nil {
+		return true
+	}
+	v := reflect.ValueOf(job)
+	return v.Kind() == reflect.Pointer && v.IsNil()
 }
 
 func (a *Actions) GetJob(jobID string) (GeneralJobInfo, bool) {
@@ -355,11 +650,46 @@ func (a *Actions) GetJob(jobID string) (GeneralJobInfo, bool) {
 	return v, ok
 }
 
+// WaitForJob blocks until the job is finished or ctx is cancelled.
+// In case onUpdate is not nil, it is called each time a change
+// of the job status is detected. Please note that a queued job
+// is not visible until it is started.
+func (a *Actions) WaitForJob(
+	ctx context.Context,
+	jobID string,
+	pollInterval time.Duration,
+	onUpdate func(GeneralJobInfo),
+) (GeneralJobInfo, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var prev GeneralJobInfo
+	for {
+		select {
+		case <-ticker.C:
+			job, ok := a.GetJob(jobID)
+			if !ok {
+				continue
+			}
+			if onUpdate != nil && (prev == nil || !reflect.DeepEqual(prev, job)) {
+				onUpdate(job)
+			}
+			prev = job
+			if job.IsFinished() {
+				return job, nil
+			}
+		case <-ctx.Done():
+			return prev, fmt.Errorf("failed to wait for job %s: %w", jobID, ctx.Err())
+		}
+	}
+}
+
 // AddNotification godoc
 // @Summary      Add recipient for email notification on job finish
 // @Produce      json
 // @Param        jobId path string true "Job ID"
 // @Param        address path string true "Email address"
+// @Param        onlyOnError query int false "If 1, the notification is sent only if the job fails"
+// @Param        lang query string false "Notification language (e.g. cs, en); the service default is used if omitted"
 // @Success      200 {object} any
 // @Failure      404 {object} uniresp.ActionError
 // @Router       /jobs/{jobId}/emailNotification/{address} [put]
@@ -371,22 +701,17 @@ func (a *Actions) AddNotification(ctx *gin.Context) {
 		return FindJob(a.jobList, jobID)
 	}()
 	if job != nil {
-		recipients, ok := a.notificationRecipients[jobID]
-		if !ok {
-			recipients = make([]string, 1)
-			recipients[0] = ctx.Param("address")
-		} else {
-			hasValue := false
-			for _, addr := range recipients {
-				if addr == ctx.Param("address") {
-					hasValue = true
-				}
-			}
-			if !hasValue {
-				recipients = append(recipients, ctx.Param("address"))
-			}
+		recipient := notificationRecipient{
+			Address:     ctx.Param("address"),
+			OnlyOnError: ctx.Query("onlyOnError") == "1",
+			Lang:        ctx.Query("lang"),
 		}
-		a.notificationRecipients[jobID] = recipients
+		func() {
+			a.notificationRecipientsLock.Lock()
+			defer a.notificationRecipientsLock.Unlock()
+			a.notificationRecipients[jobID] = addRecipient(
+				a.notificationRecipients[jobID], recipient)
+		}()
 		resp := struct {
 			Registered bool `json:"registered"`
 		}{
@@ -399,8 +724,18 @@ func (a *Actions) AddNotification(ctx *gin.Context) {
 	}
 }
 
+// jobRecipients returns a copy of notification recipients of a job
+func (a *Actions) jobRecipients(jobID string) ([]notificationRecipient, bool) {
+	a.notificationRecipientsLock.Lock()
+	defer a.notificationRecipientsLock.Unlock()
+	recipients, ok := a.notificationRecipients[jobID]
+	return slices.Clone(recipients), ok
+}
+
 // GetNotifications godoc
 // @Summary      Get recipients for email notification on job finish
+// @Description  The `recipients` field lists plain addresses. The `recipientsDetails`
+// @Description  field lists the same recipients along with their notification options.
 // @Produce      json
 // @Param        jobId path string true "Job ID"
 // @Success      200 {object} any
@@ -414,14 +749,17 @@ func (a *Actions) GetNotifications(ctx *gin.Context) {
 		return FindJob(a.jobList, jobID)
 	}()
 	if job != nil {
-		recipients, ok := a.notificationRecipients[job.GetID()]
+		recipients, ok := a.jobRecipients(job.GetID())
 		resp := struct {
-			Recipients []string `json:"recipients"`
+			Recipients        []string                `json:"recipients"`
+			RecipientsDetails []notificationRecipient `json:"recipientsDetails"`
 		}{
-			Recipients: []string{},
+			Recipients:        []string{},
+			RecipientsDetails: []notificationRecipient{},
 		}
 		if ok {
-			resp.Recipients = recipients
+			resp.Recipients = recipientAddresses(recipients)
+			resp.RecipientsDetails = recipients
 		}
 		uniresp.WriteJSONResponse(ctx.Writer, resp)
 
@@ -447,9 +785,12 @@ func (a *Actions) CheckNotification(ctx *gin.Context) {
 	}()
 	if job != nil {
 		registered := false
-		recipients, ok := a.notificationRecipients[jobID]
+		recipients, ok := a.jobRecipients(jobID)
 		if ok {
-			registered = slices.Contains(recipients, ctx.Param("address"))
+			registered = slices.ContainsFunc(
+				recipients,
+				func(v notificationRecipient) bool { return v.Address == ctx.Param("address") },
+			)
 		}
 
 		resp := struct {
@@ -485,16 +826,20 @@ func (a *Actions) RemoveNotification(ctx *gin.Context) {
 		return FindJob(a.jobList, jobID)
 	}()
 	if job != nil {
-		recipients, ok := a.notificationRecipients[jobID]
-		if ok {
-			for i, addr := range recipients {
-				if addr == ctx.Param("address") {
-					recipients = append(recipients[:i], recipients[i+1:]...)
-					break
+		func() {
+			a.notificationRecipientsLock.Lock()
+			defer a.notificationRecipientsLock.Unlock()
+			recipients, ok := a.notificationRecipients[jobID]
+			if ok {
+				for i, recipient := range recipients {
+					if recipient.Address == ctx.Param("address") {
+						recipients = append(recipients[:i], recipients[i+1:]...)
+						break
+					}
 				}
+				a.notificationRecipients[jobID] = recipients
 			}
-			a.notificationRecipients[jobID] = recipients
-		}
+		}()
 
 		resp := struct {
 			Registered bool `json:"registered"`
@@ -515,15 +860,149 @@ func (a *Actions) RemoveNotification(ctx *gin.Context) {
 // @Router       /jobs/utilization [get]
 func (a *Actions) Utilization(ctx *gin.Context) {
 	numUnfinished := a.numOfUnfinishedJobs()
+	a.jobQueueLock.Lock()
+	queueLength := a.jobQueue.Size()
+	queueSaturated := a.queueIsFull()
+	a.jobQueueLock.Unlock()
 	ans := map[string]any{
 		"maxNumConcurrentJobs": a.conf.MaxNumConcurrentJobs,
 		"currentRunningJobs":   numUnfinished,
 		"utilization":          float32(numUnfinished) / float32(a.conf.MaxNumConcurrentJobs),
-		"jobQueueLength":       a.jobQueue.Size(),
+		"jobQueueLength":       queueLength,
+		"maxQueueLength":       a.conf.MaxQueueLength,
+		"queueSaturated":       queueSaturated,
+		"tableUpdateConsumer":  a.TableUpdateConsumerStatus(),
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
 
+// RestartTableUpdateConsumer godoc
+// @Summary      Restart job table update consumer
+// @Description  This is a safety valve for situations where the job table update consumer gets stuck and the job bookkeeping freezes. The stuck consumer exits once (if ever) it finishes its current update. Only a consumer detected as stalled can be restarted.
+// @Produce      json
+// @Success      200 {object} TableUpdateConsumerStatus
+// @Failure      409 {object} uniresp.ActionError
+// @Router       /jobs/tableUpdateConsumer/restart [post]
+func (a *Actions) RestartTableUpdateConsumer(ctx *gin.Context) {
+	if err := a.restartTableUpdateConsumer(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusConflict)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, a.TableUpdateConsumerStatus())
+}
+
+// notificationBodyTemplate returns a configured notification body
+// template for the current language or the default one.
+func (a *Actions) notificationBodyTemplate(lang string, printer *message.Printer) []string {
+	if tpl, ok := a.conf.EmailNotification.LocalizedBodyTemplate(lang); ok {
+		return tpl
+	}
+	return []string{
+		mail.PlaceholderSubject,
+		printer.Sprintf("Job ID: %s", mail.PlaceholderJobID),
+		mail.PlaceholderStatus,
+		"",
+		"",
+		mail.PlaceholderSignature,
+	}
+}
+
+// jobLink returns a public URL of a job or an empty string
+// in case no base URL is configured.
+func (a *Actions) jobLink(jobID string) string {
+	if a.conf.EmailNotification.JobLinkBaseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(a.conf.EmailNotification.JobLinkBaseURL, "/") + JobURLPath(jobID)
+}
+
+// processTableUpdate applies a single update on the job table
+func (a *Actions) processTableUpdate(upd TableUpdate) {
+	switch upd.action {
+	case tableActionUpdateJob:
+		if a.isTimedOutJob(upd.itemID) {
+			log.Warn().Str("jobId", upd.itemID).Msg("ignoring update of a timed out job")
+			return
+		}
+		func() {
+			a.jobListLock.Lock()
+			defer a.jobListLock.Unlock()
+			curr, ok := a.jobList[upd.itemID]
+			if !ok {
+				log.Warn().Str("jobId", upd.itemID).Msg("received update for an unknown/removed job")
+				return
+			}
+			// make sure we keep the current error and progress even if
+			// new status comes without them
+			updated := keepProgress(curr, upd.data)
+			if currErr := curr.GetError(); currErr != nil && updated.GetError() == nil {
+				a.jobList[upd.itemID] = updated.WithError(currErr)
+
+			} else {
+				a.jobList[upd.itemID] = updated
+			}
+		}()
+		a.publishJobStatus(upd.itemID, false)
+	case tableActionFinishJob:
+		// the timeout watchdog finishes the job on its own so any
+		// later finish from the job itself must be ignored
+		if !IsTimedOut(upd.data.GetError()) && a.isTimedOutJob(upd.itemID) {
+			log.Warn().Str("jobId", upd.itemID).Msg("ignoring finish of a timed out job")
+			return
+		}
+		// timed out jobs are cancelled by the watchdog but they must keep their error
+		cancelled := a.releaseJobContext(upd.itemID) && !IsTimedOut(upd.data.GetError())
+		if cancelled && upd.data != nil && upd.data.GetError() != nil {
+			upd.data = upd.data.WithError(ErrorJobCancelled)
+		}
+		func() {
+			a.jobListLock.Lock()
+			defer a.jobListLock.Unlock()
+			curr, ok := a.jobList[upd.itemID]
+			if !ok {
+				log.Warn().Str("jobId", upd.itemID).Msg("received finish for an unknown/removed job")
+				return
+			}
+			if cancelled && curr.GetError() != nil {
+				curr = curr.WithError(ErrorJobCancelled)
+			}
+			a.jobList[upd.itemID] = curr.AsFinished()
+			a.callWebhooks(upd.itemID, compactVersion(a.jobList[upd.itemID]))
+		}()
+		a.publishJobStatus(upd.itemID, true)
+		a.jobDeps.SetParentFinished(upd.itemID, upd.data.GetError() != nil)
+		jobRecipients, _ := a.jobRecipients(upd.itemID)
+		recipients := selectRecipients(jobRecipients, upd.data.GetError() != nil)
+		logAction := log.Info().Str("jobId", upd.itemID)
+		if upd.data != nil {
+			dur := time.Since(time.Time(upd.data.GetStartDT()))
+			logAction.Float64("duration", dur.Seconds())
+			if upd.data.GetError() == nil {
+				a.jobDurations.add(upd.data.GetType(), dur)
+			}
+			a.metrics.jobFinished(upd.data.GetType(), dur, upd.data.GetError() != nil)
+		}
+		logAction.Msg("job finished")
+		if len(recipients) > 0 {
+			a.notifyJobFinished(recipients, upd.data)
+		}
+	case tableActionClearOldJobs:
+		func() {
+			a.jobListLock.Lock()
+			defer a.jobListLock.Unlock()
+			removed := clearOldJobs(a.jobList, a.conf.FinishedJobRetention())
+			ids := make([]string, len(removed))
+			for i, job := range removed {
+				ids[i] = job.GetID()
+			}
+			a.clearedJobs.add(ids...)
+			if a.conf.PersistFinishedJobs {
+				a.history.add(removed...)
+			}
+		}()
+	}
+}
+
 // NewActions is the default factory
 func NewActions(
 	conf *Conf,
@@ -537,13 +1016,42 @@ func NewActions(
 		detachedJobs:           make(map[string]GeneralJobInfo),
 		tableUpdate:            make(chan TableUpdate),
 		jobStop:                jobStop,
-		notificationRecipients: make(map[string][]string),
+		notificationRecipients: make(map[string][]notificationRecipient),
+		notificationWebhooks:   make(map[string][]string),
+		notificationDigest:     newNotificationDigest(),
+		jobContexts:            make(map[string]*jobContext),
 		msgPrinter:             message.NewPrinter(message.MatchLanguage(lang)),
+		lang:                   lang,
 		jobQueue:               &JobQueue{},
 		jobDeps:                make(JobsDeps),
 		ctx:                    ctx,
+		clearedJobs: newClearedJobs(
+			conf.ClearedJobsMemorySize,
+			time.Duration(conf.ClearedJobsRetentionHours)*time.Hour,
+		),
+		jobSubscribers: make(map[string][]chan JobInfoCompact),
+		jobDurations:   newJobDurations(),
+		history:        newJobHistory(time.Duration(conf.HistoryRetentionHours) * time.Hour),
+		exited:         make(chan struct{}),
+		resumers:       make(map[string]JobResumer),
 	}
+	ans.jobsCtx, ans.cancelJobs = context.WithCancel(context.Background())
+	ans.metrics = NewCollector(
+		ans.numOfUnfinishedJobs,
+		func() int {
+			ans.jobQueueLock.Lock()
+			defer ans.jobQueueLock.Unlock()
+			return ans.jobQueue.Size()
+		},
+	)
 	ans.goWaitExit()
+	templates, err := newTemplateStore(conf.TemplatesDataPath)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load job templates, using an empty in-memory storage")
+		templates, _ = newTemplateStore("")
+	}
+	ans.templates = templates
+	ans.jobRequests = newJobRequests()
 	isFile, err := fs.IsFile(conf.StatusDataPath)
 	if err != nil {
 		log.Error().Err(err)
@@ -555,16 +1063,28 @@ func NewActions(
 			log.Error().Err(err).Msg("failed to load status data")
 		}
 		for _, job := range jobs {
-			if job != nil {
+			if job == nil {
+				continue
+			}
+			if job.IsFinished() {
+				ans.history.add(job)
+
+			} else {
 				ans.detachedJobs[job.GetID()] = job
 				log.Info().Msgf("added detached job %s", job.GetID())
+				if conf.AutoResumes(job.GetType()) {
+					log.Info().
+						Str("jobId", job.GetID()).
+						Str("jobType", job.GetType()).
+						Msg("detached job will be resumed once its resumer is registered")
+				}
 			}
 		}
 	}
 
 	// here we listen for context Done() and clean finished
 	// jobs info regularly
-	ticker := time.NewTicker(1 * time.Hour)
+	ticker := time.NewTicker(conf.CleanupInterval())
 	go func() {
 		for {
 			select {
@@ -584,6 +1104,13 @@ func NewActions(
 		for {
 			select {
 			case <-ticker2.C:
+				ans.schedulerHeartbeat.Store(time.Now().UnixNano())
+				if ctx.Err() != nil {
+					// shutting down - no new jobs can be started
+					// (the ctx.Done() case may not be selected yet)
+					ticker2.Stop()
+					return
+				}
 				func() {
 					ans.jobQueueLock.Lock()
 					defer ans.jobQueueLock.Unlock()
@@ -637,94 +1164,9 @@ func NewActions(
 		}
 	}()
 
-	go func() {
-		for upd := range ans.tableUpdate {
-			switch upd.action {
-			case tableActionUpdateJob:
-				func() {
-					ans.jobListLock.Lock()
-					defer ans.jobListLock.Unlock()
-					curr, ok := ans.jobList[upd.itemID]
-					if !ok {
-						log.Warn().Str("jobId", upd.itemID).Msg("received update for an unknown/removed job")
-						return
-					}
-					// make sure we keep the current error even if new status
-					// comes without one
-					if currErr := curr.GetError(); currErr != nil && upd.data.GetError() == nil {
-						ans.jobList[upd.itemID] = upd.data.WithError(currErr)
-
-					} else {
-						ans.jobList[upd.itemID] = upd.data
-					}
-				}()
-			case tableActionFinishJob:
-				func() {
-					ans.jobListLock.Lock()
-					defer ans.jobListLock.Unlock()
-					curr, ok := ans.jobList[upd.itemID]
-					if !ok {
-						log.Warn().Str("jobId", upd.itemID).Msg("received finish for an unknown/removed job")
-						return
-					}
-					ans.jobList[upd.itemID] = curr.AsFinished()
-				}()
-				ans.jobDeps.SetParentFinished(upd.itemID, upd.data.GetError() != nil)
-				recipients, ok := ans.notificationRecipients[upd.itemID]
-				logAction := log.Info().Str("jobId", upd.itemID)
-				if upd.data != nil {
-					dur := time.Since(time.Time(upd.data.GetStartDT()))
-					logAction.Float64("duration", dur.Seconds())
-				}
-				logAction.Msg("job finished")
-				if ok {
-					jdesc := extractJobDescription(ans.msgPrinter, upd.data)
-					subject := ans.msgPrinter.Sprintf("Job of type \"%s\" finished", jdesc)
-					var sign string
-					if conf.EmailNotification.HasSignature() {
-						var err error
-						sign, err = conf.EmailNotification.LocalizedSignature(lang)
-						if err != nil {
-							log.Error().Err(err).Send()
-						}
-
-					} else {
-						sign = conf.EmailNotification.DefaultSignature(lang)
-					}
-
-					notificationConf := conf.EmailNotification.WithRecipients(recipients...)
-					err := cncmail.SendNotification(
-						&notificationConf,
-						time.Now().Location(),
-						cncmail.Notification{
-							Subject: subject,
-							Paragraphs: []string{
-								subject,
-								ans.msgPrinter.Sprintf("Job ID: %s", upd.itemID),
-								localizedStatus(ans.msgPrinter, upd.data),
-								"",
-								"",
-								sign,
-							},
-						},
-					)
-					if err != nil {
-						log.Error().Err(err).
-							Str("mailSubject", subject).
-							Strs("mailBody", []string{subject, jdesc}).
-							Msg("Failed to send finished job notification")
-					}
-				}
-			case tableActionClearOldJobs:
-				func() {
-					ans.jobListLock.Lock()
-					defer ans.jobListLock.Unlock()
-					clearOldJobs(ans.jobList)
-				}()
-			}
-
-		}
-	}()
+	go ans.runTableUpdateConsumer()
+	go ans.runTableUpdateWatchdog()
+	go ans.runJobTimeoutWatchdog()
 
 	return ans
 }
@@ -0,0 +1,78 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// clearedJobs is a bounded set of IDs of recently cleared jobs.
+// It allows us to distinguish between jobs which never existed
+// and jobs which are gone. Once the set is full, the oldest
+// entries are evicted. Entries older than the retention
+// are ignored.
+type clearedJobs struct {
+	lock      sync.Mutex
+	items     map[string]time.Time
+	order     []string
+	maxSize   int
+	retention time.Duration
+}
+
+// add inserts job IDs to the set. For non-positive maxSize,
+// the method does nothing.
+func (cj *clearedJobs) add(ids ...string) {
+	if cj.maxSize <= 0 {
+		return
+	}
+	cj.lock.Lock()
+	defer cj.lock.Unlock()
+	now := time.Now()
+	for _, id := range ids {
+		if _, ok := cj.items[id]; ok {
+			continue
+		}
+		cj.items[id] = now
+		cj.order = append(cj.order, id)
+	}
+	for len(cj.order) > cj.maxSize {
+		delete(cj.items, cj.order[0])
+		cj.order = cj.order[1:]
+	}
+}
+
+// contains tests whether the job has been cleared recently
+// (i.e. within the retention period)
+func (cj *clearedJobs) contains(id string) bool {
+	cj.lock.Lock()
+	defer cj.lock.Unlock()
+	t, ok := cj.items[id]
+	if !ok {
+		return false
+	}
+	return cj.retention <= 0 || time.Since(t) <= cj.retention
+}
+
+func newClearedJobs(maxSize int, retention time.Duration) *clearedJobs {
+	return &clearedJobs{
+		items:     make(map[string]time.Time),
+		order:     make([]string, 0, max(maxSize, 0)),
+		maxSize:   maxSize,
+		retention: retention,
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClearedJobsEvictsOldest(t *testing.T) {
+	cj := newClearedJobs(2, time.Hour)
+	cj.add("a", "b")
+	cj.add("c")
+	assert.False(t, cj.contains("a"))
+	assert.True(t, cj.contains("b"))
+	assert.True(t, cj.contains("c"))
+	assert.False(t, cj.contains("d"))
+}
+
+func TestClearedJobsRetention(t *testing.T) {
+	cj := newClearedJobs(10, time.Hour)
+	cj.add("a")
+	cj.items["a"] = time.Now().Add(-2 * time.Hour)
+	assert.False(t, cj.contains("a"))
+}
+
+func TestClearedJobsDisabled(t *testing.T) {
+	cj := newClearedJobs(-1, time.Hour)
+	cj.add("a")
+	assert.False(t, cj.contains("a"))
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// JobConflictResponse is a response to a request for a new job
+// which would overlap with an unfinished job for the same dataset
+type JobConflictResponse struct {
+	Code  int    `json:"code"`
+	Error string `json:"error"`
+	JobID string `json:"jobId"`
+}
+
+// ConflictingJob returns an unfinished (i.e. running or queued) job
+// of any of the types for the dataset. It can be used to prevent
+// overlapping jobs which would corrupt each other's data.
+func (a *Actions) ConflictingJob(datasetID string, jobTypes ...string) (GeneralJobInfo, bool) {
+	for _, jobType := range jobTypes {
+		if job, ok := a.LastUnfinishedJobOfType(datasetID, jobType); ok {
+			return job, true
+		}
+	}
+	a.jobQueueLock.Lock()
+	defer a.jobQueueLock.Unlock()
+	for _, job := range a.jobQueue.InitialStates() {
+		if job.GetDatasetID() == datasetID && slices.Contains(jobTypes, job.GetType()) {
+			return job, true
+		}
+	}
+	return nil, false
+}
+
+// EnqueueIfNoConflict calls enqueueFn (which is expected to enqueue
+// new jobs for the dataset) only in case there is no conflicting job
+// (see ConflictingJob). Otherwise, ConflictingJobError is returned.
+// The check and enqueueFn run under a lock shared by all the callers
+// so concurrent requests cannot pass the check before any of them
+// enqueues its job. With force set (clients use the `force=1` URL
+// argument), enqueueFn is called without any check.
+func (a *Actions) EnqueueIfNoConflict(
+	force bool,
+	datasetID string,
+	jobTypes []string,
+	enqueueFn func() error,
+) error {
+	a.jobConflictLock.Lock()
+	defer a.jobConflictLock.Unlock()
+	if !force {
+		if job, ok := a.ConflictingJob(datasetID, jobTypes...); ok {
+			return ConflictingJobError{DatasetID: datasetID, JobID: job.GetID()}
+		}
+	}
+	return enqueueFn()
+}
+
+// WriteJobConflictResponse writes the 409 Conflict response
+// along with ID of the conflicting job
+func WriteJobConflictResponse(ctx *gin.Context, err ConflictingJobError) {
+	uniresp.WriteJSONResponseWithStatus(
+		ctx.Writer,
+		http.StatusConflict,
+		JobConflictResponse{
+			Code:  http.StatusConflict,
+			Error: err.Error(),
+			JobID: err.JobID,
+		},
+	)
+}
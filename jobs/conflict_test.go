@@ -0,0 +1,116 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newConflictTestActions() *Actions {
+	a := &Actions{
+		jobList: map[string]GeneralJobInfo{
+			"running": DummyJobInfo{ID: "running", Type: "ngram-generating", CorpusID: "syn2020", Start: CurrentDatetime()},
+		},
+		jobQueue: &JobQueue{},
+	}
+	fn := func(updateJobChan chan<- GeneralJobInfo) {}
+	a.jobQueue.Enqueue(&fn, DummyJobInfo{ID: "queued", Type: "liveattrs", CorpusID: "syn2015"})
+	return a
+}
+
+func TestEnqueueIfNoConflict(t *testing.T) {
+	a := newConflictTestActions()
+	var numEnqueued int
+	enqueueFn := func() error {
+		numEnqueued++
+		return nil
+	}
+
+	err := a.EnqueueIfNoConflict(false, "syn2020", []string{"ngram-generating"}, enqueueFn)
+	var confErr ConflictingJobError
+	assert.ErrorAs(t, err, &confErr)
+	assert.Equal(t, "running", confErr.JobID)
+
+	job, ok := a.ConflictingJob("syn2015", "liveattrs")
+	assert.True(t, ok)
+	assert.Equal(t, "queued", job.GetID())
+	err = a.EnqueueIfNoConflict(false, "syn2015", []string{"liveattrs"}, enqueueFn)
+	assert.ErrorAs(t, err, &confErr)
+	assert.Equal(t, 0, numEnqueued)
+
+	assert.NoError(t, a.EnqueueIfNoConflict(false, "syn2015", []string{"ngram-generating"}, enqueueFn))
+	assert.NoError(t, a.EnqueueIfNoConflict(true, "syn2020", []string{"ngram-generating"}, enqueueFn))
+	assert.Equal(t, 2, numEnqueued)
+
+	assert.ErrorIs(
+		t,
+		a.EnqueueIfNoConflict(false, "syn2010", []string{"liveattrs"}, func() error { return ErrorQueueFull }),
+		ErrorQueueFull,
+	)
+}
+
+func TestEnqueueIfNoConflictConcurrent(t *testing.T) {
+	a := &Actions{
+		jobList:  make(map[string]GeneralJobInfo),
+		jobQueue: &JobQueue{},
+	}
+	var wg sync.WaitGroup
+	var numConflicts atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := a.EnqueueIfNoConflict(false, "syn2020", []string{"liveattrs"}, func() error {
+				// enqueuing may take some time (e.g. opening a database)
+				time.Sleep(time.Millisecond)
+				fn := func(updateJobChan chan<- GeneralJobInfo) {}
+				a.jobQueueLock.Lock()
+				defer a.jobQueueLock.Unlock()
+				a.jobQueue.Enqueue(
+					&fn, DummyJobInfo{ID: fmt.Sprintf("job%d", i), Type: "liveattrs", CorpusID: "syn2020"})
+				return nil
+			})
+			if err != nil {
+				numConflicts.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(9), numConflicts.Load())
+	assert.Len(t, a.jobQueue.InitialStates(), 1)
+}
+
+func TestWriteJobConflictResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/dictionary/syn2020/ngrams", nil)
+	WriteJobConflictResponse(ctx, ConflictingJobError{DatasetID: "syn2020", JobID: "running"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp JobConflictResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "running", resp.JobID)
+}
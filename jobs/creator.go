@@ -0,0 +1,221 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// JobRequest contains arguments of a job-creating action. It allows
+// a job to be created the same way no matter whether it is requested
+// via the HTTP API, a job template or the `runjob` command.
+type JobRequest struct {
+	CorpusID string
+	Query    url.Values
+	Args     []byte
+}
+
+// DecodeArgs decodes JSON job arguments into v. Missing arguments
+// are not considered an error.
+func (req JobRequest) DecodeArgs(v any) error {
+	if len(bytes.TrimSpace(req.Args)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(req.Args, v)
+}
+
+// IntArg returns an integer URL argument or dflt in case
+// the argument is not present
+func (req JobRequest) IntArg(name string, dflt int) (int, error) {
+	v := req.Query.Get(name)
+	if v == "" {
+		return dflt, nil
+	}
+	ans, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value of %s: %s", name, v)
+	}
+	return ans, nil
+}
+
+// NewJobRequest creates a JobRequest out of an HTTP request
+// of a job-creating action
+func NewJobRequest(ctx *gin.Context) (JobRequest, error) {
+	args, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return JobRequest{}, err
+	}
+	return JobRequest{
+		CorpusID: ctx.Param("corpusId"),
+		Query:    ctx.Request.URL.Query(),
+		Args:     args,
+	}, nil
+}
+
+// CreatedJob is a result of a job-creating action
+type CreatedJob struct {
+
+	// JobID is an ID of the created job (or of the last job
+	// in case a chain of jobs has been created). An empty value
+	// means no job has been created (e.g. in case of a dry run).
+	JobID string
+
+	// Body is a response body of the action
+	Body any
+}
+
+// JobCreator creates and enqueues a job based on the request.
+// To provide a proper HTTP status, errors should be created
+// via NewJobRequestError (or be a ConflictingJobError).
+type JobCreator func(ctx context.Context, req JobRequest) (CreatedJob, error)
+
+// JobRequestError is an error of a job-creating action
+// along with a respective HTTP status
+type JobRequestError struct {
+	Status int
+	Err    error
+}
+
+func (err JobRequestError) Error() string {
+	return err.Err.Error()
+}
+
+func (err JobRequestError) Unwrap() error {
+	return err.Err
+}
+
+// NewJobRequestError creates an error of a job-creating action
+func NewJobRequestError(err error, status int) error {
+	return JobRequestError{Status: status, Err: err}
+}
+
+// ConflictingJobError reports an unfinished job which would
+// overlap with a requested one (see ConflictingJob)
+type ConflictingJobError struct {
+	DatasetID string
+	JobID     string
+}
+
+func (err ConflictingJobError) Error() string {
+	return fmt.Sprintf(
+		"the previous job %s for %s not finished yet (use force=1 to run a new job anyway)",
+		err.JobID, err.DatasetID,
+	)
+}
+
+// JobRequestErrorStatus returns an HTTP status suitable
+// for an error returned by a JobCreator
+func JobRequestErrorStatus(err error) int {
+	var reqErr JobRequestError
+	var confErr ConflictingJobError
+	if errors.As(err, &confErr) {
+		return http.StatusConflict
+
+	} else if errors.As(err, &reqErr) {
+		return reqErr.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// WriteCreatedJobResponse writes a response of a job-creating action
+// based on the JobCreator result
+func WriteCreatedJobResponse(ctx *gin.Context, job CreatedJob, err error) {
+	var confErr ConflictingJobError
+	if errors.As(err, &confErr) {
+		WriteJobConflictResponse(ctx, confErr)
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionErrorFrom(err), JobRequestErrorStatus(err))
+
+	} else if job.JobID == "" {
+		uniresp.WriteJSONResponse(ctx.Writer, job.Body)
+
+	} else {
+		WriteJobAcceptedResponse(ctx, job.JobID, job.Body)
+	}
+}
+
+// jobCreator is a job creator registered via SetJobCreators
+type jobCreator struct {
+	create  JobCreator
+	pathTpl string
+}
+
+// SetJobCreators configures job creators usable by job templates
+// and the `runjob` command. The jobPaths maps job names to URL path
+// templates of the respective job-creating actions (with a single `%s`
+// placeholder for a corpus ID) so HTTP requests of the actions can be
+// recorded (see RecordJobRequests). Only jobs present in both maps
+// are available.
+func (a *Actions) SetJobCreators(creators map[string]JobCreator, jobPaths map[string]string) {
+	a.jobCreators = make(map[string]jobCreator)
+	for name, create := range creators {
+		if pathTpl, ok := jobPaths[name]; ok {
+			a.jobCreators[name] = jobCreator{create: create, pathTpl: pathTpl}
+		}
+	}
+}
+
+// JobCreatorNames returns sorted names of jobs configured via SetJobCreators
+func (a *Actions) JobCreatorNames() []string {
+	return slices.Sorted(maps.Keys(a.jobCreators))
+}
+
+// CreateJob creates a job of the specified name (see SetJobCreators)
+// using the same function as the respective job-creating action does.
+// The request of the created job is recorded so the job can be later
+// saved as a template.
+func (a *Actions) CreateJob(ctx context.Context, name string, req JobRequest) (CreatedJob, error) {
+	creator, ok := a.jobCreators[name]
+	if !ok {
+		return CreatedJob{}, NewJobRequestError(
+			fmt.Errorf("unknown job %s (available: %s)", name, strings.Join(a.JobCreatorNames(), ", ")),
+			http.StatusBadRequest,
+		)
+	}
+	job, err := creator.create(ctx, req)
+	if err != nil {
+		return job, err
+	}
+	if job.JobID != "" {
+		tpl := JobTemplate{
+			Job:      name,
+			CorpusID: req.CorpusID,
+			Query:    req.Query.Encode(),
+		}
+		if len(bytes.TrimSpace(req.Args)) > 0 {
+			tpl.Args = req.Args
+		}
+		a.jobRequests.add(job.JobID, tpl)
+	}
+	return job, nil
+}
@@ -36,19 +36,35 @@ type depInfo struct {
 
 type JobsDeps map[string][]*depInfo
 
-func (jd JobsDeps) Add(jobID string, parentID string) error {
-	if _, ok := jd[jobID]; !ok {
-		jd[jobID] = make([]*depInfo, 0, 10)
+// Add adds one or more parents the job depends on. The job must wait
+// until all of its parents finish. In case any of the parents cannot be
+// added (duplicate, circular dependency), none of them is added.
+func (jd JobsDeps) Add(jobID string, parentIDs ...string) error {
+	if len(parentIDs) == 0 {
+		return nil
 	}
-	for _, parent := range jd[jobID] {
-		if parent.jobID == parentID {
-			return ErrorDuplicateDependency
+	curr, ok := jd[jobID]
+	if !ok {
+		curr = make([]*depInfo, 0, 10)
+	}
+	origLen := len(curr)
+	for _, parentID := range parentIDs {
+		for _, parent := range curr {
+			if parent.jobID == parentID {
+				return ErrorDuplicateDependency
+			}
 		}
+		curr = append(curr, &depInfo{time.Now(), parentID, false, false})
 	}
-	jd[jobID] = append(jd[jobID], &depInfo{time.Now(), parentID, false, false})
+	jd[jobID] = curr
 	hasCircle := jd.findCircle(jobID)
 	if hasCircle {
-		jd[jobID] = jd[jobID][:len(jd[jobID])-1]
+		if origLen == 0 {
+			delete(jd, jobID)
+
+		} else {
+			jd[jobID] = curr[:origLen]
+		}
 		return ErrorCircularJobDependency
 	}
 	return nil
@@ -63,17 +79,24 @@ func (jd JobsDeps) getParentIDs(jobID string) []string {
 	return ans
 }
 
+// findCircle tests whether jobID is reachable from its own parents.
+// Note that a job reachable via multiple paths (e.g. two parents sharing
+// an ancestor) does not form a circle.
 func (jd JobsDeps) findCircle(jobID string) bool {
 	visited := make(map[string]bool)
-	queue := []string{jobID}
+	stack := jd.getParentIDs(jobID)
 
-	for len(queue) > 0 {
-		curr := queue[0]
-		if _, ok := visited[curr]; ok {
+	for len(stack) > 0 {
+		curr := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if curr == jobID {
 			return true
 		}
+		if visited[curr] {
+			continue
+		}
 		visited[curr] = true
-		queue = append(queue[1:], jd.getParentIDs(curr)...)
+		stack = append(stack, jd.getParentIDs(curr)...)
 	}
 	return false
 }
@@ -102,3 +102,65 @@ func TestCannotRepeatParent(t *testing.T) {
 	assert.Equal(t, ErrorDuplicateDependency, err)
 	assert.Equal(t, 2, len(deps["item1"]))
 }
+
+func TestAddMultipleParents(t *testing.T) {
+	deps := make(JobsDeps)
+	err := deps.Add("merge", "parentA", "parentB", "parentC")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"parentA", "parentB", "parentC"}, deps.getParentIDs("merge"))
+}
+
+func TestMultipleParentsPartialCompletion(t *testing.T) {
+	deps := make(JobsDeps)
+	err := deps.Add("merge", "parentA", "parentB", "parentC")
+	assert.NoError(t, err)
+	deps.SetParentFinished("parentA", false)
+	deps.SetParentFinished("parentC", false)
+	ans, err := deps.MustWait("merge")
+	assert.NoError(t, err)
+	assert.True(t, ans)
+
+	deps.SetParentFinished("parentB", false)
+	ans, err = deps.MustWait("merge")
+	assert.NoError(t, err)
+	assert.False(t, ans)
+	ans, err = deps.HasFailedParent("merge")
+	assert.NoError(t, err)
+	assert.False(t, ans)
+}
+
+func TestMultipleParentsOneFailed(t *testing.T) {
+	deps := make(JobsDeps)
+	err := deps.Add("merge", "parentA", "parentB", "parentC")
+	assert.NoError(t, err)
+	deps.SetParentFinished("parentA", false)
+	deps.SetParentFinished("parentB", true)
+	ans, err := deps.MustWait("merge")
+	assert.NoError(t, err)
+	assert.False(t, ans)
+	ans, err = deps.HasFailedParent("merge")
+	assert.NoError(t, err)
+	assert.True(t, ans)
+}
+
+func TestAddMultipleParentsIsAtomic(t *testing.T) {
+	deps := make(JobsDeps)
+	err := deps.Add("merge", "parentA")
+	assert.NoError(t, err)
+	err = deps.Add("merge", "parentB", "parentA")
+	assert.Equal(t, ErrorDuplicateDependency, err)
+	err = deps.Add("merge", "parentB", "merge")
+	assert.Equal(t, ErrorCircularJobDependency, err)
+	assert.Equal(t, []string{"parentA"}, deps.getParentIDs("merge"))
+}
+
+func TestDiamondIsNotCircle(t *testing.T) {
+	deps := make(JobsDeps)
+	assert.NoError(t, deps.Add("parentA", "root"))
+	assert.NoError(t, deps.Add("parentB", "root"))
+	assert.NoError(t, deps.Add("merge", "parentA", "parentB"))
+	assert.Equal(t, []string{"parentA", "parentB"}, deps.getParentIDs("merge"))
+	ans, err := deps.MustWait("merge")
+	assert.NoError(t, err)
+	assert.True(t, ans)
+}
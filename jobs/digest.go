@@ -0,0 +1,230 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"fmt"
+	"frodo/mail"
+	"sync"
+	"time"
+
+	cncmail "github.com/czcorpus/cnc-gokit/mail"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/message"
+)
+
+// notificationDigest buffers finished jobs notifications per recipient
+// so multiple jobs finished within a time window are reported via
+// a single email.
+type notificationDigest struct {
+	lock    sync.Mutex
+	pending map[string][]GeneralJobInfo
+	langs   map[string]string
+	timers  map[string]*time.Timer
+}
+
+// add buffers a finished job for a recipient. The onFirst
+// function is called in case the recipient has no pending jobs
+// (i.e. a new window starts). The most recent lang of a recipient
+// is used for the whole digest.
+func (nd *notificationDigest) add(recipient, lang string, job GeneralJobInfo, onFirst func() *time.Timer) {
+	nd.lock.Lock()
+	defer nd.lock.Unlock()
+	nd.pending[recipient] = append(nd.pending[recipient], job)
+	nd.langs[recipient] = lang
+	if _, ok := nd.timers[recipient]; !ok {
+		nd.timers[recipient] = onFirst()
+	}
+}
+
+// take removes and returns all the pending jobs of a recipient
+// along with the recipient's notification language
+func (nd *notificationDigest) take(recipient string) ([]GeneralJobInfo, string) {
+	nd.lock.Lock()
+	defer nd.lock.Unlock()
+	ans := nd.pending[recipient]
+	lang := nd.langs[recipient]
+	delete(nd.pending, recipient)
+	delete(nd.langs, recipient)
+	if timer, ok := nd.timers[recipient]; ok {
+		timer.Stop()
+		delete(nd.timers, recipient)
+	}
+	return ans, lang
+}
+
+func (nd *notificationDigest) recipients() []string {
+	nd.lock.Lock()
+	defer nd.lock.Unlock()
+	ans := make([]string, 0, len(nd.pending))
+	for k := range nd.pending {
+		ans = append(ans, k)
+	}
+	return ans
+}
+
+func newNotificationDigest() *notificationDigest {
+	return &notificationDigest{
+		pending: make(map[string][]GeneralJobInfo),
+		langs:   make(map[string]string),
+		timers:  make(map[string]*time.Timer),
+	}
+}
+
+// msgPrinterFor returns a message printer for the provided language.
+// The service default printer is used for the service language.
+func (a *Actions) msgPrinterFor(lang string) *message.Printer {
+	if lang == "" || lang == a.lang {
+		return a.msgPrinter
+	}
+	return message.NewPrinter(message.MatchLanguage(lang))
+}
+
+func (a *Actions) mailSignature(lang string) string {
+	if a.conf.EmailNotification.HasSignature() {
+		sign, err := a.conf.EmailNotification.LocalizedSignature(lang)
+		if err != nil {
+			log.Error().Err(err).Send()
+		}
+		return sign
+	}
+	return a.conf.EmailNotification.DefaultSignature(lang)
+}
+
+// notifyJobFinished sends (or buffers in case the digest
+// is enabled) notifications about a finished job
+func (a *Actions) notifyJobFinished(recipients []notificationRecipient, job GeneralJobInfo) {
+	window := time.Duration(a.conf.NotificationDigestWindowSecs) * time.Second
+	if window <= 0 {
+		for lang, addresses := range recipientsByLang(recipients, a.lang) {
+			a.sendJobNotification(lang, addresses, job)
+		}
+		return
+	}
+	immediate := make([]notificationRecipient, 0, len(recipients))
+	for _, recipient := range recipients {
+		if job.GetError() != nil && a.conf.ImmediateFailureNotification {
+			immediate = append(immediate, recipient)
+			continue
+		}
+		lang := recipient.Lang
+		if lang == "" {
+			lang = a.lang
+		}
+		address := recipient.Address
+		a.notificationDigest.add(address, lang, job, func() *time.Timer {
+			return time.AfterFunc(window, func() { a.flushNotificationDigest(address) })
+		})
+	}
+	for lang, addresses := range recipientsByLang(immediate, a.lang) {
+		a.sendJobNotification(lang, addresses, job)
+	}
+}
+
+// flushNotificationDigest sends all the pending notifications
+// of a recipient
+func (a *Actions) flushNotificationDigest(recipient string) {
+	jobs, lang := a.notificationDigest.take(recipient)
+	switch len(jobs) {
+	case 0:
+		return
+	case 1:
+		a.sendJobNotification(lang, []string{recipient}, jobs[0])
+	default:
+		a.sendDigestNotification(lang, recipient, jobs)
+	}
+}
+
+// flushAllNotificationDigests sends all the pending notifications
+// (e.g. on service shutdown)
+func (a *Actions) flushAllNotificationDigests() {
+	for _, recipient := range a.notificationDigest.recipients() {
+		a.flushNotificationDigest(recipient)
+	}
+}
+
+func (a *Actions) sendJobNotification(lang string, recipients []string, job GeneralJobInfo) {
+	printer := a.msgPrinterFor(lang)
+	jdesc := extractJobDescription(printer, job)
+	subject := printer.Sprintf("Job of type \"%s\" finished", jdesc)
+	notificationConf := a.conf.EmailNotification.WithRecipients(recipients...)
+	err := cncmail.SendNotification(
+		&notificationConf,
+		time.Now().Location(),
+		cncmail.Notification{
+			Subject: subject,
+			Paragraphs: mail.RenderParagraphs(
+				a.notificationBodyTemplate(lang, printer),
+				map[string]string{
+					mail.PlaceholderSubject:   subject,
+					mail.PlaceholderJobType:   jdesc,
+					mail.PlaceholderJobID:     job.GetID(),
+					mail.PlaceholderStatus:    localizedStatus(printer, job),
+					mail.PlaceholderLink:      a.jobLink(job.GetID()),
+					mail.PlaceholderSignature: a.mailSignature(lang),
+				},
+			),
+		},
+	)
+	if err != nil {
+		log.Error().Err(err).
+			Str("mailSubject", subject).
+			Strs("mailBody", []string{subject, jdesc}).
+			Msg("Failed to send finished job notification")
+	}
+}
+
+// digestParagraphs creates a notification body listing
+// all the provided jobs (one paragraph per job)
+func (a *Actions) digestParagraphs(lang, subject string, jobs []GeneralJobInfo) []string {
+	printer := a.msgPrinterFor(lang)
+	ans := make([]string, 0, len(jobs)+4)
+	ans = append(ans, subject, "")
+	for _, job := range jobs {
+		item := fmt.Sprintf(
+			"%s (%s): %s",
+			extractJobDescription(printer, job),
+			job.GetID(),
+			localizedStatus(printer, job),
+		)
+		if link := a.jobLink(job.GetID()); link != "" {
+			item += " " + link
+		}
+		ans = append(ans, item)
+	}
+	ans = append(ans, "", a.mailSignature(lang))
+	return ans
+}
+
+func (a *Actions) sendDigestNotification(lang, recipient string, jobs []GeneralJobInfo) {
+	subject := a.msgPrinterFor(lang).Sprintf("%d jobs finished", len(jobs))
+	notificationConf := a.conf.EmailNotification.WithRecipients(recipient)
+	err := cncmail.SendNotification(
+		&notificationConf,
+		time.Now().Location(),
+		cncmail.Notification{
+			Subject:    subject,
+			Paragraphs: a.digestParagraphs(lang, subject, jobs),
+		},
+	)
+	if err != nil {
+		log.Error().Err(err).
+			Str("mailSubject", subject).
+			Int("numJobs", len(jobs)).
+			Msg("Failed to send finished jobs digest notification")
+	}
+}
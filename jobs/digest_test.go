@@ -0,0 +1,49 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationDigestCoalescesJobs(t *testing.T) {
+	nd := newNotificationDigest()
+	numTimers := 0
+	newTimer := func() *time.Timer {
+		numTimers++
+		return time.NewTimer(time.Hour)
+	}
+	nd.add("user1@example.com", "en", DummyJobInfo{ID: "1"}, newTimer)
+	nd.add("user1@example.com", "cs", DummyJobInfo{ID: "2"}, newTimer)
+	nd.add("user2@example.com", "en", DummyJobInfo{ID: "2"}, newTimer)
+	assert.Equal(t, 2, numTimers)
+	assert.ElementsMatch(t, []string{"user1@example.com", "user2@example.com"}, nd.recipients())
+
+	jobs, lang := nd.take("user1@example.com")
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, "1", jobs[0].GetID())
+	assert.Equal(t, "cs", lang)
+	jobs, _ = nd.take("user1@example.com")
+	assert.Empty(t, jobs)
+
+	// a new window starts after the previous one is flushed
+	nd.add("user1@example.com", "en", DummyJobInfo{ID: "3"}, newTimer)
+	assert.Equal(t, 3, numTimers)
+}
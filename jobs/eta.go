@@ -0,0 +1,133 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// jobDurations collects durations of successfully
+// finished jobs per job type
+type jobDurations struct {
+	lock   sync.Mutex
+	sums   map[string]float64
+	counts map[string]int
+}
+
+func (jd *jobDurations) add(jobType string, dur time.Duration) {
+	jd.lock.Lock()
+	defer jd.lock.Unlock()
+	jd.sums[jobType] += dur.Seconds()
+	jd.counts[jobType]++
+}
+
+// avgSecs returns average duration of a job type in seconds. In case
+// there is no data for the type, false is returned.
+func (jd *jobDurations) avgSecs(jobType string) (float64, bool) {
+	jd.lock.Lock()
+	defer jd.lock.Unlock()
+	cnt := jd.counts[jobType]
+	if cnt == 0 {
+		return 0, false
+	}
+	return jd.sums[jobType] / float64(cnt), true
+}
+
+func newJobDurations() *jobDurations {
+	return &jobDurations{
+		sums:   make(map[string]float64),
+		counts: make(map[string]int),
+	}
+}
+
+// QueuedJobInfo describes a job waiting in the queue
+type QueuedJobInfo struct {
+	JobInfo any `json:"jobInfo"`
+
+	// QueuePosition is a zero-based position in the queue
+	QueuePosition int `json:"queuePosition"`
+
+	// ETASecs is a rough estimation of time (in seconds) the job
+	// will be finished in. It is based on average durations of
+	// the running and queued jobs.
+	ETASecs *float64 `json:"etaSecs,omitempty"`
+}
+
+// findQueuedJob searches for a job in the queue and returns its
+// initial state along with the initial states of the jobs ahead
+func (a *Actions) findQueuedJob(jobID string) (GeneralJobInfo, []GeneralJobInfo, bool) {
+	a.jobQueueLock.Lock()
+	defer a.jobQueueLock.Unlock()
+	pos, err := a.jobQueue.PositionOf(jobID)
+	if err != nil {
+		return nil, nil, false
+	}
+	items := a.jobQueue.InitialStates()
+	return items[pos], items[:pos], true
+}
+
+// estimateETA estimates (in seconds) when a queued job finishes.
+// The estimation is rough - it expects the running and queued jobs
+// to be processed evenly by all the available job slots. Jobs with
+// unknown average duration are ignored. In case the job type has no
+// history, false is returned.
+func (a *Actions) estimateETA(job GeneralJobInfo, ahead []GeneralJobInfo) (float64, bool) {
+	ownSecs, ok := a.jobDurations.avgSecs(job.GetType())
+	if !ok {
+		return 0, false
+	}
+	var waitSecs float64
+	func() {
+		a.jobListLock.RLock()
+		defer a.jobListLock.RUnlock()
+		for _, v := range a.jobList {
+			if v.IsFinished() {
+				continue
+			}
+			if avg, ok := a.jobDurations.avgSecs(v.GetType()); ok {
+				waitSecs += max(avg-time.Since(time.Time(v.GetStartDT())).Seconds(), 0)
+			}
+		}
+	}()
+	for _, v := range ahead {
+		if avg, ok := a.jobDurations.avgSecs(v.GetType()); ok {
+			waitSecs += avg
+		}
+	}
+	return waitSecs/float64(max(a.conf.MaxNumConcurrentJobs, 1)) + ownSecs, true
+}
+
+// queuedJobInfo creates info about a queued job
+func (a *Actions) queuedJobInfo(job GeneralJobInfo, ahead []GeneralJobInfo, compact bool) any {
+	var eta *float64
+	if v, ok := a.estimateETA(job, ahead); ok {
+		eta = &v
+	}
+	if compact {
+		ans := compactVersion(job)
+		pos := len(ahead)
+		ans.QueuePosition = &pos
+		ans.ETASecs = eta
+		return ans
+	}
+	return QueuedJobInfo{
+		JobInfo:       job.FullInfo(),
+		QueuePosition: len(ahead),
+		ETASecs:       eta,
+	}
+}
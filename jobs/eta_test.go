@@ -0,0 +1,56 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateETA(t *testing.T) {
+	a := &Actions{
+		conf:         &Conf{MaxNumConcurrentJobs: 2},
+		jobList:      make(map[string]GeneralJobInfo),
+		jobQueue:     &JobQueue{},
+		jobDurations: newJobDurations(),
+	}
+	a.jobDurations.add("fast", 10*time.Second)
+	a.jobDurations.add("fast", 30*time.Second)
+	a.jobDurations.add("slow", 100*time.Second)
+	f := func(chan<- GeneralJobInfo) {}
+	a.jobQueue.Enqueue(&f, &DummyJobInfo{ID: "1", Type: "slow"})
+	a.jobQueue.Enqueue(&f, &DummyJobInfo{ID: "2", Type: "unknown"})
+	a.jobQueue.Enqueue(&f, &DummyJobInfo{ID: "3", Type: "fast"})
+
+	job, ahead, ok := a.findQueuedJob("3")
+	assert.True(t, ok)
+	assert.Len(t, ahead, 2)
+	eta, ok := a.estimateETA(job, ahead)
+	assert.True(t, ok)
+	// (100 + 0) / 2 + 20
+	assert.InDelta(t, 70.0, eta, 0.001)
+
+	job, ahead, ok = a.findQueuedJob("2")
+	assert.True(t, ok)
+	_, ok = a.estimateETA(job, ahead)
+	assert.False(t, ok)
+
+	_, _, ok = a.findQueuedJob("4")
+	assert.False(t, ok)
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	jobEventsBufferSize = 20
+)
+
+// jobEventsHeartbeatInterval specifies how often an SSE comment
+// is sent to keep an otherwise idle stream (and proxies) alive
+var jobEventsHeartbeatInterval = 15 * time.Second
+
+// subscribeJob registers a new subscriber of job status changes.
+// The channel is closed once the job finishes. The returned function
+// must be called once the subscriber is not interested in the updates
+// anymore.
+func (a *Actions) subscribeJob(jobID string) (<-chan JobInfoCompact, func()) {
+	ch := make(chan JobInfoCompact, jobEventsBufferSize)
+	a.jobSubscribersLock.Lock()
+	a.jobSubscribers[jobID] = append(a.jobSubscribers[jobID], ch)
+	a.jobSubscribersLock.Unlock()
+	return ch, func() {
+		a.jobSubscribersLock.Lock()
+		defer a.jobSubscribersLock.Unlock()
+		subs := a.jobSubscribers[jobID]
+		idx := slices.Index(subs, ch)
+		if idx < 0 {
+			return // already closed by publishJobStatus
+		}
+		a.jobSubscribers[jobID] = slices.Delete(subs, idx, idx+1)
+		if len(a.jobSubscribers[jobID]) == 0 {
+			delete(a.jobSubscribers, jobID)
+		}
+		close(ch)
+	}
+}
+
+// publishJobStatus sends the current job status to all the job subscribers.
+// Slow subscribers may miss some updates but they always get the final one
+// as the channels are closed once the job finishes.
+func (a *Actions) publishJobStatus(jobID string, finished bool) {
+	a.jobSubscribersLock.Lock()
+	defer a.jobSubscribersLock.Unlock()
+	subs, ok := a.jobSubscribers[jobID]
+	if !ok {
+		return
+	}
+	job := func() GeneralJobInfo {
+		a.jobListLock.RLock()
+		defer a.jobListLock.RUnlock()
+		return a.jobList[jobID]
+	}()
+	for _, ch := range subs {
+		if job != nil {
+			info := compactVersion(job)
+			select {
+			case ch <- info:
+			default:
+				if finished {
+					// make room for the final status
+					select {
+					case <-ch:
+					default:
+					}
+					select {
+					case ch <- info:
+					default:
+					}
+
+				} else {
+					log.Warn().Str("jobId", jobID).Msg("job events subscriber is too slow, skipping update")
+				}
+			}
+		}
+		if finished {
+			close(ch)
+		}
+	}
+	if finished {
+		delete(a.jobSubscribers, jobID)
+	}
+}
+
+// JobEvents godoc
+// @Summary      Stream job status changes
+// @Description  Server-Sent Events stream providing the compact job info on each job status change. The stream is closed once the job finishes.
+// @Produce      text/event-stream
+// @Param        jobId path string true "Job ID"
+// @Success      200 {object} JobInfoCompact
+// @Failure      404 {object} uniresp.ActionError
+// @Router       /jobs/{jobId}/events [get]
+func (a *Actions) JobEvents(ctx *gin.Context) {
+	job := func() GeneralJobInfo {
+		a.jobListLock.RLock()
+		defer a.jobListLock.RUnlock()
+		return FindJob(a.jobList, ctx.Param("jobId"))
+	}()
+	if job == nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError("job not found"), http.StatusNotFound)
+		return
+	}
+	// we subscribe first to make sure we won't miss the job finish
+	updates, unsubscribe := a.subscribeJob(job.GetID())
+	defer unsubscribe()
+	job = func() GeneralJobInfo {
+		a.jobListLock.RLock()
+		defer a.jobListLock.RUnlock()
+		return a.jobList[job.GetID()]
+	}()
+	if job == nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError("job not found"), http.StatusNotFound)
+		return
+	}
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("X-Accel-Buffering", "no")
+	// the stream may last much longer than the server's WriteTimeout
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn().Err(err).Msg("failed to clear write deadline for job events stream")
+	}
+	ctx.SSEvent("status", compactVersion(job))
+	ctx.Writer.Flush()
+	if job.IsFinished() {
+		return
+	}
+	heartbeat := time.NewTicker(jobEventsHeartbeatInterval)
+	defer heartbeat.Stop()
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case info, ok := <-updates:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent("status", info)
+			return !info.Finished
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case <-ctx.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishJobStatus(t *testing.T) {
+	a := &Actions{
+		jobList:        map[string]GeneralJobInfo{"1": DummyJobInfo{ID: "1"}},
+		jobSubscribers: make(map[string][]chan JobInfoCompact),
+	}
+	updates, unsubscribe := a.subscribeJob("1")
+	a.publishJobStatus("1", false)
+	a.jobList["1"] = DummyJobInfo{ID: "1", Finished: true}
+	a.publishJobStatus("1", true)
+	received := make([]JobInfoCompact, 0, 2)
+	for info := range updates {
+		received = append(received, info)
+	}
+	assert.Len(t, received, 2)
+	assert.False(t, received[0].Finished)
+	assert.True(t, received[1].Finished)
+	assert.Empty(t, a.jobSubscribers)
+	unsubscribe() // must not panic on a closed channel
+}
+
+func TestUnsubscribeJob(t *testing.T) {
+	a := &Actions{
+		jobList:        map[string]GeneralJobInfo{"1": DummyJobInfo{ID: "1"}},
+		jobSubscribers: make(map[string][]chan JobInfoCompact),
+	}
+	_, unsubscribe1 := a.subscribeJob("1")
+	updates2, unsubscribe2 := a.subscribeJob("1")
+	unsubscribe1()
+	assert.Len(t, a.jobSubscribers["1"], 1)
+	a.publishJobStatus("1", false)
+	assert.Len(t, updates2, 1)
+	unsubscribe2()
+	assert.Empty(t, a.jobSubscribers)
+}
+
+func TestJobEventsOutlivesWriteTimeout(t *testing.T) {
+	origHeartbeat := jobEventsHeartbeatInterval
+	jobEventsHeartbeatInterval = 100 * time.Millisecond
+	defer func() { jobEventsHeartbeatInterval = origHeartbeat }()
+
+	a := &Actions{
+		jobList:        map[string]GeneralJobInfo{"1": DummyJobInfo{ID: "1"}},
+		jobSubscribers: make(map[string][]chan JobInfoCompact),
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/jobs/:jobId/events", a.JobEvents)
+	srv := httptest.NewUnstartedServer(engine)
+	srv.Config.WriteTimeout = 300 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	go func() {
+		time.Sleep(700 * time.Millisecond)
+		a.jobListLock.Lock()
+		a.jobList["1"] = DummyJobInfo{ID: "1", Finished: true}
+		a.jobListLock.Unlock()
+		a.publishJobStatus("1", true)
+	}()
+
+	resp, err := http.Get(srv.URL + "/jobs/1/events")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), ": heartbeat")
+	assert.Contains(t, string(body), `"finished":true`)
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// PersistedError is a gob-friendly representation of a job error
+// used when storing finished jobs (see Conf.PersistFinishedJobs).
+// Please note that the type must be registered via gob.Register.
+type PersistedError struct {
+	Message   string
+	Cancelled bool
+}
+
+func (err PersistedError) Error() string {
+	return err.Message
+}
+
+// Is allows matching persisted errors with the original ones
+// (including ErrorJobCancelled) via errors.Is
+func (err PersistedError) Is(target error) bool {
+	if target == ErrorJobCancelled {
+		return err.Cancelled
+	}
+	return target != nil && target.Error() == err.Message
+}
+
+// persistableJob makes sure a finished job can be gob-encoded
+// by replacing its error (if any) with PersistedError
+func persistableJob(job GeneralJobInfo) GeneralJobInfo {
+	err := job.GetError()
+	if err == nil || !job.IsFinished() {
+		return job
+	}
+	if _, ok := err.(PersistedError); ok {
+		return job
+	}
+	return job.WithError(PersistedError{Message: err.Error(), Cancelled: IsCancelled(err)})
+}
+
+// jobHistory is a read-only storage of finished jobs loaded
+// from a previous run of the service or removed from the
+// job list by the regular cleanup. Jobs older than the retention
+// period are removed.
+type jobHistory struct {
+	lock      sync.RWMutex
+	items     map[string]GeneralJobInfo
+	retention time.Duration
+}
+
+// add stores finished jobs (unfinished ones are ignored)
+// and prunes expired items
+func (jh *jobHistory) add(jobs ...GeneralJobInfo) {
+	jh.lock.Lock()
+	defer jh.lock.Unlock()
+	for _, job := range jobs {
+		if job != nil && job.IsFinished() {
+			jh.items[job.GetID()] = persistableJob(job)
+		}
+	}
+	jh.prune()
+}
+
+func (jh *jobHistory) prune() {
+	if jh.retention <= 0 {
+		return
+	}
+	curr := CurrentDatetime()
+	for k, v := range jh.items {
+		if curr.Sub(v.GetStartDT()) > jh.retention {
+			delete(jh.items, k)
+		}
+	}
+}
+
+// list returns all the stored jobs sorted from the newest one
+func (jh *jobHistory) list() JobInfoList {
+	jh.lock.RLock()
+	defer jh.lock.RUnlock()
+	ans := make(JobInfoList, 0, len(jh.items))
+	for _, v := range jh.items {
+		ans = append(ans, v)
+	}
+	sort.Sort(sort.Reverse(ans))
+	return ans
+}
+
+func newJobHistory(retention time.Duration) *jobHistory {
+	return &jobHistory{
+		items:     make(map[string]GeneralJobInfo),
+		retention: retention,
+	}
+}
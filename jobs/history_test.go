@@ -0,0 +1,57 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistableJobGobRoundTrip(t *testing.T) {
+	gob.Register(&DummyJobInfo{})
+	gob.Register(PersistedError{})
+	job := DummyJobInfo{
+		ID:       "1",
+		Start:    CurrentDatetime(),
+		Finished: true,
+		Error:    fmt.Errorf("failed to process: %w", ErrorJobCancelled),
+	}
+	var buff bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buff).Encode(JobInfoList{persistableJob(job)}))
+	var loaded JobInfoList
+	assert.NoError(t, gob.NewDecoder(&buff).Decode(&loaded))
+	assert.Len(t, loaded, 1)
+	assert.Equal(t, "failed to process: job cancelled", loaded[0].GetError().Error())
+	assert.True(t, compactVersion(loaded[0]).Cancelled)
+}
+
+func TestJobHistoryRetention(t *testing.T) {
+	history := newJobHistory(24 * time.Hour)
+	history.add(
+		DummyJobInfo{ID: "recent", Start: CurrentDatetime(), Finished: true},
+		DummyJobInfo{ID: "old", Start: JSONTime(time.Now().Add(-48 * time.Hour)), Finished: true},
+		DummyJobInfo{ID: "running", Start: CurrentDatetime()},
+	)
+	items := history.list()
+	assert.Len(t, items, 1)
+	assert.Equal(t, "recent", items[0].GetID())
+}
@@ -27,6 +27,12 @@ func extractJobDescription(printer *message.Printer, info GeneralJobInfo) string
 		desc = printer.Sprintf("N-grams and query suggestion data generation")
 	case "liveattrs":
 		desc = printer.Sprintf("Live attributes data extraction and generation")
+	case "liveattrs-update":
+		desc = printer.Sprintf("Live attributes data incremental update")
+	case "liveattrs-integrity":
+		desc = printer.Sprintf("Live attributes data integrity validation")
+	case "liveattrs-reindex":
+		desc = printer.Sprintf("Live attributes tables reindexing")
 	case "dummy-job":
 		desc = printer.Sprintf("Testing and debugging empty job")
 	default:
@@ -39,5 +45,8 @@ func localizedStatus(printer *message.Printer, info GeneralJobInfo) string {
 	if info.GetError() == nil {
 		return printer.Sprintf("Job finished without errors")
 	}
+	if IsCancelled(info.GetError()) {
+		return printer.Sprintf("Job cancelled")
+	}
 	return printer.Sprintf("Job finished with error: %s", info.GetError())
 }
@@ -0,0 +1,106 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrorJobCancelled is a final error of jobs cancelled
+	// on user request (see Actions.Delete)
+	ErrorJobCancelled = errors.New("job cancelled")
+)
+
+// QueuedFuncCtx is a context-aware variant of QueuedFunc.
+// The context is cancelled once a user asks for stopping the job
+// (or the service is shutting down and the job has not finished
+// within Conf.ShutdownDrainTimeoutSecs) so long-running jobs should
+// watch ctx.Done() and finish as soon as possible. In case a job stopped
+// on user request finishes with any error, the error is replaced
+// by ErrorJobCancelled. A job which manages to finish without an error
+// keeps its regular result. Jobs stopped due to the service shutdown
+// keep their original error.
+//
+// Existing QueuedFunc jobs keep working (they just cannot be stopped
+// this way). To adopt the context, a job function should be rewritten
+// to QueuedFuncCtx and wrapped via Actions.WithJobContext before
+// it is enqueued.
+type QueuedFuncCtx = func(ctx context.Context, updateJobChan chan<- GeneralJobInfo)
+
+// IsCancelled tests whether a job error means
+// the job has been cancelled
+func IsCancelled(err error) bool {
+	return errors.Is(err, ErrorJobCancelled)
+}
+
+type jobContext struct {
+	cancel          context.CancelFunc
+	cancelRequested bool
+}
+
+// WithJobContext creates a new context for a job and returns a QueuedFunc
+// passing the context to fn. The result can be used with any of the
+// EnqueueJob* methods. The context is released once the job finishes.
+func (a *Actions) WithJobContext(jobID string, fn QueuedFuncCtx) *QueuedFunc {
+	jctx, cancel := context.WithCancel(a.jobsContext())
+	a.jobContextsLock.Lock()
+	a.jobContexts[jobID] = &jobContext{cancel: cancel}
+	a.jobContextsLock.Unlock()
+	ans := func(updateJobChan chan<- GeneralJobInfo) {
+		fn(jctx, updateJobChan)
+	}
+	return &ans
+}
+
+// cancelJob cancels context of a job. It returns false in case
+// the job has no context (i.e. it does not support cancellation).
+func (a *Actions) cancelJob(jobID string) bool {
+	a.jobContextsLock.Lock()
+	defer a.jobContextsLock.Unlock()
+	jc, ok := a.jobContexts[jobID]
+	if !ok {
+		return false
+	}
+	jc.cancelRequested = true
+	jc.cancel()
+	return true
+}
+
+// releaseJobContext removes a context of a finished job and returns
+// true in case the job has been cancelled on user request.
+func (a *Actions) releaseJobContext(jobID string) bool {
+	a.jobContextsLock.Lock()
+	defer a.jobContextsLock.Unlock()
+	jc, ok := a.jobContexts[jobID]
+	if !ok {
+		return false
+	}
+	jc.cancel()
+	delete(a.jobContexts, jobID)
+	return jc.cancelRequested
+}
+
+// compactVersion returns a compact version of a job info
+// with the cancellation status and progress resolved
+func compactVersion(job GeneralJobInfo) JobInfoCompact {
+	ans := job.CompactVersion()
+	ans.Cancelled = IsCancelled(job.GetError())
+	ans.Progress = GetProgress(job)
+	return ans
+}
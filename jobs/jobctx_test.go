@@ -0,0 +1,112 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobContextCancellation(t *testing.T) {
+	a := &Actions{
+		ctx:         context.Background(),
+		jobContexts: make(map[string]*jobContext),
+	}
+	var jobErr error
+	fn := a.WithJobContext("1", func(ctx context.Context, updateJobChan chan<- GeneralJobInfo) {
+		<-ctx.Done()
+		jobErr = ctx.Err()
+	})
+	assert.True(t, a.cancelJob("1"))
+	(*fn)(make(chan GeneralJobInfo))
+	assert.ErrorIs(t, jobErr, context.Canceled)
+	assert.True(t, a.releaseJobContext("1"))
+	assert.False(t, a.cancelJob("1"))
+}
+
+func TestFinishCancelledJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := NewActions(&Conf{MaxNumConcurrentJobs: 2}, "en", ctx, make(chan string, 10))
+
+	// a job stopped with an error is marked as cancelled
+	startShutdownTestJob(t, a, "1", func(ctx context.Context, updateJobChan chan<- GeneralJobInfo) {
+		<-ctx.Done()
+		updateJobChan <- DummyJobInfo{ID: "1", Type: "dummy"}.WithError(ctx.Err())
+		close(updateJobChan)
+	})
+	// a job finishing regularly despite the request keeps its result
+	startShutdownTestJob(t, a, "2", func(ctx context.Context, updateJobChan chan<- GeneralJobInfo) {
+		<-ctx.Done()
+		updateJobChan <- DummyJobInfo{ID: "2", Type: "dummy", Result: &DummyJobResult{}}
+		close(updateJobChan)
+	})
+	assert.True(t, a.cancelJob("1"))
+	assert.True(t, a.cancelJob("2"))
+	isFinished := func(jobID string) func() bool {
+		return func() bool {
+			job, ok := a.GetJob(jobID)
+			return ok && job.IsFinished()
+		}
+	}
+	assert.Eventually(t, isFinished("1"), 5*time.Second, 20*time.Millisecond)
+	assert.Eventually(t, isFinished("2"), 5*time.Second, 20*time.Millisecond)
+
+	job, _ := a.GetJob("1")
+	assert.True(t, IsCancelled(job.GetError()))
+	job, _ = a.GetJob("2")
+	assert.NoError(t, job.GetError())
+	assert.False(t, a.cancelJob("1"))
+}
+
+func TestCompactVersionCancelled(t *testing.T) {
+	job := DummyJobInfo{ID: "1", Error: fmt.Errorf("failed to process: %w", ErrorJobCancelled)}
+	item := compactVersion(job)
+	assert.True(t, item.Cancelled)
+	assert.False(t, item.OK)
+	assert.False(t, compactVersion(DummyJobInfo{ID: "2"}).Cancelled)
+}
+
+func TestWithdrawJob(t *testing.T) {
+	a := &Actions{
+		ctx:         context.Background(),
+		conf:        &Conf{},
+		jobContexts: make(map[string]*jobContext),
+		jobQueue:    &JobQueue{},
+		jobDeps:     make(JobsDeps),
+	}
+	noop := func(ctx context.Context, updateJobChan chan<- GeneralJobInfo) {}
+	assert.NoError(t, a.EnqueueJob(a.WithJobContext("1", noop), DummyJobInfo{ID: "1"}))
+	assert.NoError(t, a.EqueueJobAfter(a.WithJobContext("2", noop), DummyJobInfo{ID: "2"}, "1"))
+
+	// a queued job is removed
+	assert.True(t, a.WithdrawJob("2"))
+	assert.Equal(t, 1, a.jobQueue.Size())
+	assert.NotContains(t, a.jobContexts, "2")
+
+	// a running job (i.e. not in the queue anymore) is cancelled
+	_, _, err := a.jobQueue.Dequeue()
+	assert.NoError(t, err)
+	assert.True(t, a.WithdrawJob("1"))
+	assert.True(t, a.jobContexts["1"].cancelRequested)
+
+	assert.False(t, a.WithdrawJob("3"))
+}
@@ -18,19 +18,148 @@ package jobs
 
 import (
 	"encoding/gob"
+	"errors"
 	"frodo/mail"
+	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	dfltFinishedJobRetention = 168 * time.Hour
+	dfltCleanupInterval      = time.Hour
+)
+
 type Conf struct {
 	StatusDataPath       string                 `json:"statusDataPath"`
 	MaxNumConcurrentJobs int                    `json:"maxNumConcurrentJobs"`
 	MaxNumRestarts       int                    `json:"maxNumRestarts"`
 	EmailNotification    mail.EmailNotification `json:"emailNotification"`
+
+	// TableUpdateStallTimeoutSecs specifies how long can a single job table
+	// update take before the update consumer is considered stalled.
+	// A negative value disables the watchdog.
+	TableUpdateStallTimeoutSecs int `json:"tableUpdateStallTimeoutSecs"`
+
+	// RestartStalledTableUpdate, if true, makes the watchdog start a new
+	// job table update consumer once the current one is considered stalled.
+	RestartStalledTableUpdate bool `json:"restartStalledTableUpdate"`
+
+	// ClearedJobsMemorySize specifies how many IDs of cleared (expired) jobs
+	// are remembered so a request for such a job can be answered with
+	// 410 Gone instead of 404. A negative value disables the feature.
+	ClearedJobsMemorySize int `json:"clearedJobsMemorySize"`
+
+	// ClearedJobsRetentionHours specifies how long are IDs of cleared jobs
+	// remembered. A negative value means "until evicted by newer cleared jobs".
+	ClearedJobsRetentionHours int `json:"clearedJobsRetentionHours"`
+
+	// TemplatesDataPath specifies a JSON file where job templates are stored.
+	// If empty, templates are kept in memory only (i.e. they are lost
+	// on service restart).
+	TemplatesDataPath string `json:"templatesDataPath"`
+
+	// WebhookTimeoutSecs is a timeout for calling job webhooks
+	WebhookTimeoutSecs int `json:"webhookTimeoutSecs"`
+
+	// PersistFinishedJobs, if true, makes the service store also finished
+	// jobs on exit. Such jobs (along with jobs removed by the regular
+	// cleanup) are available as a read-only history (see JobList).
+	PersistFinishedJobs bool `json:"persistFinishedJobs"`
+
+	// HistoryRetentionHours specifies how long are finished jobs kept
+	// in the history. A negative value means "forever".
+	HistoryRetentionHours int `json:"historyRetentionHours"`
+
+	// FinishedJobRetentionHours specifies how old finished jobs are removed
+	// from the job list by the regular cleanup (see FinishedJobRetention).
+	// Unfinished jobs are never removed by the cleanup.
+	FinishedJobRetentionHours int `json:"finishedJobRetentionHours"`
+
+	// CleanupIntervalSecs specifies how often the job list cleanup
+	// runs (see CleanupInterval)
+	CleanupIntervalSecs int `json:"cleanupIntervalSecs"`
+
+	// MaxJobDurationSecs specifies how long can a job run before it is
+	// stopped and marked as failed. Zero or negative value means no limit.
+	MaxJobDurationSecs int `json:"maxJobDurationSecs"`
+
+	// MaxJobDurationSecsByType allows for overriding MaxJobDurationSecs
+	// for specific job types (e.g. "ngram-generating"). Zero or negative
+	// value means no limit for the type.
+	MaxJobDurationSecsByType map[string]int `json:"maxJobDurationSecsByType"`
+
+	// NotificationDigestWindowSecs, if positive, makes the service coalesce
+	// finished job notifications for the same recipient within the time
+	// window into a single email.
+	NotificationDigestWindowSecs int `json:"notificationDigestWindowSecs"`
+
+	// ImmediateFailureNotification, if true, makes failed job notifications
+	// bypass the digest (see NotificationDigestWindowSecs)
+	ImmediateFailureNotification bool `json:"immediateFailureNotification"`
+
+	// MaxQueueLength specifies max. number of jobs waiting in the queue.
+	// Once reached, new jobs are rejected. Zero or negative value means
+	// no limit.
+	MaxQueueLength int `json:"maxQueueLength"`
+
+	// MetricsPath specifies a URL path where job metrics are exposed
+	// in the Prometheus format (e.g. "/jobs/metrics"). If empty, metrics
+	// are not exposed.
+	MetricsPath string `json:"metricsPath"`
+
+	// ShutdownDrainTimeoutSecs specifies how long the service waits
+	// for running jobs to finish on shutdown before it saves the job list.
+	// Jobs still running after the timeout are saved as unfinished
+	// (i.e. they become detached after restart). Zero or negative value
+	// means no waiting.
+	ShutdownDrainTimeoutSecs int `json:"shutdownDrainTimeoutSecs"`
+
+	// AutoResumeJobTypes lists job types (e.g. "liveattrs-reindex") whose
+	// unfinished jobs are enqueued again after the service restart instead
+	// of being kept as detached. This works only for types with a registered
+	// resumer (see Actions.RegisterJobResumer) and it should be used only
+	// for idempotent jobs.
+	AutoResumeJobTypes []string `json:"autoResumeJobTypes"`
+}
+
+// FinishedJobRetention returns the age of jobs removed by the regular
+// cleanup. For zero or negative configured value, the default
+// (one week) is used.
+func (conf *Conf) FinishedJobRetention() time.Duration {
+	if conf.FinishedJobRetentionHours <= 0 {
+		return dfltFinishedJobRetention
+	}
+	return time.Duration(conf.FinishedJobRetentionHours) * time.Hour
+}
+
+// CleanupInterval returns the interval of the regular job list
+// cleanup. For zero or negative configured value, the default
+// (one hour) is used.
+func (conf *Conf) CleanupInterval() time.Duration {
+	if conf.CleanupIntervalSecs <= 0 {
+		return dfltCleanupInterval
+	}
+	return time.Duration(conf.CleanupIntervalSecs) * time.Second
+}
+
+// ShutdownDrainTimeout returns how long the service waits for
+// running jobs on shutdown (see ShutdownDrainTimeoutSecs)
+func (conf *Conf) ShutdownDrainTimeout() time.Duration {
+	if conf.ShutdownDrainTimeoutSecs <= 0 {
+		return 0
+	}
+	return time.Duration(conf.ShutdownDrainTimeoutSecs) * time.Second
+}
+
+// AutoResumes tests whether unfinished jobs of the type
+// should be resumed after the service restart
+func (conf *Conf) AutoResumes(jobType string) bool {
+	return slices.Contains(conf.AutoResumeJobTypes, jobType)
 }
 
 // GeneralJobInfo defines a general job information
@@ -119,18 +248,57 @@ func (jil JobInfoList) Swap(i, j int) {
 	jil[i], jil[j] = jil[j], jil[i]
 }
 
-func clearOldJobs(data map[string]GeneralJobInfo) {
+// clearOldJobs removes finished jobs older than the retention from the provided map
+// and returns the removed jobs. Unfinished jobs are always kept (no matter how
+// long they run) as they still occupy a concurrency slot and report their progress.
+func clearOldJobs(data map[string]GeneralJobInfo, retention time.Duration) []GeneralJobInfo {
 	curr := CurrentDatetime()
-	numRemoved := 0
+	removed := make([]GeneralJobInfo, 0, 10)
 	for k, v := range data {
-		if curr.Sub(v.GetStartDT()) > time.Duration(168)*time.Hour {
+		if v.IsFinished() && curr.Sub(v.GetStartDT()) > retention {
 			delete(data, k)
-			numRemoved++
+			removed = append(removed, v)
 		}
 	}
-	if numRemoved > 0 {
-		log.Info().Msgf("removed %d old job(s)", numRemoved)
+	if len(removed) > 0 {
+		log.Info().Msgf("removed %d old job(s)", len(removed))
 	}
+	return removed
+}
+
+// jobListFilter specifies which jobs should be listed.
+// All the specified conditions must be met.
+type jobListFilter struct {
+	unfinishedOnly bool
+
+	// corpusID, if not empty, is compared with GetCorpus()
+	corpusID string
+
+	// jobType, if not empty, is compared with GetType()
+	jobType string
+}
+
+func (f jobListFilter) matches(job GeneralJobInfo) bool {
+	if f.unfinishedOnly && job.IsFinished() {
+		return false
+	}
+	if f.corpusID != "" && job.GetCorpus() != f.corpusID {
+		return false
+	}
+	if f.jobType != "" && job.GetType() != f.jobType {
+		return false
+	}
+	return true
+}
+
+func (f jobListFilter) apply(jobs JobInfoList) JobInfoList {
+	ans := make(JobInfoList, 0, len(jobs))
+	for _, job := range jobs {
+		if f.matches(job) {
+			ans = append(ans, job)
+		}
+	}
+	return ans
 }
 
 // FindJob searches a job by providing either full id or its prefix.
@@ -162,6 +330,27 @@ func ClearFinishedJob(syncJobs map[string]GeneralJobInfo, jobID string) (General
 	return nil, false
 }
 
+// EnqueueErrorStatus returns a proper HTTP status code
+// for an error returned when enqueuing a job
+func EnqueueErrorStatus(err error) int {
+	if errors.Is(err, ErrorQueueFull) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// ClearFinishedJobs removes all the finished jobs and returns their IDs
+func ClearFinishedJobs(syncJobs map[string]GeneralJobInfo) []string {
+	removed := make([]string, 0, len(syncJobs))
+	for jobID, job := range syncJobs {
+		if job.IsFinished() {
+			delete(syncJobs, jobID)
+			removed = append(removed, jobID)
+		}
+	}
+	return removed
+}
+
 // JobInfoCompact is a simplified and unified version of
 // any specific job information
 type JobInfoCompact struct {
@@ -173,6 +362,19 @@ type JobInfoCompact struct {
 	Update          JSONTime `json:"update"`
 	Finished        bool     `json:"finished"`
 	OK              bool     `json:"ok"`
+
+	// Cancelled is true for jobs stopped on user request
+	// (the OK is always false in such case)
+	Cancelled bool `json:"cancelled,omitempty"`
+
+	// Progress is a job progress in percents (see ProgressReporter)
+	Progress float64 `json:"progress"`
+
+	// QueuePosition is set only for jobs waiting in the queue
+	QueuePosition *int `json:"queuePosition,omitempty"`
+
+	// ETASecs is an estimated time to finish a queued job
+	ETASecs *float64 `json:"etaSecs,omitempty"`
 }
 
 // JobInfoListCompact represents a list of jobs for quick reviews
@@ -0,0 +1,135 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfRetentionDefaults(t *testing.T) {
+	var conf Conf
+	assert.Equal(t, 168*time.Hour, conf.FinishedJobRetention())
+	assert.Equal(t, time.Hour, conf.CleanupInterval())
+	conf.FinishedJobRetentionHours = 24
+	conf.CleanupIntervalSecs = 60
+	assert.Equal(t, 24*time.Hour, conf.FinishedJobRetention())
+	assert.Equal(t, time.Minute, conf.CleanupInterval())
+}
+
+func TestClearOldJobsRetention(t *testing.T) {
+	data := map[string]GeneralJobInfo{
+		"recent":     DummyJobInfo{ID: "recent", Start: CurrentDatetime(), Finished: true},
+		"old":        DummyJobInfo{ID: "old", Start: JSONTime(time.Now().Add(-3 * time.Hour)), Finished: true},
+		"oldRunning": DummyJobInfo{ID: "oldRunning", Start: JSONTime(time.Now().Add(-3 * time.Hour))},
+	}
+	removed := clearOldJobs(data, 2*time.Hour)
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "old", removed[0].GetID())
+	assert.Contains(t, data, "recent")
+	assert.Contains(t, data, "oldRunning")
+}
+
+func TestClearFinishedJobsKeepsUnfinished(t *testing.T) {
+	data := map[string]GeneralJobInfo{
+		"done":    DummyJobInfo{ID: "done", Finished: true},
+		"done2":   DummyJobInfo{ID: "done2", Finished: true},
+		"running": DummyJobInfo{ID: "running"},
+	}
+	removed := ClearFinishedJobs(data)
+	assert.ElementsMatch(t, []string{"done", "done2"}, removed)
+	assert.Len(t, data, 1)
+	assert.Contains(t, data, "running")
+}
+
+func filterTestJobs() JobInfoList {
+	return JobInfoList{
+		DummyJobInfo{ID: "1", CorpusID: "syn2020", Type: "liveattrs-create", Finished: true},
+		DummyJobInfo{ID: "2", CorpusID: "syn2020", Type: "ngram-generating"},
+		DummyJobInfo{ID: "3", CorpusID: "intercorp", Type: "liveattrs-create"},
+		DummyJobInfo{ID: "4", CorpusID: "intercorp", Type: "ngram-generating", Finished: true},
+	}
+}
+
+func filteredIDs(filter jobListFilter) []string {
+	ans := make([]string, 0, 4)
+	for _, job := range filter.apply(filterTestJobs()) {
+		ans = append(ans, job.GetID())
+	}
+	return ans
+}
+
+func TestJobListFilterEmpty(t *testing.T) {
+	assert.Equal(t, []string{"1", "2", "3", "4"}, filteredIDs(jobListFilter{}))
+}
+
+func TestJobListFilterCorpus(t *testing.T) {
+	assert.Equal(t, []string{"1", "2"}, filteredIDs(jobListFilter{corpusID: "syn2020"}))
+}
+
+func TestJobListFilterType(t *testing.T) {
+	assert.Equal(t, []string{"1", "3"}, filteredIDs(jobListFilter{jobType: "liveattrs-create"}))
+}
+
+func TestJobListFilterCorpusAndType(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"4"},
+		filteredIDs(jobListFilter{corpusID: "intercorp", jobType: "ngram-generating"}),
+	)
+}
+
+func TestJobListFilterUnfinishedAndCorpus(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"3"},
+		filteredIDs(jobListFilter{unfinishedOnly: true, corpusID: "intercorp"}),
+	)
+}
+
+func TestJobListFilterUnfinishedAndType(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"2"},
+		filteredIDs(jobListFilter{unfinishedOnly: true, jobType: "ngram-generating"}),
+	)
+}
+
+func TestJobListFilterAll(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"2"},
+		filteredIDs(jobListFilter{unfinishedOnly: true, corpusID: "syn2020", jobType: "ngram-generating"}),
+	)
+	assert.Empty(
+		t,
+		filteredIDs(jobListFilter{unfinishedOnly: true, corpusID: "syn2020", jobType: "liveattrs-create"}),
+	)
+}
+
+func TestPaginationBounds(t *testing.T) {
+	from, to := pagination{}.bounds(10)
+	assert.Equal(t, []int{0, 10}, []int{from, to})
+	from, to = pagination{offset: 3, limit: 4}.bounds(10)
+	assert.Equal(t, []int{3, 7}, []int{from, to})
+	from, to = pagination{offset: 8, limit: 4}.bounds(10)
+	assert.Equal(t, []int{8, 10}, []int{from, to})
+	from, to = pagination{offset: 20, limit: 4}.bounds(10)
+	assert.Equal(t, []int{10, 10}, []int{from, to})
+}
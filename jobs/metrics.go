@@ -0,0 +1,134 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+type durationStats struct {
+	sumSecs float64
+	count   int64
+}
+
+// Collector collects metrics of the job subsystem and exposes them
+// in the Prometheus text exposition format. Gauges (running jobs,
+// queue length) are evaluated on each scrape.
+type Collector struct {
+	lock         sync.Mutex
+	numStarted   int64
+	numFinished  int64
+	numFailed    int64
+	durations    map[string]*durationStats
+	numRunningFn func() int
+	queueLenFn   func() int
+}
+
+// jobStarted should be called by the scheduler once a job is dequeued
+func (c *Collector) jobStarted() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.numStarted++
+}
+
+// jobFinished should be called once a job finishes
+func (c *Collector) jobFinished(jobType string, dur time.Duration, failed bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.numFinished++
+	if failed {
+		c.numFailed++
+	}
+	stats, ok := c.durations[jobType]
+	if !ok {
+		stats = &durationStats{}
+		c.durations[jobType] = stats
+	}
+	stats.sumSecs += dur.Seconds()
+	stats.count++
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// WriteTo writes all the metrics in the Prometheus text format
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var buff strings.Builder
+	writeMetric := func(name, mtype, help string, value any) {
+		fmt.Fprintf(&buff, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, mtype, name, value)
+	}
+	writeMetric("frodo_jobs_running", "gauge", "Number of currently running jobs.", c.numRunningFn())
+	writeMetric("frodo_jobs_queue_length", "gauge", "Number of jobs waiting in the queue.", c.queueLenFn())
+	c.lock.Lock()
+	writeMetric("frodo_jobs_started_total", "counter", "Total number of started jobs.", c.numStarted)
+	writeMetric("frodo_jobs_finished_total", "counter", "Total number of finished jobs (including failed ones).", c.numFinished)
+	writeMetric("frodo_jobs_failed_total", "counter", "Total number of failed jobs.", c.numFailed)
+	buff.WriteString("# HELP frodo_jobs_duration_seconds Duration of finished jobs.\n")
+	buff.WriteString("# TYPE frodo_jobs_duration_seconds summary\n")
+	jobTypes := make([]string, 0, len(c.durations))
+	for k := range c.durations {
+		jobTypes = append(jobTypes, k)
+	}
+	slices.Sort(jobTypes)
+	for _, jobType := range jobTypes {
+		stats := c.durations[jobType]
+		label := escapeLabelValue(jobType)
+		fmt.Fprintf(&buff, "frodo_jobs_duration_seconds_sum{type=\"%s\"} %v\n", label, stats.sumSecs)
+		fmt.Fprintf(&buff, "frodo_jobs_duration_seconds_count{type=\"%s\"} %d\n", label, stats.count)
+	}
+	c.lock.Unlock()
+	n, err := io.WriteString(w, buff.String())
+	return int64(n), err
+}
+
+// Metrics godoc
+// @Summary      Job subsystem metrics
+// @Description  Metrics in the Prometheus text exposition format. The path of the action is configurable (jobs.metricsPath).
+// @Produce      plain
+// @Success      200 {string} string
+// @Router       /jobs/metrics [get]
+func (a *Actions) Metrics(ctx *gin.Context) {
+	ctx.Writer.Header().Set("Content-Type", prometheusContentType)
+	ctx.Writer.WriteHeader(http.StatusOK)
+	if _, err := a.metrics.WriteTo(ctx.Writer); err != nil {
+		log.Error().Err(err).Msg("failed to write job metrics")
+	}
+}
+
+// NewCollector creates a new metrics collector. The functions
+// are used to obtain the gauge values on each scrape.
+func NewCollector(numRunningFn, queueLenFn func() int) *Collector {
+	return &Collector{
+		durations:    make(map[string]*durationStats),
+		numRunningFn: numRunningFn,
+		queueLenFn:   queueLenFn,
+	}
+}
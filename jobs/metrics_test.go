@@ -0,0 +1,44 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorWriteTo(t *testing.T) {
+	c := NewCollector(func() int { return 2 }, func() int { return 5 })
+	c.jobStarted()
+	c.jobStarted()
+	c.jobFinished("ngram-generating", 10*time.Second, false)
+	c.jobFinished("ngram-generating", 20*time.Second, true)
+	var buff strings.Builder
+	_, err := c.WriteTo(&buff)
+	assert.NoError(t, err)
+	out := buff.String()
+	assert.Contains(t, out, "\nfrodo_jobs_running 2\n")
+	assert.Contains(t, out, "\nfrodo_jobs_queue_length 5\n")
+	assert.Contains(t, out, "\nfrodo_jobs_started_total 2\n")
+	assert.Contains(t, out, "\nfrodo_jobs_finished_total 2\n")
+	assert.Contains(t, out, "\nfrodo_jobs_failed_total 1\n")
+	assert.Contains(t, out, "frodo_jobs_duration_seconds_sum{type=\"ngram-generating\"} 30\n")
+	assert.Contains(t, out, "frodo_jobs_duration_seconds_count{type=\"ngram-generating\"} 2\n")
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+// notificationRecipient is an email address registered
+// for a notification on job finish
+type notificationRecipient struct {
+	Address string `json:"address"`
+
+	// OnlyOnError specifies that the recipient is notified only
+	// in case the job fails
+	OnlyOnError bool `json:"onlyOnError"`
+
+	// Lang is a language of the notification. If empty,
+	// the service default language is used.
+	Lang string `json:"lang,omitempty"`
+}
+
+// addRecipient adds a recipient to the list. In case the address is
+// already present, its options are updated.
+func addRecipient(recipients []notificationRecipient, recipient notificationRecipient) []notificationRecipient {
+	for i, r := range recipients {
+		if r.Address == recipient.Address {
+			recipients[i] = recipient
+			return recipients
+		}
+	}
+	return append(recipients, recipient)
+}
+
+// recipientAddresses returns plain addresses of the recipients
+func recipientAddresses(recipients []notificationRecipient) []string {
+	ans := make([]string, len(recipients))
+	for i, r := range recipients {
+		ans[i] = r.Address
+	}
+	return ans
+}
+
+// selectRecipients returns recipients which should be notified
+// about a finished job based on whether the job failed
+func selectRecipients(recipients []notificationRecipient, jobFailed bool) []notificationRecipient {
+	ans := make([]notificationRecipient, 0, len(recipients))
+	for _, r := range recipients {
+		if r.OnlyOnError && !jobFailed {
+			continue
+		}
+		ans = append(ans, r)
+	}
+	return ans
+}
+
+// recipientsByLang groups recipients' addresses by notification language.
+// Recipients without language are assigned to dfltLang.
+func recipientsByLang(recipients []notificationRecipient, dfltLang string) map[string][]string {
+	ans := make(map[string][]string)
+	for _, r := range recipients {
+		lang := r.Lang
+		if lang == "" {
+			lang = dfltLang
+		}
+		ans[lang] = append(ans[lang], r.Address)
+	}
+	return ans
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func mixedRecipients() []notificationRecipient {
+	return []notificationRecipient{
+		{Address: "always@example.com"},
+		{Address: "errors@example.com", OnlyOnError: true},
+		{Address: "always2@example.com"},
+	}
+}
+
+func TestSelectRecipientsSuccessfulJob(t *testing.T) {
+	ans := recipientAddresses(selectRecipients(mixedRecipients(), false))
+	assert.Equal(t, []string{"always@example.com", "always2@example.com"}, ans)
+}
+
+func TestSelectRecipientsFailedJob(t *testing.T) {
+	ans := recipientAddresses(selectRecipients(mixedRecipients(), true))
+	assert.Equal(
+		t,
+		[]string{"always@example.com", "errors@example.com", "always2@example.com"},
+		ans,
+	)
+}
+
+func TestSelectRecipientsOnlyErrorRecipientsSuccessfulJob(t *testing.T) {
+	recipients := []notificationRecipient{{Address: "errors@example.com", OnlyOnError: true}}
+	assert.Empty(t, selectRecipients(recipients, false))
+}
+
+func TestAddRecipientUpdatesFlag(t *testing.T) {
+	recipients := addRecipient(mixedRecipients(), notificationRecipient{Address: "always@example.com", OnlyOnError: true})
+	assert.Len(t, recipients, 3)
+	assert.True(t, recipients[0].OnlyOnError)
+	recipients = addRecipient(recipients, notificationRecipient{Address: "new@example.com"})
+	assert.Len(t, recipients, 4)
+	assert.Equal(t, "new@example.com", recipients[3].Address)
+}
+
+func TestRecipientsByLang(t *testing.T) {
+	recipients := []notificationRecipient{
+		{Address: "user1@example.com", Lang: "cs"},
+		{Address: "user2@example.com"},
+		{Address: "user3@example.com", Lang: "en"},
+		{Address: "user4@example.com", Lang: "cs"},
+	}
+	ans := recipientsByLang(recipients, "en")
+	assert.Equal(
+		t,
+		map[string][]string{
+			"cs": {"user1@example.com", "user4@example.com"},
+			"en": {"user2@example.com", "user3@example.com"},
+		},
+		ans,
+	)
+}
+
+func TestGetNotificationsKeepsPlainRecipients(t *testing.T) {
+	a := &Actions{
+		jobList: map[string]GeneralJobInfo{
+			"job1": DummyJobInfo{ID: "job1", Type: "liveattrs", CorpusID: "syn2020"},
+		},
+		notificationRecipients: map[string][]notificationRecipient{"job1": mixedRecipients()},
+	}
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/jobs/job1/emailNotification", nil)
+	ctx.Params = gin.Params{{Key: "jobId", Value: "job1"}}
+	a.GetNotifications(ctx)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Recipients        []string                `json:"recipients"`
+		RecipientsDetails []notificationRecipient `json:"recipientsDetails"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(
+		t,
+		[]string{"always@example.com", "errors@example.com", "always2@example.com"},
+		resp.Recipients,
+	)
+	assert.Equal(t, mixedRecipients(), resp.RecipientsDetails)
+}
+
+func TestClearAllFinishedConcurrentWithNotifications(t *testing.T) {
+	a := &Actions{
+		jobList: map[string]GeneralJobInfo{
+			"running": DummyJobInfo{ID: "running", Type: "liveattrs", CorpusID: "syn2020"},
+		},
+		notificationRecipients: map[string][]notificationRecipient{},
+		clearedJobs:            newClearedJobs(100, 0),
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(address string) {
+			defer wg.Done()
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodPut, "/jobs/running/emailNotification/"+address, nil)
+			ctx.Params = gin.Params{{Key: "jobId", Value: "running"}, {Key: "address", Value: address}}
+			a.AddNotification(ctx)
+		}(fmt.Sprintf("user%d@example.com", i))
+		go func(jobID string) {
+			defer wg.Done()
+			a.jobListLock.Lock()
+			a.jobList[jobID] = DummyJobInfo{ID: jobID, Type: "liveattrs", CorpusID: "syn2020"}.AsFinished()
+			a.jobListLock.Unlock()
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/jobs/clearFinished", nil)
+			a.ClearAllFinished(ctx)
+		}(fmt.Sprintf("job%d", i))
+	}
+	wg.Wait()
+	assert.Len(t, a.jobList, 1)
+	assert.Len(t, a.notificationRecipients["running"], 50)
+}
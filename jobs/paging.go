@@ -0,0 +1,68 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	totalCountHeader = "X-Total-Count"
+)
+
+// pagination specifies a page of a job list.
+// Zero limit means "no limit".
+type pagination struct {
+	offset int
+	limit  int
+}
+
+// bounds returns slice bounds of the page for a list
+// of the specified size
+func (p pagination) bounds(total int) (int, int) {
+	from := min(p.offset, total)
+	if p.limit == 0 {
+		return from, total
+	}
+	return from, min(from+p.limit, total)
+}
+
+// parsePagination reads `offset` and `limit` URL arguments
+func parsePagination(ctx *gin.Context) (pagination, error) {
+	var ans pagination
+	for _, arg := range []struct {
+		name string
+		dst  *int
+	}{
+		{"offset", &ans.offset},
+		{"limit", &ans.limit},
+	} {
+		v := ctx.Request.URL.Query().Get(arg.name)
+		if v == "" {
+			continue
+		}
+		iv, err := strconv.Atoi(v)
+		if err != nil || iv < 0 {
+			return pagination{}, fmt.Errorf("invalid value of %s: %s", arg.name, v)
+		}
+		*arg.dst = iv
+	}
+	return ans, nil
+}
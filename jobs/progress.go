@@ -0,0 +1,55 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+// ProgressReporter is an optional extension of GeneralJobInfo
+// for jobs able to report a partial progress of their processing.
+type ProgressReporter interface {
+
+	// GetProgress returns job progress in percents (0 - 100)
+	GetProgress() float64
+
+	// WithProgress creates a clone of the status with progress
+	// set to the provided value
+	WithProgress(p float64) GeneralJobInfo
+}
+
+// GetProgress returns job progress in percents. For jobs not
+// implementing ProgressReporter, 0 is returned for unfinished
+// jobs and 100 for successfully finished ones.
+func GetProgress(job GeneralJobInfo) float64 {
+	if job.IsFinished() && job.GetError() == nil {
+		return 100
+	}
+	if pr, ok := job.(ProgressReporter); ok {
+		return pr.GetProgress()
+	}
+	return 0
+}
+
+// keepProgress makes sure a job update does not decrease
+// a progress already reached by the job
+func keepProgress(curr, upd GeneralJobInfo) GeneralJobInfo {
+	pr, ok := upd.(ProgressReporter)
+	if !ok {
+		return upd
+	}
+	if currProgress := GetProgress(curr); currProgress > pr.GetProgress() {
+		return pr.WithProgress(currProgress)
+	}
+	return upd
+}
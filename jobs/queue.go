@@ -21,7 +21,15 @@ import (
 )
 
 var (
-	ErrorEmptyQueue = errors.New("empty queue")
+	ErrorEmptyQueue    = errors.New("empty queue")
+	ErrorJobNotInQueue = errors.New("job not in queue")
+	ErrorQueueFull     = errors.New("job queue is full")
+)
+
+const (
+	// DefaultJobPriority is used for jobs enqueued without
+	// an explicit priority
+	DefaultJobPriority = 0
 )
 
 type QueuedFunc = func(chan<- GeneralJobInfo)
@@ -30,8 +38,13 @@ type JobEntry struct {
 	next         *JobEntry
 	job          *QueuedFunc
 	initialState GeneralJobInfo
+
+	// priority - jobs with higher priority are dequeued first
+	priority int
 }
 
+// JobQueue is a queue of jobs ordered by their priority (higher
+// first) and then by the time of enqueuing (older first).
 type JobQueue struct {
 	firstEntry *JobEntry
 	lastEntry  *JobEntry
@@ -45,56 +58,65 @@ func (jq *JobQueue) Size() int {
 	return ans
 }
 
+// Enqueue adds a job with DefaultJobPriority
 func (jq *JobQueue) Enqueue(item *QueuedFunc, initialState GeneralJobInfo) {
+	jq.EnqueueWithPriority(item, initialState, DefaultJobPriority)
+}
+
+// EnqueueWithPriority adds a job to the queue behind all the jobs
+// with the same or higher priority.
+func (jq *JobQueue) EnqueueWithPriority(item *QueuedFunc, initialState GeneralJobInfo, priority int) {
 	entry := &JobEntry{
 		job:          item,
 		initialState: initialState,
+		priority:     priority,
 	}
 	if jq.firstEntry == nil {
 		jq.firstEntry = entry
-	}
-	if jq.lastEntry == nil {
 		jq.lastEntry = entry
-
-	} else {
-		jq.lastEntry.next = entry
+		return
 	}
-	jq.lastEntry = entry
-}
-
-func (jq *JobQueue) getPenultimate() *JobEntry {
-	var prev *JobEntry
-	for curr := jq.firstEntry; curr != nil && curr.next != nil; curr = curr.next {
-		prev = curr
+	if jq.firstEntry.priority < priority {
+		entry.next = jq.firstEntry
+		jq.firstEntry = entry
+		return
+	}
+	prev := jq.firstEntry
+	for prev.next != nil && prev.next.priority >= priority {
+		prev = prev.next
+	}
+	entry.next = prev.next
+	prev.next = entry
+	if entry.next == nil {
+		jq.lastEntry = entry
 	}
-	return prev
 }
 
 // DelayNext takes the current item to be dequeued and moves
-// it one position back. In case the queue contains only a single
-// item, the function does nothing. In case the queue is empty,
-// ErrorEmptyQueue is returned.
+// it one position back. This is used for jobs which cannot run yet
+// (e.g. due to unfinished parent jobs) so the next job can run instead
+// - even if it has a lower priority. To keep the queue ordered by
+// priority (which EnqueueWithPriority relies on), the delayed item
+// takes over the priority of the item moved ahead of it. I.e. the
+// delayed job loses its precedence over jobs enqueued later with
+// a priority between the original and the new one.
+// In case the queue contains only a single item, the function does
+// nothing. In case the queue is empty, ErrorEmptyQueue is returned.
 func (jq *JobQueue) DelayNext() error {
 	if jq.firstEntry == nil {
 		return ErrorEmptyQueue
 	}
-	if jq.Size() == 2 {
-		first := jq.firstEntry
-		jq.firstEntry = jq.lastEntry
-		jq.firstEntry.next = first
-		first.next = nil
+	first := jq.firstEntry
+	second := first.next
+	if second == nil {
+		return nil
+	}
+	first.next = second.next
+	first.priority = second.priority
+	second.next = first
+	jq.firstEntry = second
+	if first.next == nil {
 		jq.lastEntry = first
-
-	} else if jq.Size() > 2 {
-		pu := jq.getPenultimate()
-		last := jq.lastEntry
-		pu.next = nil
-		jq.lastEntry = pu
-		var pupu *JobEntry
-		for pupu = jq.firstEntry; pupu != nil && pupu.next != pu; pupu = pupu.next {
-		}
-		pupu.next = last
-		last.next = jq.lastEntry
 	}
 	return nil
 }
@@ -115,6 +137,53 @@ func (jq *JobQueue) Dequeue() (*QueuedFunc, GeneralJobInfo, error) {
 	return ret.job, ret.initialState, nil
 }
 
+// Remove removes a job from the queue and returns its initial state.
+// In case the job is not in the queue, ErrorJobNotInQueue is returned.
+func (jq *JobQueue) Remove(jobID string) (GeneralJobInfo, error) {
+	var prev *JobEntry
+	for curr := jq.firstEntry; curr != nil; curr = curr.next {
+		if curr.initialState.GetID() != jobID {
+			prev = curr
+			continue
+		}
+		if prev == nil {
+			jq.firstEntry = curr.next
+
+		} else {
+			prev.next = curr.next
+		}
+		if jq.lastEntry == curr {
+			jq.lastEntry = prev
+		}
+		return curr.initialState, nil
+	}
+	return nil, ErrorJobNotInQueue
+}
+
+// PositionOf returns a zero-based position of a job in the queue
+// (i.e. 0 means the job will be dequeued next). In case the job
+// is not in the queue, ErrorJobNotInQueue is returned.
+func (jq *JobQueue) PositionOf(jobID string) (int, error) {
+	pos := 0
+	for curr := jq.firstEntry; curr != nil; curr = curr.next {
+		if curr.initialState.GetID() == jobID {
+			return pos, nil
+		}
+		pos++
+	}
+	return -1, ErrorJobNotInQueue
+}
+
+// InitialStates returns initial states of all the queued jobs
+// in the order they will be dequeued
+func (jq *JobQueue) InitialStates() []GeneralJobInfo {
+	ans := make([]GeneralJobInfo, 0, 10)
+	for curr := jq.firstEntry; curr != nil; curr = curr.next {
+		ans = append(ans, curr.initialState)
+	}
+	return ans
+}
+
 func (jq *JobQueue) PeekID() (string, error) {
 	if jq.firstEntry == nil {
 		return "", ErrorEmptyQueue
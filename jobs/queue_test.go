@@ -119,3 +119,208 @@ func TestDelayNextOnTwoItemQueue(t *testing.T) {
 	assert.Equal(t, &f2, v)
 	assert.NoError(t, err)
 }
+
+func TestDelayNextOnThreeItemQueue(t *testing.T) {
+	q := JobQueue{}
+	f1 := func(chan<- GeneralJobInfo) {}
+	f2 := func(chan<- GeneralJobInfo) {}
+	f3 := func(chan<- GeneralJobInfo) {}
+	q.Enqueue(&f1, &DummyJobInfo{ID: "1"})
+	q.Enqueue(&f2, &DummyJobInfo{ID: "2"})
+	q.Enqueue(&f3, &DummyJobInfo{ID: "3"})
+	err := q.DelayNext()
+	assert.NoError(t, err)
+	id, err := q.PeekID()
+	assert.NoError(t, err)
+	assert.Equal(t, "2", id)
+	assert.Equal(t, "3", q.lastEntry.initialState.GetID())
+	_, st, _ := q.Dequeue()
+	assert.Equal(t, "2", st.GetID())
+	_, st, _ = q.Dequeue()
+	assert.Equal(t, "1", st.GetID())
+	_, st, _ = q.Dequeue()
+	assert.Equal(t, "3", st.GetID())
+}
+
+func TestEnqueueWithPriority(t *testing.T) {
+	q := JobQueue{}
+	f := func(chan<- GeneralJobInfo) {}
+	q.Enqueue(&f, &DummyJobInfo{ID: "1"})
+	q.EnqueueWithPriority(&f, &DummyJobInfo{ID: "2"}, 10)
+	q.EnqueueWithPriority(&f, &DummyJobInfo{ID: "3"}, -5)
+	q.EnqueueWithPriority(&f, &DummyJobInfo{ID: "4"}, 10)
+	q.Enqueue(&f, &DummyJobInfo{ID: "5"})
+	assert.Equal(t, "3", q.lastEntry.initialState.GetID())
+	ids := make([]string, 0, 5)
+	for q.Size() > 0 {
+		id, err := q.PeekID()
+		assert.NoError(t, err)
+		_, st, err := q.Dequeue()
+		assert.NoError(t, err)
+		assert.Equal(t, id, st.GetID())
+		ids = append(ids, st.GetID())
+	}
+	assert.Equal(t, []string{"2", "4", "1", "5", "3"}, ids)
+}
+
+func TestEnqueueAfterDelayedItem(t *testing.T) {
+	q := JobQueue{}
+	f := func(chan<- GeneralJobInfo) {}
+	q.EnqueueWithPriority(&f, &DummyJobInfo{ID: "1"}, 5)
+	q.Enqueue(&f, &DummyJobInfo{ID: "2"})
+	assert.NoError(t, q.DelayNext())
+	q.EnqueueWithPriority(&f, &DummyJobInfo{ID: "3"}, 1)
+	q.Enqueue(&f, &DummyJobInfo{ID: "4"})
+	id, _ := q.PeekID()
+	assert.Equal(t, "3", id)
+	assert.Equal(t, "4", q.lastEntry.initialState.GetID())
+	assert.Equal(t, 4, q.Size())
+}
+
+func TestDelayNextKeepsPriorityOrder(t *testing.T) {
+	q := JobQueue{}
+	f := func(chan<- GeneralJobInfo) {}
+	q.EnqueueWithPriority(&f, &DummyJobInfo{ID: "1"}, 10)
+	q.Enqueue(&f, &DummyJobInfo{ID: "2"})
+	q.EnqueueWithPriority(&f, &DummyJobInfo{ID: "3"}, -5)
+	assert.NoError(t, q.DelayNext())
+	for curr := q.firstEntry; curr.next != nil; curr = curr.next {
+		assert.GreaterOrEqual(t, curr.priority, curr.next.priority)
+	}
+	// the delayed job has taken over the priority of job "2" so
+	// a job with a priority between the two is dequeued before both
+	q.EnqueueWithPriority(&f, &DummyJobInfo{ID: "4"}, 5)
+	q.Enqueue(&f, &DummyJobInfo{ID: "5"})
+	assert.Equal(t, []string{"4", "2", "1", "5", "3"}, queuedIDs(q))
+}
+
+func queuedIDs(q JobQueue) []string {
+	ans := make([]string, 0, q.Size())
+	for _, st := range q.InitialStates() {
+		ans = append(ans, st.GetID())
+	}
+	return ans
+}
+
+func TestPositionOf(t *testing.T) {
+	q := JobQueue{}
+	f1 := func(chan<- GeneralJobInfo) {}
+	f2 := func(chan<- GeneralJobInfo) {}
+	f3 := func(chan<- GeneralJobInfo) {}
+	f4 := func(chan<- GeneralJobInfo) {}
+	q.Enqueue(&f1, &DummyJobInfo{ID: "1"})
+	q.Enqueue(&f2, &DummyJobInfo{ID: "2"})
+	q.EnqueueWithPriority(&f3, &DummyJobInfo{ID: "3"}, 10)
+	q.Enqueue(&f4, &DummyJobInfo{ID: "4"})
+	for id, expected := range map[string]int{"3": 0, "1": 1, "2": 2, "4": 3} {
+		pos, err := q.PositionOf(id)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, pos, "job %s", id)
+	}
+	_, err := q.PositionOf("5")
+	assert.ErrorIs(t, err, ErrorJobNotInQueue)
+}
+
+func TestPositionOfAfterDequeue(t *testing.T) {
+	q := JobQueue{}
+	f1 := func(chan<- GeneralJobInfo) {}
+	f2 := func(chan<- GeneralJobInfo) {}
+	f3 := func(chan<- GeneralJobInfo) {}
+	q.Enqueue(&f1, &DummyJobInfo{ID: "1"})
+	q.Enqueue(&f2, &DummyJobInfo{ID: "2"})
+	q.Enqueue(&f3, &DummyJobInfo{ID: "3"})
+	_, _, err := q.Dequeue()
+	assert.NoError(t, err)
+	_, err = q.PositionOf("1")
+	assert.ErrorIs(t, err, ErrorJobNotInQueue)
+	pos, err := q.PositionOf("3")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pos)
+	assert.Len(t, q.InitialStates(), 2)
+}
+
+func TestPositionOfEmptyQueue(t *testing.T) {
+	q := JobQueue{}
+	_, err := q.PositionOf("1")
+	assert.ErrorIs(t, err, ErrorJobNotInQueue)
+}
+
+func TestEnqueueRejectsOnFullQueue(t *testing.T) {
+	a := &Actions{
+		conf:        &Conf{MaxQueueLength: 2},
+		jobQueue:    &JobQueue{},
+		jobDeps:     make(JobsDeps),
+		jobContexts: make(map[string]*jobContext),
+	}
+	f := func(chan<- GeneralJobInfo) {}
+	assert.NoError(t, a.EnqueueJob(&f, &DummyJobInfo{ID: "1"}))
+	assert.NoError(t, a.EnqueueJobWithPriority(&f, &DummyJobInfo{ID: "2"}, 10))
+	assert.True(t, a.queueIsFull())
+	assert.ErrorIs(t, a.EnqueueJob(&f, &DummyJobInfo{ID: "3"}), ErrorQueueFull)
+	assert.ErrorIs(t, a.EqueueJobAfter(&f, &DummyJobInfo{ID: "4"}, "1"), ErrorQueueFull)
+	assert.Equal(t, 2, a.jobQueue.Size())
+	_, err := a.jobDeps.MustWait("4")
+	assert.ErrorIs(t, err, ErrorNoSuchJobDependency)
+
+	_, _, err = a.jobQueue.Dequeue()
+	assert.NoError(t, err)
+	assert.NoError(t, a.EnqueueJob(&f, &DummyJobInfo{ID: "5"}))
+}
+
+func TestEnqueueUnlimitedQueue(t *testing.T) {
+	a := &Actions{
+		conf:     &Conf{},
+		jobQueue: &JobQueue{},
+	}
+	f := func(chan<- GeneralJobInfo) {}
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, a.EnqueueJob(&f, &DummyJobInfo{ID: "x"}))
+	}
+	assert.False(t, a.queueIsFull())
+}
+
+func TestRemove(t *testing.T) {
+	q := JobQueue{}
+	f := func(chan<- GeneralJobInfo) {}
+	q.Enqueue(&f, &DummyJobInfo{ID: "1"})
+	q.Enqueue(&f, &DummyJobInfo{ID: "2"})
+	q.Enqueue(&f, &DummyJobInfo{ID: "3"})
+
+	job, err := q.Remove("3")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", job.GetID())
+	assert.Equal(t, "2", q.lastEntry.initialState.GetID())
+
+	_, err = q.Remove("1")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", q.firstEntry.initialState.GetID())
+
+	_, err = q.Remove("1")
+	assert.ErrorIs(t, err, ErrorJobNotInQueue)
+
+	_, err = q.Remove("2")
+	assert.NoError(t, err)
+	assert.Nil(t, q.firstEntry)
+	assert.Nil(t, q.lastEntry)
+	assert.Equal(t, 0, q.Size())
+}
+
+func TestEnqueueAfterRejectsCircularDependency(t *testing.T) {
+	a := &Actions{
+		conf:        &Conf{},
+		jobQueue:    &JobQueue{},
+		jobDeps:     make(JobsDeps),
+		jobContexts: make(map[string]*jobContext),
+	}
+	f := func(chan<- GeneralJobInfo) {}
+	assert.NoError(t, a.EqueueJobAfter(&f, &DummyJobInfo{ID: "1"}, "2"))
+	assert.ErrorIs(t, a.EqueueJobAfter(&f, &DummyJobInfo{ID: "2"}, "1"), ErrorCircularJobDependency)
+	assert.Equal(t, 1, a.jobQueue.Size())
+	_, err := a.jobDeps.MustWait("2")
+	assert.ErrorIs(t, err, ErrorNoSuchJobDependency)
+
+	assert.NoError(t, a.EqueueJobAfter(&f, &DummyJobInfo{ID: "3"}, "1", "2"))
+	assert.NoError(t, a.EqueueJobAfter(&f, &DummyJobInfo{ID: "4"}, "1"))
+	assert.NoError(t, a.EqueueJobAfter(&f, &DummyJobInfo{ID: "5"}, "3", "4"))
+	assert.Equal(t, 4, a.jobQueue.Size())
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// JobResumer reconstructs a job function from a job loaded from
+// the status data file (see Conf.StatusDataPath) so the job can be
+// enqueued again after the service restart. Along with the function,
+// it returns the initial status of the resumed job (e.g. with
+// an increased number of restarts).
+//
+// Please note that functions cannot be persisted. So to make a job
+// resumable, its job info must contain everything needed to create
+// the function again (e.g. job arguments) and the job info type must
+// be registered via gob.Register. As a resumed job starts from scratch,
+// only idempotent jobs should be resumed this way.
+type JobResumer func(jinfo GeneralJobInfo) (*QueuedFunc, GeneralJobInfo, error)
+
+// RegisterJobResumer sets a resumer for a job type. In case the type
+// is configured to be resumed automatically (see Conf.AutoResumeJobTypes),
+// matching detached jobs are enqueued right away.
+func (a *Actions) RegisterJobResumer(jobType string, resumer JobResumer) {
+	a.resumersLock.Lock()
+	a.resumers[jobType] = resumer
+	a.resumersLock.Unlock()
+	if a.conf.AutoResumes(jobType) {
+		a.resumeDetachedJobs(jobType)
+	}
+}
+
+// resumeDetachedJobs enqueues all the unfinished detached jobs
+// of the type. Jobs which cannot be resumed are removed.
+func (a *Actions) resumeDetachedJobs(jobType string) {
+	a.resumersLock.Lock()
+	resumer, ok := a.resumers[jobType]
+	a.resumersLock.Unlock()
+	if !ok {
+		return
+	}
+	candidates := make([]GeneralJobInfo, 0, len(a.detachedJobs))
+	func() {
+		a.detachedJobsLock.Lock()
+		defer a.detachedJobsLock.Unlock()
+		for _, job := range a.detachedJobs {
+			if job.GetType() == jobType && !job.IsFinished() {
+				candidates = append(candidates, job)
+			}
+		}
+	}()
+	for _, job := range candidates {
+		a.ClearDetachedJob(job.GetID())
+		if err := a.resumeJob(job, resumer); err != nil {
+			log.Error().
+				Err(err).
+				Str("jobId", job.GetID()).
+				Msg("failed to resume detached job, the job will be removed")
+		}
+	}
+}
+
+func (a *Actions) resumeJob(jinfo GeneralJobInfo, resumer JobResumer) error {
+	if err := a.TestAllowsJobRestart(jinfo); err != nil {
+		return err
+	}
+	fn, initialStatus, err := resumer(jinfo)
+	if err != nil {
+		return fmt.Errorf("failed to resume job %s: %w", jinfo.GetID(), err)
+	}
+	if err := a.EnqueueJob(fn, initialStatus); err != nil {
+		return fmt.Errorf("failed to resume job %s: %w", jinfo.GetID(), err)
+	}
+	log.Info().
+		Str("jobId", initialStatus.GetID()).
+		Str("jobType", initialStatus.GetType()).
+		Msg("resumed detached job")
+	return nil
+}
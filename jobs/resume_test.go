@@ -0,0 +1,58 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterJobResumer(t *testing.T) {
+	a := &Actions{
+		ctx:  context.Background(),
+		conf: &Conf{MaxNumRestarts: 2, AutoResumeJobTypes: []string{"dummy"}},
+		detachedJobs: map[string]GeneralJobInfo{
+			"1": &DummyJobInfo{ID: "1", Type: "dummy"},
+			"2": &DummyJobInfo{ID: "2", Type: "dummy", NumRestarts: 2},
+			"3": &DummyJobInfo{ID: "3", Type: "other"},
+		},
+		jobQueue:    &JobQueue{},
+		jobContexts: make(map[string]*jobContext),
+		resumers:    make(map[string]JobResumer),
+	}
+	resumed := make([]string, 0, 2)
+	resumer := func(jinfo GeneralJobInfo) (*QueuedFunc, GeneralJobInfo, error) {
+		resumed = append(resumed, jinfo.GetID())
+		job := *(jinfo.(*DummyJobInfo))
+		job.NumRestarts++
+		fn := func(updateJobChan chan<- GeneralJobInfo) {}
+		return &fn, job, nil
+	}
+	a.RegisterJobResumer("dummy", resumer)
+	a.RegisterJobResumer("other", resumer)
+
+	assert.Equal(t, []string{"1"}, resumed)
+	assert.Equal(t, 1, a.jobQueue.Size())
+	_, initState, err := a.jobQueue.Dequeue()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, initState.GetNumRestarts())
+	// job "2" reached max. num. of restarts so it is just removed
+	assert.Len(t, a.detachedJobs, 1)
+	assert.Contains(t, a.detachedJobs, "3")
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"encoding/gob"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startShutdownTestJob(
+	t *testing.T,
+	a *Actions,
+	jobID string,
+	fn QueuedFuncCtx,
+) {
+	err := a.EnqueueJob(
+		a.WithJobContext(jobID, fn),
+		DummyJobInfo{ID: jobID, Type: "dummy", Start: CurrentDatetime()},
+	)
+	assert.NoError(t, err)
+	assert.Eventually(
+		t,
+		func() bool {
+			_, ok := a.GetJob(jobID)
+			return ok
+		},
+		5*time.Second,
+		50*time.Millisecond,
+	)
+}
+
+func TestShutdownDrainsRunningJobs(t *testing.T) {
+	gob.Register(&DummyJobInfo{})
+	statusPath := filepath.Join(t.TempDir(), "jobs.gob")
+	ctx, cancel := context.WithCancel(context.Background())
+	a := NewActions(
+		&Conf{StatusDataPath: statusPath, MaxNumConcurrentJobs: 2, ShutdownDrainTimeoutSecs: 5},
+		"en",
+		ctx,
+		make(chan string, 10),
+	)
+	var jobCtxErr error
+	startShutdownTestJob(t, a, "slow", func(ctx context.Context, updateJobChan chan<- GeneralJobInfo) {
+		time.Sleep(300 * time.Millisecond)
+		jobCtxErr = ctx.Err()
+		updateJobChan <- DummyJobInfo{ID: "slow", Type: "dummy", Result: &DummyJobResult{}}.AsFinished()
+		close(updateJobChan)
+	})
+	cancel()
+	select {
+	case <-a.Exited():
+	case <-time.After(10 * time.Second):
+		t.Fatal("shutdown did not finish in time")
+	}
+	assert.NoError(t, jobCtxErr)
+	jobs, err := LoadJobList(statusPath)
+	assert.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestShutdownSavesJobsRunningAfterTimeout(t *testing.T) {
+	gob.Register(&DummyJobInfo{})
+	statusPath := filepath.Join(t.TempDir(), "jobs.gob")
+	ctx, cancel := context.WithCancel(context.Background())
+	a := NewActions(
+		&Conf{StatusDataPath: statusPath, MaxNumConcurrentJobs: 2, ShutdownDrainTimeoutSecs: 1},
+		"en",
+		ctx,
+		make(chan string, 10),
+	)
+	jobStopped := make(chan struct{})
+	startShutdownTestJob(t, a, "stuck", func(ctx context.Context, updateJobChan chan<- GeneralJobInfo) {
+		<-ctx.Done()
+		close(jobStopped)
+		updateJobChan <- DummyJobInfo{ID: "stuck", Type: "dummy"}.WithError(ctx.Err())
+		close(updateJobChan)
+	})
+	cancel()
+	select {
+	case <-a.Exited():
+	case <-time.After(10 * time.Second):
+		t.Fatal("shutdown did not finish in time")
+	}
+	jobs, err := LoadJobList(statusPath)
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 1) {
+		assert.Equal(t, "stuck", jobs[0].GetID())
+		assert.False(t, jobs[0].IsFinished())
+	}
+	select {
+	case <-jobStopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job context has not been cancelled after shutdown")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"encoding/json"
+	"frodo/middleware"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// jobRef is a lightweight job reference used to snapshot
+// the job list without copying whole job infos.
+type jobRef struct {
+	id    string
+	start JSONTime
+}
+
+// snapshotJobRefs returns references to jobs matching the filter
+// sorted from the newest one. The job list lock is held only while collecting
+// the references.
+func (a *Actions) snapshotJobRefs(filter jobListFilter) []jobRef {
+	ans := func() []jobRef {
+		a.jobListLock.RLock()
+		defer a.jobListLock.RUnlock()
+		ans := make([]jobRef, 0, len(a.jobList))
+		for id, v := range a.jobList {
+			if filter.matches(v) {
+				ans = append(ans, jobRef{id: id, start: v.GetStartDT()})
+			}
+		}
+		return ans
+	}()
+	sort.SliceStable(ans, func(i, j int) bool {
+		return ans[j].start.Before(ans[i].start)
+	})
+	return ans
+}
+
+// streamJobList writes jobs one per line (NDJSON). Jobs removed
+// between the snapshot and their rendering are skipped. The history
+// jobs (if any) are written after the current ones. The page is applied
+// to the concatenation of both lists.
+func (a *Actions) streamJobList(
+	ctx *gin.Context,
+	filter jobListFilter,
+	compact bool,
+	history JobInfoList,
+	page pagination,
+) {
+	refs := a.snapshotJobRefs(filter)
+	total := len(refs) + len(history)
+	from, to := page.bounds(total)
+	refs = refs[min(from, len(refs)):min(to, len(refs))]
+	history = history[max(from-total+len(history), 0):max(to-total+len(history), 0)]
+	ctx.Writer.Header().Set(totalCountHeader, strconv.Itoa(total))
+	ctx.Writer.Header().Set("Content-Type", middleware.NDJSONContentType)
+	middleware.DisableWriteDeadline(ctx)
+	ctx.Writer.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(ctx.Writer)
+	for _, ref := range refs {
+		item := func() any {
+			a.jobListLock.RLock()
+			defer a.jobListLock.RUnlock()
+			job, ok := a.jobList[ref.id]
+			if !ok {
+				return nil
+			}
+			if compact {
+				return compactVersion(job)
+			}
+			return job.FullInfo()
+		}()
+		if item == nil {
+			continue
+		}
+		if err := enc.Encode(item); err != nil {
+			log.Error().Err(err).Str("jobId", ref.id).Msg("failed to stream job info")
+			return
+		}
+		ctx.Writer.Flush()
+	}
+	for _, job := range history {
+		var item any
+		if compact {
+			item = compactVersion(job)
+
+		} else {
+			item = job.FullInfo()
+		}
+		if err := enc.Encode(item); err != nil {
+			log.Error().Err(err).Str("jobId", job.GetID()).Msg("failed to stream job info")
+			return
+		}
+		ctx.Writer.Flush()
+	}
+}
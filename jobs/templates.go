@@ -0,0 +1,266 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+)
+
+const (
+	// maxRecordedJobRequests is a maximum number of remembered
+	// requests of created jobs (see jobRequests)
+	maxRecordedJobRequests = 1000
+)
+
+var (
+	ErrorNoSuchTemplate = errors.New("job template not found")
+
+	ErrorNoSuchJobRequest = errors.New("arguments of the job not available")
+)
+
+// JobTemplate is a stored job definition which can be repeatedly
+// used to create new jobs (see Actions.CreateFromTemplate).
+type JobTemplate struct {
+	Name string `json:"name"`
+
+	// Job is a name of the job as known to job-creating actions
+	// (e.g. `liveattrs`, `ngrams`)
+	Job string `json:"job"`
+
+	CorpusID string `json:"corpusId"`
+
+	// Args are arguments of the job (i.e. a request body
+	// of the respective job-creating action)
+	Args json.RawMessage `json:"args,omitempty"`
+
+	// Query contains optional URL arguments of the job-creating action
+	Query string `json:"query,omitempty"`
+
+	Updated JSONTime `json:"updated"`
+}
+
+// JobTemplateOverrides specifies values replacing the ones stored
+// in a template when a job is created from the template.
+type JobTemplateOverrides struct {
+	CorpusID string `json:"corpusId"`
+
+	// Args are merged into the template arguments. Nested objects
+	// are merged recursively, all other values are replaced.
+	Args map[string]any `json:"args"`
+
+	Query string `json:"query"`
+}
+
+// Apply returns a copy of the template with the overrides applied
+func (o JobTemplateOverrides) Apply(tpl JobTemplate) (JobTemplate, error) {
+	ans := tpl
+	if o.CorpusID != "" {
+		ans.CorpusID = o.CorpusID
+	}
+	if o.Query != "" {
+		ans.Query = o.Query
+	}
+	if len(o.Args) > 0 {
+		args := make(map[string]any)
+		if len(tpl.Args) > 0 {
+			if err := json.Unmarshal(tpl.Args, &args); err != nil {
+				return ans, fmt.Errorf("failed to apply template overrides: %w", err)
+			}
+		}
+		mergeArgs(args, o.Args)
+		var err error
+		ans.Args, err = json.Marshal(args)
+		if err != nil {
+			return ans, fmt.Errorf("failed to apply template overrides: %w", err)
+		}
+	}
+	return ans, nil
+}
+
+// mergeArgs recursively merges src into dst
+func mergeArgs(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[k].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeArgs(dstMap, srcMap)
+
+		} else {
+			dst[k] = v
+		}
+	}
+}
+
+// templateStore keeps job templates and (if a path is configured)
+// stores them to a JSON file on each change.
+type templateStore struct {
+	lock      sync.RWMutex
+	templates map[string]JobTemplate
+	path      string
+}
+
+func (ts *templateStore) list() []JobTemplate {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+	ans := make([]JobTemplate, 0, len(ts.templates))
+	for _, v := range ts.templates {
+		ans = append(ans, v)
+	}
+	slices.SortFunc(ans, func(a, b JobTemplate) int {
+		if a.Name < b.Name {
+			return -1
+		}
+		if a.Name > b.Name {
+			return 1
+		}
+		return 0
+	})
+	return ans
+}
+
+func (ts *templateStore) get(name string) (JobTemplate, error) {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+	tpl, ok := ts.templates[name]
+	if !ok {
+		return tpl, ErrorNoSuchTemplate
+	}
+	return tpl, nil
+}
+
+func (ts *templateStore) set(tpl JobTemplate) error {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	prev, hadPrev := ts.templates[tpl.Name]
+	ts.templates[tpl.Name] = tpl
+	if err := ts.save(); err != nil {
+		if hadPrev {
+			ts.templates[tpl.Name] = prev
+
+		} else {
+			delete(ts.templates, tpl.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+func (ts *templateStore) remove(name string) error {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	prev, ok := ts.templates[name]
+	if !ok {
+		return ErrorNoSuchTemplate
+	}
+	delete(ts.templates, name)
+	if err := ts.save(); err != nil {
+		ts.templates[name] = prev
+		return err
+	}
+	return nil
+}
+
+// save writes templates to the configured file. The method
+// expects the caller to hold the lock.
+func (ts *templateStore) save() error {
+	if ts.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ts.templates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save job templates: %w", err)
+	}
+	tmpPath := ts.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to save job templates: %w", err)
+	}
+	if err := os.Rename(tmpPath, ts.path); err != nil {
+		return fmt.Errorf("failed to save job templates: %w", err)
+	}
+	return nil
+}
+
+// jobRequests remembers requests which created jobs so the jobs
+// can be later saved as templates. The requests are kept in memory
+// only and the oldest ones are evicted once maxRecordedJobRequests
+// is reached.
+type jobRequests struct {
+	lock  sync.Mutex
+	items map[string]JobTemplate
+	order []string
+}
+
+func (jr *jobRequests) add(jobID string, tpl JobTemplate) {
+	jr.lock.Lock()
+	defer jr.lock.Unlock()
+	if _, ok := jr.items[jobID]; !ok {
+		jr.order = append(jr.order, jobID)
+	}
+	jr.items[jobID] = tpl
+	for len(jr.order) > maxRecordedJobRequests {
+		delete(jr.items, jr.order[0])
+		jr.order = jr.order[1:]
+	}
+}
+
+func (jr *jobRequests) get(jobID string) (JobTemplate, error) {
+	jr.lock.Lock()
+	defer jr.lock.Unlock()
+	tpl, ok := jr.items[jobID]
+	if !ok {
+		return tpl, ErrorNoSuchJobRequest
+	}
+	return tpl, nil
+}
+
+func newJobRequests() *jobRequests {
+	return &jobRequests{items: make(map[string]JobTemplate)}
+}
+
+// newTemplateStore creates a template store backed by a file.
+// In case path is empty, templates are kept in memory only.
+func newTemplateStore(path string) (*templateStore, error) {
+	ans := &templateStore{
+		templates: make(map[string]JobTemplate),
+		path:      path,
+	}
+	if path == "" {
+		return ans, nil
+	}
+	isFile, err := fs.IsFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job templates: %w", err)
+	}
+	if !isFile {
+		return ans, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job templates: %w", err)
+	}
+	if err := json.Unmarshal(data, &ans.templates); err != nil {
+		return nil, fmt.Errorf("failed to load job templates: %w", err)
+	}
+	return ans, nil
+}
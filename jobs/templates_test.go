@@ -0,0 +1,161 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateOverridesMergeArgs(t *testing.T) {
+	tpl := JobTemplate{
+		Name:     "rebuild",
+		Job:      "ngrams",
+		CorpusID: "syn2020",
+		Args:     json.RawMessage(`{"ngramSize": 2, "posTagset": {"name": "cs_cnc2020", "attr": "tag"}}`),
+	}
+	ans, err := JobTemplateOverrides{
+		CorpusID: "syn2015",
+		Args:     map[string]any{"posTagset": map[string]any{"attr": "pos"}},
+	}.Apply(tpl)
+	assert.NoError(t, err)
+	assert.Equal(t, "syn2015", ans.CorpusID)
+	assert.JSONEq(
+		t,
+		`{"ngramSize": 2, "posTagset": {"name": "cs_cnc2020", "attr": "pos"}}`,
+		string(ans.Args),
+	)
+	assert.Equal(t, "syn2020", tpl.CorpusID)
+}
+
+func TestTemplateStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	store, err := newTemplateStore(path)
+	assert.NoError(t, err)
+	assert.NoError(t, store.set(JobTemplate{Name: "b", Job: "ngrams", CorpusID: "syn2020"}))
+	assert.NoError(t, store.set(JobTemplate{Name: "a", Job: "liveattrs", CorpusID: "syn2020"}))
+	assert.NoError(t, store.remove("b"))
+
+	store2, err := newTemplateStore(path)
+	assert.NoError(t, err)
+	assert.Len(t, store2.list(), 1)
+	tpl, err := store2.get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "liveattrs", tpl.Job)
+	_, err = store2.get("b")
+	assert.ErrorIs(t, err, ErrorNoSuchTemplate)
+}
+
+func TestSaveTemplateFromJob(t *testing.T) {
+	store, err := newTemplateStore("")
+	assert.NoError(t, err)
+	a := &Actions{templates: store, jobRequests: newJobRequests()}
+	engine := gin.New()
+	engine.Use(a.RecordJobRequests)
+	engine.POST("/dictionary/:corpusId/ngrams", func(ctx *gin.Context) {
+		var args map[string]any
+		assert.NoError(t, json.NewDecoder(ctx.Request.Body).Decode(&args))
+		ctx.Header("Location", JobURLPath("job1"))
+		ctx.Status(http.StatusAccepted)
+	})
+	engine.PUT("/jobs/templates/:name", a.SaveTemplate)
+	a.SetJobCreators(
+		map[string]JobCreator{
+			"ngrams": func(ctx context.Context, req JobRequest) (CreatedJob, error) {
+				return CreatedJob{}, nil
+			},
+		},
+		map[string]string{"ngrams": "/dictionary/%s/ngrams"},
+	)
+
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(
+		http.MethodPost, "/dictionary/syn2020/ngrams?append=1", strings.NewReader(`{"ngramSize": 2}`)))
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+
+	resp = httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(
+		http.MethodPut, "/jobs/templates/rebuild?fromJob=job1", strings.NewReader(`{"args": {"minFreq": 5}}`)))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	tpl, err := store.get("rebuild")
+	assert.NoError(t, err)
+	assert.Equal(t, "ngrams", tpl.Job)
+	assert.Equal(t, "syn2020", tpl.CorpusID)
+	assert.Equal(t, "append=1", tpl.Query)
+	assert.JSONEq(t, `{"ngramSize": 2, "minFreq": 5}`, string(tpl.Args))
+
+	resp = httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(
+		http.MethodPut, "/jobs/templates/other?fromJob=job2", http.NoBody))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestCreateFromTemplateCallsJobCreator(t *testing.T) {
+	store, err := newTemplateStore("")
+	assert.NoError(t, err)
+	a := &Actions{templates: store, jobRequests: newJobRequests()}
+	var received JobRequest
+	a.SetJobCreators(
+		map[string]JobCreator{
+			"ngrams": func(ctx context.Context, req JobRequest) (CreatedJob, error) {
+				received = req
+				if req.CorpusID == "busy" {
+					return CreatedJob{}, ConflictingJobError{DatasetID: req.CorpusID, JobID: "job0"}
+				}
+				return CreatedJob{JobID: "job1", Body: map[string]any{"id": "job1"}}, nil
+			},
+		},
+		map[string]string{"ngrams": "/dictionary/%s/ngrams"},
+	)
+	assert.NoError(t, store.set(JobTemplate{
+		Name:     "rebuild",
+		Job:      "ngrams",
+		CorpusID: "syn2020",
+		Query:    "append=1",
+		Args:     json.RawMessage(`{"ngramSize": 2}`),
+	}))
+	engine := gin.New()
+	engine.POST("/jobs/from-template/:name", a.CreateFromTemplate)
+
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(
+		http.MethodPost, "/jobs/from-template/rebuild", strings.NewReader(`{"args": {"minFreq": 5}}`)))
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, JobURLPath("job1"), resp.Header().Get("Location"))
+	assert.Equal(t, "syn2020", received.CorpusID)
+	assert.Equal(t, "1", received.Query.Get("append"))
+	assert.JSONEq(t, `{"ngramSize": 2, "minFreq": 5}`, string(received.Args))
+	tpl, err := a.jobRequests.get("job1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ngrams", tpl.Job)
+
+	resp = httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(
+		http.MethodPost, "/jobs/from-template/rebuild", strings.NewReader(`{"corpusId": "busy"}`)))
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	var conflict JobConflictResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&conflict))
+	assert.Equal(t, "job0", conflict.JobID)
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	jobTimeoutWatchdogInterval = 30 * time.Second
+)
+
+var (
+	ErrorJobTimeout = errors.New("job exceeded max. allowed duration")
+)
+
+// IsTimedOut tests whether a job error means
+// the job has been stopped by the timeout watchdog
+func IsTimedOut(err error) bool {
+	return errors.Is(err, ErrorJobTimeout)
+}
+
+// MaxJobDuration returns max. allowed duration of a job of a specified
+// type. Zero means no limit.
+func (conf *Conf) MaxJobDuration(jobType string) time.Duration {
+	secs, ok := conf.MaxJobDurationSecsByType[jobType]
+	if !ok {
+		secs = conf.MaxJobDurationSecs
+	}
+	if secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// findTimedOutJobs returns IDs of running jobs exceeding their max. duration
+// and sets their error to ErrorJobTimeout. The jobs are then expected
+// to be finished via tableActionFinishJob.
+// Please note that only the job list is searched (i.e. detached jobs
+// are never affected).
+func (a *Actions) findTimedOutJobs() []GeneralJobInfo {
+	a.jobListLock.Lock()
+	defer a.jobListLock.Unlock()
+	ans := make([]GeneralJobInfo, 0, 5)
+	for jobID, job := range a.jobList {
+		if job.IsFinished() {
+			continue
+		}
+		limit := a.conf.MaxJobDuration(job.GetType())
+		if limit <= 0 || time.Since(time.Time(job.GetStartDT())) <= limit {
+			continue
+		}
+		a.jobList[jobID] = job.WithError(ErrorJobTimeout)
+		ans = append(ans, a.jobList[jobID])
+	}
+	return ans
+}
+
+// isTimedOutJob tests whether the job has been already
+// finished by the timeout watchdog
+func (a *Actions) isTimedOutJob(jobID string) bool {
+	a.jobListLock.RLock()
+	defer a.jobListLock.RUnlock()
+	job, ok := a.jobList[jobID]
+	return ok && job.IsFinished() && IsTimedOut(job.GetError())
+}
+
+func (a *Actions) checkJobTimeouts() {
+	for _, job := range a.findTimedOutJobs() {
+		log.Error().
+			Str("jobId", job.GetID()).
+			Str("jobType", job.GetType()).
+			Msg("job exceeded max. allowed duration, stopping")
+		// jobs supporting cancellation (see WithJobContext) must
+		// be stopped via their context
+		a.cancelJob(job.GetID())
+		go func(jobID string) {
+			select {
+			case a.jobStop <- jobID:
+			case <-a.ctx.Done():
+			}
+		}(job.GetID())
+		a.tableUpdate <- TableUpdate{
+			action: tableActionFinishJob,
+			itemID: job.GetID(),
+			data:   job,
+		}
+	}
+}
+
+func (a *Actions) runJobTimeoutWatchdog() {
+	if a.conf.MaxJobDurationSecs <= 0 && len(a.conf.MaxJobDurationSecsByType) == 0 {
+		log.Info().Msg("job timeout watchdog disabled")
+		return
+	}
+	ticker := time.NewTicker(jobTimeoutWatchdogInterval)
+	for {
+		select {
+		case <-ticker.C:
+			a.checkJobTimeouts()
+		case <-a.ctx.Done():
+			ticker.Stop()
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfMaxJobDuration(t *testing.T) {
+	conf := Conf{
+		MaxJobDurationSecs:       60,
+		MaxJobDurationSecsByType: map[string]int{"slow": 3600, "unlimited": -1},
+	}
+	assert.Equal(t, time.Minute, conf.MaxJobDuration("dummy"))
+	assert.Equal(t, time.Hour, conf.MaxJobDuration("slow"))
+	assert.Equal(t, time.Duration(0), conf.MaxJobDuration("unlimited"))
+}
+
+func TestFindTimedOutJobs(t *testing.T) {
+	started := JSONTime(time.Now().Add(-2 * time.Minute))
+	a := &Actions{
+		ctx:  context.Background(),
+		conf: &Conf{MaxJobDurationSecs: 60},
+		jobList: map[string]GeneralJobInfo{
+			"stuck":    DummyJobInfo{ID: "stuck", Type: "dummy", Start: started},
+			"finished": DummyJobInfo{ID: "finished", Type: "dummy", Start: started, Finished: true},
+			"fresh":    DummyJobInfo{ID: "fresh", Type: "dummy", Start: CurrentDatetime()},
+		},
+		detachedJobs: map[string]GeneralJobInfo{
+			"detached": DummyJobInfo{ID: "detached", Type: "dummy", Start: started},
+		},
+	}
+	timedOut := a.findTimedOutJobs()
+	assert.Len(t, timedOut, 1)
+	assert.Equal(t, "stuck", timedOut[0].GetID())
+	assert.True(t, IsTimedOut(a.jobList["stuck"].GetError()))
+	assert.NoError(t, a.jobList["fresh"].GetError())
+	assert.NoError(t, a.detachedJobs["detached"].GetError())
+}
+
+func TestTimedOutJobIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := NewActions(&Conf{MaxNumConcurrentJobs: 2, MaxJobDurationSecs: 60}, "en", ctx, make(chan string, 10))
+	jobErr := make(chan error, 1)
+	err := a.EnqueueJob(
+		a.WithJobContext("1", func(ctx context.Context, updateJobChan chan<- GeneralJobInfo) {
+			<-ctx.Done()
+			jobErr <- ctx.Err()
+			updateJobChan <- DummyJobInfo{ID: "1", Type: "dummy"}.WithError(ctx.Err())
+			close(updateJobChan)
+		}),
+		DummyJobInfo{ID: "1", Type: "dummy", Start: JSONTime(time.Now().Add(-2 * time.Minute))},
+	)
+	assert.NoError(t, err)
+	assert.Eventually(
+		t,
+		func() bool {
+			_, ok := a.GetJob("1")
+			return ok
+		},
+		5*time.Second,
+		20*time.Millisecond,
+	)
+	a.checkJobTimeouts()
+	select {
+	case err := <-jobErr:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out job has not been cancelled")
+	}
+	assert.Eventually(
+		t,
+		func() bool {
+			job, ok := a.GetJob("1")
+			return ok && job.IsFinished()
+		},
+		5*time.Second,
+		20*time.Millisecond,
+	)
+	job, _ := a.GetJob("1")
+	assert.True(t, IsTimedOut(job.GetError()))
+	assert.False(t, IsCancelled(job.GetError()))
+}
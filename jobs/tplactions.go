@@ -0,0 +1,281 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// RecordJobRequests is a middleware remembering requests of job-creating
+// actions (as configured via SetJobCreators) so the created jobs can be
+// later saved as templates (see SaveTemplate). The middleware must be
+// registered before the job-creating actions.
+func (a *Actions) RecordJobRequests(ctx *gin.Context) {
+	jobName := a.templateJobName(ctx)
+	if jobName == "" {
+		ctx.Next()
+		return
+	}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to read job arguments: %w", err),
+			http.StatusBadRequest,
+		)
+		ctx.Abort()
+		return
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	ctx.Next()
+	location := ctx.Writer.Header().Get("Location")
+	if ctx.Writer.Status() != http.StatusAccepted || location == "" {
+		return
+	}
+	jobID, err := url.PathUnescape(path.Base(location))
+	if err != nil {
+		log.Warn().Err(err).Str("location", location).Msg("failed to record job request")
+		return
+	}
+	tpl := JobTemplate{
+		Job:      jobName,
+		CorpusID: ctx.Param("corpusId"),
+		Query:    ctx.Request.URL.RawQuery,
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		tpl.Args = body
+	}
+	a.jobRequests.add(jobID, tpl)
+}
+
+// templateJobName returns a name of the job created by the requested
+// action or an empty string if the action is not a job-creating one
+func (a *Actions) templateJobName(ctx *gin.Context) string {
+	if ctx.Request.Method != http.MethodPost {
+		return ""
+	}
+	for name, creator := range a.jobCreators {
+		if fmt.Sprintf(creator.pathTpl, ":corpusId") == ctx.FullPath() {
+			return name
+		}
+	}
+	return ""
+}
+
+// ListTemplates godoc
+// @Summary      List stored job templates
+// @Produce      json
+// @Success      200 {object} []JobTemplate
+// @Router       /jobs/templates [get]
+func (a *Actions) ListTemplates(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, a.templates.list())
+}
+
+// GetTemplate godoc
+// @Summary      Get a stored job template
+// @Produce      json
+// @Param        name path string true "Template name"
+// @Success      200 {object} JobTemplate
+// @Failure      404 {object} uniresp.ActionError
+// @Router       /jobs/templates/{name} [get]
+func (a *Actions) GetTemplate(ctx *gin.Context) {
+	tpl, err := a.templates.get(ctx.Param("name"))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusNotFound)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, tpl)
+}
+
+// SaveTemplate godoc
+// @Summary      Store job arguments as a named template
+// @Description  Create or replace a job template. The `job` must be one of the job names supported by the `runjob` action (e.g. `liveattrs`, `ngrams`) and `args` are the arguments otherwise sent as a request body of the respective job-creating action. With `fromJob`, the template is created from the request of an existing job (the service remembers requests of recently created jobs until it is restarted) and the optional request body may override its values the same way as in case of creating a job from a template.
+// @Accept       json
+// @Produce      json
+// @Param        name path string true "Template name"
+// @Param        fromJob query string false "ID of a job to create the template from"
+// @Param        template body JobTemplate false "Template (the `name` and `updated` fields are ignored) or JobTemplateOverrides in case of fromJob"
+// @Success      200 {object} JobTemplate
+// @Failure      400 {object} uniresp.ActionError
+// @Failure      404 {object} uniresp.ActionError
+// @Router       /jobs/templates/{name} [put]
+func (a *Actions) SaveTemplate(ctx *gin.Context) {
+	var tpl JobTemplate
+	if jobID := ctx.Query("fromJob"); jobID != "" {
+		var err error
+		tpl, err = a.jobRequests.get(jobID)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError("failed to save job template: %w", err),
+				http.StatusNotFound,
+			)
+			return
+		}
+		var overrides JobTemplateOverrides
+		if err := json.NewDecoder(ctx.Request.Body).Decode(&overrides); err != nil && err != io.EOF {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError("failed to save job template: %w", err),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		tpl, err = overrides.Apply(tpl)
+		if err != nil {
+			uniresp.WriteJSONErrorResponse(
+				ctx.Writer,
+				uniresp.NewActionError("failed to save job template: %w", err),
+				http.StatusBadRequest,
+			)
+			return
+		}
+
+	} else if err := json.NewDecoder(ctx.Request.Body).Decode(&tpl); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to save job template: %w", err),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	tpl.Name = ctx.Param("name")
+	tpl.Updated = CurrentDatetime()
+	if err := a.validateTemplate(tpl); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to save job template: %w", err),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if err := a.templates.set(tpl); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, tpl)
+}
+
+// DeleteTemplate godoc
+// @Summary      Delete a stored job template
+// @Produce      json
+// @Param        name path string true "Template name"
+// @Success      200 {object} JobTemplate
+// @Failure      404 {object} uniresp.ActionError
+// @Router       /jobs/templates/{name} [delete]
+func (a *Actions) DeleteTemplate(ctx *gin.Context) {
+	tpl, err := a.templates.get(ctx.Param("name"))
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusNotFound)
+		return
+	}
+	if err := a.templates.remove(tpl.Name); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, tpl)
+}
+
+// CreateFromTemplate godoc
+// @Summary      Create a job from a stored template
+// @Description  Instantiate and enqueue a job based on a stored template. An optional request body may override the corpus, URL arguments and (recursively merged) job arguments of the template. The response is the same as the one of the respective job-creating action.
+// @Accept       json
+// @Produce      json
+// @Param        name path string true "Template name"
+// @Param        overrides body JobTemplateOverrides false "Overridden template values"
+// @Success      202 {object} any
+// @Header       202 {string} Location "URL path of the created job"
+// @Failure      400 {object} uniresp.ActionError
+// @Failure      404 {object} uniresp.ActionError
+// @Router       /jobs/from-template/{name} [post]
+func (a *Actions) CreateFromTemplate(ctx *gin.Context) {
+	baseErrTpl := "failed to create job from template %s: %w"
+	name := ctx.Param("name")
+	tpl, err := a.templates.get(name)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, name, err), http.StatusNotFound)
+		return
+	}
+	var overrides JobTemplateOverrides
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&overrides); err != nil && err != io.EOF {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, name, err), http.StatusBadRequest)
+		return
+	}
+	tpl, err = overrides.Apply(tpl)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, name, err), http.StatusBadRequest)
+		return
+	}
+	if err := a.validateTemplate(tpl); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, name, err), http.StatusBadRequest)
+		return
+	}
+	query, err := url.ParseQuery(tpl.Query)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, name, err), http.StatusBadRequest)
+		return
+	}
+	job, err := a.CreateJob(
+		ctx.Request.Context(),
+		tpl.Job,
+		JobRequest{CorpusID: tpl.CorpusID, Query: query, Args: tpl.Args},
+	)
+	if err == nil && job.JobID != "" {
+		log.Info().
+			Str("template", name).
+			Str("corpusId", tpl.CorpusID).
+			Str("jobId", job.JobID).
+			Msg("created job from template")
+	}
+	WriteCreatedJobResponse(ctx, job, err)
+}
+
+func (a *Actions) validateTemplate(tpl JobTemplate) error {
+	if len(a.jobCreators) == 0 {
+		return fmt.Errorf("job templates not supported")
+	}
+	if tpl.Name == "" {
+		return fmt.Errorf("missing template name")
+	}
+	if _, ok := a.jobCreators[tpl.Job]; !ok {
+		return fmt.Errorf(
+			"unknown job %s (available: %s)",
+			tpl.Job, strings.Join(a.JobCreatorNames(), ", "),
+		)
+	}
+	if tpl.CorpusID == "" {
+		return fmt.Errorf("missing corpus ID")
+	}
+	if len(tpl.Args) > 0 {
+		var args map[string]any
+		if err := json.Unmarshal(tpl.Args, &args); err != nil {
+			return fmt.Errorf("job arguments must be a JSON object: %w", err)
+		}
+	}
+	return nil
+}
@@ -16,6 +16,14 @@
 
 package jobs
 
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
 // ErrorToString is a helper function for exporting job status
 // to JSON. It just ensures string is always returned no matter
 // err is nil or not.
@@ -25,3 +33,17 @@ func ErrorToString(err error) string {
 	}
 	return err.Error()
 }
+
+// JobURLPath returns the URL path of a job info resource
+func JobURLPath(jobID string) string {
+	return "/jobs/" + url.PathEscape(jobID)
+}
+
+// WriteJobAcceptedResponse writes a response for a newly created
+// (asynchronous) job. It uses the 202 Accepted status along with the
+// Location header pointing to the job info resource so clients can follow
+// the job without parsing the body.
+func WriteJobAcceptedResponse(ctx *gin.Context, jobID string, body any) {
+	ctx.Header("Location", JobURLPath(jobID))
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, body)
+}
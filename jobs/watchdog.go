@@ -0,0 +1,168 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	tableUpdateWatchdogInterval = 10 * time.Second
+
+	// schedulerHeartbeatMaxAge specifies how old can the last job
+	// scheduler activity be (the scheduler ticks every second)
+	// before the scheduler is considered dead
+	schedulerHeartbeatMaxAge = 10 * time.Second
+)
+
+// ErrConsumerNotStalled is returned when a restart of a healthy
+// tableUpdate consumer is requested
+var ErrConsumerNotStalled = errors.New("tableUpdate consumer is not stalled")
+
+// tableUpdateWatchdog tracks activity of the (single) tableUpdate
+// consumer so we are able to detect that it got stuck.
+type tableUpdateWatchdog struct {
+
+	// busySince contains time (unix nanoseconds) when the consumer
+	// started to process the current update. Zero means "idle".
+	busySince atomic.Int64
+
+	// generation identifies the currently active consumer. Once
+	// a consumer is replaced, the older one exits as soon as it
+	// finishes its current update.
+	generation atomic.Int64
+
+	numRestarts atomic.Int64
+
+	stalled atomic.Bool
+}
+
+// TableUpdateConsumerStatus describes the current state
+// of the job table update consumer
+type TableUpdateConsumerStatus struct {
+	Stalled     bool    `json:"stalled"`
+	BusySecs    float64 `json:"busySecs"`
+	NumRestarts int64   `json:"numRestarts"`
+}
+
+// TableUpdateConsumerStatus returns the current state of the tableUpdate
+// consumer. A stalled consumer means that the whole job bookkeeping
+// is frozen.
+func (a *Actions) TableUpdateConsumerStatus() TableUpdateConsumerStatus {
+	ans := TableUpdateConsumerStatus{
+		Stalled:     a.tuWatchdog.stalled.Load(),
+		NumRestarts: a.tuWatchdog.numRestarts.Load(),
+	}
+	if busy := a.tuWatchdog.busySince.Load(); busy > 0 {
+		ans.BusySecs = time.Since(time.Unix(0, busy)).Seconds()
+	}
+	return ans
+}
+
+// SchedulerAlive tests whether the job scheduler (i.e. the goroutine
+// dequeuing and starting jobs) has been active recently. It also returns
+// the time of the last scheduler activity (zero time if there was none).
+func (a *Actions) SchedulerAlive() (bool, time.Time) {
+	beat := a.schedulerHeartbeat.Load()
+	if beat == 0 {
+		return false, time.Time{}
+	}
+	last := time.Unix(0, beat)
+	return time.Since(last) <= schedulerHeartbeatMaxAge, last
+}
+
+// runTableUpdateConsumer is the only place where the tableUpdate
+// channel is consumed (unless the consumer is restarted - in such case
+// the older consumer exits once it finishes its current update without
+// dequeuing any other update)
+func (a *Actions) runTableUpdateConsumer() {
+	gen := a.tuWatchdog.generation.Load()
+	for {
+		if a.tuWatchdog.generation.Load() != gen {
+			log.Warn().Int64("generation", gen).Msg("replaced tableUpdate consumer is exiting")
+			return
+		}
+		upd, ok := <-a.tableUpdate
+		if !ok {
+			return
+		}
+		a.tuWatchdog.busySince.Store(time.Now().UnixNano())
+		a.processTableUpdate(upd)
+		if a.tuWatchdog.generation.Load() == gen {
+			a.tuWatchdog.busySince.Store(0)
+			a.tuWatchdog.stalled.Store(false)
+		}
+	}
+}
+
+// restartTableUpdateConsumer starts a new consumer of the tableUpdate
+// channel. The stuck one will exit once (if ever) it finishes its
+// current update. Only a stalled consumer can be replaced as otherwise
+// two consumers could process updates concurrently
+// (ErrConsumerNotStalled is returned in such case).
+func (a *Actions) restartTableUpdateConsumer() error {
+	if !a.tuWatchdog.stalled.CompareAndSwap(true, false) {
+		return ErrConsumerNotStalled
+	}
+	a.tuWatchdog.generation.Add(1)
+	a.tuWatchdog.busySince.Store(0)
+	a.tuWatchdog.numRestarts.Add(1)
+	go a.runTableUpdateConsumer()
+	log.Warn().Msg("started a new tableUpdate consumer")
+	return nil
+}
+
+func (a *Actions) checkTableUpdateConsumer() {
+	busy := a.tuWatchdog.busySince.Load()
+	if busy == 0 {
+		return
+	}
+	dur := time.Since(time.Unix(0, busy))
+	if dur < time.Duration(a.conf.TableUpdateStallTimeoutSecs)*time.Second {
+		return
+	}
+	a.tuWatchdog.stalled.Store(true)
+	log.Error().
+		Float64("busySecs", dur.Seconds()).
+		Msg("tableUpdate consumer seems to be stalled, job bookkeeping is frozen")
+	if a.conf.RestartStalledTableUpdate {
+		if err := a.restartTableUpdateConsumer(); err != nil {
+			log.Error().Err(err).Msg("failed to restart tableUpdate consumer")
+		}
+	}
+}
+
+func (a *Actions) runTableUpdateWatchdog() {
+	if a.conf.TableUpdateStallTimeoutSecs <= 0 {
+		log.Warn().Msg("tableUpdate consumer watchdog disabled")
+		return
+	}
+	ticker := time.NewTicker(tableUpdateWatchdogInterval)
+	for {
+		select {
+		case <-ticker.C:
+			a.checkTableUpdateConsumer()
+		case <-a.ctx.Done():
+			ticker.Stop()
+			return
+		}
+	}
+}
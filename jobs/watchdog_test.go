@@ -0,0 +1,55 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newWatchdogTestActions(t *testing.T) *Actions {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return NewActions(
+		&Conf{StatusDataPath: filepath.Join(t.TempDir(), "jobs.gob"), MaxNumConcurrentJobs: 2},
+		"en",
+		ctx,
+		make(chan string, 10),
+	)
+}
+
+func TestRestartRefusedForHealthyConsumer(t *testing.T) {
+	a := newWatchdogTestActions(t)
+	err := a.restartTableUpdateConsumer()
+	assert.ErrorIs(t, err, ErrConsumerNotStalled)
+	assert.Equal(t, int64(0), a.tuWatchdog.numRestarts.Load())
+	assert.Equal(t, int64(0), a.tuWatchdog.generation.Load())
+}
+
+func TestRestartStalledConsumer(t *testing.T) {
+	a := newWatchdogTestActions(t)
+	a.tuWatchdog.stalled.Store(true)
+	assert.NoError(t, a.restartTableUpdateConsumer())
+	assert.Equal(t, int64(1), a.tuWatchdog.numRestarts.Load())
+	assert.Equal(t, int64(1), a.tuWatchdog.generation.Load())
+	assert.False(t, a.TableUpdateConsumerStatus().Stalled)
+	// a second restart must wait for another stall detection
+	assert.ErrorIs(t, a.restartTableUpdateConsumer(), ErrConsumerNotStalled)
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// webhookURLArg returns a webhook URL passed as the `*url` path argument
+// and validates it
+func webhookURLArg(ctx *gin.Context) (string, error) {
+	hook := strings.TrimPrefix(ctx.Param("url"), "/")
+	u, err := url.Parse(hook)
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid webhook URL %s: only absolute http(s) URLs are supported", hook)
+	}
+	return hook, nil
+}
+
+func (a *Actions) findJobForNotification(ctx *gin.Context) (GeneralJobInfo, bool) {
+	job := func() GeneralJobInfo {
+		a.jobListLock.RLock()
+		defer a.jobListLock.RUnlock()
+		return FindJob(a.jobList, ctx.Param("jobId"))
+	}()
+	if job == nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError("job not found"), http.StatusNotFound)
+		return nil, false
+	}
+	return job, true
+}
+
+// AddWebhook godoc
+// @Summary      Add a webhook called on job finish
+// @Description  Once the job finishes, a JSON POST request with the compact job info is sent to the URL. The URL must be URL-encoded.
+// @Produce      json
+// @Param        jobId path string true "Job ID"
+// @Param        url path string true "Webhook URL"
+// @Success      200 {object} any
+// @Failure      400 {object} uniresp.ActionError
+// @Failure      404 {object} uniresp.ActionError
+// @Router       /jobs/{jobId}/webhook/{url} [put]
+func (a *Actions) AddWebhook(ctx *gin.Context) {
+	job, ok := a.findJobForNotification(ctx)
+	if !ok {
+		return
+	}
+	hook, err := webhookURLArg(ctx)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusBadRequest)
+		return
+	}
+	a.notificationWebhooksLock.Lock()
+	if !slices.Contains(a.notificationWebhooks[job.GetID()], hook) {
+		a.notificationWebhooks[job.GetID()] = append(a.notificationWebhooks[job.GetID()], hook)
+	}
+	a.notificationWebhooksLock.Unlock()
+	resp := struct {
+		Registered bool `json:"registered"`
+	}{
+		Registered: true,
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, resp)
+}
+
+// GetWebhooks godoc
+// @Summary      Get webhooks called on job finish
+// @Description  Without the URL argument, all the registered webhooks are listed. Otherwise, the action checks whether the webhook is registered (404 if not).
+// @Produce      json
+// @Param        jobId path string true "Job ID"
+// @Param        url path string false "Webhook URL"
+// @Success      200 {object} any
+// @Failure      404 {object} uniresp.ActionError
+// @Router       /jobs/{jobId}/webhook/{url} [get]
+func (a *Actions) GetWebhooks(ctx *gin.Context) {
+	job, ok := a.findJobForNotification(ctx)
+	if !ok {
+		return
+	}
+	a.notificationWebhooksLock.Lock()
+	webhooks := slices.Clone(a.notificationWebhooks[job.GetID()])
+	a.notificationWebhooksLock.Unlock()
+	if strings.TrimPrefix(ctx.Param("url"), "/") == "" {
+		resp := struct {
+			Webhooks []string `json:"webhooks"`
+		}{
+			Webhooks: webhooks,
+		}
+		if resp.Webhooks == nil {
+			resp.Webhooks = []string{}
+		}
+		uniresp.WriteJSONResponse(ctx.Writer, resp)
+		return
+	}
+	hook, err := webhookURLArg(ctx)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusBadRequest)
+		return
+	}
+	resp := struct {
+		Registered bool `json:"registered"`
+	}{
+		Registered: slices.Contains(webhooks, hook),
+	}
+	if resp.Registered {
+		uniresp.WriteJSONResponse(ctx.Writer, resp)
+
+	} else {
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusNotFound, resp)
+	}
+}
+
+// RemoveWebhook godoc
+// @Summary      Remove a webhook called on job finish
+// @Produce      json
+// @Param        jobId path string true "Job ID"
+// @Param        url path string true "Webhook URL"
+// @Success      200 {object} any
+// @Failure      400 {object} uniresp.ActionError
+// @Failure      404 {object} uniresp.ActionError
+// @Router       /jobs/{jobId}/webhook/{url} [delete]
+func (a *Actions) RemoveWebhook(ctx *gin.Context) {
+	job, ok := a.findJobForNotification(ctx)
+	if !ok {
+		return
+	}
+	hook, err := webhookURLArg(ctx)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionErrorFrom(err), http.StatusBadRequest)
+		return
+	}
+	a.notificationWebhooksLock.Lock()
+	a.notificationWebhooks[job.GetID()] = slices.DeleteFunc(
+		a.notificationWebhooks[job.GetID()],
+		func(v string) bool { return v == hook },
+	)
+	a.notificationWebhooksLock.Unlock()
+	resp := struct {
+		Registered bool `json:"registered"`
+	}{
+		Registered: false,
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, resp)
+}
+
+// callWebhooks sends the job info to all the webhooks registered
+// for the job. Requests are sent in the background and any problems
+// are just logged (i.e. they do not affect the job).
+func (a *Actions) callWebhooks(jobID string, info JobInfoCompact) {
+	a.notificationWebhooksLock.Lock()
+	webhooks := a.notificationWebhooks[jobID]
+	delete(a.notificationWebhooks, jobID)
+	a.notificationWebhooksLock.Unlock()
+	if len(webhooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(info)
+	if err != nil {
+		log.Error().Err(err).Str("jobId", jobID).Msg("failed to prepare webhook payload")
+		return
+	}
+	client := &http.Client{
+		Timeout: time.Duration(a.conf.WebhookTimeoutSecs) * time.Second,
+	}
+	for _, hook := range webhooks {
+		go func(hook string) {
+			resp, err := client.Post(hook, "application/json", bytes.NewReader(body))
+			if err != nil {
+				log.Error().Err(err).Str("jobId", jobID).Str("url", hook).Msg("failed to call job webhook")
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				log.Error().
+					Str("jobId", jobID).
+					Str("url", hook).
+					Int("status", resp.StatusCode).
+					Msg("job webhook responded with an error status")
+			}
+		}(hook)
+	}
+}
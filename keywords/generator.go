@@ -201,6 +201,8 @@ func RunJob(db *mysql.Adapter, datasetID string, args KeywordsBuildArgs, jobActi
 		generateKeywordsSync(ctx, db, args, statusChan)
 		close(statusChan)
 	}
-	jobActions.EnqueueJob(&fn, &jobStatus)
+	if err := jobActions.EnqueueJob(&fn, &jobStatus); err != nil {
+		return KeywordsBuildJob{}, err
+	}
 	return jobStatus, nil
 }
@@ -113,10 +113,10 @@ func (handler *ActionHandler) ProcessKWOFWeek(ctx *gin.Context) {
 
 	job, err := RunJob(handler.laDB, dataset.Ident, args, handler.jobActions)
 	if err != nil {
-		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		uniresp.RespondWithErrorJSON(ctx, err, jobs.EnqueueErrorStatus(err))
 		return
 	}
-	uniresp.WriteJSONResponse(ctx.Writer, job)
+	jobs.WriteJobAcceptedResponse(ctx, job.ID, job)
 
 }
 
@@ -130,10 +130,10 @@ func (handler *ActionHandler) Process(ctx *gin.Context) {
 
 	job, err := RunJob(handler.laDB, datasetID, args, handler.jobActions)
 	if err != nil {
-		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+		uniresp.RespondWithErrorJSON(ctx, err, jobs.EnqueueErrorStatus(err))
 		return
 	}
-	uniresp.WriteJSONResponse(ctx.Writer, job)
+	jobs.WriteJobAcceptedResponse(ctx, job.ID, job)
 
 }
 
@@ -17,19 +17,66 @@
 package actions
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"frodo/corpus"
+	"frodo/liveattrs/db"
 	"frodo/liveattrs/db/qbuilder/laquery"
 	"frodo/liveattrs/laconf"
 	"frodo/liveattrs/request/query"
 	"frodo/liveattrs/request/response"
 	"frodo/liveattrs/utils"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/czcorpus/cnc-gokit/collections"
+	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
+// getLocaleArg returns an optional `locale` URL argument. An empty
+// string means "use corpus locale".
+func getLocaleArg(ctx *gin.Context) (string, error) {
+	locale := ctx.Query("locale")
+	if locale == "" {
+		return "", nil
+	}
+	if _, err := response.ParseCollatorLocale(locale); err != nil {
+		return "", err
+	}
+	return locale, nil
+}
+
+// transformAttrValues applies buckets, bib. items grouping and the
+// MinCount filter to collected attribute values. The totals of queried
+// attributes (see Payload.IncludeEmptyAttrs) are calculated only after
+// that so they match the returned values.
+func transformAttrValues(ans *response.QueryAns, corpusInfo *corpus.DBInfo, qry query.Payload) error {
+	for attr, buckets := range qry.Buckets {
+		if err := ans.ApplyBuckets(attr, buckets); err != nil {
+			return err
+		}
+	}
+	// now each line contains: (shortened_label, identifier, label, num_grouped_items, num_positions)
+	// where num_grouped_items is initialized to 1
+	if corpusInfo.BibGroupDuplicates > 0 {
+		groupBibItems(ans, corpusInfo.BibLabelAttr)
+	}
+	ans.FilterMinCount(qry.MinCount, qry.Buckets)
+	if qry.IncludeEmptyAttrs {
+		queriedAttrs := make([]string, 0, len(qry.Attrs))
+		for attr := range qry.Attrs {
+			queriedAttrs = append(queriedAttrs, strings.TrimPrefix(attr, "!"))
+		}
+		ans.EnsureAttrsWithTotals(queriedAttrs)
+	}
+	return nil
+}
+
 func groupBibItems(data *response.QueryAns, bibLabel string) {
 	grouping := make(map[string]*response.ListedValue)
 	entry := data.AttrValues[bibLabel]
@@ -40,14 +87,15 @@ func groupBibItems(data *response.QueryAns, bibLabel string) {
 	for _, item := range tEntry {
 		val, ok := grouping[item.Label]
 		if ok {
-			grouping[item.Label].Count += val.Count
-			grouping[item.Label].Grouping++
+			val.Count += item.Count
+			val.Grouping++
 
 		} else {
 			grouping[item.Label] = item
 		}
 		if grouping[item.Label].Grouping > 1 {
 			grouping[item.Label].ID = "@" + grouping[item.Label].Label
+			grouping[item.Label].IsGroup = true
 		}
 	}
 	data.AttrValues[bibLabel] = make([]*response.ListedValue, 0, len(grouping))
@@ -60,10 +108,212 @@ func groupBibItems(data *response.QueryAns, bibLabel string) {
 	}
 }
 
-func (a *Actions) getAttrValues(
+// logSlowQuery writes a warning in case the query took more time
+// than the configured threshold
+func (a *Actions) logSlowQuery(corpusID string, qry query.Payload, procTime time.Duration, numRows int) {
+	thr := a.conf.LA.SlowQueryThresholdSecs
+	if thr <= 0 || procTime.Seconds() < thr {
+		return
+	}
+	// note: JSON encoding of maps has sorted keys so we
+	// get the same string for the same query
+	normQuery, err := json.Marshal(qry)
+	if err != nil {
+		normQuery = []byte(fmt.Sprintf("%v", qry))
+	}
+	log.Warn().
+		Str("corpusId", corpusID).
+		RawJSON("query", normQuery).
+		Float64("procTimeSecs", procTime.Seconds()).
+		Int("numRows", numRows).
+		Msg("slow liveattrs query")
+}
+
+// resolveAlignedCorpora splits requested aligned corpora into the ones
+// sharing the parallel group (i.e. the grouped liveattrs table) with
+// the queried corpus and the ones which cannot be joined.
+// Aligned corpora can be joined only via the grouped table so the ones
+// outside the group are not used in the query (reported as ignored)
+// instead of matching no rows at all. In case an aligned corpus does not
+// exist, ErrorUnknownAlignedCorpus is returned.
+func (a *Actions) resolveAlignedCorpora(
+	corpusInfo *corpus.DBInfo,
+	aligned []string,
+) (resolved []string, ignored []string, err error) {
+	resolved = make([]string, 0, len(aligned))
+	for _, alignedCorp := range aligned {
+		alignedGroup, err := a.groupedNames.Get(alignedCorp)
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("%w: %s", ErrorUnknownAlignedCorpus, alignedCorp)
+
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve aligned corpus %s: %w", alignedCorp, err)
+		}
+		if corpusInfo.ParallelCorpus == "" || alignedGroup != corpusInfo.GroupedName() {
+			ignored = append(ignored, alignedCorp)
+			continue
+		}
+		resolved = append(resolved, alignedCorp)
+	}
+	if len(ignored) > 0 {
+		log.Warn().
+			Str("corpusId", corpusInfo.Name).
+			Strs("ignoredAligned", ignored).
+			Msg("some aligned corpora are not part of the parallel group, ignoring")
+	}
+	return
+}
+
+// qualifyAlignedAttr works like utils.QualifyAttr but it also accepts
+// attributes prefixed by an aligned corpus ID (the prefix is preserved).
+// Aligned corpora are expected to share attributes with the queried corpus.
+func qualifyAlignedAttr(attr string, known []string) (string, error) {
+	alignedCorpus, name := utils.SplitAlignedAttr(attr)
+	qa, err := utils.QualifyAttr(name, known)
+	if err != nil || alignedCorpus == "" {
+		return qa, err
+	}
+	return alignedCorpus + utils.AlignedAttrSeparator + qa, nil
+}
+
+// validateAlignedAttrs tests attributes prefixed by an aligned corpus ID.
+// Such attributes are supported only as the autocomplete attribute and
+// the respective corpus must be listed among the aligned ones. Otherwise,
+// utils.ErrorUnknownAttr is returned.
+func validateAlignedAttrs(qry query.Payload) error {
+	for attr := range qry.Attrs {
+		if alignedCorpus, _ := utils.SplitAlignedAttr(attr); alignedCorpus != "" &&
+			attr != qry.AutocompleteAttr {
+			return fmt.Errorf(
+				"%w %s (attributes of aligned corpora can be used only for autocomplete)",
+				utils.ErrorUnknownAttr, attr)
+		}
+	}
+	alignedCorpus, _ := utils.SplitAlignedAttr(qry.AutocompleteAttr)
+	if alignedCorpus != "" && !collections.SliceContains(qry.Aligned, alignedCorpus) {
+		return fmt.Errorf(
+			"%w %s (corpus %s not among aligned corpora)",
+			utils.ErrorUnknownAttr, qry.AutocompleteAttr, alignedCorpus)
+	}
+	return nil
+}
+
+// qualifyQueryAttrs returns a copy of the query with all the attributes
+// qualified by their structures (e.g. `author` => `doc.author`) so they
+// cannot be confused with same-named attributes of other structures.
+// Ambiguous and unknown unqualified attributes as well as misused attributes
+// of aligned corpora (see validateAlignedAttrs) produce an error.
+func qualifyQueryAttrs(qry query.Payload, known []string) (query.Payload, error) {
+	if err := validateAlignedAttrs(qry); err != nil {
+		return qry, err
+	}
+	ans := qry
+	ans.Attrs = make(query.Attrs, len(qry.Attrs))
+	for k, v := range qry.Attrs {
+		qk, err := qualifyAlignedAttr(k, known)
+		if err != nil {
+			return ans, err
+		}
+		ans.Attrs[qk] = v
+	}
+	if qry.AutocompleteAttr != "" {
+		qa, err := qualifyAlignedAttr(qry.AutocompleteAttr, known)
+		if err != nil {
+			return ans, err
+		}
+		ans.AutocompleteAttr = qa
+	}
+	if qry.Buckets != nil {
+		ans.Buckets = make(query.Buckets, len(qry.Buckets))
+		for k, v := range qry.Buckets {
+			qk, err := utils.QualifyAttr(k, known)
+			if err != nil {
+				return ans, err
+			}
+			ans.Buckets[qk] = v
+		}
+	}
+	if qry.OrGroups != nil {
+		ans.OrGroups = make(query.OrGroups, len(qry.OrGroups))
+		for i, group := range qry.OrGroups {
+			ans.OrGroups[i] = make([]string, len(group))
+			for j, k := range group {
+				qk, err := utils.QualifyAttr(k, known)
+				if err != nil {
+					return ans, err
+				}
+				ans.OrGroups[i][j] = qk
+			}
+		}
+	}
+	if qry.Sort != nil {
+		ans.Sort = make(query.SortSpec, len(qry.Sort))
+		for k, v := range qry.Sort {
+			qk, err := utils.QualifyAttr(k, known)
+			if err != nil {
+				return ans, err
+			}
+			ans.Sort[qk] = v
+		}
+	}
+	return ans, nil
+}
+
+// sqlRowLimit returns a maximum number of database rows to be read
+// while processing the query. The limit applies only to plain listings
+// (with cutoff applied) where the client accepts approximate counts.
+// Otherwise, zero ("no limit") is returned.
+func (a *Actions) sqlRowLimit(qry query.Payload, raw bool) int {
+	if raw || !qry.ApproxCounts || !qry.ApplyCutoff || len(qry.Buckets) > 0 ||
+		qry.MinCount > 0 || qry.IncludeEmptyAttrs {
+		return 0
+	}
+	return a.conf.LA.ApproxQueryRowLimit
+}
+
+// collectedAttrValues contains attribute values accumulated
+// from liveattrs database before any export transformations
+type collectedAttrValues struct {
+	ans         response.QueryAns
+	expandAttrs *collections.Set[string]
+
+	// qry is the original query with structure-qualified attributes
+	qry query.Payload
+
+	// numRows is the number of processed database rows
+	numRows int
+
+	// values contains {attr_id: {attr_val: listed_value,...},...}
+	values map[string]map[string]*response.ListedValue
+
+	// nilColumns contains numbers of NULL values per database column
+	nilColumns map[string]int
+}
+
+// preparedQuery contains a liveattrs query filter along with
+// additional information derived from the original query
+type preparedQuery struct {
+	filter *laquery.LAFilter
+
+	// qry is the original query with structure-qualified attributes
+	qry query.Payload
+
+	// expandAttrs contains attributes which must be always listed
+	// (autocomplete, regexp and interval attributes, buckets)
+	expandAttrs *collections.Set[string]
+
+	ignoredAligned []string
+}
+
+// prepareQuery qualifies query attributes, determines attributes
+// to be listed and creates a filter for iterating over matching
+// liveattrs rows. The sqlLimit argument specifies max. number
+// of rows to be read (0 = no limit).
+func (a *Actions) prepareQuery(
 	corpusInfo *corpus.DBInfo,
 	qry query.Payload,
-) (*response.QueryAns, error) {
+	sqlLimit int,
+) (*preparedQuery, error) {
 
 	laConf, err := a.laConfCache.Get(corpusInfo.Name) // set(self._get_subcorp_attrs(corpus))
 	if err != nil {
@@ -74,11 +324,14 @@ func (a *Actions) getAttrValues(
 	if corpusInfo.BibLabelAttr != "" {
 		srchAttrs.Add(corpusInfo.BibLabelAttr)
 	}
+	qry, err = qualifyQueryAttrs(qry, append(srchAttrs.ToOrderedSlice(), corpusInfo.BibIDAttr))
+	if err != nil {
+		return nil, err
+	}
 	// if in autocomplete mode then always expand list of the target column
 	if qry.AutocompleteAttr != "" {
-		a := utils.ImportKey(qry.AutocompleteAttr)
-		srchAttrs.Add(a)
-		expandAttrs.Add(a)
+		srchAttrs.Add(qry.AutocompleteAttr)
+		expandAttrs.Add(qry.AutocompleteAttr)
 		acVals, err := qry.Attrs.GetListingOf(qry.AutocompleteAttr)
 		if err != nil {
 			return nil, err
@@ -88,25 +341,89 @@ func (a *Actions) getAttrValues(
 	// also make sure that range attributes are expanded to full lists
 	for attr := range qry.Attrs {
 		if _, air := qry.Attrs.GetRegexpAttrVal(attr); air {
-			expandAttrs.Add(utils.ImportKey(attr))
+			expandAttrs.Add(strings.TrimPrefix(attr, "!"))
+		}
+		if _, isInterval, err := qry.Attrs.GetIntervalAttrVal(attr); err != nil {
+			return nil, err
+
+		} else if isInterval {
+			numeric, err := db.IsNumericAttr(a.laDB.DB(), corpusInfo, strings.TrimPrefix(attr, "!"))
+			if err != nil {
+				return nil, err
+			}
+			if !numeric {
+				return nil, fmt.Errorf(
+					"cannot search attribute %s by interval: %w", strings.TrimPrefix(attr, "!"), utils.ErrorNonNumericAttr)
+			}
+			expandAttrs.Add(strings.TrimPrefix(attr, "!"))
+		}
+	}
+	// buckets are checked before the (possibly expensive) query is run
+	// so a non-numeric attribute is reported as a client error
+	for attr := range qry.Buckets {
+		numeric, err := db.IsNumericAttr(a.laDB.DB(), corpusInfo, attr)
+		if err != nil {
+			return nil, err
+		}
+		if !numeric {
+			return nil, fmt.Errorf(
+				"cannot apply buckets to attribute %s: %w", attr, utils.ErrorNonNumericAttr)
 		}
 	}
+	alignedCorpora, ignoredAligned, err := a.resolveAlignedCorpora(corpusInfo, qry.Aligned)
+	if err != nil {
+		return nil, err
+	}
 	qBuilder := &laquery.LAFilter{
 		CorpusInfo:          corpusInfo,
 		AttrMap:             qry.Attrs,
 		SearchAttrs:         srchAttrs.ToOrderedSlice(),
-		AlignedCorpora:      qry.Aligned,
+		AlignedCorpora:      alignedCorpora,
 		AutocompleteAttr:    qry.AutocompleteAttr,
-		EmptyValPlaceholder: emptyValuePlaceholder,
+		EmptyValPlaceholder: a.conf.LA.GetEmptyValuePlaceholder(),
+		OrGroups:            qry.OrGroups,
+		SQLLimit:            sqlLimit,
 	}
+	return &preparedQuery{
+		filter:         qBuilder,
+		qry:            qry,
+		expandAttrs:    expandAttrs,
+		ignoredAligned: ignoredAligned,
+	}, nil
+}
+
+// collectAttrValues queries liveattrs database for attribute values
+// matching provided query and accumulates their counts. In the raw mode,
+// attributes exceeding the distinct values limit are always switched
+// to the count-only mode (i.e. no ErrorTooManyDistinctValues is returned)
+// and the limit is always applied (see dfltMaxRawAttrValues).
+// Cancelling ctx aborts the database query.
+func (a *Actions) collectAttrValues(
+	ctx context.Context,
+	corpusInfo *corpus.DBInfo,
+	qry query.Payload,
+	raw bool,
+) (*collectedAttrValues, error) {
+
+	prepared, err := a.prepareQuery(corpusInfo, qry, a.sqlRowLimit(qry, raw))
+	if err != nil {
+		return nil, err
+	}
+	qry = prepared.qry
+	qBuilder := prepared.filter
+	expandAttrs := prepared.expandAttrs
+	ignoredAligned := prepared.ignoredAligned
 	dataIterator := laquery.DataIterator{
 		DB:      a.laDB.DB(),
 		Builder: qBuilder,
 	}
 
 	ans := response.QueryAns{
-		Poscount:   0,
-		AttrValues: make(map[string]any),
+		Poscount:              0,
+		AttrValues:            make(map[string]any),
+		AlignedCorpora:        qBuilder.AlignedCorpora,
+		JoinStrategy:          qBuilder.JoinStrategy(),
+		IgnoredAlignedCorpora: ignoredAligned,
 	}
 
 	for _, sattr := range qBuilder.SearchAttrs {
@@ -119,11 +436,22 @@ func (a *Actions) getAttrValues(
 	// {attr_id: {attr_val: num_positions,...},...}
 	tmpAns := make(map[string]map[string]*response.ListedValue)
 	bibID := utils.ImportKey(qBuilder.CorpusInfo.BibIDAttr)
+	colAttrs := qBuilder.ColumnAttrs()
 	nilCol := make(map[string]int)
-	err = dataIterator.Iterate(func(row laquery.ResultRow) error {
+	maxDistinct := a.conf.LA.MaxAttrDistinctValues
+	if raw && (maxDistinct <= 0 || maxDistinct > dfltMaxRawAttrValues) {
+		maxDistinct = dfltMaxRawAttrValues
+	}
+	var numRows int
+	t0 := time.Now()
+	err = dataIterator.Iterate(ctx, func(row laquery.ResultRow) error {
+		numRows++
 		ans.Poscount += row.Poscount
 		for dbKey, dbVal := range row.Attrs {
-			colKey := utils.ExportKey(dbKey)
+			colKey, ok := colAttrs[dbKey]
+			if !ok {
+				colKey = utils.ExportKey(dbKey)
+			}
 			switch tColVal := ans.AttrValues[colKey].(type) {
 			case []*response.ListedValue:
 				var valIdent string
@@ -135,7 +463,7 @@ func (a *Actions) getAttrValues(
 				}
 				attrVal := response.ListedValue{
 					ID:         valIdent,
-					ShortLabel: utils.ShortenVal(dbVal, shortLabelMaxLength),
+					ShortLabel: utils.ShortenVal(dbVal, a.conf.LA.GetShortLabelMaxLength()),
 					Label:      dbVal,
 					Grouping:   1,
 				}
@@ -150,6 +478,28 @@ func (a *Actions) getAttrValues(
 				} else {
 					attrVal.Count = row.Poscount
 					tmpAns[colKey][attrVal.ID] = &attrVal
+					_, bucketed := qry.Buckets[colKey]
+					// bucketed attributes must keep all their values as they
+					// are collapsed into the buckets once collected
+					if maxDistinct > 0 && len(tmpAns[colKey]) > maxDistinct && (raw || !bucketed) {
+						if !raw && expandAttrs.Contains(colKey) {
+							return fmt.Errorf(
+								"%w (attribute %s, limit %d)", ErrorTooManyDistinctValues, colKey, maxDistinct)
+						}
+						// we switch the attribute to the count-only mode
+						// to prevent further accumulation of its values
+						var total int
+						for _, v := range tmpAns[colKey] {
+							total += v.Count
+						}
+						ans.AttrValues[colKey] = total
+						delete(tmpAns, colKey)
+						log.Warn().
+							Str("corpusId", corpusInfo.Name).
+							Str("attr", colKey).
+							Int("limit", maxDistinct).
+							Msg("attribute exceeded max. number of distinct values, switching to count-only mode")
+					}
 				}
 			case int:
 				ans.AttrValues[colKey] = tColVal + row.Poscount
@@ -164,42 +514,151 @@ func (a *Actions) getAttrValues(
 		}
 		return nil
 	})
+	a.logSlowQuery(corpusInfo.Name, qry, time.Since(t0), numRows)
+	if qBuilder.SQLLimit > 0 && numRows >= qBuilder.SQLLimit {
+		ans.Approximate = true
+	}
+	if err == nil && qry.ExactPoscount {
+		ans.Poscount, err = dataIterator.TotalPoscount(ctx)
+	}
+	if errors.Is(err, context.Canceled) {
+		log.Info().
+			Str("corpusId", corpusInfo.Name).
+			Int("processedRows", numRows).
+			Msg("liveAttributes data iteration cancelled")
+
+	} else if err != nil && !errors.Is(err, ErrorTooManyDistinctValues) {
+		log.Error().
+			Err(err).
+			Str("corpusId", corpusInfo.Name).
+			Int("processedRows", numRows).
+			Msg("liveAttributes failed to iterate over data")
+	}
 	for k, num := range nilCol {
 		log.Error().
 			Str("column", k).
 			Int("occurrences", num).
 			Msgf("liveAttributes getAttrValues encountered nil column")
 	}
+	return &collectedAttrValues{
+		ans:         ans,
+		expandAttrs: expandAttrs,
+		qry:         qry,
+		numRows:     numRows,
+		values:      tmpAns,
+		nilColumns:  nilCol,
+	}, err
+}
+
+// getRawAttrValues queries liveattrs database for attribute values
+// matching provided query and returns their accumulated counts without
+// any export transformations (no bib. items grouping, no cutoff, no buckets).
+func (a *Actions) getRawAttrValues(
+	ctx context.Context,
+	corpusInfo *corpus.DBInfo,
+	qry query.Payload,
+) (*response.RawQueryAns, error) {
+	collected, err := a.collectAttrValues(ctx, corpusInfo, qry, true)
+	if err != nil {
+		return nil, err
+	}
+	ans := response.RawQueryAns{
+		Poscount:   collected.ans.Poscount,
+		AttrValues: make(map[string]map[string]int),
+		AttrTotals: make(map[string]int),
+	}
+	for attr, v := range collected.ans.AttrValues {
+		if total, ok := v.(int); ok {
+			ans.AttrTotals[attr] = total
+		}
+	}
+	for attr, v := range collected.values {
+		ans.AttrValues[attr] = make(map[string]int, len(v))
+		for id, item := range v {
+			ans.AttrValues[attr][id] = item.Count
+		}
+	}
+	return &ans, nil
+}
+
+// canBePartial tests whether an error produced while collecting
+// values still allows for returning values accumulated so far.
+// This is true for errors occurring during data iteration
+// (i.e. not for exceeded limits or cancelled requests).
+func (cv *collectedAttrValues) canBePartial(err error) bool {
+	return cv.numRows > 0 && !errors.Is(err, ErrorTooManyDistinctValues) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// getAttrValues queries liveattrs database for attribute values
+// matching provided query. The collatorLocale argument, if non-empty,
+// overrides corpus locale when sorting exported values.
+// In case allowPartial is true and reading of the data fails
+// in the middle, values accumulated so far are returned
+// and the answer is marked as partial.
+func (a *Actions) getAttrValues(
+	ctx context.Context,
+	corpusInfo *corpus.DBInfo,
+	qry query.Payload,
+	collatorLocale string,
+	allowPartial bool,
+) (*response.QueryAns, error) {
+	collected, err := a.collectAttrValues(ctx, corpusInfo, qry, false)
+	if collected == nil {
+		return nil, err
+	}
+	ans := collected.ans
 	if err != nil {
-		return &ans, err
+		if !allowPartial || !collected.canBePartial(err) {
+			return &ans, err
+		}
+		log.Warn().
+			Str("corpusId", corpusInfo.Name).
+			Int("processedRows", collected.numRows).
+			Msg("returning partial liveattrs result")
+		ans.Partial = true
+	}
+	if len(collected.nilColumns) > 0 {
+		ans.NilColumns = collected.nilColumns
 	}
-	for attr, v := range tmpAns {
+	qry = collected.qry
+	for attr, v := range collected.values {
 		for _, c := range v {
 			if err := ans.AddListedValue(attr, c); err != nil {
 				return nil, fmt.Errorf("failed to execute getAttrValues(): %w", err)
 			}
 		}
 	}
-	// now each line contains: (shortened_label, identifier, label, num_grouped_items, num_positions)
-	// where num_grouped_items is initialized to 1
-	if corpusInfo.BibGroupDuplicates > 0 {
-		groupBibItems(&ans, corpusInfo.BibLabelAttr)
+	ans.RecordDistinctCounts()
+	if collatorLocale == "" {
+		collatorLocale = corpusInfo.Locale
+	}
+	if err := transformAttrValues(&ans, corpusInfo, qry); err != nil {
+		return nil, err
 	}
 	maxAttrListSize := qry.MaxAttrListSize
 	if maxAttrListSize == 0 {
-		maxAttrListSize = dfltMaxAttrListSize
+		maxAttrListSize = a.conf.LA.GetMaxAttrListSize()
 	}
 
+	dfltSort, err := query.ParseSortBy(qry.SortBy)
+	if err != nil {
+		return nil, err
+	}
 	if qry.ApplyCutoff {
+		// the values must be sorted first so the cutoff keeps the top ones
+		ans.SortValues(collatorLocale, qry.Sort, dfltSort)
 		ans.CutoffValues(maxAttrListSize)
 	}
 
 	response.ExportAttrValues(
 		&ans,
-		qBuilder.AlignedCorpora,
-		expandAttrs.ToOrderedSlice(),
-		corpusInfo.Locale,
+		ans.AlignedCorpora,
+		collected.expandAttrs.ToOrderedSlice(),
+		collatorLocale,
 		maxAttrListSize,
+		qry.Sort,
+		dfltSort,
 	)
 	return &ans, nil
 }
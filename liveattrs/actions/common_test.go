@@ -0,0 +1,332 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"frodo/corpus"
+	"frodo/db/mysql"
+	"frodo/liveattrs"
+	"frodo/liveattrs/laconf"
+	"frodo/liveattrs/request/query"
+	"frodo/liveattrs/request/response"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/mquery-common/corp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingCorpusMeta struct {
+	data     map[string]*corpus.DBInfo
+	numLoads int
+}
+
+func (cm *countingCorpusMeta) LoadInfo(corpusID string) (*corpus.DBInfo, error) {
+	cm.numLoads++
+	v, ok := cm.data[corpusID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return v, nil
+}
+
+func (cm *countingCorpusMeta) GetCorpusTagsets(corpusID string) ([]corp.SupportedTagset, error) {
+	return []corp.SupportedTagset{}, nil
+}
+
+func (cm *countingCorpusMeta) LoadAliasedInfo(corpusID, aliasOf string) (*corpus.DBInfo, error) {
+	return cm.LoadInfo(corpusID)
+}
+
+func newAlignedTestActions() (*Actions, *countingCorpusMeta) {
+	cm := &countingCorpusMeta{
+		data: map[string]*corpus.DBInfo{
+			"intercorp_cs": {Name: "intercorp_cs", ParallelCorpus: "intercorp"},
+			"intercorp_en": {Name: "intercorp_en", ParallelCorpus: "intercorp"},
+			"europarl_en":  {Name: "europarl_en", ParallelCorpus: "europarl"},
+			"syn2020":      {Name: "syn2020"},
+		},
+	}
+	return &Actions{corpusMeta: cm, groupedNames: newGroupedNameCache(cm, time.Hour)}, cm
+}
+
+func TestResolveAlignedCorporaSameGroup(t *testing.T) {
+	a, cm := newAlignedTestActions()
+	resolved, ignored, err := a.resolveAlignedCorpora(
+		cm.data["intercorp_cs"], []string{"intercorp_en", "europarl_en"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"intercorp_en"}, resolved)
+	// a corpus from another parallel group cannot be joined
+	assert.Equal(t, []string{"europarl_en"}, ignored)
+}
+
+func TestResolveAlignedCorporaNonParallelCorpus(t *testing.T) {
+	a, cm := newAlignedTestActions()
+	resolved, ignored, err := a.resolveAlignedCorpora(cm.data["syn2020"], []string{"intercorp_en"})
+	assert.NoError(t, err)
+	assert.Empty(t, resolved)
+	assert.Equal(t, []string{"intercorp_en"}, ignored)
+}
+
+func TestResolveAlignedCorporaUnknownCorpus(t *testing.T) {
+	a, cm := newAlignedTestActions()
+	_, _, err := a.resolveAlignedCorpora(cm.data["intercorp_cs"], []string{"intercorp_de"})
+	assert.ErrorIs(t, err, ErrorUnknownAlignedCorpus)
+}
+
+func TestResolveAlignedCorporaCachesGroups(t *testing.T) {
+	a, cm := newAlignedTestActions()
+	for i := 0; i < 3; i++ {
+		resolved, _, err := a.resolveAlignedCorpora(cm.data["intercorp_cs"], []string{"intercorp_en"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"intercorp_en"}, resolved)
+	}
+	assert.Equal(t, 1, cm.numLoads)
+}
+
+// laEntryDriver is a fake database driver returning fixed liveattrs
+// entries (poscount, id, doc_genre, doc_id) for any listing query
+// and their total poscount for an aggregate query. All the queries
+// are recorded.
+type laEntryDriver struct {
+	entries [][]driver.Value
+	queries []string
+}
+
+func (d *laEntryDriver) Open(name string) (driver.Conn, error) {
+	return &laEntryConn{drv: d}, nil
+}
+
+func (d *laEntryDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *laEntryDriver) Driver() driver.Driver {
+	return d
+}
+
+type laEntryConn struct {
+	drv *laEntryDriver
+}
+
+func (c *laEntryConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements not supported")
+}
+
+func (c *laEntryConn) Close() error {
+	return nil
+}
+
+func (c *laEntryConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *laEntryConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	c.drv.queries = append(c.drv.queries, query)
+	if strings.HasPrefix(query, "SELECT SUM(tc.poscount)") {
+		var total int64
+		for _, e := range c.drv.entries {
+			total += e[0].(int64)
+		}
+		return &laEntryRows{cols: []string{"total"}, data: [][]driver.Value{{total}}}, nil
+	}
+	ans := &laEntryRows{
+		cols: []string{"poscount", "id", "doc_genre", "doc_id"},
+		data: c.drv.entries,
+	}
+	if strings.Contains(query, "LIMIT ?") {
+		if limit := int(args[len(args)-1].Value.(int64)); limit < len(ans.data) {
+			ans.data = ans.data[:limit]
+		}
+	}
+	return ans, nil
+}
+
+type laEntryRows struct {
+	cols []string
+	data [][]driver.Value
+	pos  int
+}
+
+func (r *laEntryRows) Columns() []string {
+	return r.cols
+}
+
+func (r *laEntryRows) Close() error {
+	return nil
+}
+
+func (r *laEntryRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.pos])
+	r.pos++
+	return nil
+}
+
+// newLAEntryTestActions creates Actions with the `susanne` corpus
+// configured and a liveattrs database containing the provided entries
+func newLAEntryTestActions(t *testing.T, laConf *liveattrs.Conf, entries [][]driver.Value) (*Actions, *laEntryDriver) {
+	confDir := t.TempDir()
+	err := os.WriteFile(
+		filepath.Join(confDir, "susanne.json"),
+		[]byte(`{"corpus": "susanne", "structures": {"doc": ["genre", "id"]}}`),
+		0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drv := &laEntryDriver{entries: entries}
+	db := sql.OpenDB(drv)
+	t.Cleanup(func() { db.Close() })
+	return &Actions{
+		conf:        LAConf{LA: laConf},
+		laConfCache: laconf.NewLiveAttrsBuildConfProvider(confDir, nil, nil),
+		laDB:        mysql.NewAdapter(db, "test"),
+	}, drv
+}
+
+func TestCollectAttrValuesExactPoscount(t *testing.T) {
+	entries := [][]driver.Value{
+		{int64(10), int64(1), "fiction", "d1"},
+		{int64(20), int64(2), "poetry", "d2"},
+		{int64(30), int64(3), "fiction", "d3"},
+	}
+	corpusInfo := &corpus.DBInfo{Name: "susanne", BibIDAttr: "doc.id"}
+	qry := query.Payload{
+		Attrs:         query.Attrs{},
+		ExactPoscount: true,
+	}
+
+	// no row limit
+	a, drv := newLAEntryTestActions(t, &liveattrs.Conf{}, entries)
+	collected, err := a.collectAttrValues(context.Background(), corpusInfo, qry, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 60, collected.ans.Poscount)
+	assert.False(t, collected.ans.Approximate)
+	assert.Len(t, drv.queries, 2)
+
+	// row limit reached
+	a, drv = newLAEntryTestActions(t, &liveattrs.Conf{ApproxQueryRowLimit: 2}, entries)
+	qry.ApplyCutoff = true
+	qry.ApproxCounts = true
+	collected, err = a.collectAttrValues(context.Background(), corpusInfo, qry, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, collected.numRows)
+	assert.Equal(t, 60, collected.ans.Poscount)
+	assert.True(t, collected.ans.Approximate)
+	assert.Len(t, drv.queries, 2)
+
+	// no exact poscount
+	a, drv = newLAEntryTestActions(t, &liveattrs.Conf{ApproxQueryRowLimit: 2}, entries)
+	qry.ExactPoscount = false
+	collected, err = a.collectAttrValues(context.Background(), corpusInfo, qry, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, collected.ans.Poscount)
+	assert.Len(t, drv.queries, 1)
+}
+
+func TestTransformAttrValuesTotalsAfterMinCount(t *testing.T) {
+	ans := response.QueryAns{
+		AttrValues: map[string]any{
+			"doc.genre": []*response.ListedValue{
+				{ID: "fiction", Label: "fiction", Count: 100},
+				{ID: "poetry", Label: "poetry", Count: 3},
+			},
+			"doc.title": []*response.ListedValue{
+				{ID: "t1", Label: "Title", Count: 4},
+				{ID: "t2", Label: "Title", Count: 8},
+				{ID: "t3", Label: "Other", Count: 2},
+			},
+		},
+	}
+	qry := query.Payload{
+		Attrs:             query.Attrs{"doc.genre": []any{"fiction", "poetry"}, "doc.title": []any{"Title"}, "doc.author": "x"},
+		MinCount:          10,
+		IncludeEmptyAttrs: true,
+	}
+	err := transformAttrValues(
+		&ans,
+		&corpus.DBInfo{BibGroupDuplicates: 1, BibLabelAttr: "doc.title"},
+		qry,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, ans.AttrTotals["doc.genre"])
+	// grouped "Title" items (4 + 8) pass the MinCount filter, "Other" does not
+	assert.Equal(t, 12, ans.AttrTotals["doc.title"])
+	assert.Equal(t, 0, ans.AttrTotals["doc.author"])
+	assert.Len(t, ans.AttrValues["doc.title"], 1)
+}
+
+func TestGetLocaleArg(t *testing.T) {
+	mkCtx := func(url string) *gin.Context {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodPost, url, nil)
+		return ctx
+	}
+	locale, err := getLocaleArg(mkCtx("/liveAttributes/susanne/query"))
+	assert.NoError(t, err)
+	assert.Equal(t, "", locale)
+
+	locale, err = getLocaleArg(mkCtx("/liveAttributes/susanne/query?locale=cs_CZ"))
+	assert.NoError(t, err)
+	assert.Equal(t, "cs_CZ", locale)
+
+	_, err = getLocaleArg(mkCtx("/liveAttributes/susanne/query?locale=c$"))
+	assert.Error(t, err)
+}
+
+func TestGetAttrValuesLocaleOverride(t *testing.T) {
+	entries := [][]driver.Value{
+		{int64(10), int64(1), "hrad", "d1"},
+		{int64(20), int64(2), "chata", "d2"},
+		{int64(30), int64(3), "cukr", "d3"},
+	}
+	corpusInfo := &corpus.DBInfo{Name: "susanne", BibIDAttr: "doc.id", Locale: "en_US"}
+	labels := func(ans *response.QueryAns) []string {
+		ans2 := make([]string, 0, 3)
+		for _, v := range ans.AttrValues["doc.genre"].([]*response.ListedValue) {
+			ans2 = append(ans2, v.Label)
+		}
+		return ans2
+	}
+
+	a, _ := newLAEntryTestActions(t, &liveattrs.Conf{}, entries)
+	ans, err := a.getAttrValues(context.Background(), corpusInfo, query.Payload{Attrs: query.Attrs{}}, "", false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chata", "cukr", "hrad"}, labels(ans))
+
+	ans, err = a.getAttrValues(context.Background(), corpusInfo, query.Payload{Attrs: query.Attrs{}}, "cs_CZ", false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cukr", "hrad", "chata"}, labels(ans))
+}
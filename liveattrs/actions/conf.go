@@ -18,12 +18,14 @@ package actions
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"frodo/corpus"
 	"frodo/liveattrs/laconf"
+	"frodo/middleware"
 	"io"
 	"net/http"
-	"path/filepath"
+	"strconv"
 
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/czcorpus/mquery-common/corp"
@@ -33,12 +35,21 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	confVersionHeader = "X-Config-Version"
+)
+
 func (a *Actions) getPatchArgs(req *http.Request) (*laconf.PatchArgs, error) {
 	var jsonArgs laconf.PatchArgs
 	err := json.NewDecoder(req.Body).Decode(&jsonArgs)
 	if err == io.EOF {
 		err = nil
 	}
+	a.fillMissingPatchArgs(&jsonArgs)
+	return &jsonArgs, err
+}
+
+func (a *Actions) fillMissingPatchArgs(jsonArgs *laconf.PatchArgs) {
 	if jsonArgs.GetTagsetAttr() == "" {
 		ta := "tag"
 		log.Warn().Str("value", ta).Msg("filling missing value of tagsetAttr in patchArgs")
@@ -49,60 +60,82 @@ func (a *Actions) getPatchArgs(req *http.Request) (*laconf.PatchArgs, error) {
 		log.Warn().Str("value", tn.String()).Msg("filling missing value of tagsetName in patchArgs")
 		jsonArgs.TagsetName = &tn
 	}
-	return &jsonArgs, err
 }
 
 // createConf creates a data extraction configuration
 // (for vert-tagextract library) based on provided corpus
 // (= effectively a vertical file) and request data
 // (where it expects JSON version of liveattrsJsonArgs).
+// Along with the configuration, all the defined bib views
+// are returned (see laconf.CreateWithBibViews).
 func (a *Actions) createConf(
 	corpusID string,
 	aliasOf string,
 	jsonArgs *laconf.PatchArgs,
-) (*vteCnf.VTEConf, error) {
+) (*vteCnf.VTEConf, []laconf.NamedBibView, error) {
 	srcCorpusID := corpusID
 	if aliasOf != "" {
 		srcCorpusID = aliasOf
 	}
 	corpusInfo, err := corpus.GetCorpusInfo(srcCorpusID, a.conf.Corp, false)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	corpusDBInfo, err := a.corpusMeta.LoadInfo(srcCorpusID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if aliasOf != "" { // working with an alias => let's use it for config name
 		corpusInfo.ID = corpusID
 	}
 
-	conf, err := laconf.Create(
+	conf, bibViews, err := laconf.CreateWithBibViews(
 		a.conf.LA,
 		corpusInfo,
 		corpusDBInfo,
 		jsonArgs,
 	)
 	if err != nil {
-		return conf, err
+		return conf, bibViews, err
 	}
 
 	err = a.applyPatchArgs(conf, jsonArgs)
 	if err != nil {
-		return conf, fmt.Errorf("failed to create conf: %w", err)
+		return conf, bibViews, fmt.Errorf("failed to create conf: %w", err)
 	}
 
 	err = a.ensureVerticalFile(conf, corpusInfo)
 	if err != nil {
-		return conf, fmt.Errorf("failed to create conf: %w", err)
+		return conf, bibViews, fmt.Errorf("failed to create conf: %w", err)
 	}
-	return conf, err
+	return conf, bibViews, err
+}
+
+// createConfErrorStatus maps an error returned by createConf
+// to a respective HTTP status. Unclassified errors (e.g. an unknown
+// corpus) are considered to be client errors.
+func createConfErrorStatus(err error) int {
+	if err == ErrorMissingVertical {
+		return http.StatusConflict
+
+	} else if laconf.IsInvalidArgsError(err) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+// saveConf saves a new configuration along with its bib views
+func (a *Actions) saveConf(conf *vteCnf.VTEConf, bibViews []laconf.NamedBibView) error {
+	if err := a.laConfCache.Save(conf); err != nil {
+		return err
+	}
+	return a.laConfCache.SaveBibViews(conf.Corpus, bibViews)
 }
 
 // ViewConf		 godoc
 // @Summary      ViewConf shows actual liveattrs processing configuration
-// @Description  ViewConf shows actual liveattrs processing configuration. Note: passwords are replaced with multiple asterisk characters.
+// @Description  ViewConf shows actual liveattrs processing configuration. Note: passwords are replaced with multiple asterisk characters. The current config version is provided via the X-Config-Version header.
 // @Produce      json
 // @Param        corpusId path string true "Used corpus"
 // @Param 		 noCache query int false "Get uncached data" default(0)
@@ -127,9 +160,97 @@ func (a *Actions) ViewConf(ctx *gin.Context) {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
 	}
+	ctx.Header(confVersionHeader, strconv.Itoa(a.laConfCache.Version(corpusID)))
 	uniresp.WriteJSONResponse(ctx.Writer, conf)
 }
 
+// ViewConfYAML godoc
+// @Summary      ViewConfYAML shows actual liveattrs processing configuration in YAML format
+// @Description  The YAML keys match the JSON ones so the output can be used as a base for a hand-edited corpname.yaml configuration. Note: passwords are replaced with multiple asterisk characters.
+// @Produce      application/yaml
+// @Param        corpusId path string true "Used corpus"
+// @Success      200 {string} string
+// @Router       /liveAttributes/{corpusId}/conf/yaml [get]
+func (a *Actions) ViewConfYAML(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to get liveattrs conf for %s: %w"
+	ans, err := a.laConfCache.GetAsYAML(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	ctx.Header("Content-Type", "application/yaml")
+	ctx.Header(confVersionHeader, strconv.Itoa(a.laConfCache.Version(corpusID)))
+	ctx.Writer.Write(ans)
+}
+
+// ConfVersion godoc
+// @Summary      ConfVersion shows the current version of a liveattrs processing configuration
+// @Description  The version changes each time the configuration is saved or cleared. Clients caching the configuration can poll this lightweight endpoint and re-fetch the whole configuration only in case the version changes. Note: the versions are kept in memory only so they are reset by a service restart.
+// @Produce      json
+// @Param        corpusId path string true "Used corpus"
+// @Success      200 {object} map[string]int
+// @Router       /liveAttributes/{corpusId}/conf/version [get]
+func (a *Actions) ConfVersion(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]int{"version": a.laConfCache.Version(corpusID)})
+}
+
+// ConfValidationResult is a response of ValidateConf
+type ConfValidationResult struct {
+	Valid  bool                    `json:"valid"`
+	Errors laconf.ValidationErrors `json:"errors"`
+}
+
+// ValidateConf godoc
+// @Summary      ValidateConf checks a liveattrs processing configuration without saving it
+// @Description  The configuration is checked for atom structure existence, self-join columns format and bib view referential consistency. All the found problems are reported via the `errors` list.
+// @Accept  	 json
+// @Produce      json
+// @Param        corpusId path string true "Used corpus"
+// @Param 		 conf body vteCnf.VTEConf true "Validated config"
+// @Success      200 {object} ConfValidationResult
+// @Router       /liveAttributes/{corpusId}/conf/validate [post]
+func (a *Actions) ValidateConf(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	var conf vteCnf.VTEConf
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&conf); err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, middleware.RequestBodyErrorStatus(err))
+		return
+	}
+	if conf.Corpus == "" {
+		conf.Corpus = corpusID
+
+	} else if conf.Corpus != corpusID {
+		uniresp.RespondWithErrorJSON(
+			ctx,
+			fmt.Errorf("config corpus %s does not match %s", conf.Corpus, corpusID),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	ans := ConfValidationResult{Valid: true, Errors: laconf.ValidationErrors{}}
+	err := a.laConfCache.Validate(&conf)
+	var verrs laconf.ValidationErrors
+	if errors.As(err, &verrs) {
+		ans.Valid = false
+		ans.Errors = verrs
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to validate liveattrs config for %s: %w", corpusID, err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
 // CreateConf godoc
 // @Summary      CreateConf creates a new liveattrs processing configuration for a specified corpus
 // @Description  In case user does not fill in the information regarding n-gram processing, no defaults are used. To attach n-gram information automatically, PatchConfig is used (with URL arg. auto-kontext-setup=1).
@@ -138,6 +259,9 @@ func (a *Actions) ViewConf(ctx *gin.Context) {
 // @Param        corpusId path string true "Used corpus"
 // @Param 		 patchArgs body laconf.PatchArgs true "Config data"
 // @Success      200 {object} vteCnf.VTEConf
+// @Failure      400 {object} uniresp.ActionError "Invalid request (e.g. unknown corpus)"
+// @Failure      409 {object} uniresp.ActionError "Missing vertical file"
+// @Failure      422 {object} uniresp.ActionError "Invalid config data (e.g. atom structure cannot be inferred)"
 // @Router       /liveAttributes/{corpusId}/conf [put]
 func (a *Actions) CreateConf(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
@@ -148,16 +272,14 @@ func (a *Actions) CreateConf(ctx *gin.Context) {
 		uniresp.RespondWithErrorJSON(
 			ctx,
 			err,
-			http.StatusBadRequest,
+			middleware.RequestBodyErrorStatus(err),
 		)
-	}
-	newConf, err := a.createConf(corpusID, aliasOf, jsonArgs)
-	if err == ErrorMissingVertical {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusConflict)
 		return
-
-	} else if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+	}
+	newConf, bibViews, err := a.createConf(corpusID, aliasOf, jsonArgs)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), createConfErrorStatus(err))
 		return
 	}
 	err = a.laConfCache.Clear(corpusID)
@@ -165,7 +287,7 @@ func (a *Actions) CreateConf(ctx *gin.Context) {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
 	}
-	err = a.laConfCache.Save(newConf)
+	err = a.saveConf(newConf, bibViews)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
@@ -174,6 +296,149 @@ func (a *Actions) CreateConf(ctx *gin.Context) {
 	uniresp.WriteJSONResponse(ctx.Writer, &expConf)
 }
 
+// DiffConf godoc
+// @Summary      DiffConf shows how a new liveattrs processing configuration would differ from the stored one
+// @Description  A candidate configuration is created from the provided patch args (the same way as in CreateConf) and compared with the stored configuration. Nothing is saved. Passwords are removed from both configurations.
+// @Accept  	 json
+// @Produce      json
+// @Param        corpusId path string true "Used corpus"
+// @Param        aliasOf query string false "Source corpus in case corpusId is an alias"
+// @Param 		 patchArgs body laconf.PatchArgs true "Config data"
+// @Success      200 {object} laconf.ConfDiff
+// @Router       /liveAttributes/{corpusId}/conf/diff [post]
+func (a *Actions) DiffConf(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	aliasOf := ctx.Query("aliasOf")
+	baseErrTpl := "failed to diff liveattrs config for %s: %w"
+	jsonArgs, err := a.getPatchArgs(ctx.Request)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, middleware.RequestBodyErrorStatus(err))
+		return
+	}
+	candidate, _, err := a.createConf(corpusID, aliasOf, jsonArgs)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), createConfErrorStatus(err))
+		return
+	}
+	expCandidate := candidate.WithoutPasswords()
+	curr, err := a.laConfCache.GetUncachedWithoutPasswords(corpusID)
+	if err != nil && err != laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, laconf.Diff(curr, &expCandidate))
+}
+
+// BulkConfItem is a corpus along with its own config data
+// (see BulkConfArgs.Items)
+type BulkConfItem struct {
+	CorpusID  string            `json:"corpusId"`
+	PatchArgs *laconf.PatchArgs `json:"patchArgs"`
+}
+
+// BulkConfArgs is a request body for BulkCreateConf. The `PatchArgs`
+// serve as a template for all the corpora while `Overrides` may
+// specify per-corpus changes of the template. Corpora can be also
+// specified via `Items` along with their config data which
+// are applied on top of the template (and respective overrides).
+type BulkConfArgs struct {
+	Corpora   []string                     `json:"corpora"`
+	Items     []BulkConfItem               `json:"items"`
+	PatchArgs *laconf.PatchArgs            `json:"patchArgs"`
+	Overrides map[string]*laconf.PatchArgs `json:"overrides"`
+}
+
+// corpusPatchArgs returns config data for all the corpora
+// specified in the args (in the order of the request)
+func (args *BulkConfArgs) corpusPatchArgs() ([]BulkConfItem, error) {
+	tpl := args.PatchArgs
+	if tpl == nil {
+		tpl = &laconf.PatchArgs{}
+	}
+	ans := make([]BulkConfItem, 0, len(args.Corpora)+len(args.Items))
+	for _, corpusID := range args.Corpora {
+		ans = append(ans, BulkConfItem{CorpusID: corpusID})
+	}
+	ans = append(ans, args.Items...)
+	used := make(map[string]bool)
+	for i, item := range ans {
+		if item.CorpusID == "" {
+			return nil, fmt.Errorf("missing corpusId")
+		}
+		if used[item.CorpusID] {
+			return nil, fmt.Errorf("duplicate corpus %s", item.CorpusID)
+		}
+		used[item.CorpusID] = true
+		ans[i].PatchArgs = tpl.WithOverrides(args.Overrides[item.CorpusID]).WithOverrides(item.PatchArgs)
+	}
+	return ans, nil
+}
+
+// BulkConfResult describes an outcome of a config creation
+// for a single corpus within BulkCreateConf.
+type BulkConfResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+
+	// Status is an HTTP status the error would be reported
+	// with by a respective single-corpus action (see CreateConf)
+	Status int             `json:"status,omitempty"`
+	Conf   *vteCnf.VTEConf `json:"conf,omitempty"`
+}
+
+// BulkCreateConf godoc
+// @Summary      BulkCreateConf creates liveattrs processing configurations for multiple corpora
+// @Description  All the corpora share provided PatchArgs template which can be adjusted per corpus via the `overrides` object. Corpora can be also listed as `items` containing their own config data (applied on top of the template). A failure in one corpus does not prevent the others from being processed.
+// @Accept  	 json
+// @Produce      json
+// @Param 		 bulkArgs body BulkConfArgs true "Corpora and config data"
+// @Success      200 {object} map[string]BulkConfResult
+// @Router       /liveAttributes/conf/bulk [post]
+func (a *Actions) BulkCreateConf(ctx *gin.Context) {
+	var args BulkConfArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, middleware.RequestBodyErrorStatus(err))
+		return
+	}
+	items, err := args.corpusPatchArgs()
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusBadRequest)
+		return
+	}
+	if len(items) == 0 {
+		uniresp.RespondWithErrorJSON(ctx, fmt.Errorf("no corpora specified"), http.StatusBadRequest)
+		return
+	}
+	ans := make(map[string]BulkConfResult)
+	for _, item := range items {
+		a.fillMissingPatchArgs(item.PatchArgs)
+		ans[item.CorpusID] = a.createAndSaveConf(item.CorpusID, item.PatchArgs)
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// createAndSaveConf creates and saves a new configuration for a corpus
+// within the bulk action. Errors are reported via the result
+// so the caller can continue with other corpora.
+func (a *Actions) createAndSaveConf(corpusID string, jsonArgs *laconf.PatchArgs) BulkConfResult {
+	newConf, bibViews, err := a.createConf(corpusID, "", jsonArgs)
+	if err != nil {
+		log.Warn().Err(err).Str("corpusId", corpusID).Msg("failed to create liveattrs config in bulk mode")
+		return BulkConfResult{Error: err.Error(), Status: createConfErrorStatus(err)}
+	}
+	err = a.laConfCache.Clear(corpusID)
+	if err == nil {
+		err = a.saveConf(newConf, bibViews)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("corpusId", corpusID).Msg("failed to save liveattrs config in bulk mode")
+		return BulkConfResult{Error: err.Error(), Status: http.StatusInternalServerError}
+	}
+	expConf := newConf.WithoutPasswords()
+	return BulkConfResult{OK: true, Conf: &expConf}
+}
+
 // FlushCache godoc
 // @Summary      FlushCache removes an actual cached liveattrs configuration for a specified corpus
 // @Description  FlushCache removes an actual cached liveattrs configuration for a specified corpus. This is mostly useful in cases where a manual editation of liveattrs config was done and we need Frodo to use the actual file version.
@@ -190,6 +455,55 @@ func (a *Actions) FlushCache(ctx *gin.Context) {
 	uniresp.WriteJSONResponse(ctx.Writer, map[string]bool{"ok": true})
 }
 
+// BibViews godoc
+// @Summary      BibViews shows all the bib views defined for a corpus
+// @Description  A corpus may provide alternative bibliography groupings (e.g. by edition vs. by author). The views are defined via `bibViews` when creating the configuration. The processing configuration itself contains just the first (active) view.
+// @Produce      json
+// @Param        corpusId path string true "Used corpus"
+// @Success      200 {object} []laconf.NamedBibView
+// @Router       /liveAttributes/{corpusId}/conf/bibViews [get]
+func (a *Actions) BibViews(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	ans, err := a.laConfCache.GetBibViews(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("failed to get bib views for %s: %w", corpusID, err),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// RestoreConf godoc
+// @Summary      RestoreConf swaps the backup of a liveattrs processing configuration with the current one
+// @Description  Each time a configuration is saved or cleared, its previous version is kept as a backup. This action puts the backup back in while the current configuration becomes the new backup (i.e. calling the action twice returns to the original state).
+// @Produce      json
+// @Param        corpusId path string true "Used corpus"
+// @Success      200 {object} vteCnf.VTEConf
+// @Router       /liveAttributes/{corpusId}/conf/restore [post]
+func (a *Actions) RestoreConf(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to restore liveattrs config for %s: %w"
+	err := a.laConfCache.Restore(corpusID)
+	if err == laconf.ErrorNoSuchBackup {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	conf, err := a.laConfCache.GetWithoutPasswords(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	ctx.Header(confVersionHeader, strconv.Itoa(a.laConfCache.Version(corpusID)))
+	uniresp.WriteJSONResponse(ctx.Writer, conf)
+}
+
 // PatchConfig godoc
 // @Summary      PatchConfig allows for updating liveattrs processing configuration
 // @Description  It also allows a semi-automatic mode (using url query argument auto-kontext-setup=1) where the columns to be fetched from a corresponding vertical and other parameters with respect to a typical CNC setup used for its corpora.
@@ -216,7 +530,7 @@ func (a *Actions) PatchConfig(ctx *gin.Context) {
 
 	jsonArgs, err := a.getPatchArgs(ctx.Request)
 	if err != nil {
-		uniresp.RespondWithErrorJSON(ctx, err, http.StatusBadRequest)
+		uniresp.RespondWithErrorJSON(ctx, err, middleware.RequestBodyErrorStatus(err))
 		return
 	}
 	if jsonArgs == nil && !inferNgramCols {
@@ -225,13 +539,17 @@ func (a *Actions) PatchConfig(ctx *gin.Context) {
 	}
 
 	if inferNgramCols {
-		regPath := filepath.Join(a.conf.Corp.RegistryDirPaths[0], corpusID)
+		regPath, err := a.conf.Corp.FindRegistryFile(corpusID)
+		if err != nil {
+			uniresp.RespondWithErrorJSON(ctx, err, http.StatusUnprocessableEntity)
+			return
+		}
 		corpTagsets, err := a.corpusMeta.GetCorpusTagsets(corpusID)
 		if err != nil {
 			uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
 			return
 		}
-		tagset := corpus.GetFirstSupportedTagset(corpTagsets)
+		tagset := a.conf.Corp.GetPreferredTagset(corpTagsets)
 		if tagset == "" {
 			uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
 			return
@@ -290,9 +608,13 @@ func (a *Actions) PatchConfig(ctx *gin.Context) {
 // @Router       /liveAttributes/{corpusId}/qsDefaults [get]
 func (a *Actions) QSDefaults(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
-	regPath := filepath.Join(a.conf.Corp.RegistryDirPaths[0], corpusID)
+	regPath, err := a.conf.Corp.FindRegistryFile(corpusID)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusNotFound)
+		return
+	}
 	corpTagsets, err := a.corpusMeta.GetCorpusTagsets(corpusID)
-	tagset := corpus.GetFirstSupportedTagset(corpTagsets)
+	tagset := a.conf.Corp.GetPreferredTagset(corpTagsets)
 	if tagset == "" {
 		uniresp.RespondWithErrorJSON(ctx, fmt.Errorf("no supported tagset"), http.StatusUnprocessableEntity)
 		return
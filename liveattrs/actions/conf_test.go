@@ -0,0 +1,83 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"errors"
+	"frodo/liveattrs/laconf"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	vteCnf "github.com/czcorpus/vert-tagextract/v3/cnf"
+	vteDB "github.com/czcorpus/vert-tagextract/v3/db"
+)
+
+func TestApplyPatchArgsInvalidNgrams(t *testing.T) {
+	a := &Actions{}
+	err := a.applyPatchArgs(
+		&vteCnf.VTEConf{},
+		&laconf.PatchArgs{Ngrams: &vteCnf.NgramConf{VertColumns: vteDB.VertColumns{{Idx: 0}}}},
+	)
+	assert.ErrorIs(t, err, laconf.ErrorInvalidNgramSize)
+	assert.Equal(t, http.StatusUnprocessableEntity, createConfErrorStatus(err))
+
+	err = a.applyPatchArgs(
+		&vteCnf.VTEConf{},
+		&laconf.PatchArgs{Ngrams: &vteCnf.NgramConf{NgramSize: 2}},
+	)
+	assert.ErrorIs(t, err, laconf.ErrorMissingNgramColumns)
+	assert.Equal(t, http.StatusUnprocessableEntity, createConfErrorStatus(err))
+}
+
+func TestCreateConfErrorStatus(t *testing.T) {
+	assert.Equal(t, http.StatusConflict, createConfErrorStatus(ErrorMissingVertical))
+	assert.Equal(
+		t, http.StatusUnprocessableEntity, createConfErrorStatus(laconf.ErrorAmbiguousAtomStructure))
+	assert.Equal(t, http.StatusBadRequest, createConfErrorStatus(errors.New("unknown corpus")))
+}
+
+func TestBulkConfArgsCorpusPatchArgs(t *testing.T) {
+	tpl, override, own := "doc", "p", "s"
+	args := BulkConfArgs{
+		Corpora: []string{"syn2020", "syn2015"},
+		Items: []BulkConfItem{
+			{CorpusID: "intercorp_v16_cs", PatchArgs: &laconf.PatchArgs{AtomStructure: &own}},
+		},
+		PatchArgs: &laconf.PatchArgs{AtomStructure: &tpl},
+		Overrides: map[string]*laconf.PatchArgs{
+			"syn2015":          {AtomStructure: &override},
+			"intercorp_v16_cs": {AtomStructure: &override},
+		},
+	}
+	items, err := args.corpusPatchArgs()
+	assert.NoError(t, err)
+	if assert.Len(t, items, 3) {
+		assert.Equal(t, "syn2020", items[0].CorpusID)
+		assert.Equal(t, "doc", *items[0].PatchArgs.AtomStructure)
+		assert.Equal(t, "p", *items[1].PatchArgs.AtomStructure)
+		assert.Equal(t, "intercorp_v16_cs", items[2].CorpusID)
+		assert.Equal(t, "s", *items[2].PatchArgs.AtomStructure)
+	}
+
+	args.Items = append(args.Items, BulkConfItem{CorpusID: "syn2020"})
+	_, err = args.corpusPatchArgs()
+	assert.Error(t, err)
+	_, err = (&BulkConfArgs{Items: []BulkConfItem{{}}}).corpusPatchArgs()
+	assert.Error(t, err)
+}
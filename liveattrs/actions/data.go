@@ -17,11 +17,15 @@
 package actions
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"frodo/db/tables"
 	"frodo/jobs"
 	"frodo/liveattrs"
 	"frodo/liveattrs/db"
-	_ "frodo/liveattrs/laconf"
+	"frodo/liveattrs/laconf"
+	"frodo/middleware"
 	"net/http"
 
 	"github.com/czcorpus/cnc-gokit/uniresp"
@@ -39,6 +43,8 @@ import (
 //     be stored replacing the previous one. In case the option is not set, then the
 //     provided PatchArgs will be applied only to a temporary copy of a respective config
 //     keeping the stored value intact.
+//   - maxNumErrors - a one-off override of the tolerated number of vertical parsing errors.
+//     The value is applied only to the started build, the stored config is not affected.
 //
 // request body:
 //
@@ -53,13 +59,29 @@ import (
 // @Param 		 patchArgs body laconf.PatchArgs true "The input todo struct"
 // @Param 		 reconfigure query int false "Ignore the stored liveattrs config (if any) and generate a new one based on corpus properties and provided PatchArgs. The resulting new config will be stored replacing the previous one." default(0)
 // @Param 		 append query int false "Append mode" default(0)
-// @Success      200 {object} any
+// @Param 		 maxNumErrors query int false "One-off override of the max. number of tolerated vertical parsing errors (the stored config is not changed)"
+// @Param 		 force query int false "Create the job even if another liveattrs job for the corpus is unfinished" default(0)
+// @Success      202 {object} any
+// @Header       202 {string} Location "URL path of the created job"
+// @Failure      409 {object} jobs.JobConflictResponse "A liveattrs job for the corpus is unfinished"
+// @Failure      429 {object} uniresp.ActionError "Job queue is full"
 // @Router       /liveAttributes/{corpusId}/data [post]
 func (a *Actions) Create(ctx *gin.Context) {
-	corpusID := ctx.Param("corpusId")
-	aliasOf := ctx.Query("aliasOf")
+	req, err := jobs.NewJobRequest(ctx)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, middleware.RequestBodyErrorStatus(err))
+		return
+	}
+	job, err := a.CreateDataJob(ctx, req)
+	jobs.WriteCreatedJobResponse(ctx, job, err)
+}
+
+// CreateDataJob is a jobs.JobCreator behind the Create action
+func (a *Actions) CreateDataJob(ctx context.Context, req jobs.JobRequest) (jobs.CreatedJob, error) {
+	corpusID := req.CorpusID
+	aliasOf := req.Query.Get("aliasOf")
 	baseErrTpl := "failed to generate liveattrs for %s: %w"
-	reconfigure := ctx.Request.URL.Query().Get("reconfigure") == "1"
+	reconfigure := req.Query.Get("reconfigure") == "1"
 
 	var err error
 	var conf *vteCnf.VTEConf
@@ -74,78 +96,61 @@ func (a *Actions) Create(ctx *gin.Context) {
 	}
 	//  else { ... "reconfigure" => create everything from scratch
 
-	jsonArgs, err := a.getPatchArgs(ctx.Request)
-
-	if err != nil {
-		uniresp.RespondWithErrorJSON(
-			ctx,
-			err,
-			http.StatusBadRequest,
-		)
-		return
+	var jsonArgs laconf.PatchArgs
+	if err := req.DecodeArgs(&jsonArgs); err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusBadRequest)
 	}
+	a.fillMissingPatchArgs(&jsonArgs)
 
 	if err := jsonArgs.ValidateDataWindow(); err != nil {
-		uniresp.RespondWithErrorJSON(
-			ctx,
-			err,
-			http.StatusBadRequest,
-		)
-		return
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusBadRequest)
 	}
 
 	if conf == nil {
 		var newConf *vteCnf.VTEConf
+		var bibViews []laconf.NamedBibView
 		var err error
-		newConf, err = a.createConf(corpusID, aliasOf, jsonArgs)
+		newConf, bibViews, err = a.createConf(corpusID, aliasOf, &jsonArgs)
 		if err != nil && err != ErrorMissingVertical {
-			uniresp.WriteJSONErrorResponse(
-				ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
-			return
+			return jobs.CreatedJob{}, jobs.NewJobRequestError(
+				fmt.Errorf(baseErrTpl, corpusID, err), createConfErrorStatus(err))
 		}
 
-		err = a.laConfCache.Save(newConf)
+		err = a.saveConf(newConf, bibViews)
 		if err != nil {
-			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
-			return
+			return jobs.CreatedJob{}, jobs.NewJobRequestError(
+				fmt.Errorf(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		}
 
 		conf, err = a.laConfCache.Get(corpusID)
 		if err != nil {
-			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
-			return
+			return jobs.CreatedJob{}, jobs.NewJobRequestError(
+				fmt.Errorf(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		}
 	}
 
 	runtimeConf := *conf
-	if err := a.applyPatchArgs(&runtimeConf, jsonArgs); err != nil {
-		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
-		return
+	if err := a.applyPatchArgs(&runtimeConf, &jsonArgs); err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusInternalServerError)
+	}
+	maxNumErrors, err := req.IntArg("maxNumErrors", -1)
+	if err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(err, http.StatusBadRequest)
+	}
+	if maxNumErrors >= 0 {
+		runtimeConf.MaxNumErrors = maxNumErrors
 	}
 	if !runtimeConf.HasConfiguredVertical() {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusConflict)
-		return
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, ErrorMissingVertical), http.StatusConflict)
 	}
 
-	// TODO search collisions only in liveattrs type jobs
 	jobID, err := uuid.NewUUID()
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusUnauthorized)
-		return
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 	}
 
-	if prevRunning, ok := a.jobActions.LastUnfinishedJobOfType(corpusID, liveattrs.JobType); ok {
-		err := fmt.Errorf("the previous job %s not finished yet", prevRunning.GetID())
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer,
-			uniresp.NewActionError(baseErrTpl, corpusID, err),
-			http.StatusConflict,
-		)
-		return
-	}
-
-	append := ctx.Request.URL.Query().Get("append")
 	status := &liveattrs.LiveAttrsJobInfo{
 		ID:              jobID.String(),
 		CorpusID:        corpusID,
@@ -153,14 +158,27 @@ func (a *Actions) Create(ctx *gin.Context) {
 		Start:           jobs.CurrentDatetime(),
 		Args: liveattrs.JobInfoArgs{
 			VteConf:          runtimeConf,
-			Append:           append == "1",
+			Append:           req.Query.Get("append") == "1",
 			NoCorpusDBUpdate: aliasOf != "",
 			TagsetAttr:       jsonArgs.GetTagsetAttr(),
 			TagsetName:       jsonArgs.GetTagsetName(),
 		},
 	}
-	a.generateData(status)
-	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, status.FullInfo())
+	err = a.jobActions.EnqueueIfNoConflict(
+		req.Query.Get("force") == "1",
+		corpusID,
+		[]string{liveattrs.JobType, liveattrs.UpdateJobType},
+		func() error { return a.generateData(status) },
+	)
+	var confErr jobs.ConflictingJobError
+	if errors.As(err, &confErr) {
+		return jobs.CreatedJob{}, err
+
+	} else if err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), jobs.EnqueueErrorStatus(err))
+	}
+	return jobs.CreatedJob{JobID: status.ID, Body: status.FullInfo()}, nil
 }
 
 // Delete godoc
@@ -246,14 +264,14 @@ func (a *Actions) CleanTmpTables(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
 
 	if _, err := a.laDB.DB().Exec(
-		fmt.Sprintf("DROP TABLE IF EXISTS %s_liveattrs_entry_new", corpusID),
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", tables.Name(corpusID, tables.LiveAttrsEntryNew)),
 	); err != nil {
 		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
 		return
 	}
 
 	if _, err := a.laDB.DB().Exec(
-		fmt.Sprintf("DROP TABLE IF EXISTS %s_colcounts_new", corpusID),
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", tables.Name(corpusID, tables.ColCountsNew)),
 	); err != nil {
 		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
 		return
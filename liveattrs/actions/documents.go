@@ -22,6 +22,7 @@ import (
 	"frodo/liveattrs/db"
 	"frodo/liveattrs/request/biblio"
 	"frodo/liveattrs/request/query"
+	"frodo/middleware"
 	"io"
 	"net/http"
 	"regexp"
@@ -50,7 +51,7 @@ func (a *Actions) GetBibliography(ctx *gin.Context) {
 	var qry biblio.Payload
 	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
 		return
 	}
 	corpInfo, err := a.corpusMeta.LoadInfo(corpusID)
@@ -90,7 +91,7 @@ func (a *Actions) FindBibTitles(ctx *gin.Context) {
 	var qry biblio.PayloadList
 	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
 		return
 	}
 	corpInfo, err := a.corpusMeta.LoadInfo(corpusID)
@@ -204,7 +205,7 @@ func (a *Actions) DocumentList(ctx *gin.Context) {
 	var qry query.Payload
 	err = json.NewDecoder(ctx.Request.Body).Decode(&qry)
 	if err != nil && err != io.EOF {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
 		return
 
 	}
@@ -261,7 +262,7 @@ func (a *Actions) NumMatchingDocuments(ctx *gin.Context) {
 	var qry query.Payload
 	err = json.NewDecoder(ctx.Request.Body).Decode(&qry)
 	if err != nil && err != io.EOF {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
 		return
 	}
 
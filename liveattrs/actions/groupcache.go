@@ -0,0 +1,72 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"frodo/metadb"
+	"sync"
+	"time"
+)
+
+const (
+	dfltGroupedNameCacheTTL = 10 * time.Minute
+)
+
+type groupedNameItem struct {
+	groupedName string
+	loaded      time.Time
+}
+
+// groupedNameCache caches grouped names (i.e. parallel groups, see
+// corpus.DBInfo.GroupedName) of corpora so queries with aligned
+// corpora do not have to load information about each aligned corpus
+// from the database. As the parallel group of a corpus changes only
+// rarely (and only by a direct database modification), the entries
+// simply expire after the ttl. Errors are not cached.
+type groupedNameCache struct {
+	corpusMeta metadb.Provider
+	ttl        time.Duration
+	items      map[string]groupedNameItem
+	lock       sync.Mutex
+}
+
+// Get returns a grouped name of a corpus. For an unknown corpus,
+// the error from the metadb.Provider (i.e. sql.ErrNoRows) is returned.
+func (c *groupedNameCache) Get(corpusID string) (string, error) {
+	c.lock.Lock()
+	item, ok := c.items[corpusID]
+	c.lock.Unlock()
+	if ok && time.Since(item.loaded) < c.ttl {
+		return item.groupedName, nil
+	}
+	info, err := c.corpusMeta.LoadInfo(corpusID)
+	if err != nil {
+		return "", err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.items[corpusID] = groupedNameItem{groupedName: info.GroupedName(), loaded: time.Now()}
+	return info.GroupedName(), nil
+}
+
+func newGroupedNameCache(corpusMeta metadb.Provider, ttl time.Duration) *groupedNameCache {
+	return &groupedNameCache{
+		corpusMeta: corpusMeta,
+		ttl:        ttl,
+		items:      make(map[string]groupedNameItem),
+	}
+}
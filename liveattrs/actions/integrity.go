@@ -0,0 +1,104 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"frodo/jobs"
+	"frodo/liveattrs/db"
+	"frodo/liveattrs/laconf"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ValidateIntegrity godoc
+// @Summary      Validate liveattrs data integrity
+// @Description  Start a job scanning liveattrs tables of a corpus for common integrity problems (NULL key columns, aligned rows without a primary match, duplicate item IDs). The report is available as the job result.
+// @Produce      json
+// @Param        corpusId path string true "Used corpus"
+// @Success      202 {object} any
+// @Header       202 {string} Location "URL path of the created job"
+// @Failure      429 {object} uniresp.ActionError "Job queue is full"
+// @Router       /liveAttributes/{corpusId}/validateIntegrity [post]
+func (a *Actions) ValidateIntegrity(ctx *gin.Context) {
+	job, err := a.CreateIntegrityJob(ctx, jobs.JobRequest{
+		CorpusID: ctx.Param("corpusId"),
+		Query:    ctx.Request.URL.Query(),
+	})
+	jobs.WriteCreatedJobResponse(ctx, job, err)
+}
+
+// CreateIntegrityJob is a jobs.JobCreator behind the ValidateIntegrity action
+func (a *Actions) CreateIntegrityJob(ctx context.Context, req jobs.JobRequest) (jobs.CreatedJob, error) {
+	corpusID := req.CorpusID
+	baseErrTpl := "failed to validate liveattrs integrity of %s: %w"
+	corpusDBInfo, err := a.corpusMeta.LoadInfo(corpusID)
+	if err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+	}
+	laConf, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), http.StatusNotFound)
+
+	} else if err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+	}
+	subcorpAttrs := laconf.GetSubcorpAttrs(laConf)
+
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+	}
+	jobStatus := db.IntegrityJobInfo{
+		ID:       jobID.String(),
+		Type:     db.IntegrityJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+	}
+	fn := func(jctx context.Context, updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		report, err := db.CheckIntegrity(jctx, a.laDB.DB(), corpusDBInfo, subcorpAttrs)
+		if err != nil {
+			log.Error().Err(err).Str("corpusId", corpusID).Msg("liveattrs integrity validation failed")
+			updateJobChan <- jobStatus.WithError(err)
+			return
+		}
+		if !report.IsOK() {
+			log.Warn().
+				Str("corpusId", corpusID).
+				Int("orphanedAlignedRows", report.OrphanedAlignedRows).
+				Int("numDuplicateItemIds", report.NumDuplicateItemIDs).
+				Msg("found liveattrs integrity problems")
+		}
+		jobStatus.Result = &report
+		updateJobChan <- jobStatus.AsFinished()
+	}
+	if err := a.jobActions.EnqueueJob(a.jobActions.WithJobContext(jobStatus.ID, fn), jobStatus); err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), jobs.EnqueueErrorStatus(err))
+	}
+	return jobs.CreatedJob{JobID: jobStatus.ID, Body: jobStatus.FullInfo()}, nil
+}
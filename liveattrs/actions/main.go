@@ -33,8 +33,11 @@ import (
 	"frodo/liveattrs/request/fillattrs"
 	"frodo/liveattrs/request/query"
 	"frodo/liveattrs/request/response"
+	"frodo/liveattrs/utils"
 	"frodo/metadb"
+	"frodo/middleware"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -50,13 +53,18 @@ import (
 )
 
 const (
-	emptyValuePlaceholder = "?"
-	dfltMaxAttrListSize   = 30
-	shortLabelMaxLength   = 30
+	// dfltMaxRawAttrValues is a safety limit of distinct values per attribute
+	// in the "raw" query mode (the configured maxAttrDistinctValues applies
+	// in case it is lower)
+	dfltMaxRawAttrValues = 10000
 )
 
 var (
 	ErrorMissingVertical = errors.New("missing vertical file")
+
+	ErrorTooManyDistinctValues = errors.New("too many distinct attribute values")
+
+	ErrorUnknownAlignedCorpus = errors.New("unknown aligned corpus")
 )
 
 type CreateLiveAttrsReqBody struct {
@@ -92,6 +100,9 @@ type Actions struct {
 
 	corpusMeta metadb.Provider
 
+	// groupedNames caches parallel groups of aligned corpora
+	groupedNames *groupedNameCache
+
 	corpusMetaW metadb.SQLUpdater
 
 	// eqCache stores results for live-attributes empty queries (= initial text types data)
@@ -101,7 +112,13 @@ type Actions struct {
 
 	usageData chan<- db.RequestData
 
-	vteJobCancel map[string]context.CancelFunc
+	// vteJobCancel contains cancel functions of running
+	// vert-tagextract jobs (see setVteJobCancel)
+	vteJobCancel     map[string]context.CancelFunc
+	vteJobCancelLock sync.Mutex
+
+	// exited is closed once the query cache is stored on exit
+	exited chan struct{}
 }
 
 // applyPatchArgs based on configuration stored in `jsonArgs`
@@ -118,7 +135,7 @@ func (a *Actions) applyPatchArgs(
 
 		} else if len(jsonArgs.Ngrams.VertColumns) > 0 {
 			if jsonArgs.Ngrams.NgramSize <= 0 {
-				return fmt.Errorf("invalid n-gram size: %d", jsonArgs.Ngrams.NgramSize)
+				return fmt.Errorf("%w: %d", laconf.ErrorInvalidNgramSize, jsonArgs.Ngrams.NgramSize)
 			}
 			targetConf.Ngrams = *jsonArgs.Ngrams
 		}
@@ -131,7 +148,7 @@ func (a *Actions) applyPatchArgs(
 	}
 
 	if targetConf.Ngrams.NgramSize > 0 && len(targetConf.Ngrams.VertColumns) == 0 {
-		return fmt.Errorf("missing columns to extract n-grams from")
+		return laconf.ErrorMissingNgramColumns
 	}
 
 	if jsonArgs.VerticalFiles != nil {
@@ -207,10 +224,11 @@ func (a *Actions) ensureVerticalFile(vconf *vteCnf.VTEConf, corpusInfo *corpus.I
 }
 
 // generateData starts data extraction and generation
-// based on (initial) job status
-func (a *Actions) generateData(initialStatus *liveattrs.LiveAttrsJobInfo) {
+// based on (initial) job status. In case the job cannot be
+// enqueued, an error is returned.
+func (a *Actions) generateData(initialStatus *liveattrs.LiveAttrsJobInfo) error {
 	jctx, cancel := context.WithCancel(a.ctx)
-	a.vteJobCancel[initialStatus.ID] = cancel
+	a.setVteJobCancel(initialStatus.ID, cancel)
 	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
 		procStatus, err := vteLib.ExtractData(
 			jctx,
@@ -225,7 +243,7 @@ func (a *Actions) generateData(initialStatus *liveattrs.LiveAttrsJobInfo) {
 		go func() {
 			defer func() {
 				close(updateJobChan)
-				delete(a.vteJobCancel, initialStatus.ID)
+				a.releaseVteJobCancel(initialStatus.ID)
 			}()
 			jobStatus := liveattrs.LiveAttrsJobInfo{
 				ID:              initialStatus.ID,
@@ -256,6 +274,8 @@ func (a *Actions) generateData(initialStatus *liveattrs.LiveAttrsJobInfo) {
 				}
 			}
 
+			// the whole data has been replaced so no cached
+			// result for the corpus is valid anymore
 			a.eqCache.Del(jobStatus.CorpusID)
 			if jobStatus.Args.VteConf.DB.Type != "mysql" {
 				updateJobChan <- jobStatus.WithError(fmt.Errorf("only mysql liveattrs backend is supported in Frodo"))
@@ -290,15 +310,58 @@ func (a *Actions) generateData(initialStatus *liveattrs.LiveAttrsJobInfo) {
 			updateJobChan <- jobStatus.AsFinished()
 		}()
 	}
-	a.jobActions.EnqueueJob(&fn, initialStatus)
+	return a.enqueueVteJob(&fn, initialStatus)
+}
+
+// enqueueVteJob enqueues a job based on vert-tagextract processing.
+// In case the job is rejected, its cancel function is released.
+func (a *Actions) enqueueVteJob(fn *jobs.QueuedFunc, initialStatus *liveattrs.LiveAttrsJobInfo) error {
+	if err := a.jobActions.EnqueueJob(fn, initialStatus); err != nil {
+		a.releaseVteJobCancel(initialStatus.ID)
+		return err
+	}
+	return nil
+}
+
+// setVteJobCancel stores a cancel function of a vert-tagextract job
+// so the job can be stopped on user request (see runStopJobListener).
+// As the jobs run in their own goroutines, the map of the functions
+// must be accessed only via setVteJobCancel, cancelVteJob and
+// releaseVteJobCancel.
+func (a *Actions) setVteJobCancel(jobID string, cancel context.CancelFunc) {
+	a.vteJobCancelLock.Lock()
+	defer a.vteJobCancelLock.Unlock()
+	a.vteJobCancel[jobID] = cancel
+}
+
+// cancelVteJob cancels a vert-tagextract job. It returns false
+// in case the job is unknown (or already finished).
+func (a *Actions) cancelVteJob(jobID string) bool {
+	a.vteJobCancelLock.Lock()
+	defer a.vteJobCancelLock.Unlock()
+	cancel, ok := a.vteJobCancel[jobID]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// releaseVteJobCancel removes a cancel function of a finished
+// (or rejected) job and releases the job context
+func (a *Actions) releaseVteJobCancel(jobID string) {
+	a.vteJobCancelLock.Lock()
+	defer a.vteJobCancelLock.Unlock()
+	if cancel, ok := a.vteJobCancel[jobID]; ok {
+		cancel()
+		delete(a.vteJobCancel, jobID)
+	}
 }
 
 func (a *Actions) runStopJobListener() {
 	for id := range a.jobStopChannel {
 		if job, ok := a.jobActions.GetJob(id); ok {
 			if tJob, ok2 := job.(liveattrs.LiveAttrsJobInfo); ok2 {
-				if cancel, ok3 := a.vteJobCancel[tJob.ID]; ok3 {
-					cancel()
+				if a.cancelVteJob(tJob.ID) {
 					log.Debug().Msg("cancelled job on user request")
 				}
 			}
@@ -312,7 +375,11 @@ func (a *Actions) runStopJobListener() {
 // @Produce      json
 // @Param        corpusId path string true "An ID of a corpus for which to make query"
 // @Param 		 queryArgs body query.Payload true "Query arguments"
-// @Success      200 {object} response.QueryAns
+// @Param        locale query string false "Locale used for sorting values (default is corpus locale)"
+// @Param        raw query int false "Return accumulated value counts without bib. items grouping, cutoff and other export transformations" default(0)
+// @Param        partial query int false "In case reading of data fails in the middle, return values accumulated so far (marked as partial) instead of an error (not applicable in the raw mode)" default(0)
+// @Param        debug query int false "Include non-fatal warnings (e.g. NULL columns found in the data) in the response (not applicable in the raw mode)" default(0)
+// @Success      200 {object} response.QueryAns "or response.RawQueryAns in the raw mode"
 // @Router       /liveAttributes/{corpusId}/query [post]
 func (a *Actions) Query(ctx *gin.Context) {
 	t0 := time.Now()
@@ -320,6 +387,27 @@ func (a *Actions) Query(ctx *gin.Context) {
 	baseErrTpl := "failed to query liveattrs in corpus %s: %w"
 	var qry query.Payload
 	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
+		return
+	}
+	if err := qry.Buckets.Validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if err := qry.Sort.Validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if _, err := query.ParseSortBy(qry.SortBy); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if err := qry.ValidateConditions(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	locale, err := getLocaleArg(ctx)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
@@ -334,21 +422,58 @@ func (a *Actions) Query(ctx *gin.Context) {
 		Payload:  qry,
 		Created:  time.Now(),
 	}
+	if ctx.Query("raw") == "1" {
+		rawAns, err := a.getRawAttrValues(ctx.Request.Context(), corpInfo, qry)
+		if err == laconf.ErrorNoSuchConfig {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+			return
+
+		} else if errors.Is(err, utils.ErrorAmbiguousAttr) || errors.Is(err, utils.ErrorUnknownAttr) ||
+			errors.Is(err, utils.ErrorNonNumericAttr) || errors.Is(err, ErrorUnknownAlignedCorpus) {
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+			return
+
+		} else if err != nil {
+			log.Error().Str("corpusId", corpusID).Err(err).Msg("failed to get raw attribute values")
+			uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+			return
+		}
+		usageEntry.ProcTime = time.Since(t0)
+		a.usageData <- usageEntry
+		uniresp.WriteJSONResponse(ctx.Writer, rawAns)
+		return
+	}
 
-	ans := a.eqCache.Get(corpusID, qry)
+	// cached answers are sorted according to the corpus locale
+	var ans *response.QueryAns
+	if locale == "" {
+		ans = a.eqCache.Get(corpusID, qry)
+	}
 	if ans != nil {
+		if ctx.Query("debug") == "1" {
+			ans = ans.WithDebugWarnings()
+		}
 		uniresp.WriteJSONResponse(ctx.Writer, &ans)
 		usageEntry.IsCached = true
 		usageEntry.ProcTime = time.Since(t0)
 		a.usageData <- usageEntry
 		return
 	}
-	ans, err = a.getAttrValues(corpInfo, qry)
+	ans, err = a.getAttrValues(ctx.Request.Context(), corpInfo, qry, locale, ctx.Query("partial") == "1")
 	if err == laconf.ErrorNoSuchConfig {
 		log.Error().Str("corpusId", corpusID).Err(err).Msgf("configuration not found for %s", corpusID)
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
 		return
 
+	} else if errors.Is(err, ErrorTooManyDistinctValues) {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusUnprocessableEntity)
+		return
+
+	} else if errors.Is(err, utils.ErrorAmbiguousAttr) || errors.Is(err, utils.ErrorUnknownAttr) ||
+		errors.Is(err, utils.ErrorNonNumericAttr) || errors.Is(err, ErrorUnknownAlignedCorpus) {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+
 	} else if err != nil {
 		log.Error().Str("corpusId", corpusID).Err(err).Msg("failed to get attribute values")
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
@@ -356,7 +481,12 @@ func (a *Actions) Query(ctx *gin.Context) {
 	}
 	usageEntry.ProcTime = time.Since(t0)
 	a.usageData <- usageEntry
-	a.eqCache.Set(corpusID, qry, ans)
+	if locale == "" && !ans.Partial {
+		a.eqCache.Set(corpusID, qry, ans)
+	}
+	if ctx.Query("debug") == "1" {
+		ans = ans.WithDebugWarnings()
+	}
 	uniresp.WriteJSONResponse(ctx.Writer, &ans)
 }
 
@@ -375,7 +505,7 @@ func (a *Actions) FillAttrs(ctx *gin.Context) {
 	var qry fillattrs.Payload
 	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
 		return
 	}
 	corpusDBInfo, err := a.corpusMeta.LoadInfo(corpusID)
@@ -410,7 +540,7 @@ func (a *Actions) GetAdhocSubcSize(ctx *gin.Context) {
 	var qry equery.Payload
 	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
 		return
 	}
 	corpora := append([]string{corpusID}, qry.Aligned...)
@@ -429,10 +559,14 @@ func (a *Actions) GetAdhocSubcSize(ctx *gin.Context) {
 
 // AttrValAutocomplete godoc
 // @Summary      Find autocomplete suggestions for specified corpus
+// @Description  The autocompleted attribute may be also located in one of the aligned corpora. In such case, it must be prefixed by the aligned corpus ID (e.g. `intercorp_v16ud_en:doc.title`).
 // @Accept  	 json
 // @Produce      json
 // @Param        corpusId path string true "Used corpus"
 // @Param 		 queryArgs body query.Payload true "Query arguments"
+// @Param        locale query string false "Locale used for sorting values (default is corpus locale)"
+// @Param        partial query int false "In case reading of data fails in the middle, return values accumulated so far (marked as partial) instead of an error" default(0)
+// @Param        debug query int false "Include non-fatal warnings (e.g. NULL columns found in the data) in the response" default(0)
 // @Success      200 {object} response.QueryAns
 // @Router       /liveAttributes/{corpusId}/attrValAutocomplete [post]
 func (a *Actions) AttrValAutocomplete(ctx *gin.Context) {
@@ -441,6 +575,23 @@ func (a *Actions) AttrValAutocomplete(ctx *gin.Context) {
 
 	var qry query.Payload
 	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
+		return
+	}
+	if err := qry.Sort.Validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if _, err := query.ParseSortBy(qry.SortBy); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	if err := qry.ValidateConditions(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	locale, err := getLocaleArg(ctx)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
@@ -450,11 +601,23 @@ func (a *Actions) AttrValAutocomplete(ctx *gin.Context) {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
-	ans, err := a.getAttrValues(corpInfo, qry)
-	if err != nil {
+	ans, err := a.getAttrValues(ctx.Request.Context(), corpInfo, qry, locale, ctx.Query("partial") == "1")
+	if errors.Is(err, ErrorTooManyDistinctValues) {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusUnprocessableEntity)
+		return
+
+	} else if errors.Is(err, utils.ErrorAmbiguousAttr) || errors.Is(err, utils.ErrorUnknownAttr) ||
+		errors.Is(err, utils.ErrorNonNumericAttr) || errors.Is(err, ErrorUnknownAlignedCorpus) {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+
+	} else if err != nil {
 		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
+	if ctx.Query("debug") == "1" {
+		ans = ans.WithDebugWarnings()
+	}
 	uniresp.WriteJSONResponse(ctx.Writer, &ans)
 }
 
@@ -475,6 +638,16 @@ func (a *Actions) Stats(ctx *gin.Context) {
 	uniresp.WriteJSONResponse(ctx.Writer, &ans)
 }
 
+// CacheStats godoc
+// @Summary      Get stats of the liveattrs query cache
+// @Description  Returns hit/miss/eviction counters of the query cache along with numbers of cache keys per corpus. The counters live in memory only so they are reset by a service restart.
+// @Produce      json
+// @Success      200 {object} cache.Stats
+// @Router       /liveAttributes/cacheStats [get]
+func (a *Actions) CacheStats(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, a.eqCache.Stats())
+}
+
 func (a *Actions) RestartLiveAttrsJob(ctx context.Context, jinfo *liveattrs.LiveAttrsJobInfo) error {
 	err := a.jobActions.TestAllowsJobRestart(jinfo)
 	if err != nil {
@@ -484,7 +657,15 @@ func (a *Actions) RestartLiveAttrsJob(ctx context.Context, jinfo *liveattrs.Live
 	jinfo.NumRestarts++
 	jinfo.Update = jobs.CurrentDatetime()
 
-	a.generateData(jinfo)
+	if jinfo.Type == liveattrs.UpdateJobType {
+		err = a.updateData(jinfo)
+
+	} else {
+		err = a.generateData(jinfo)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restart liveAttributes job %s: %w", jinfo.ID, err)
+	}
 	log.Info().Msgf("Restarted liveAttributes job %s", jinfo.ID)
 	return nil
 }
@@ -517,6 +698,62 @@ func (a *Actions) InferredAtomStructure(ctx *gin.Context) {
 	uniresp.WriteJSONResponse(ctx.Writer, &ans)
 }
 
+// liveattrsModifiedAfter tells whether liveattrs data of a corpus
+// were modified after the provided time. In case the time cannot
+// be determined, true is returned.
+func (a *Actions) liveattrsModifiedAfter(corpusID string, t time.Time) bool {
+	corpusDBInfo, err := a.corpusMeta.LoadInfo(corpusID)
+	if err != nil {
+		log.Warn().Err(err).Str("corpusId", corpusID).Msg("failed to get corpus info, considering liveattrs data modified")
+		return true
+	}
+	buildTime, err := db.LoadBuildTime(a.laDB.DB(), corpusDBInfo.GroupedName())
+	if err != nil {
+		log.Warn().Err(err).Str("corpusId", corpusID).Msg("failed to get liveattrs build time, considering liveattrs data modified")
+		return true
+	}
+	if buildTime == nil {
+		log.Warn().Str("corpusId", corpusID).Msg("liveattrs build time not available, considering liveattrs data modified")
+		return true
+	}
+	return buildTime.After(t)
+}
+
+// loadQueryCache loads the query cache dump (if configured and available)
+func (a *Actions) loadQueryCache() {
+	if a.conf.LA.QueryCacheDumpPath == "" {
+		return
+	}
+	if !fs.IsFile(a.conf.LA.QueryCacheDumpPath) {
+		return
+	}
+	log.Info().Msgf("found liveattrs cache dump in %s - loading...", a.conf.LA.QueryCacheDumpPath)
+	err := a.eqCache.Load(a.conf.LA.QueryCacheDumpPath, a.liveattrsModifiedAfter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load liveattrs cache dump")
+	}
+}
+
+// dumpQueryCacheOnExit stores the query cache (if configured)
+// once the service is stopped
+func (a *Actions) dumpQueryCacheOnExit() {
+	defer close(a.exited)
+	<-a.ctx.Done()
+	if a.conf.LA.QueryCacheDumpPath == "" {
+		return
+	}
+	log.Info().Msgf("saving liveattrs cache to %s", a.conf.LA.QueryCacheDumpPath)
+	if err := a.eqCache.Dump(a.conf.LA.QueryCacheDumpPath); err != nil {
+		log.Error().Err(err).Msg("failed to save liveattrs cache")
+	}
+}
+
+// Exited returns a channel which is closed once the liveattrs
+// actions finish their shutdown (i.e. the query cache is stored)
+func (a *Actions) Exited() <-chan struct{} {
+	return a.exited
+}
+
 // NewActions is the recommended factory for Actions
 func NewActions(
 	conf LAConf,
@@ -537,14 +774,19 @@ func NewActions(
 		jobStopChannel:  jobStopChannel,
 		laConfCache:     laConfRegistry,
 		corpusMeta:      corpusMeta,
+		groupedNames:    newGroupedNameCache(corpusMeta, dfltGroupedNameCacheTTL),
 		corpusMetaW:     corpusMetaW,
 		laDB:            laDB,
-		eqCache:         cache.NewEmptyQueryCache(),
+		eqCache:         cache.NewEmptyQueryCache(conf.LA.EmptyQueryCacheMaxEntries, conf.LA.FullQueryCacheTTL()),
 		structAttrStats: db.NewStructAttrUsage(laDB.DB(), usageChan),
 		usageData:       usageChan,
 		vteJobCancel:    make(map[string]context.CancelFunc),
+		exited:          make(chan struct{}),
 	}
+	laConfRegistry.OnConfChange(actions.eqCache.Del)
+	actions.loadQueryCache()
 	go actions.structAttrStats.RunHandler()
 	go actions.runStopJobListener()
+	go actions.dumpQueryCacheOnExit()
 	return actions
 }
@@ -0,0 +1,45 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVteJobCancelConcurrentAccess(t *testing.T) {
+	a := &Actions{vteJobCancel: make(map[string]context.CancelFunc)}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(jobID string) {
+			defer wg.Done()
+			ctx, cancel := context.WithCancel(context.Background())
+			a.setVteJobCancel(jobID, cancel)
+			assert.True(t, a.cancelVteJob(jobID))
+			assert.Error(t, ctx.Err())
+			a.releaseVteJobCancel(jobID)
+			assert.False(t, a.cancelVteJob(jobID))
+		}(fmt.Sprintf("job%d", i))
+	}
+	wg.Wait()
+	assert.Empty(t, a.vteJobCancel)
+}
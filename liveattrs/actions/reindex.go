@@ -0,0 +1,175 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"frodo/corpus"
+	"frodo/jobs"
+	"frodo/liveattrs/db"
+	"frodo/liveattrs/utils"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dfltReindexMaxColumns = 10
+)
+
+// Reindex godoc
+// @Summary      Add missing indexes to liveattrs tables
+// @Description  Start a job creating missing indexes on the `*_liveattrs_entry` table of a corpus. Without explicit columns, the most used columns (according to recorded queries) along with the bib. ID column are indexed. Indexes are created online so the corpus remains searchable. Progress is available via the job info.
+// @Produce      json
+// @Param        corpusId path string true "Used corpus"
+// @Param        column query []string false "Columns to be indexed (either `struct.attr` or `struct_attr`)" collectionFormat(multi)
+// @Param        maxColumns query int false "Max. number of most used columns to be indexed if no columns are specified" default(10)
+// @Success      202 {object} any
+// @Header       202 {string} Location "URL path of the created job"
+// @Failure      429 {object} uniresp.ActionError "Job queue is full"
+// @Failure      400 {object} uniresp.ActionError
+// @Router       /liveAttributes/{corpusId}/reindex [post]
+func (a *Actions) Reindex(ctx *gin.Context) {
+	job, err := a.CreateReindexJob(ctx, jobs.JobRequest{
+		CorpusID: ctx.Param("corpusId"),
+		Query:    ctx.Request.URL.Query(),
+	})
+	jobs.WriteCreatedJobResponse(ctx, job, err)
+}
+
+// CreateReindexJob is a jobs.JobCreator behind the Reindex action
+func (a *Actions) CreateReindexJob(ctx context.Context, req jobs.JobRequest) (jobs.CreatedJob, error) {
+	corpusID := req.CorpusID
+	baseErrTpl := "failed to reindex liveattrs tables of %s: %w"
+	corpusDBInfo, err := a.corpusMeta.LoadInfo(corpusID)
+	if err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+	}
+	columns := req.Query["column"]
+	if len(columns) == 0 {
+		maxColumns := dfltReindexMaxColumns
+		if smax := req.Query.Get("maxColumns"); smax != "" {
+			maxColumns, err = strconv.Atoi(smax)
+			if err != nil || maxColumns < 1 {
+				return jobs.CreatedJob{}, jobs.NewJobRequestError(
+					fmt.Errorf(baseErrTpl, corpusID, fmt.Errorf("invalid maxColumns value: %s", smax)),
+					http.StatusBadRequest,
+				)
+			}
+		}
+		columns, err = db.MostUsedColumns(ctx, a.laDB.DB(), corpusID, maxColumns)
+		if err != nil {
+			return jobs.CreatedJob{}, jobs.NewJobRequestError(
+				fmt.Errorf(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		}
+		if corpusDBInfo.BibIDAttr != "" {
+			bibCol := utils.ImportKey(corpusDBInfo.BibIDAttr)
+			if !slices.Contains(columns, bibCol) {
+				columns = append(columns, bibCol)
+			}
+		}
+	}
+
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+	}
+	jobStatus := db.ReindexJobInfo{
+		ID:       jobID.String(),
+		Type:     db.ReindexJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Result:   &db.ReindexResult{Columns: columns},
+	}
+	fn := a.reindexJobFn(jobStatus, corpusDBInfo, columns)
+	if err := a.jobActions.EnqueueJob(a.jobActions.WithJobContext(jobStatus.ID, fn), jobStatus); err != nil {
+		return jobs.CreatedJob{}, jobs.NewJobRequestError(
+			fmt.Errorf(baseErrTpl, corpusID, err), jobs.EnqueueErrorStatus(err))
+	}
+	return jobs.CreatedJob{JobID: jobStatus.ID, Body: jobStatus.FullInfo()}, nil
+}
+
+// reindexJobFn creates a function of a reindexing job
+func (a *Actions) reindexJobFn(
+	jobStatus db.ReindexJobInfo,
+	corpusDBInfo *corpus.DBInfo,
+	columns []string,
+) jobs.QueuedFuncCtx {
+	return func(jctx context.Context, updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer close(updateJobChan)
+		result, err := db.Reindex(
+			jctx,
+			a.laDB.DB(),
+			corpusDBInfo,
+			columns,
+			func(progress db.ReindexResult) {
+				jobStatus.Update = jobs.CurrentDatetime()
+				jobStatus.Result = &progress
+				updateJobChan <- jobStatus
+			},
+		)
+		jobStatus.Result = &result
+		if err != nil {
+			log.Error().Err(err).Str("corpusId", jobStatus.CorpusID).Msg("liveattrs reindexing failed")
+			updateJobChan <- jobStatus.WithError(err)
+			return
+		}
+		log.Info().
+			Str("corpusId", jobStatus.CorpusID).
+			Strs("createdIndexes", result.CreatedIndexes).
+			Msg("finished liveattrs reindexing")
+		updateJobChan <- jobStatus.AsFinished()
+	}
+}
+
+// ResumeReindexJob is a jobs.JobResumer for detached reindexing jobs.
+// As indexes are created only for columns which do not have one yet,
+// the job can be safely run again.
+func (a *Actions) ResumeReindexJob(jinfo jobs.GeneralJobInfo) (*jobs.QueuedFunc, jobs.GeneralJobInfo, error) {
+	var jobStatus db.ReindexJobInfo
+	switch tJob := jinfo.(type) {
+	case *db.ReindexJobInfo:
+		jobStatus = *tJob
+	case db.ReindexJobInfo:
+		jobStatus = tJob
+	default:
+		return nil, nil, fmt.Errorf("unexpected job info type %T", jinfo)
+	}
+	if jobStatus.Result == nil || len(jobStatus.Result.Columns) == 0 {
+		return nil, nil, fmt.Errorf("no columns to be indexed found in job %s", jobStatus.ID)
+	}
+	corpusDBInfo, err := a.corpusMeta.LoadInfo(jobStatus.CorpusID)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := jobStatus.Result.Columns
+	jobStatus.Start = jobs.CurrentDatetime()
+	jobStatus.Update = jobs.CurrentDatetime()
+	jobStatus.NumRestarts++
+	jobStatus.Error = nil
+	jobStatus.Result = &db.ReindexResult{Columns: columns}
+	fn := a.reindexJobFn(jobStatus, corpusDBInfo, columns)
+	return a.jobActions.WithJobContext(jobStatus.ID, fn), jobStatus, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"frodo/liveattrs/db/qbuilder/laquery"
+	"frodo/liveattrs/laconf"
+	"frodo/liveattrs/request/query"
+	"frodo/liveattrs/request/response"
+	"frodo/liveattrs/utils"
+	"frodo/middleware"
+	"net/http"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// streamFlushInterval specifies how many rows are written
+	// to a streamed response before the output is flushed
+	streamFlushInterval = 1000
+)
+
+// QueryStream godoc
+// @Summary      Query liveattrs for specified corpus and stream matching rows as NDJSON
+// @Description  Unlike the `query` endpoint, the values are not accumulated in memory.
+// @Description  Each line represents one liveattrs database row (i.e. a single combination
+// @Description  of attribute values along with its number of positions) and the last line
+// @Description  is a summary. This keeps memory usage low even for unconstrained listings
+// @Description  on large corpora, but the tradeoff is that no exact grouping is performed -
+// @Description  the same value may occur on multiple lines, bib. items are not grouped and
+// @Description  no cutoff, sorting, buckets or other export transformations are applied
+// @Description  (the respective query arguments are ignored). Clients must aggregate
+// @Description  the counts by themselves if needed.
+// @Accept  	 json
+// @Produce      application/x-ndjson
+// @Param        corpusId path string true "An ID of a corpus for which to make query"
+// @Param 		 queryArgs body query.Payload true "Query arguments"
+// @Success      200 {object} response.StreamedRow "one per line, followed by response.StreamSummary"
+// @Router       /liveAttributes/{corpusId}/query/stream [post]
+func (a *Actions) QueryStream(ctx *gin.Context) {
+	t0 := time.Now()
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to stream liveattrs query in corpus %s: %w"
+	var qry query.Payload
+	err := json.NewDecoder(ctx.Request.Body).Decode(&qry)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
+		return
+	}
+	if err := qry.ValidateConditions(); err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	corpInfo, err := a.corpusMeta.LoadInfo(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	prepared, err := a.prepareQuery(corpInfo, qry, 0)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if errors.Is(err, utils.ErrorAmbiguousAttr) || errors.Is(err, utils.ErrorUnknownAttr) ||
+		errors.Is(err, utils.ErrorNonNumericAttr) || errors.Is(err, ErrorUnknownAlignedCorpus) {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	dataIterator := laquery.DataIterator{
+		DB:      a.laDB.DB(),
+		Builder: prepared.filter,
+	}
+	colAttrs := prepared.filter.ColumnAttrs()
+
+	ctx.Writer.Header().Set("Content-Type", middleware.NDJSONContentType)
+	middleware.DisableWriteDeadline(ctx)
+	ctx.Writer.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(ctx.Writer)
+	summary := response.StreamSummary{Done: true}
+	err = dataIterator.Iterate(ctx.Request.Context(), func(row laquery.ResultRow) error {
+		item := response.StreamedRow{
+			Poscount: row.Poscount,
+			Attrs:    make(map[string]string, len(row.Attrs)),
+		}
+		for dbKey, dbVal := range row.Attrs {
+			colKey, ok := colAttrs[dbKey]
+			if !ok {
+				colKey = utils.ExportKey(dbKey)
+			}
+			item.Attrs[colKey] = dbVal
+		}
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+		summary.Poscount += row.Poscount
+		summary.NumRows++
+		if summary.NumRows%streamFlushInterval == 0 {
+			ctx.Writer.Flush()
+		}
+		return nil
+	})
+	a.logSlowQuery(corpInfo.Name, prepared.qry, time.Since(t0), summary.NumRows)
+	if errors.Is(err, context.Canceled) {
+		log.Info().
+			Str("corpusId", corpusID).
+			Int("processedRows", summary.NumRows).
+			Msg("liveAttributes data streaming cancelled")
+		return
+
+	} else if err != nil {
+		log.Error().
+			Err(err).
+			Str("corpusId", corpusID).
+			Int("processedRows", summary.NumRows).
+			Msg("liveAttributes failed to stream data")
+		summary.Done = false
+		summary.Error = err.Error()
+	}
+	if err := enc.Encode(summary); err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to write liveattrs stream summary")
+		return
+	}
+	ctx.Writer.Flush()
+}
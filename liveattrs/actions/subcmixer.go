@@ -17,41 +17,104 @@
 package actions
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"frodo/common"
+	"frodo/db/tables"
 	"frodo/general/collections"
 	"frodo/liveattrs/subcmixer"
+	"frodo/liveattrs/utils"
+	"frodo/middleware"
 	"net/http"
 	"strings"
 
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 const (
 	corpusMaxSize = 500000000
 )
 
+// subcmixerRatio specifies a required ratio (in percents) of texts
+// matching the `attrName op attrValue` condition (e.g. `doc.txtype == "fiction"`).
+// Ratios of the same attribute are expected to sum up to (at most) 100.
 type subcmixerRatio struct {
-	AttrName  string  `json:"attrName"`
-	AttrValue string  `json:"attrValue"`
-	Ratio     float64 `json:"ratio"`
+	AttrName  string `json:"attrName"`
+	AttrValue string `json:"attrValue"`
+
+	// Op is the condition operator (==, <>, <, <=, >, >=).
+	// If empty, == is used.
+	Op    string  `json:"op,omitempty"`
+	Ratio float64 `json:"ratio"`
 }
 
+func (sr subcmixerRatio) op() string {
+	if sr.Op == "" {
+		return "=="
+	}
+	return sr.Op
+}
+
+// subcmixerArgs represents the request body of the MixSubcorpus action:
+//
+//	{
+//	  "corpora": ["intercorp_v16ud_cs", "intercorp_v16ud_en"],
+//	  "textTypes": [
+//	    {"attrName": "div.txtype", "attrValue": "fiction", "ratio": 70},
+//	    {"attrName": "div.txtype", "attrValue": "journalism", "ratio": 30}
+//	  ]
+//	}
+//
+// The `corpora` list is optional. If present, its first item must be
+// the corpus the action is called for and the other items are aligned
+// corpora the mixed texts must be present in.
 type subcmixerArgs struct {
 	Corpora   []string         `json:"corpora"`
 	TextTypes []subcmixerRatio `json:"textTypes"`
 }
 
+// alignedCorpora returns aligned corpora specified in args and
+// checks that the primary corpus matches the one from URL
+func (sa *subcmixerArgs) alignedCorpora(corpusID string) ([]string, error) {
+	if len(sa.Corpora) == 0 {
+		return []string{}, nil
+	}
+	if sa.Corpora[0] != corpusID {
+		return nil, fmt.Errorf("the first item of corpora must be %s", corpusID)
+	}
+	return sa.Corpora[1:], nil
+}
+
 func (sa *subcmixerArgs) validate() error {
+	if len(sa.TextTypes) == 0 {
+		return fmt.Errorf("no ratio rules specified")
+	}
 	currStruct := ""
+	ratioSums := make(map[string]float64)
 	for _, tt := range sa.TextTypes {
+		if !utils.IsValidAttrName(tt.AttrName) || strings.HasPrefix(tt.AttrName, "!") {
+			return fmt.Errorf("%w: %s", utils.ErrorUnknownAttr, tt.AttrName)
+		}
 		strc := strings.Split(tt.AttrName, ".")
 		if currStruct != "" && currStruct != strc[0] {
 			return fmt.Errorf("the ratio rules for subcmixer may contain only attributes of a single structure")
 		}
 		currStruct = strc[0]
+		if _, err := subcmixer.NewCategoryExpression(tt.AttrName, tt.op(), tt.AttrValue); err != nil {
+			return err
+		}
+		if tt.Ratio < 0 || tt.Ratio > 100 {
+			return fmt.Errorf("invalid ratio %01.2f for %s (must be between 0 and 100)", tt.Ratio, tt.AttrName)
+		}
+		ratioSums[tt.AttrName] += tt.Ratio
+	}
+	for attr, sum := range ratioSums {
+		if sum > 100.001 {
+			return fmt.Errorf("ratios of %s sum up to %01.2f which is more than 100", attr, sum)
+		}
 	}
 	return nil
 }
@@ -76,7 +139,7 @@ func importTaskArgs(args subcmixerArgs) ([]subcmixer.TaskArgs, error) {
 		tmp := []subcmixer.TaskArgs{}
 		for _, pg := range ans[len(ans)-1] {
 			for _, item := range expressions {
-				sm, err := subcmixer.NewCategoryExpression(item.AttrName, "==", item.AttrValue)
+				sm, err := subcmixer.NewCategoryExpression(item.AttrName, item.op(), item.AttrValue)
 				if err != nil {
 					return err
 				}
@@ -109,49 +172,69 @@ func importTaskArgs(args subcmixerArgs) ([]subcmixer.TaskArgs, error) {
 
 // MixSubcorpus godoc
 // @Summary      Mix subcorpus for specified corpus
+// @Description  Finds a set of documents (identified by the corpus bib. ID attribute) matching required ratios of text types. The category tree is built from the ratio rules and the sizes are solved with respect to the available data in the liveattrs database. The response contains `docIds` (the selected documents), `sizeAssembled` (the total size in positions), `categorySizes` (a list of `{"total", "ratio", "expression"}` items) and possibly `error` in case the solver failed.
 // @Accept  	 json
 // @Produce      json
 // @Param        corpusId path string true "Used corpus"
 // @Param 		 queryArgs body subcmixerArgs true "Query arguments"
 // @Success      200 {object} subcmixer.CorpusComposition
+// @Failure      400 {object} uniresp.ActionError "Invalid corpora specification"
+// @Failure      404 {object} uniresp.ActionError "Corpus not found"
+// @Failure      422 {object} uniresp.ActionError "Invalid ratio rules"
 // @Router       /liveAttributes/{corpusId}/mixSubcorpus [post]
 func (a *Actions) MixSubcorpus(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to mix subcorpus for %s: %w"
 	var args subcmixerArgs
 	err := json.NewDecoder(ctx.Request.Body).Decode(&args)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError("failed to mix subcorpus: %w", err), http.StatusBadRequest)
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
+		return
+	}
+	alignedCorpora, err := args.alignedCorpora(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
 		return
 	}
-	baseErrTpl := "failed to mix subcorpus for %s: %w"
 	err = args.validate()
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusUnprocessableEntity)
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusUnprocessableEntity)
+		return
+	}
+	corpusDBInfo, err := a.corpusMeta.LoadInfo(corpusID)
+	if err == sql.ErrNoRows {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
 	}
 	conditions, err := importTaskArgs(args)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusInternalServerError)
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
 	}
-	laTableName := fmt.Sprintf("%s_liveattrs_entry", args.Corpora[0])
+	// similarly to other liveattrs queries, members of parallel corpora
+	// share a single table (see corpus.DBInfo.GroupedName)
+	laTableName := tables.Name(corpusDBInfo.GroupedName(), tables.LiveAttrsEntry)
 	catTree, err := subcmixer.NewCategoryTree(
 		conditions,
 		a.laDB.DB(),
-		args.Corpora[0],
-		args.Corpora[1:],
+		corpusID,
+		alignedCorpora,
 		laTableName,
 		corpusMaxSize,
 	)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusInternalServerError)
-		return
-	}
-	corpusDBInfo, err := a.corpusMeta.LoadInfo(args.Corpora[0])
-	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusInternalServerError)
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
 	mm, err := subcmixer.NewMetadataModel(
@@ -162,9 +245,12 @@ func (a *Actions) MixSubcorpus(ctx *gin.Context) {
 	)
 	if err != nil {
 		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, args.Corpora[0], err), http.StatusInternalServerError)
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
 		return
 	}
 	ans := mm.Solve()
+	if ans.Error != "" {
+		log.Error().Str("corpusId", corpusID).Str("error", ans.Error).Msg("subcmixer failed to solve the task")
+	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
@@ -0,0 +1,246 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"frodo/corpus"
+	"frodo/jobs"
+	"frodo/liveattrs"
+	"frodo/liveattrs/db"
+	"frodo/liveattrs/laconf"
+	"frodo/liveattrs/utils"
+	"frodo/middleware"
+	"net/http"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	vteCnf "github.com/czcorpus/vert-tagextract/v3/cnf"
+	vteLib "github.com/czcorpus/vert-tagextract/v3/library"
+	vteProc "github.com/czcorpus/vert-tagextract/v3/proc"
+)
+
+// updateData runs an incremental update of liveattrs data. First, the updated
+// documents are extracted (in the append mode) from the provided vertical
+// files and then the original rows of all the affected documents are removed.
+//
+// The extraction is performed on a working copy of the table which replaces
+// the production one only once the extraction is finished. So in case
+// it fails, the data remain untouched. The removal of the original rows
+// (identified by their IDs lower than the IDs of the newly imported rows)
+// runs in a single transaction. In case it fails, the update can be repeated
+// as it always removes all the rows imported before the current run.
+// In case the job cannot be enqueued, an error is returned.
+func (a *Actions) updateData(initialStatus *liveattrs.LiveAttrsJobInfo) error {
+	jctx, cancel := context.WithCancel(a.ctx)
+	a.setVteJobCancel(initialStatus.ID, cancel)
+	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+		defer func() {
+			close(updateJobChan)
+			a.releaseVteJobCancel(initialStatus.ID)
+		}()
+		jobStatus := *initialStatus
+		jobStatus.Type = liveattrs.UpdateJobType
+		jobStatus.Update = jobs.CurrentDatetime()
+		incArgs := jobStatus.Args.Incremental
+		if incArgs == nil {
+			updateJobChan <- jobStatus.WithError(fmt.Errorf("missing incremental update arguments"))
+			return
+		}
+		corpusDBInfo, err := a.corpusMeta.LoadInfo(jobStatus.CorpusID)
+		if err != nil {
+			updateJobChan <- jobStatus.WithError(err)
+			return
+		}
+		maxID, err := db.MaxEntryID(jctx, a.laDB.DB(), corpusDBInfo.GroupedName())
+		if err != nil {
+			updateJobChan <- jobStatus.WithError(err)
+			return
+		}
+
+		if len(incArgs.UpdatedDocs) > 0 {
+			procStatus, err := vteLib.ExtractData(jctx, &jobStatus.Args.VteConf, true)
+			if err != nil {
+				updateJobChan <- jobStatus.WithError(
+					fmt.Errorf("failed to start vert-tagextract: %s", err))
+				return
+			}
+			for upd := range procStatus {
+				if upd.Error == vteProc.ErrorTooManyParsingErrors {
+					jobStatus.Error = upd.Error
+				}
+				jobStatus.ProcessedAtoms = upd.ProcessedAtoms
+				jobStatus.ProcessedLines = upd.ProcessedLines
+				jobStatus.ProcessedTokens = upd.ProcessedTokens
+				jobStatus.Update = jobs.CurrentDatetime()
+				updateJobChan <- jobStatus
+
+				if upd.Error == vteProc.ErrorTooManyParsingErrors {
+					log.Error().
+						Str("corpusId", jobStatus.CorpusID).
+						Err(upd.Error).
+						Msg("live attributes incremental update failed")
+					return
+
+				} else if upd.Error != nil {
+					log.Error().Str("corpusId", jobStatus.CorpusID).Err(upd.Error).Msg("(just registered)")
+				}
+			}
+		}
+		numRemoved, err := a.removeDocuments(jctx, jobStatus.CorpusID, corpusDBInfo, incArgs.AffectedDocs(), maxID)
+		if err != nil {
+			updateJobChan <- jobStatus.WithError(err)
+			return
+		}
+		jobStatus.NumRemovedRows = numRemoved
+		// The whole corpus cache is invalidated on purpose. Cached results
+		// contain value counts of all the listed attributes so changing
+		// even a single document may affect any of them. Also, we do not
+		// know which attribute values the updated documents had before.
+		a.eqCache.Del(jobStatus.CorpusID)
+		log.Info().
+			Str("corpusId", jobStatus.CorpusID).
+			Int("numRemovedRows", jobStatus.NumRemovedRows).
+			Int("processedAtoms", jobStatus.ProcessedAtoms).
+			Msg("finished incremental liveattrs update")
+		updateJobChan <- jobStatus.AsFinished()
+	}
+	return a.enqueueVteJob(&fn, initialStatus)
+}
+
+// removeDocuments removes rows of the documents imported
+// before the current update (i.e. with IDs up to maxID)
+func (a *Actions) removeDocuments(
+	ctx context.Context,
+	corpusID string,
+	corpusDBInfo *corpus.DBInfo,
+	docIDs []string,
+	maxID int64,
+) (int, error) {
+	if corpusDBInfo.BibIDAttr == "" {
+		return 0, fmt.Errorf("failed to remove documents: bibIdAttr not defined for %s", corpusID)
+	}
+	return db.RemoveDocuments(
+		ctx,
+		a.laDB.DB(),
+		corpusDBInfo.GroupedName(),
+		corpusID,
+		utils.ImportKey(corpusDBInfo.BibIDAttr),
+		docIDs,
+		maxID,
+	)
+}
+
+// UpdateData godoc
+// @Summary      Incrementally update liveattrs data of a corpus
+// @Description  Remove rows of removed and updated documents and extract the updated documents from provided vertical files (which should contain just the updated documents). This is much faster than a full rebuild in case of small changes.
+// @Accept  	 json
+// @Produce      json
+// @Param        corpusId path string true "Used corpus"
+// @Param 		 updateArgs body liveattrs.IncrementalUpdateArgs true "Affected documents"
+// @Param        force query int false "Create the job even if another liveattrs job for the corpus is unfinished" default(0)
+// @Success      202 {object} any
+// @Header       202 {string} Location "URL path of the created job"
+// @Failure      409 {object} jobs.JobConflictResponse "A liveattrs job for the corpus is unfinished"
+// @Failure      429 {object} uniresp.ActionError "Job queue is full"
+// @Router       /liveAttributes/{corpusId}/data [patch]
+func (a *Actions) UpdateData(ctx *gin.Context) {
+	corpusID := ctx.Param("corpusId")
+	baseErrTpl := "failed to update liveattrs data for %s: %w"
+	var args liveattrs.IncrementalUpdateArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), middleware.RequestBodyErrorStatus(err))
+		return
+	}
+	if err := args.Validate(); err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusBadRequest)
+		return
+	}
+	corpusDBInfo, err := a.corpusMeta.LoadInfo(corpusID)
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	if corpusDBInfo.BibIDAttr == "" {
+		err := fmt.Errorf("bibIdAttr not defined, cannot identify documents")
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusUnprocessableEntity)
+		return
+	}
+	conf, err := a.laConfCache.Get(corpusID)
+	if err == laconf.ErrorNoSuchConfig {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	runtimeConf := *conf
+	runtimeConf.VerticalFile = ""
+	runtimeConf.VerticalFiles = args.VerticalFiles
+	// n-grams are not supported in the incremental mode
+	runtimeConf.Ngrams = vteCnf.NgramConf{}
+
+	jobID, err := uuid.NewUUID()
+	if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		return
+	}
+	status := &liveattrs.LiveAttrsJobInfo{
+		ID:       jobID.String(),
+		Type:     liveattrs.UpdateJobType,
+		CorpusID: corpusID,
+		Start:    jobs.CurrentDatetime(),
+		Update:   jobs.CurrentDatetime(),
+		Args: liveattrs.JobInfoArgs{
+			VteConf:          runtimeConf,
+			Append:           true,
+			NoCorpusDBUpdate: true,
+			Incremental:      &args,
+		},
+	}
+	err = a.jobActions.EnqueueIfNoConflict(
+		ctx.Query("force") == "1",
+		corpusID,
+		[]string{liveattrs.JobType, liveattrs.UpdateJobType},
+		func() error { return a.updateData(status) },
+	)
+	var confErr jobs.ConflictingJobError
+	if errors.As(err, &confErr) {
+		jobs.WriteJobConflictResponse(ctx, confErr)
+		return
+
+	} else if err != nil {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), jobs.EnqueueErrorStatus(err))
+		return
+	}
+	jobs.WriteJobAcceptedResponse(ctx, status.ID, status.FullInfo())
+}
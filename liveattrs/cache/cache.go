@@ -17,10 +17,13 @@
 package cache
 
 import (
+	"container/list"
+	"encoding/json"
 	"frodo/liveattrs/request/query"
 	"frodo/liveattrs/request/response"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -29,29 +32,133 @@ func mkKey(corpusID string, aligned []string) string {
 	return strings.Join(append(aligned, corpusID), ":")
 }
 
+// mkFullKey creates a cache key based on the whole query
+// (used for non-empty queries)
+func mkFullKey(corpusID string, qry query.Payload) (string, error) {
+	rawQuery, err := json.Marshal(qry)
+	if err != nil {
+		return "", err
+	}
+	return mkKey(corpusID, qry.Aligned) + "|" + string(rawQuery), nil
+}
+
+func isEmptyQuery(qry query.Payload) bool {
+	return len(qry.Attrs) == 0 && len(qry.Buckets) == 0 && qry.MinCount == 0 && qry.SortBy == "" &&
+		len(qry.Sort) == 0 && !qry.ApproxCounts
+}
+
+type cacheItem struct {
+	key   string
+	value *response.QueryAns
+
+	// expires is a zero time for items which never expire
+	expires time.Time
+}
+
+func (item *cacheItem) isExpired(t time.Time) bool {
+	return !item.expires.IsZero() && t.After(item.expires)
+}
+
+// Stats contains basic monitoring data of EmptyQueryCache
+type Stats struct {
+	Size       int    `json:"size"`
+	MaxEntries int    `json:"maxEntries"`
+	Hits       uint64 `json:"hits"`
+	Misses     uint64 `json:"misses"`
+
+	// Evictions counts entries removed due to the size limit
+	// or due to expiration
+	Evictions uint64 `json:"evictions"`
+
+	// Invalidations counts entries removed via Del
+	Invalidations uint64 `json:"invalidations"`
+
+	// CorpusKeys contains numbers of cache keys each corpus
+	// is involved in
+	CorpusKeys map[string]int `json:"corpusKeys"`
+}
+
 // EmptyQueryCache provides caching for any query with attributes empty.
 // It is perfectly OK to Get/Set any query but only the ones with attributes
-// empty will be actually stored. For other ones, nil is always returned by Get.
+// empty (and with no value bucketing) will be actually stored. For other ones, nil is always returned by Get.
+// Optionally (with fullQueryTTL set), also non-empty queries are cached (keyed
+// by the whole query) for a limited time.
+// In case maxEntries is set, the least recently used entries are evicted
+// once the limit is exceeded.
 type EmptyQueryCache struct {
 
-	// data contains cached results for initial corpus+aligned corpora text types listings
-	data map[string]*response.QueryAns
+	// data contains cached results for initial corpus+aligned corpora text types listings.
+	// The values are elements of the `lru` list.
+	data map[string]*list.Element
+
+	// lru keeps cache items ordered by their last access
+	// (the most recently used item is at the front)
+	lru *list.List
+
+	// maxEntries is a maximum number of stored results.
+	// Zero means "no limit".
+	maxEntries int
+
+	// fullQueryTTL specifies how long results of non-empty queries
+	// are cached. Zero means "do not cache non-empty queries".
+	fullQueryTTL time.Duration
 
 	// corpKeyDeps maps corpus ID to cache keys it is involved in.
 	// This allows us removing all the affected results once a single corpus
 	// changes
 	corpKeyDeps map[string][]string
 
+	hits          uint64
+	misses        uint64
+	evictions     uint64
+	invalidations uint64
+
 	lock sync.Mutex
 }
 
 // Get returns a cached result based on provided corpus (and possible aligned corpora)
 // In case nothing is found, nil is returned
 func (qc *EmptyQueryCache) Get(corpusID string, qry query.Payload) *response.QueryAns {
-	if len(qry.Attrs) > 0 {
+	cKey, ok := qc.cacheKey(corpusID, qry)
+	if !ok {
+		return nil
+	}
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	elm, ok := qc.data[cKey]
+	if !ok {
+		qc.misses++
 		return nil
 	}
-	return qc.data[mkKey(corpusID, qry.Aligned)]
+	item := elm.Value.(*cacheItem)
+	if item.isExpired(time.Now()) {
+		qc.lru.Remove(elm)
+		delete(qc.data, cKey)
+		qc.pruneKeyInDeps(cKey)
+		qc.misses++
+		qc.evictions++
+		return nil
+	}
+	qc.hits++
+	qc.lru.MoveToFront(elm)
+	return item.value
+}
+
+// cacheKey returns a cache key for a query. In case the query
+// is not cacheable, false is returned.
+func (qc *EmptyQueryCache) cacheKey(corpusID string, qry query.Payload) (string, bool) {
+	if isEmptyQuery(qry) {
+		return mkKey(corpusID, qry.Aligned), true
+	}
+	if qc.fullQueryTTL <= 0 {
+		return "", false
+	}
+	cKey, err := mkFullKey(corpusID, qry)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create liveattrs cache key, skipping cache")
+		return "", false
+	}
+	return cKey, true
 }
 
 // setKeyCorpusDependency create a dependency between corpus and cache key
@@ -71,21 +178,50 @@ func (qc *EmptyQueryCache) setKeyCorpusDependency(corpusID, key string) {
 }
 
 func (qc *EmptyQueryCache) Set(corpusID string, qry query.Payload, value *response.QueryAns) {
-	if len(qry.Attrs) > 0 {
+	cKey, ok := qc.cacheKey(corpusID, qry)
+	if !ok {
 		return
 	}
+	var expires time.Time
+	if !isEmptyQuery(qry) {
+		expires = time.Now().Add(qc.fullQueryTTL)
+	}
 	qc.lock.Lock()
-	cKey := mkKey(corpusID, qry.Aligned)
-	qc.data[cKey] = value
+	if elm, ok := qc.data[cKey]; ok {
+		item := elm.Value.(*cacheItem)
+		item.value = value
+		item.expires = expires
+		qc.lru.MoveToFront(elm)
+
+	} else {
+		qc.data[cKey] = qc.lru.PushFront(&cacheItem{key: cKey, value: value, expires: expires})
+	}
 	qc.setKeyCorpusDependency(corpusID, cKey)
 	for _, alignedCorpusID := range qry.Aligned {
 		qc.setKeyCorpusDependency(alignedCorpusID, cKey)
 	}
+	qc.evictOverflowing()
 	qc.lock.Unlock()
 }
 
+// evictOverflowing removes the least recently used entries
+// in case the cache exceeds its maximum size.
+// The method expects the cache to be already locked.
+func (qc *EmptyQueryCache) evictOverflowing() {
+	for qc.maxEntries > 0 && len(qc.data) > qc.maxEntries {
+		elm := qc.lru.Back()
+		item := elm.Value.(*cacheItem)
+		qc.lru.Remove(elm)
+		delete(qc.data, item.key)
+		qc.pruneKeyInDeps(item.key)
+		qc.evictions++
+		log.Debug().Str("key", item.key).Msg("evicting liveattrs cache entry")
+	}
+}
+
 // pruneKeyInDeps in corpus key dependency mapping, remove all
-// the values of "key". Return number of removed occurrences.
+// the values of "key". Corpora with no remaining keys are removed
+// from the mapping. Return number of removed occurrences.
 func (qc *EmptyQueryCache) pruneKeyInDeps(key string) int {
 	var totalRemoved int
 	for corpID, keys := range qc.corpKeyDeps {
@@ -98,7 +234,12 @@ func (qc *EmptyQueryCache) pruneKeyInDeps(key string) int {
 				totalRemoved++
 			}
 		}
-		qc.corpKeyDeps[corpID] = newKeys
+		if len(newKeys) > 0 {
+			qc.corpKeyDeps[corpID] = newKeys
+
+		} else {
+			delete(qc.corpKeyDeps, corpID)
+		}
 	}
 	return totalRemoved
 }
@@ -108,7 +249,11 @@ func (qc *EmptyQueryCache) Del(corpusID string) {
 	cInv := qc.corpKeyDeps[corpusID]
 	var totalPruned int
 	for _, key := range cInv {
-		delete(qc.data, key)
+		if elm, ok := qc.data[key]; ok {
+			qc.lru.Remove(elm)
+			delete(qc.data, key)
+			qc.invalidations++
+		}
 		totalPruned += qc.pruneKeyInDeps(key)
 	}
 	delete(qc.corpKeyDeps, corpusID)
@@ -120,9 +265,36 @@ func (qc *EmptyQueryCache) Del(corpusID string) {
 	qc.lock.Unlock()
 }
 
-func NewEmptyQueryCache() *EmptyQueryCache {
+// Stats returns the current size, counters and per-corpus
+// key counts of the cache
+func (qc *EmptyQueryCache) Stats() Stats {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	corpusKeys := make(map[string]int, len(qc.corpKeyDeps))
+	for corpusID, keys := range qc.corpKeyDeps {
+		corpusKeys[corpusID] = len(keys)
+	}
+	return Stats{
+		Size:          len(qc.data),
+		MaxEntries:    qc.maxEntries,
+		Hits:          qc.hits,
+		Misses:        qc.misses,
+		Evictions:     qc.evictions,
+		Invalidations: qc.invalidations,
+		CorpusKeys:    corpusKeys,
+	}
+}
+
+// NewEmptyQueryCache creates a new cache. The maxEntries argument
+// specifies a maximum number of stored results (zero means no limit).
+// The fullQueryTTL enables caching of non-empty queries for
+// the specified time (zero disables the mode).
+func NewEmptyQueryCache(maxEntries int, fullQueryTTL time.Duration) *EmptyQueryCache {
 	return &EmptyQueryCache{
-		data:        make(map[string]*response.QueryAns),
-		corpKeyDeps: make(map[string][]string),
+		data:         make(map[string]*list.Element),
+		lru:          list.New(),
+		maxEntries:   maxEntries,
+		fullQueryTTL: fullQueryTTL,
+		corpKeyDeps:  make(map[string][]string),
 	}
 }
@@ -17,9 +17,13 @@
 package cache
 
 import (
+	"encoding/gob"
 	"frodo/liveattrs/request/query"
 	"frodo/liveattrs/request/response"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,7 +31,7 @@ import (
 // createTestingCache create a testing cache with an entry
 // for corpus 'corp1' and aligned corpora 'corp2', 'corp3'
 func createTestingCache() (*EmptyQueryCache, query.Payload, response.QueryAns) {
-	qcache := NewEmptyQueryCache()
+	qcache := NewEmptyQueryCache(0, 0)
 	qry := query.Payload{
 		Aligned: []string{"corp2", "corp3"},
 	}
@@ -95,3 +99,102 @@ func TestCacheDelAligned(t *testing.T) {
 	assert.Equal(t, 0, len(qcache.data))
 	assert.Equal(t, 0, len(qcache.corpKeyDeps))
 }
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	qcache := NewEmptyQueryCache(2, 0)
+	qry1 := query.Payload{Aligned: []string{"corp2"}}
+	qry2 := query.Payload{}
+	qcache.Set("corp1", qry1, &response.QueryAns{})
+	qcache.Set("corp3", qry2, &response.QueryAns{})
+	assert.NotNil(t, qcache.Get("corp1", qry1)) // corp3 becomes the LRU entry
+	qcache.Set("corp4", qry2, &response.QueryAns{})
+
+	assert.Nil(t, qcache.Get("corp3", qry2))
+	assert.NotNil(t, qcache.Get("corp1", qry1))
+	assert.NotNil(t, qcache.Get("corp4", qry2))
+	assert.NotContains(t, qcache.corpKeyDeps, "corp3")
+	assert.Contains(t, qcache.corpKeyDeps, "corp2")
+	stats := qcache.Stats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, map[string]int{"corp1": 1, "corp2": 1, "corp4": 1}, stats.CorpusKeys)
+
+	qcache.Del("corp2")
+	assert.Equal(t, uint64(1), qcache.Stats().Invalidations)
+}
+
+func TestCacheNonEmptyQueries(t *testing.T) {
+	qry := query.Payload{
+		Aligned: []string{"corp2"},
+		Attrs:   query.Attrs{"doc.genre": "fiction"},
+	}
+	qcache := NewEmptyQueryCache(0, 0)
+	qcache.Set("corp1", qry, &response.QueryAns{})
+	assert.Nil(t, qcache.Get("corp1", qry))
+
+	qcache = NewEmptyQueryCache(0, time.Hour)
+	value := response.QueryAns{AlignedCorpora: []string{"corp2"}}
+	qcache.Set("corp1", qry, &value)
+	assert.Equal(t, &value, qcache.Get("corp1", qry))
+	assert.Nil(t, qcache.Get("corp1", query.Payload{Aligned: []string{"corp2"}}))
+	qcache.Del("corp2")
+	assert.Nil(t, qcache.Get("corp1", qry))
+
+	qcache = NewEmptyQueryCache(0, time.Nanosecond)
+	qcache.Set("corp1", qry, &value)
+	time.Sleep(time.Millisecond)
+	assert.Nil(t, qcache.Get("corp1", qry))
+	assert.Equal(t, 0, qcache.Stats().Size)
+}
+
+func TestCacheDumpAndLoad(t *testing.T) {
+	gob.Register([]*response.ListedValue{})
+	qcache, qry, value := createTestingCache()
+	qcache.Set("corp4", query.Payload{}, &response.QueryAns{Poscount: 10})
+	dumpPath := filepath.Join(t.TempDir(), "cache.gob")
+	assert.NoError(t, qcache.Dump(dumpPath))
+
+	loaded := NewEmptyQueryCache(0, 0)
+	err := loaded.Load(dumpPath, func(corpusID string, dumpTime time.Time) bool {
+		return corpusID == "corp4"
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, value, *loaded.Get("corp1", qry))
+	assert.Nil(t, loaded.Get("corp4", query.Payload{}))
+	assert.NotContains(t, loaded.corpKeyDeps, "corp4")
+	assert.Contains(t, loaded.corpKeyDeps, "corp3")
+}
+
+func TestCacheSkipsSortedQuery(t *testing.T) {
+	qcache := NewEmptyQueryCache(0, 0)
+	qry := query.Payload{
+		Sort: query.SortSpec{"doc.title": {Key: query.SortKeyCount, Desc: true}},
+	}
+	assert.False(t, isEmptyQuery(qry))
+	qcache.Set("corp1", qry, &response.QueryAns{})
+	assert.Nil(t, qcache.Get("corp1", qry))
+	assert.Nil(t, qcache.Get("corp1", query.Payload{}))
+}
+
+func TestCacheFailedDumpKeepsPreviousFile(t *testing.T) {
+	qcache, _, _ := createTestingCache()
+	dir := t.TempDir()
+	dumpPath := filepath.Join(dir, "cache.gob")
+	assert.NoError(t, qcache.Dump(dumpPath))
+	orig, err := os.ReadFile(dumpPath)
+	assert.NoError(t, err)
+
+	type unregistered struct{ V int }
+	qcache.Set("corp5", query.Payload{}, &response.QueryAns{
+		AttrValues: map[string]any{"attrC": unregistered{V: 1}},
+	})
+	assert.Error(t, qcache.Dump(dumpPath))
+	curr, err := os.ReadFile(dumpPath)
+	assert.NoError(t, err)
+	assert.Equal(t, orig, curr)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"encoding/gob"
+	"fmt"
+	"frodo/liveattrs/request/response"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// dumpFormatVersion must be incremented each time
+	// the cacheDump type (or the QueryAns type) changes.
+	dumpFormatVersion = 1
+)
+
+type dumpItem struct {
+	Key     string
+	Value   *response.QueryAns
+	Expires time.Time
+}
+
+// cacheDump is a serializable form of EmptyQueryCache.
+// Items are ordered from the least recently used one.
+// Please note that all the types stored in QueryAns.AttrValues
+// must be registered via gob.Register.
+type cacheDump struct {
+	Version     int
+	Created     time.Time
+	Items       []dumpItem
+	CorpKeyDeps map[string][]string
+}
+
+// Dump stores cached data to a file so they can be
+// loaded after service restart (see Load). The data are written
+// to a temporary file first so an interrupted dump cannot
+// leave a truncated file behind.
+func (qc *EmptyQueryCache) Dump(path string) error {
+	qc.lock.Lock()
+	data := cacheDump{
+		Version:     dumpFormatVersion,
+		Created:     time.Now(),
+		Items:       make([]dumpItem, 0, len(qc.data)),
+		CorpKeyDeps: make(map[string][]string, len(qc.corpKeyDeps)),
+	}
+	for elm := qc.lru.Back(); elm != nil; elm = elm.Prev() {
+		item := elm.Value.(*cacheItem)
+		data.Items = append(
+			data.Items, dumpItem{Key: item.key, Value: item.value, Expires: item.expires})
+	}
+	for corpusID, keys := range qc.corpKeyDeps {
+		data.CorpKeyDeps[corpusID] = append([]string{}, keys...)
+	}
+	qc.lock.Unlock()
+
+	fw, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to dump liveattrs cache: %w", err)
+	}
+	err = gob.NewEncoder(fw).Encode(data)
+	if err == nil {
+		err = fw.Sync()
+	}
+	if cErr := fw.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(fw.Name(), path)
+	}
+	if err != nil {
+		os.Remove(fw.Name())
+		return fmt.Errorf("failed to dump liveattrs cache: %w", err)
+	}
+	return nil
+}
+
+// Load loads data previously stored via Dump. Entries involving
+// corpora for which isOutdated returns true (e.g. because their
+// liveattrs data were rebuilt after the dump) are discarded as well as
+// expired entries. A dump of a different format version is ignored.
+// Existing cached data are replaced.
+func (qc *EmptyQueryCache) Load(path string, isOutdated func(corpusID string, dumpTime time.Time) bool) error {
+	fr, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to load liveattrs cache: %w", err)
+	}
+	defer fr.Close()
+	var data cacheDump
+	if err := gob.NewDecoder(fr).Decode(&data); err != nil {
+		return fmt.Errorf("failed to load liveattrs cache: %w", err)
+	}
+	if data.Version != dumpFormatVersion {
+		log.Warn().
+			Int("version", data.Version).
+			Int("expectedVersion", dumpFormatVersion).
+			Msg("incompatible liveattrs cache dump, ignoring")
+		return nil
+	}
+
+	discardedKeys := make(map[string]bool)
+	for corpusID, keys := range data.CorpKeyDeps {
+		if isOutdated(corpusID, data.Created) {
+			for _, k := range keys {
+				discardedKeys[k] = true
+			}
+		}
+	}
+	now := time.Now()
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	qc.data = make(map[string]*list.Element)
+	qc.lru.Init()
+	qc.corpKeyDeps = make(map[string][]string)
+	for _, item := range data.Items {
+		cItem := &cacheItem{key: item.Key, value: item.Value, expires: item.Expires}
+		if discardedKeys[item.Key] || cItem.isExpired(now) {
+			continue
+		}
+		qc.data[item.Key] = qc.lru.PushFront(cItem)
+	}
+	for corpusID, keys := range data.CorpKeyDeps {
+		for _, k := range keys {
+			if _, ok := qc.data[k]; ok {
+				qc.setKeyCorpusDependency(corpusID, k)
+			}
+		}
+	}
+	qc.evictOverflowing()
+	log.Info().
+		Int("numLoaded", len(qc.data)).
+		Int("numDiscarded", len(data.Items)-len(qc.data)).
+		Msg("loaded liveattrs cache dump")
+	return nil
+}
@@ -17,13 +17,117 @@
 package liveattrs
 
 import (
+	"frodo/db/mysql"
+	"time"
+
 	vtedb "github.com/czcorpus/vert-tagextract/v3/db"
 )
 
+const (
+	dfltEmptyValuePlaceholder = "?"
+	dfltMaxAttrListSize       = 30
+	dfltShortLabelMaxLength   = 30
+)
+
 type Conf struct {
 	DB                       *vtedb.Conf `json:"db"`
 	CustomNgramTablesDataDir string      `json:"customNgramTablesDataDir"`
 	ConfDirPath              string      `json:"confDirPath"`
 	VertMaxNumErrors         int         `json:"vertMaxNumErrors"`
 	VerticalFilesDirPath     string      `json:"verticalFilesDirPath"`
+
+	// DBPool configures connection pool of the liveattrs database
+	// (see mysql.PoolConf for defaults)
+	DBPool mysql.PoolConf `json:"dbPool"`
+
+	// SlowQueryThresholdSecs specifies a duration of a liveattrs query
+	// above which the query is logged as slow (at the warn level).
+	// Zero value disables the slow query log.
+	SlowQueryThresholdSecs float64 `json:"slowQueryThresholdSecs"`
+
+	// MaxAttrDistinctValues is a ceiling for a number of distinct values
+	// of a single attribute collected while processing a liveattrs query.
+	// Once exceeded, the attribute is switched to a "count-only" mode
+	// (i.e. no values are listed). In case the attribute must be listed
+	// (e.g. autocomplete), the query fails. Zero value means "no limit".
+	MaxAttrDistinctValues int `json:"maxAttrDistinctValues"`
+
+	// EmptyQueryCacheMaxEntries is a maximum number of results stored
+	// in the empty query cache (i.e. initial text types listings). Once
+	// exceeded, the least recently used results are evicted.
+	// Zero value means "no limit".
+	EmptyQueryCacheMaxEntries int `json:"emptyQueryCacheMaxEntries"`
+
+	// FullQueryCacheTTLSecs enables caching of non-empty liveattrs
+	// queries (keyed by the whole query) for the specified time.
+	// Zero value (default) means only empty queries are cached.
+	FullQueryCacheTTLSecs int `json:"fullQueryCacheTTLSecs"`
+
+	// QueryCacheDumpPath specifies a file where the query cache
+	// is stored on service shutdown and loaded from on startup.
+	// Empty value disables the persistence.
+	QueryCacheDumpPath string `json:"queryCacheDumpPath"`
+
+	// ApproxQueryRowLimit is a maximum number of database rows read
+	// by queries accepting approximate counts (see query.Payload.ApproxCounts).
+	// Zero value (default) means "no limit".
+	ApproxQueryRowLimit int `json:"approxQueryRowLimit"`
+
+	// EmptyValuePlaceholder is a query value matching empty attribute
+	// values (i.e. clients use it to select e.g. documents without
+	// an author). If empty, "?" is used.
+	EmptyValuePlaceholder string `json:"emptyValuePlaceholder"`
+
+	// ShortLabelMaxLength is a maximum length of shortened labels
+	// of attribute values. Zero or negative value means default (30).
+	ShortLabelMaxLength int `json:"shortLabelMaxLength"`
+
+	// MaxAttrListSize is a default maximum number of listed values
+	// of an attribute (a query may override the value). Larger lists
+	// are replaced by just their size. Zero or negative value means
+	// default (30).
+	MaxAttrListSize int `json:"maxAttrListSize"`
+
+	// ImportQueryTimeoutSecs limits duration of long running queries
+	// performed on the import-tuned database connection by data generators
+	// (e.g. n-grams). Exceeding queries are aborted and the respective job
+	// fails with a timeout error. Zero value (default) means "no limit".
+	ImportQueryTimeoutSecs int `json:"importQueryTimeoutSecs"`
+}
+
+// GetEmptyValuePlaceholder returns EmptyValuePlaceholder
+// or the default value if not configured
+func (conf *Conf) GetEmptyValuePlaceholder() string {
+	if conf.EmptyValuePlaceholder == "" {
+		return dfltEmptyValuePlaceholder
+	}
+	return conf.EmptyValuePlaceholder
+}
+
+// GetShortLabelMaxLength returns ShortLabelMaxLength
+// or the default value if not configured
+func (conf *Conf) GetShortLabelMaxLength() int {
+	if conf.ShortLabelMaxLength <= 0 {
+		return dfltShortLabelMaxLength
+	}
+	return conf.ShortLabelMaxLength
+}
+
+// GetMaxAttrListSize returns MaxAttrListSize
+// or the default value if not configured
+func (conf *Conf) GetMaxAttrListSize() int {
+	if conf.MaxAttrListSize <= 0 {
+		return dfltMaxAttrListSize
+	}
+	return conf.MaxAttrListSize
+}
+
+// ImportQueryTimeout returns ImportQueryTimeoutSecs as time.Duration
+func (conf *Conf) ImportQueryTimeout() time.Duration {
+	return time.Duration(conf.ImportQueryTimeoutSecs) * time.Second
+}
+
+// FullQueryCacheTTL returns FullQueryCacheTTLSecs as time.Duration
+func (conf *Conf) FullQueryCacheTTL() time.Duration {
+	return time.Duration(conf.FullQueryCacheTTLSecs) * time.Second
 }
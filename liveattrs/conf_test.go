@@ -0,0 +1,41 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liveattrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfDisplayDefaults(t *testing.T) {
+	conf := Conf{}
+	assert.Equal(t, "?", conf.GetEmptyValuePlaceholder())
+	assert.Equal(t, 30, conf.GetShortLabelMaxLength())
+	assert.Equal(t, 30, conf.GetMaxAttrListSize())
+}
+
+func TestConfDisplayOverrides(t *testing.T) {
+	conf := Conf{
+		EmptyValuePlaceholder: "N/A",
+		ShortLabelMaxLength:   12,
+		MaxAttrListSize:       100,
+	}
+	assert.Equal(t, "N/A", conf.GetEmptyValuePlaceholder())
+	assert.Equal(t, 12, conf.GetShortLabelMaxLength())
+	assert.Equal(t, 100, conf.GetMaxAttrListSize())
+}
@@ -17,16 +17,27 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"frodo/corpus"
+	"frodo/db/tables"
 	"frodo/liveattrs/db/qbuilder/adhoc"
 	"frodo/liveattrs/request/query"
+	"frodo/liveattrs/utils"
+	"strings"
+	"time"
+)
+
+const (
+	deleteDocsChunkSize = 1000
+
+	numericValueRegexp = "^-?[0-9]+(\\.[0-9]+)?$"
 )
 
 func DeleteTable(tx *sql.Tx, groupedName string, corpusName string) error {
 	_, err := tx.Exec(
-		fmt.Sprintf("DELETE FROM %s_liveattrs_entry WHERE corpus_id = ?", groupedName),
+		fmt.Sprintf("DELETE FROM %s WHERE corpus_id = ?", tables.Name(groupedName, tables.LiveAttrsEntry)),
 		corpusName,
 	)
 	if err != nil {
@@ -34,12 +45,99 @@ func DeleteTable(tx *sql.Tx, groupedName string, corpusName string) error {
 	}
 	if groupedName == corpusName {
 		_, err = tx.Exec(
-			fmt.Sprintf("DROP TABLE %s_liveattrs_entry", groupedName),
+			fmt.Sprintf("DROP TABLE %s", tables.Name(groupedName, tables.LiveAttrsEntry)),
 		)
 	}
 	return err
 }
 
+// MaxEntryID returns the highest row ID of a corpus liveattrs table.
+// As newly inserted rows always get higher IDs, the value can be used
+// to distinguish between rows existing before and after an import.
+func MaxEntryID(ctx context.Context, laDB *sql.DB, groupedName string) (int64, error) {
+	var ans int64
+	row := laDB.QueryRowContext(
+		ctx,
+		fmt.Sprintf(
+			"SELECT COALESCE(MAX(id), 0) FROM `%s`",
+			tables.Name(groupedName, tables.LiveAttrsEntry),
+		),
+	)
+	if err := row.Scan(&ans); err != nil {
+		return 0, fmt.Errorf("failed to get max. liveattrs entry ID: %w", err)
+	}
+	return ans, nil
+}
+
+// RemoveDocuments removes rows of specified documents (see DeleteDocuments)
+// in a single transaction so either all the rows or none of them
+// are removed.
+func RemoveDocuments(
+	ctx context.Context,
+	laDB *sql.DB,
+	groupedName string,
+	corpusName string,
+	bibIDCol string,
+	docIDs []string,
+	maxID int64,
+) (int, error) {
+	tx, err := laDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove documents: %w", err)
+	}
+	numRemoved, err := DeleteDocuments(tx, groupedName, corpusName, bibIDCol, docIDs, maxID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to remove documents: %w", err)
+	}
+	return numRemoved, nil
+}
+
+// DeleteDocuments removes rows of specified documents (identified
+// by values of the bibIDCol column) from corpus liveattrs table.
+// Only rows with ID lower or equal to maxID are removed (see MaxEntryID)
+// so freshly imported versions of the documents can be kept.
+// The function returns number of removed rows.
+func DeleteDocuments(
+	tx *sql.Tx,
+	groupedName string,
+	corpusName string,
+	bibIDCol string,
+	docIDs []string,
+	maxID int64,
+) (int, error) {
+	var total int
+	for i := 0; i < len(docIDs); i += deleteDocsChunkSize {
+		chunk := docIDs[i:min(i+deleteDocsChunkSize, len(docIDs))]
+		args := make([]any, 0, len(chunk)+2)
+		args = append(args, corpusName, maxID)
+		for _, v := range chunk {
+			args = append(args, v)
+		}
+		res, err := tx.Exec(
+			fmt.Sprintf(
+				"DELETE FROM `%s` WHERE corpus_id = ? AND id <= ? AND `%s` IN (%s)",
+				tables.Name(groupedName, tables.LiveAttrsEntry),
+				bibIDCol,
+				strings.Repeat("?, ", len(chunk)-1)+"?",
+			),
+			args...,
+		)
+		if err != nil {
+			return total, fmt.Errorf("failed to delete documents: %w", err)
+		}
+		numDel, err := res.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("failed to delete documents: %w", err)
+		}
+		total += int(numDel)
+	}
+	return total, nil
+}
+
 func GetSubcSize(laDB *sql.DB, corpusInfo *corpus.DBInfo, corpora []string, attrMap query.Attrs) (int, error) {
 	sizeCalc := adhoc.SubcSize{
 		CorpusInfo:          corpusInfo,
@@ -58,3 +156,43 @@ func GetSubcSize(laDB *sql.DB, corpusInfo *corpus.DBInfo, corpora []string, attr
 	}
 	return 0, nil
 }
+
+// LoadBuildTime returns the last modification time of the liveattrs
+// table of a corpus (as provided by information_schema). In case the
+// table does not exist, sql.ErrNoRows is returned.
+// Please note that MySQL may not provide UPDATE_TIME (e.g. after
+// a server restart) in which case CREATE_TIME is used. In case none
+// of the two is available, nil is returned.
+func LoadBuildTime(db *sql.DB, groupedName string) (*time.Time, error) {
+	var ans sql.NullTime
+	row := db.QueryRow(
+		"SELECT COALESCE(UPDATE_TIME, CREATE_TIME) FROM information_schema.TABLES "+
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+		tables.Name(groupedName, tables.LiveAttrsEntry),
+	)
+	if err := row.Scan(&ans); err != nil {
+		return nil, err
+	}
+	if !ans.Valid {
+		return nil, nil
+	}
+	return &ans.Time, nil
+}
+
+// IsNumericAttr tests whether all the non-empty values of a structural
+// attribute (e.g. `doc.year`) of a corpus are numbers.
+func IsNumericAttr(laDB *sql.DB, corpusInfo *corpus.DBInfo, attr string) (bool, error) {
+	col := utils.ImportKey(attr)
+	row := laDB.QueryRow(
+		fmt.Sprintf(
+			"SELECT COUNT(*) FROM `%s` WHERE corpus_id = ? AND %s <> '' AND %s NOT REGEXP ?",
+			tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry), col, col,
+		),
+		corpusInfo.Name, numericValueRegexp,
+	)
+	var numInvalid int
+	if err := row.Scan(&numInvalid); err != nil {
+		return false, err
+	}
+	return numInvalid == 0, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errFakeExec = errors.New("fake exec failure")
+
+// txDriver is a fake database driver recording executed statements
+// and transaction outcomes. The failAtExec (1-based) specifies which
+// statement fails (zero means "none").
+type txDriver struct {
+	failAtExec int
+	maxID      int64
+	buildTime  driver.Value
+	execs      []string
+	execArgs   [][]driver.NamedValue
+	committed  bool
+	rolledBack bool
+}
+
+func (d *txDriver) Open(name string) (driver.Conn, error) {
+	return &txConn{drv: d}, nil
+}
+
+func (d *txDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *txDriver) Driver() driver.Driver {
+	return d
+}
+
+type txConn struct {
+	drv *txDriver
+}
+
+func (c *txConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements not supported")
+}
+
+func (c *txConn) Close() error {
+	return nil
+}
+
+func (c *txConn) Begin() (driver.Tx, error) {
+	return &fakeTx{drv: c.drv}, nil
+}
+
+func (c *txConn) ExecContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Result, error) {
+	c.drv.execs = append(c.drv.execs, query)
+	c.drv.execArgs = append(c.drv.execArgs, args)
+	if len(c.drv.execs) == c.drv.failAtExec {
+		return nil, errFakeExec
+	}
+	return driver.RowsAffected(len(args) - 2), nil
+}
+
+func (c *txConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	if strings.Contains(query, "information_schema.TABLES") {
+		return &maxIDRows{value: c.drv.buildTime}, nil
+	}
+	return &maxIDRows{value: c.drv.maxID}, nil
+}
+
+type fakeTx struct {
+	drv *txDriver
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.drv.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.drv.rolledBack = true
+	return nil
+}
+
+type maxIDRows struct {
+	value  driver.Value
+	served bool
+}
+
+func (r *maxIDRows) Columns() []string {
+	return []string{"max_id"}
+}
+
+func (r *maxIDRows) Close() error {
+	return nil
+}
+
+func (r *maxIDRows) Next(dest []driver.Value) error {
+	if r.served {
+		return io.EOF
+	}
+	r.served = true
+	dest[0] = r.value
+	return nil
+}
+
+func mkDocIDs(n int) []string {
+	ans := make([]string, n)
+	for i := range ans {
+		ans[i] = fmt.Sprintf("doc%d", i)
+	}
+	return ans
+}
+
+func TestRemoveDocuments(t *testing.T) {
+	drv := &txDriver{}
+	laDB := sql.OpenDB(drv)
+	defer laDB.Close()
+
+	numRemoved, err := RemoveDocuments(
+		context.Background(), laDB, "syn2020", "syn2020", "doc_id", mkDocIDs(1500), 42)
+	assert.NoError(t, err)
+	assert.Equal(t, 1500, numRemoved)
+	assert.Len(t, drv.execs, 2)
+	assert.Contains(t, drv.execs[0], "DELETE FROM `syn2020_liveattrs_entry` WHERE corpus_id = ? AND id <= ?")
+	assert.Equal(t, int64(42), drv.execArgs[0][1].Value)
+	assert.True(t, drv.committed)
+	assert.False(t, drv.rolledBack)
+}
+
+func TestRemoveDocumentsRollsBackOnFailure(t *testing.T) {
+	drv := &txDriver{failAtExec: 2}
+	laDB := sql.OpenDB(drv)
+	defer laDB.Close()
+
+	numRemoved, err := RemoveDocuments(
+		context.Background(), laDB, "syn2020", "syn2020", "doc_id", mkDocIDs(1500), 42)
+	assert.ErrorIs(t, err, errFakeExec)
+	assert.Zero(t, numRemoved)
+	assert.Len(t, drv.execs, 2)
+	assert.False(t, drv.committed)
+	assert.True(t, drv.rolledBack)
+}
+
+func TestMaxEntryID(t *testing.T) {
+	laDB := sql.OpenDB(&txDriver{maxID: 1234})
+	defer laDB.Close()
+	maxID, err := MaxEntryID(context.Background(), laDB, "syn2020")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1234), maxID)
+}
+
+func TestLoadBuildTime(t *testing.T) {
+	built := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	laDB := sql.OpenDB(&txDriver{buildTime: built})
+	defer laDB.Close()
+	ans, err := LoadBuildTime(laDB, "syn2020")
+	assert.NoError(t, err)
+	if assert.NotNil(t, ans) {
+		assert.True(t, built.Equal(*ans))
+	}
+
+	laDB2 := sql.OpenDB(&txDriver{})
+	defer laDB2.Close()
+	ans, err = LoadBuildTime(laDB2, "syn2020")
+	assert.NoError(t, err)
+	assert.Nil(t, ans)
+}
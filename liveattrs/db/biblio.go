@@ -20,6 +20,7 @@ import (
 	"database/sql"
 	"fmt"
 	"frodo/corpus"
+	"frodo/db/tables"
 	"frodo/liveattrs/laconf"
 	"frodo/liveattrs/request/biblio"
 	"frodo/liveattrs/request/query"
@@ -47,9 +48,9 @@ func GetBibliography(
 	}
 
 	sql1 := fmt.Sprintf(
-		"SELECT %s FROM `%s_liveattrs_entry` WHERE %s = ? LIMIT 1",
+		"SELECT %s FROM `%s` WHERE %s = ? LIMIT 1",
 		strings.Join(selAttrs, ", "),
-		corpusInfo.GroupedName(),
+		tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry),
 		utils.ImportKey(corpusInfo.BibIDAttr),
 	)
 
@@ -95,10 +96,10 @@ func FindBibTitles(
 		valuesPlaceholders[i] = "?"
 	}
 	sql1 := fmt.Sprintf(
-		"SELECT %s, %s FROM `%s_liveattrs_entry` WHERE %s IN (%s)",
+		"SELECT %s, %s FROM `%s` WHERE %s IN (%s)",
 		utils.ImportKey(corpusInfo.BibIDAttr),
 		utils.ImportKey(corpusInfo.BibLabelAttr),
-		corpusInfo.GroupedName(),
+		tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry),
 		utils.ImportKey(corpusInfo.BibIDAttr),
 		strings.Join(valuesPlaceholders, ", "),
 	)
@@ -242,14 +243,14 @@ func buildQuery(
 ) (string, []any) {
 	sql := strings.Builder{}
 	sql.WriteString(fmt.Sprintf(
-		"SELECT %s FROM `%s_liveattrs_entry` AS t1 ",
-		strings.Join(selection, ", "), corpusInfo.GroupedName(),
+		"SELECT %s FROM `%s` AS t1 ",
+		strings.Join(selection, ", "), tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry),
 	))
 	whereSQL := make([]string, 0, len(alignedCorpora))
 	queryArgs := make([]any, 0, len(alignedCorpora)+2)
 	for i, item := range alignedCorpora {
 		sql.WriteString(fmt.Sprintf(
-			" INNER JOIN `%s_liveattrs_entry` AS t%d ON t1.item_id = t%d.item_id AND t%d.corpus_id = ? ", corpusInfo.GroupedName(),
+			" INNER JOIN `%s` AS t%d ON t1.item_id = t%d.item_id AND t%d.corpus_id = ? ", tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry),
 			i+2, i+2, i+2,
 		))
 		queryArgs = append(queryArgs, item)
@@ -20,6 +20,7 @@ import (
 	"database/sql"
 	"fmt"
 	"frodo/corpus"
+	"frodo/db/tables"
 	"frodo/liveattrs/request/fillattrs"
 	"frodo/liveattrs/utils"
 	"strings"
@@ -44,9 +45,9 @@ func FillAttrs(
 		valuesPlaceholders[i] = "?"
 	}
 	sql1 := fmt.Sprintf(
-		"SELECT %s FROM `%s_liveattrs_entry` WHERE %s IN (%s)",
+		"SELECT %s FROM `%s` WHERE %s IN (%s)",
 		strings.Join(selAttrs, ", "),
-		corpusInfo.GroupedName(),
+		tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry),
 		utils.ImportKey(qry.Search),
 		strings.Join(valuesPlaceholders, ", "),
 	)
@@ -0,0 +1,66 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package freqdb
+
+import (
+	"fmt"
+	"frodo/db/mysql"
+	"frodo/db/tables"
+)
+
+// NgramTables returns names of all the tables produced by the n-gram
+// generator for a corpus (or a group of corpora) identified by groupedName.
+// In case withColCounts is true, the colcounts table (produced by liveattrs
+// and used as the n-gram generator's input) is included too.
+// The order of the tables respects their dependencies so they can be
+// dropped in the returned order.
+func NgramTables(groupedName string, withColCounts bool) []string {
+	ans := []string{
+		tables.Name(groupedName, tables.LemmaStats),
+		tables.Name(groupedName, tables.TermSearch),
+		tables.Name(groupedName, tables.Word),
+	}
+	if withColCounts {
+		ans = append(ans, tables.Name(groupedName, tables.ColCounts))
+	}
+	return ans
+}
+
+// DeleteNgramTables drops all the existing n-gram tables of a corpus
+// (see NgramTables) and returns names of the actually removed tables.
+// Missing tables are silently skipped.
+func DeleteNgramTables(db *mysql.Adapter, groupedName string, withColCounts bool) ([]string, error) {
+	removed := make([]string, 0, 4)
+	for _, tbl := range NgramTables(groupedName, withColCounts) {
+		row := db.DB().QueryRow(
+			`SELECT COUNT(*) > 0 FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`,
+			db.DBName(), tbl,
+		)
+		var exists bool
+		if err := row.Scan(&exists); err != nil {
+			return removed, fmt.Errorf("failed to delete n-gram tables: %w", err)
+		}
+		if !exists {
+			continue
+		}
+		if _, err := db.DB().Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", tbl)); err != nil {
+			return removed, fmt.Errorf("failed to delete n-gram table %s: %w", tbl, err)
+		}
+		removed = append(removed, tbl)
+	}
+	return removed, nil
+}
@@ -39,6 +39,7 @@ type genNgramsStatus struct {
 	TotalLines          int
 	NumProcLines        int
 	NumStopWords        int
+	NumSkippedLowFreq   int
 	ChunkID             int
 	AvgSpeedItemsPerSec int
 	TimeEstimationSecs  int
@@ -47,6 +48,14 @@ type genNgramsStatus struct {
 	ClientWarn          string
 }
 
+// percentDone returns the processing progress in percents
+func (gns genNgramsStatus) percentDone() float64 {
+	if gns.TotalLines <= 0 {
+		return 0
+	}
+	return min(maths.RoundToN(float64(gns.NumProcLines)/float64(gns.TotalLines)*100, 1), 100)
+}
+
 func (gns genNgramsStatus) MarshalJSON() ([]byte, error) {
 	progress := -1.0
 	if gns.TotalLines > 0 {
@@ -57,6 +66,7 @@ func (gns genNgramsStatus) MarshalJSON() ([]byte, error) {
 			CorpusID            string  `json:"corpusId"`
 			NumProcLines        int     `json:"numProcLines"`
 			NumStopWords        int     `json:"numStopWords"`
+			NumSkippedLowFreq   int     `json:"numSkippedLowFreq"`
 			Progress            float64 `json:"progress"`
 			ChunkID             int     `json:"chunkId"`
 			TotalLines          int     `json:"totalLines"`
@@ -69,6 +79,7 @@ func (gns genNgramsStatus) MarshalJSON() ([]byte, error) {
 			CorpusID:            gns.CorpusID,
 			NumProcLines:        gns.NumProcLines,
 			NumStopWords:        gns.NumStopWords,
+			NumSkippedLowFreq:   gns.NumSkippedLowFreq,
 			Progress:            progress,
 			ChunkID:             gns.ChunkID,
 			TotalLines:          gns.TotalLines,
@@ -21,6 +21,10 @@ import (
 	"time"
 )
 
+const (
+	NgramJobType = "ngram-generating"
+)
+
 type NgramJobInfoArgs struct {
 }
 
@@ -37,6 +41,9 @@ type NgramJobInfo struct {
 	NumRestarts     int              `json:"numRestarts"`
 	Args            NgramJobInfoArgs `json:"args"`
 	Result          genNgramsStatus  `json:"result"`
+
+	// Progress is in percents
+	Progress float64 `json:"progress"`
 }
 
 func (j NgramJobInfo) GetID() string {
@@ -89,6 +96,7 @@ func (j NgramJobInfo) FullInfo() any {
 		NumRestarts int              `json:"numRestarts"`
 		Args        NgramJobInfoArgs `json:"args"`
 		Result      genNgramsStatus  `json:"result"`
+		Progress    float64          `json:"progress"`
 	}{
 		ID:          j.ID,
 		Type:        j.Type,
@@ -101,6 +109,7 @@ func (j NgramJobInfo) FullInfo() any {
 		NumRestarts: j.NumRestarts,
 		Args:        j.Args,
 		Result:      j.Result,
+		Progress:    j.Progress,
 	}
 }
 
@@ -133,5 +142,15 @@ func (j NgramJobInfo) WithError(err error) jobs.GeneralJobInfo {
 		Error:       err,
 		Result:      j.Result,
 		NumRestarts: j.NumRestarts,
+		Progress:    j.Progress,
 	}
 }
+
+func (j NgramJobInfo) GetProgress() float64 {
+	return j.Progress
+}
+
+func (j NgramJobInfo) WithProgress(p float64) jobs.GeneralJobInfo {
+	j.Progress = p
+	return j
+}
@@ -25,9 +25,11 @@ package freqdb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"frodo/corpus"
 	"frodo/db/mysql"
+	"frodo/db/tables"
 	"frodo/jobs"
 	"frodo/liveattrs/db"
 	"math"
@@ -51,6 +53,17 @@ const (
 	maxNonOptimizedNgramsLen = 1500000
 )
 
+var (
+	ErrorConfigChanged = errors.New("liveattrs configuration changed during the build")
+	ErrorQueryTimeout  = errors.New("n-gram generator query exceeded the time limit")
+)
+
+// ConfigGuard is called repeatedly during the n-gram generation
+// and in case it returns an error, the generation is aborted.
+// It is typically used to detect that a respective liveattrs
+// configuration has been changed meanwhile.
+type ConfigGuard func() error
+
 type NgramFreqGenerator struct {
 	db                   *mysql.Adapter
 	customDBDataDir      string
@@ -63,43 +76,112 @@ type NgramFreqGenerator struct {
 	jobActions           *jobs.Actions
 	qsaAttrs             corpus.QSAttributes
 	minFreq              int
+	configGuard          ConfigGuard
+
+	// queryTimeout limits duration of generator's long running
+	// queries (zero means no limit)
+	queryTimeout time.Duration
+}
+
+// SetConfigGuard sets a function checking that the generation
+// can continue. See ConfigGuard for more info.
+func (nfg *NgramFreqGenerator) SetConfigGuard(guard ConfigGuard) {
+	nfg.configGuard = guard
+}
+
+// SetQueryTimeout sets a maximum duration of the generator's long running
+// queries (reading of colcounts, building of lemma stats). Once exceeded,
+// the generation fails with ErrorQueryTimeout. Zero means no limit.
+func (nfg *NgramFreqGenerator) SetQueryTimeout(timeout time.Duration) {
+	nfg.queryTimeout = timeout
+}
+
+// queryContext derives a context for a single generator query
+// with the configured timeout applied (if any)
+func (nfg *NgramFreqGenerator) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if nfg.queryTimeout > 0 {
+		return context.WithTimeout(ctx, nfg.queryTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// wrapQueryError marks errors caused by an exceeded query timeout
+// (either the context deadline or the server-side max_execution_time)
+// with ErrorQueryTimeout
+func wrapQueryError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || mysql.IsMaxExecTimeError(err) {
+		return fmt.Errorf("%w: %w", ErrorQueryTimeout, err)
+	}
+	return err
+}
+
+func (nfg *NgramFreqGenerator) checkConfigGuard() error {
+	if nfg.configGuard == nil {
+		return nil
+	}
+	if err := nfg.configGuard(); err != nil {
+		return fmt.Errorf("n-gram generation aborted: %w", err)
+	}
+	return nil
+}
+
+func (nfg *NgramFreqGenerator) wordTable() string {
+	return tables.Name(nfg.groupedName, tables.Word)
+}
+
+func (nfg *NgramFreqGenerator) termSearchTable() string {
+	return tables.Name(nfg.groupedName, tables.TermSearch)
+}
+
+func (nfg *NgramFreqGenerator) lemmaStatsTable() string {
+	return tables.Name(nfg.groupedName, tables.LemmaStats)
+}
+
+func (nfg *NgramFreqGenerator) colCountsTable() string {
+	return tables.Name(nfg.groupedName, tables.ColCounts)
 }
 
 // updateTablesStats plays crucial role after table data insert. Experience shows,
 // that not running analyze may completely kill performance of word search.
 func (nfg *NgramFreqGenerator) updateTablesStats() error {
-	if _, err := nfg.db.DB().Exec(fmt.Sprintf("ANALYZE TABLE %s_term_search", nfg.groupedName)); err != nil {
-		return fmt.Errorf("failed to update stats for the %s_term_search: %w", nfg.groupedName, err)
+	if _, err := nfg.db.DB().Exec(fmt.Sprintf("ANALYZE TABLE %s", nfg.termSearchTable())); err != nil {
+		return fmt.Errorf("failed to update stats for the %s: %w", nfg.termSearchTable(), err)
 	}
-	if _, err := nfg.db.DB().Exec(fmt.Sprintf("ANALYZE TABLE %s_word", nfg.groupedName)); err != nil {
-		return fmt.Errorf("failed to update stats for the %s_word: %w", nfg.groupedName, err)
+	if _, err := nfg.db.DB().Exec(fmt.Sprintf("ANALYZE TABLE %s", nfg.wordTable())); err != nil {
+		return fmt.Errorf("failed to update stats for the %s: %w", nfg.wordTable(), err)
 	}
 	return nil
 }
 
 // BuildLemmaStats creates (or recreates) the auxiliary {groupedName}_lemma_stats table
-// and fills it with aggregated data from {groupedName}_word. This should be called
-// once the initial import into _word is complete.
+// and fills it with aggregated data from {groupedName}_word (including lemma-level ARF
+// used by the similar frequency search). This should be called once the initial import
+// into _word is complete.
 func (nfg *NgramFreqGenerator) BuildLemmaStats(ctx context.Context) error {
+	ctx, cancel := nfg.queryContext(ctx)
+	defer cancel()
 	if _, err := nfg.db.DB().ExecContext(
 		ctx,
-		fmt.Sprintf("DROP TABLE IF EXISTS %s_lemma_stats", nfg.groupedName),
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", nfg.lemmaStatsTable()),
 	); err != nil {
 		return fmt.Errorf("failed to build lemma stats: %w", err)
 	}
 	if _, err := nfg.db.DB().ExecContext(
 		ctx,
 		fmt.Sprintf(
-			`CREATE TABLE %s_lemma_stats (
+			`CREATE TABLE %s (
 				`+"`lemma`"+` varchar(500) NOT NULL,
 				`+"`pos`"+` varchar(20) DEFAULT NULL,
 				`+"`ngram`"+` tinyint(4) NOT NULL,
 				`+"`sum_count`"+` bigint DEFAULT NULL,
 				`+"`avg_sim_freqs_score`"+` float DEFAULT NULL,
 				`+"`sublemma`"+` text DEFAULT NULL,
+				`+"`sum_arf`"+` float DEFAULT NULL,
 				PRIMARY KEY (lemma, ngram, pos),
-				KEY %s_lemma_stats_score_idx (ngram, avg_sim_freqs_score)
+				KEY %s_lemma_stats_score_idx (ngram, avg_sim_freqs_score),
+				KEY %s_lemma_stats_arf_idx (ngram, sum_arf)
 			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin`,
+			nfg.lemmaStatsTable(),
 			nfg.groupedName,
 			nfg.groupedName,
 		),
@@ -109,19 +191,19 @@ func (nfg *NgramFreqGenerator) BuildLemmaStats(ctx context.Context) error {
 	if _, err := nfg.db.DB().ExecContext(
 		ctx,
 		fmt.Sprintf(
-			`INSERT INTO %s_lemma_stats
-			SELECT lemma, pos, ngram, SUM(count), AVG(sim_freqs_score), MIN(sublemma)
-			FROM %s_word
+			`INSERT INTO %s
+			SELECT lemma, pos, ngram, SUM(count), AVG(sim_freqs_score), MIN(sublemma), SUM(arf)
+			FROM %s
 			GROUP BY lemma, pos, ngram`,
-			nfg.groupedName,
-			nfg.groupedName,
+			nfg.lemmaStatsTable(),
+			nfg.wordTable(),
 		),
 	); err != nil {
-		return fmt.Errorf("failed to build lemma stats: %w", err)
+		return fmt.Errorf("failed to build lemma stats: %w", wrapQueryError(err))
 	}
 	if _, err := nfg.db.DB().ExecContext(
 		ctx,
-		fmt.Sprintf("ANALYZE TABLE %s_lemma_stats", nfg.groupedName),
+		fmt.Sprintf("ANALYZE TABLE %s", nfg.lemmaStatsTable()),
 	); err != nil {
 		return fmt.Errorf("failed to build lemma stats: %w", err)
 	}
@@ -132,10 +214,10 @@ func (nfg *NgramFreqGenerator) createTables() error {
 	errMsgTpl := "failed to create tables: %w"
 	db := nfg.db.DB()
 
-	if _, err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s_term_search", nfg.groupedName)); err != nil {
+	if _, err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", nfg.termSearchTable())); err != nil {
 		return fmt.Errorf(errMsgTpl, err)
 	}
-	if _, err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s_word", nfg.groupedName)); err != nil {
+	if _, err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", nfg.wordTable())); err != nil {
 		return fmt.Errorf(errMsgTpl, err)
 	}
 	dataDirSQL := util.Ternary(
@@ -155,7 +237,7 @@ func (nfg *NgramFreqGenerator) createTables() error {
 	)
 	if _, err := db.Exec(
 		fmt.Sprintf(
-			`CREATE TABLE %s_word (
+			`CREATE TABLE %s (
 			id varchar(40),
 			value TEXT,
 			lemma TEXT,
@@ -168,7 +250,7 @@ func (nfg *NgramFreqGenerator) createTables() error {
 			initial_cap TINYINT NOT NULL DEFAULT 0,
 			%s
 			) COLLATE utf8mb4_bin %s %s`,
-			nfg.groupedName,
+			nfg.wordTable(),
 			primaryKeySQL,
 			partitioningSQL,
 			dataDirSQL,
@@ -177,54 +259,54 @@ func (nfg *NgramFreqGenerator) createTables() error {
 		return fmt.Errorf(errMsgTpl, err)
 	}
 	if _, err := db.Exec(fmt.Sprintf(
-		`CREATE TABLE %s_term_search (
+		`CREATE TABLE %s (
 			id int auto_increment,
 			word_id varchar(40) NOT NULL,
 			value TEXT,
 			value_lc TEXT GENERATED ALWAYS AS (LOWER(value)) STORED,
 			PRIMARY KEY (id),
-			FOREIGN KEY (word_id) REFERENCES %s_word(id)
+			FOREIGN KEY (word_id) REFERENCES %s(id)
 		) COLLATE utf8mb4_bin %s`,
-		nfg.groupedName, nfg.groupedName, dataDirSQL)); err != nil {
+		nfg.termSearchTable(), nfg.wordTable(), dataDirSQL)); err != nil {
 		return fmt.Errorf(errMsgTpl, err)
 	}
 
 	if _, err := db.Exec(fmt.Sprintf(
-		`CREATE index %s_term_search_value_idx ON %s_term_search(value)`,
-		nfg.groupedName, nfg.groupedName,
+		`CREATE index %s_term_search_value_idx ON %s(value)`,
+		nfg.groupedName, nfg.termSearchTable(),
 	)); err != nil {
 		return fmt.Errorf(errMsgTpl, err)
 	}
 	if _, err := db.Exec(fmt.Sprintf(
-		`CREATE index %s_term_search_value_lc_idx ON %s_term_search(value_lc)`,
-		nfg.groupedName, nfg.groupedName,
+		`CREATE index %s_term_search_value_lc_idx ON %s(value_lc)`,
+		nfg.groupedName, nfg.termSearchTable(),
 	)); err != nil {
 		return fmt.Errorf(errMsgTpl, err)
 	}
 	if nfg.useTablePartitioning { // in this case, foreign keys are off as is the default index
 		if _, err := db.Exec(fmt.Sprintf(
-			`create index %s_term_search_word_id_idx ON %s_term_search(word_id)`,
-			nfg.groupedName, nfg.groupedName,
+			`create index %s_term_search_word_id_idx ON %s(word_id)`,
+			nfg.groupedName, nfg.termSearchTable(),
 		)); err != nil {
 			return fmt.Errorf(errMsgTpl, err)
 		}
 	}
 
 	if _, err := db.Exec(fmt.Sprintf(
-		`CREATE index %s_word_pos_idx ON %s_word(pos)`,
-		nfg.groupedName, nfg.groupedName,
+		`CREATE index %s_word_pos_idx ON %s(pos)`,
+		nfg.groupedName, nfg.wordTable(),
 	)); err != nil {
 		return fmt.Errorf(errMsgTpl, err)
 	}
 	if _, err := db.Exec(fmt.Sprintf(
-		`create index %s_word_sim_freqs_score_idx on %s_word(sim_freqs_score, ngram)`,
-		nfg.groupedName, nfg.groupedName,
+		`create index %s_word_sim_freqs_score_idx on %s(sim_freqs_score, ngram)`,
+		nfg.groupedName, nfg.wordTable(),
 	)); err != nil {
 		return fmt.Errorf(errMsgTpl, err)
 	}
 	if _, err := db.Exec(fmt.Sprintf(
-		`create index %s_word_lemma_idx on %s_word(lemma)`,
-		nfg.groupedName, nfg.groupedName,
+		`create index %s_word_lemma_idx on %s(lemma)`,
+		nfg.groupedName, nfg.wordTable(),
 	)); err != nil {
 		return fmt.Errorf(errMsgTpl, err)
 	}
@@ -276,9 +358,9 @@ func (nfg *NgramFreqGenerator) procLineGroupIgnoreErrors(
 	for i := range len(words) {
 		_, err := tx.Exec(
 			fmt.Sprintf(
-				`INSERT INTO %s_word (id, value, lemma, sublemma, pos, count, arf, initial_cap, ngram, sim_freqs_score)
+				`INSERT INTO %s (id, value, lemma, sublemma, pos, count, arf, initial_cap, ngram, sim_freqs_score)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-				nfg.groupedName,
+				nfg.wordTable(),
 			),
 			words[i].hashId,
 			words[i].word,
@@ -353,9 +435,9 @@ func (nfg *NgramFreqGenerator) procLineGroup(
 	}
 	if _, err := tx.Exec(
 		fmt.Sprintf(
-			`INSERT INTO %s_word (id, value, lemma, sublemma, pos, count, arf, initial_cap, ngram, sim_freqs_score)
+			`INSERT INTO %s (id, value, lemma, sublemma, pos, count, arf, initial_cap, ngram, sim_freqs_score)
 			VALUES %s`,
-			nfg.groupedName,
+			nfg.wordTable(),
 			strings.Join(valPlaceholders, ", "),
 		),
 		queryArgs...,
@@ -375,8 +457,8 @@ func (nfg *NgramFreqGenerator) procLineGroup(
 	}
 	if _, err := tx.Exec(
 		fmt.Sprintf(
-			`INSERT INTO %s_term_search (value, word_id) VALUES %s`,
-			nfg.groupedName,
+			`INSERT INTO %s (value, word_id) VALUES %s`,
+			nfg.termSearchTable(),
 			strings.Join(stPlaceholders, ", "),
 		),
 		stArgs...,
@@ -391,9 +473,9 @@ func (nfg *NgramFreqGenerator) findTotalNumLines() (int, error) {
 	row := nfg.db.DB().QueryRow(
 		fmt.Sprintf(
 			"SELECT COUNT(*) "+
-				"FROM %s_colcounts "+
+				"FROM %s "+
 				"WHERE %s <> ? AND ngram_size = ? ",
-			nfg.groupedName,
+			nfg.colCountsTable(),
 			nfg.qsaAttrs.ExportCol("tag"),
 		),
 		NonWordCSCNC2020Tag,
@@ -410,34 +492,53 @@ func (nfg *NgramFreqGenerator) findTotalNumLines() (int, error) {
 	return ans, nil
 }
 
-// preloadCols loads ngram info
+// acceptNgram tests whether an n-gram should be stored. Stop n-grams
+// and n-grams with frequency below the configured minimum are rejected.
+// The second returned value tells whether the n-gram has been rejected
+// due to the frequency cutoff.
+func (nfg *NgramFreqGenerator) acceptNgram(rec *ngRecord) (bool, bool) {
+	if isStopNgram(rec.lemma) {
+		return false, false
+	}
+	if rec.abs < nfg.minFreq {
+		return false, true
+	}
+	return true, false
+}
+
+// preloadCols loads ngram info. Along with the loaded n-grams,
+// it returns the number of n-grams skipped because of the minFreq
+// cutoff.
 func (nfg *NgramFreqGenerator) preloadCols(
 	ctx context.Context,
 	totalItems int64,
 	baseStatus genNgramsStatus,
 	statusCh chan<- genNgramsStatus,
-) []*ngRecord {
+) ([]*ngRecord, int) {
 	baseStatus.CurrAction = "preloading cols"
+	ctx, cancel := nfg.queryContext(ctx)
+	defer cancel()
 	rows, err := nfg.db.DB().QueryContext(
 		ctx,
 		fmt.Sprintf(
 			"SELECT hash_id, %s, `count` AS abs, arf, initial_cap "+
-				"FROM %s_colcounts "+
+				"FROM %s "+
 				"WHERE col%d <> ? AND ngram_size = ? ",
 			strings.Join(nfg.qsaAttrs.ExportCols("word", "lemma", "sublemma", "tag"), ", "),
-			nfg.groupedName,
+			nfg.colCountsTable(),
 			nfg.qsaAttrs.Tag,
 		),
 		NonWordCSCNC2020Tag,
 		nfg.ngramSize,
 	)
 	if err != nil {
-		baseStatus.Error = fmt.Errorf("failed to select data for the chunk: %w", err)
+		baseStatus.Error = fmt.Errorf("failed to select data for the chunk: %w", wrapQueryError(err))
 		statusCh <- baseStatus
-		return []*ngRecord{}
+		return []*ngRecord{}, 0
 	}
+	defer rows.Close()
 	ngrams := make([]*ngRecord, 0, totalItems)
-	numIgnored := 0
+	var numLowFreq int
 	for rowNum := 1; rows.Next(); rowNum++ {
 		baseStatus.NumProcLines = rowNum
 		rec := &ngRecord{ngramSize: nfg.ngramSize}
@@ -456,12 +557,27 @@ func (nfg *NgramFreqGenerator) preloadCols(
 			log.Warn().Err(err).Msg("failed to read colcounts record, skipping")
 			continue
 		}
-		if isStopNgram(rec.lemma) || rec.abs < nfg.minFreq {
-			numIgnored++
+		// filtering must be done here to avoid keeping all the rows in memory
+		if ok, lowFreq := nfg.acceptNgram(rec); !ok {
+			if lowFreq {
+				numLowFreq++
+			}
 			continue
 		}
 		ngrams = append(ngrams, rec)
 	}
+	if err := rows.Err(); err != nil {
+		baseStatus.Error = fmt.Errorf("failed to read data for the chunk: %w", wrapQueryError(err))
+		statusCh <- baseStatus
+		return []*ngRecord{}, 0
+	}
+	if numLowFreq > 0 {
+		log.Info().
+			Str("corpusId", nfg.corpusName).
+			Int("minFreq", nfg.minFreq).
+			Int("numSkipped", numLowFreq).
+			Msg("skipped n-grams below the minimum frequency")
+	}
 
 	slices.SortFunc(
 		ngrams,
@@ -470,7 +586,7 @@ func (nfg *NgramFreqGenerator) preloadCols(
 	baseStatus.CurrAction = fmt.Sprintf("sorting ngram list of size %d", len(ngrams))
 	statusCh <- baseStatus
 	nfg.determineSimFreqsScore(ngrams)
-	return ngrams
+	return ngrams, numLowFreq
 }
 
 func (nfg *NgramFreqGenerator) procChunk(
@@ -499,6 +615,12 @@ func (nfg *NgramFreqGenerator) procChunk(
 	rowBatch := make([]*ngRecord, 0, sqlInsertBatchSize)
 
 	procRowBatch := func(rowNum int, batch []*ngRecord) bool {
+		if err := nfg.checkConfigGuard(); err != nil {
+			tx.Rollback()
+			baseStatus.Error = err
+			statusCh <- baseStatus
+			return false
+		}
 		err := nfg.procLineGroup(tx, batch)
 		if err != nil {
 			rerr := tx.Rollback()
@@ -520,6 +642,7 @@ func (nfg *NgramFreqGenerator) procChunk(
 
 		procTime := time.Since(t0).Seconds()
 		if (baseStatus.ChunkID*procChunkSize+rowNum)%reportEachNthItem == 0 {
+			baseStatus.NumProcLines = baseStatus.ChunkID*procChunkSize + rowNum
 			baseStatus.AvgSpeedItemsPerSec = int(math.RoundToEven(float64(baseStatus.ChunkID*procChunkSize+rowNum) / procTime))
 			statusCh <- baseStatus
 
@@ -613,7 +736,7 @@ func (nfg *NgramFreqGenerator) run(
 		total, nfg.corpusName, estim)
 	t0 := time.Now()
 
-	ngrams := nfg.preloadCols(ctx, int64(total), baseStatus, statusChan)
+	ngrams, numLowFreq := nfg.preloadCols(ctx, int64(total), baseStatus, statusChan)
 	if len(ngrams) == 0 {
 		return 0, false
 	}
@@ -626,6 +749,7 @@ func (nfg *NgramFreqGenerator) run(
 			TotalLines:         total,
 			TimeEstimationSecs: estim,
 			NumProcLines:       i * procChunkSize,
+			NumSkippedLowFreq:  numLowFreq,
 		}
 		if ok := nfg.procChunk(
 			ctx,
@@ -637,13 +761,22 @@ func (nfg *NgramFreqGenerator) run(
 			return len(ngrams), false
 		}
 	}
+	statusChan <- genNgramsStatus{
+		CorpusID:           nfg.corpusName,
+		ChunkID:            numChunks - 1,
+		TotalLines:         total,
+		TimeEstimationSecs: estim,
+		NumProcLines:       total,
+		NumSkippedLowFreq:  numLowFreq,
+		CurrAction:         "finished processing n-grams",
+	}
 	return len(ngrams), true
 }
 
 func (nfg *NgramFreqGenerator) tablesExist() (bool, error) {
 	row := nfg.db.DB().QueryRow(
 		`SELECT COUNT(*) > 0 FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`,
-		nfg.db.DBName(), nfg.groupedName+"_word",
+		nfg.db.DBName(), nfg.wordTable(),
 	)
 	var ans bool
 	err := row.Scan(&ans)
@@ -662,6 +795,12 @@ func (nfg *NgramFreqGenerator) generateSync(
 ) {
 	var status genNgramsStatus
 
+	if err := nfg.checkConfigGuard(); err != nil {
+		status.Error = err
+		statusChan <- status
+		return
+	}
+
 	tblEx, err := nfg.tablesExist()
 	if err != nil {
 		status.Error = fmt.Errorf("failed to generate ngrams: %w", err)
@@ -710,17 +849,16 @@ func (nfg *NgramFreqGenerator) GenerateAfter(parentJobID string) (NgramJobInfo,
 	}
 	jobStatus := NgramJobInfo{
 		ID:       jobID.String(),
-		Type:     "ngram-generating",
+		Type:     NgramJobType,
 		CorpusID: nfg.corpusName,
 		Start:    jobs.CurrentDatetime(),
 		Update:   jobs.CurrentDatetime(),
 		Finished: false,
 		Args:     NgramJobInfoArgs{},
 	}
-	fn := func(updateJobChan chan<- jobs.GeneralJobInfo) {
+	fn := func(jctx context.Context, updateJobChan chan<- jobs.GeneralJobInfo) {
 		statusChan := make(chan genNgramsStatus)
-		ctx := context.Background()
-		ctx, cancel := context.WithCancel(ctx)
+		ctx, cancel := context.WithCancel(jctx)
 		go func(runStatus NgramJobInfo) {
 			defer close(updateJobChan)
 			for statUpd := range statusChan {
@@ -761,6 +899,7 @@ func (nfg *NgramFreqGenerator) GenerateAfter(parentJobID string) (NgramJobInfo,
 				}
 
 				runStatus.Result = statUpd
+				runStatus.Progress = max(runStatus.Progress, statUpd.percentDone())
 				runStatus.Error = statUpd.Error
 				runStatus.Update = jobs.CurrentDatetime()
 				updateJobChan <- runStatus
@@ -779,17 +918,60 @@ func (nfg *NgramFreqGenerator) GenerateAfter(parentJobID string) (NgramJobInfo,
 			log.Error().Err(err).Msg("failed to close import-tuned connection")
 		}
 	}
+	wrappedFn := nfg.jobActions.WithJobContext(jobStatus.ID, fn)
 	if parentJobID != "" {
-		nfg.jobActions.EqueueJobAfter(&fn, &jobStatus, parentJobID)
+		err = nfg.jobActions.EqueueJobAfter(wrappedFn, &jobStatus, parentJobID)
 
 	} else {
-		nfg.jobActions.EnqueueJob(&fn, &jobStatus)
+		err = nfg.jobActions.EnqueueJob(wrappedFn, &jobStatus)
+	}
+	if err != nil {
+		return NgramJobInfo{}, err
 	}
 	return jobStatus, nil
 }
 
+// NgramPreview provides information about an n-gram generation
+// without actually running it.
+type NgramPreview struct {
+	NgramSize int `json:"ngramSize"`
+
+	// NumSourceRows is the number of colcounts rows
+	// the generator will process
+	NumSourceRows int `json:"numSourceRows"`
+
+	// TimeEstimationSecs is -1 in case no estimation is available
+	TimeEstimationSecs int  `json:"timeEstimationSecs"`
+	TablesExist        bool `json:"tablesExist"`
+}
+
+// Preview calculates basic properties of the n-gram generation
+// without modifying any data.
+func (nfg *NgramFreqGenerator) Preview() (NgramPreview, error) {
+	ans := NgramPreview{NgramSize: nfg.ngramSize}
+	total, err := nfg.findTotalNumLines()
+	if err != nil {
+		return ans, fmt.Errorf("failed to preview ngrams generation: %w", err)
+	}
+	ans.NumSourceRows = total
+	ans.TimeEstimationSecs, err = db.EstimateProcTimeSecs(nfg.db.DB(), "ngrams", total)
+	if err == db.ErrorEstimationNotAvail {
+		ans.TimeEstimationSecs = -1
+
+	} else if err != nil {
+		return ans, fmt.Errorf("failed to preview ngrams generation: %w", err)
+	}
+	ans.TablesExist, err = nfg.tablesExist()
+	if err != nil {
+		return ans, fmt.Errorf("failed to preview ngrams generation: %w", err)
+	}
+	return ans, nil
+}
+
 // NewNgramFreqGenerator
-// The minFreq argument with value 0 means "no limit"
+// The minFreq argument specifies the minimum absolute frequency
+// an n-gram must have to be written to the database. Values
+// 0 and 1 mean "no limit".
 //
 // TODO the number of args is too much here
 func NewNgramFreqGenerator(
@@ -816,5 +998,6 @@ func NewNgramFreqGenerator(
 		posFn:                posFn,
 		qsaAttrs:             qsaAttrs,
 		appendExisting:       appendExisting,
+		minFreq:              minFreq,
 	}
 }
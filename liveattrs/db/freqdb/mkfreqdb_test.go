@@ -17,8 +17,13 @@
 package freqdb
 
 import (
+	"context"
+	"errors"
+	"frodo/db/tables"
 	"testing"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,3 +54,97 @@ func TestDetermineSimFreqsScore(t *testing.T) {
 	assert.InDelta(t, 3000.0, items[8].simFreqsScore, 0.001)
 	assert.InDelta(t, 3000.0, items[9].simFreqsScore, 0.001)
 }
+
+// filterTestNgrams applies acceptNgram the same way
+// preloadCols does on the loaded rows
+func filterTestNgrams(nfg *NgramFreqGenerator, items []*ngRecord) ([]*ngRecord, int) {
+	ans := make([]*ngRecord, 0, len(items))
+	var numLowFreq int
+	for _, item := range items {
+		ok, lowFreq := nfg.acceptNgram(item)
+		if lowFreq {
+			numLowFreq++
+		}
+		if ok {
+			ans = append(ans, item)
+		}
+	}
+	return ans, numLowFreq
+}
+
+func TestFilterNgramsMinFreq(t *testing.T) {
+	items := []*ngRecord{
+		{word: "dům", lemma: "dům", abs: 120},
+		{word: "domek", lemma: "domek", abs: 2},
+		{word: "domeček", lemma: "domeček", abs: 1},
+		{word: "domov", lemma: "domov", abs: 3},
+		{word: ",", lemma: ",", abs: 500},
+	}
+	nfg := &NgramFreqGenerator{minFreq: 3}
+	ans, numSkipped := filterTestNgrams(nfg, items)
+	assert.Equal(t, 2, numSkipped)
+	assert.Equal(t, []string{"dům", "domov"}, []string{ans[0].lemma, ans[1].lemma})
+	assert.Len(t, ans, 2)
+}
+
+func TestFilterNgramsNoMinFreq(t *testing.T) {
+	items := []*ngRecord{
+		{word: "domek", lemma: "domek", abs: 1},
+		{word: "domov", lemma: "domov", abs: 3},
+	}
+	nfg := &NgramFreqGenerator{minFreq: 1}
+	ans, numSkipped := filterTestNgrams(nfg, items)
+	assert.Equal(t, 0, numSkipped)
+	assert.Len(t, ans, 2)
+}
+
+func TestNgramTables(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"syn2020_lemma_stats", "syn2020_term_search", "syn2020_word"},
+		NgramTables("syn2020", false),
+	)
+	assert.Equal(
+		t,
+		[]string{"syn2020_lemma_stats", "syn2020_term_search", "syn2020_word", "syn2020_colcounts"},
+		NgramTables("syn2020", true),
+	)
+}
+
+func TestWordTableRespectsTemplate(t *testing.T) {
+	assert.NoError(t, tables.Configure("frodo_{table}_{corpus}"))
+	defer tables.Configure(tables.DefaultTemplate)
+	nfg := &NgramFreqGenerator{groupedName: "syn2020"}
+	assert.Equal(t, "frodo_word_syn2020", nfg.wordTable())
+	assert.Contains(t, NgramTables("syn2020", false), nfg.wordTable())
+}
+
+func TestQueryTimeoutIsReported(t *testing.T) {
+	nfg := &NgramFreqGenerator{}
+	nfg.SetQueryTimeout(10 * time.Millisecond)
+	ctx, cancel := nfg.queryContext(context.Background())
+	defer cancel()
+	// simulate a slow query respecting the context
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("query context not cancelled in time")
+	}
+	err := wrapQueryError(ctx.Err())
+	assert.ErrorIs(t, err, ErrorQueryTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = wrapQueryError(&mysql.MySQLError{Number: 3024, Message: "maximum statement execution time exceeded"})
+	assert.ErrorIs(t, err, ErrorQueryTimeout)
+
+	err = wrapQueryError(errors.New("connection refused"))
+	assert.NotErrorIs(t, err, ErrorQueryTimeout)
+}
+
+func TestNoQueryTimeoutByDefault(t *testing.T) {
+	nfg := &NgramFreqGenerator{}
+	ctx, cancel := nfg.queryContext(context.Background())
+	defer cancel()
+	_, hasDeadline := ctx.Deadline()
+	assert.False(t, hasDeadline)
+}
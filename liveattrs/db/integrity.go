@@ -0,0 +1,191 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"frodo/corpus"
+	"frodo/db/tables"
+	"frodo/liveattrs/utils"
+	"slices"
+)
+
+const (
+	maxReportedDuplicates = 20
+)
+
+// IntegrityReport describes problems found in liveattrs
+// tables of a corpus.
+type IntegrityReport struct {
+	CorpusID string `json:"corpusId"`
+
+	// NumRows is the total number of corpus rows in the `*_liveattrs_entry` table
+	NumRows int `json:"numRows"`
+
+	// NullKeyColumns maps key columns (item_id, corpus_id, bib. ID)
+	// to the number of rows with NULL value in the column
+	NullKeyColumns map[string]int `json:"nullKeyColumns"`
+
+	// NullAttrColumns maps structural attribute columns to the number of rows
+	// with NULL value in the column. Only columns with at least one NULL
+	// value are reported.
+	NullAttrColumns map[string]int `json:"nullAttrColumns"`
+
+	// OrphanedAlignedRows is the number of rows of aligned corpora (i.e. ones
+	// sharing the same grouped table) without a matching item_id in the
+	// primary corpus. For non-parallel corpora, this is always zero.
+	OrphanedAlignedRows int `json:"orphanedAlignedRows"`
+
+	// NumDuplicateItemIDs is the number of item_id values used in more
+	// than a single row of the corpus
+	NumDuplicateItemIDs int `json:"numDuplicateItemIds"`
+
+	// DuplicateItemIDs contains a sample of duplicate item_id values
+	DuplicateItemIDs []string `json:"duplicateItemIds"`
+}
+
+// IsOK returns true if no problems were found
+func (r IntegrityReport) IsOK() bool {
+	for _, v := range r.NullKeyColumns {
+		if v > 0 {
+			return false
+		}
+	}
+	return len(r.NullAttrColumns) == 0 && r.OrphanedAlignedRows == 0 &&
+		r.NumDuplicateItemIDs == 0
+}
+
+func countRows(ctx context.Context, db *sql.DB, sqlq string, args ...any) (int, error) {
+	var ans sql.NullInt64
+	if err := db.QueryRowContext(ctx, sqlq, args...).Scan(&ans); err != nil {
+		return 0, err
+	}
+	return int(ans.Int64), nil
+}
+
+// CheckIntegrity scans `*_liveattrs_entry` table of a corpus for common
+// integrity problems. The subcorpAttrs argument specifies structural
+// attributes (in the `struct.attr` form) to be checked for NULL values.
+func CheckIntegrity(
+	ctx context.Context,
+	db *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	subcorpAttrs []string,
+) (IntegrityReport, error) {
+	ans := IntegrityReport{
+		CorpusID:         corpusInfo.Name,
+		NullKeyColumns:   make(map[string]int),
+		NullAttrColumns:  make(map[string]int),
+		DuplicateItemIDs: []string{},
+	}
+	table := fmt.Sprintf("`%s`", tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry))
+	var err error
+
+	ans.NumRows, err = countRows(
+		ctx, db, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE corpus_id = ?", table), corpusInfo.Name)
+	if err != nil {
+		return ans, fmt.Errorf("failed to count rows: %w", err)
+	}
+
+	// corpus_id cannot be tested along with other columns as all the other
+	// tests are bound to a specific corpus_id
+	ans.NullKeyColumns["corpus_id"], err = countRows(
+		ctx, db, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE corpus_id IS NULL", table))
+	if err != nil {
+		return ans, fmt.Errorf("failed to test NULL values in corpus_id: %w", err)
+	}
+	keyCols := []string{"item_id"}
+	if corpusInfo.BibIDAttr != "" {
+		keyCols = append(keyCols, utils.ImportKey(corpusInfo.BibIDAttr))
+	}
+	for _, col := range keyCols {
+		ans.NullKeyColumns[col], err = countRows(
+			ctx,
+			db,
+			fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE corpus_id = ? AND `%s` IS NULL", table, col),
+			corpusInfo.Name,
+		)
+		if err != nil {
+			return ans, fmt.Errorf("failed to test NULL values in %s: %w", col, err)
+		}
+	}
+
+	for _, attr := range subcorpAttrs {
+		col := utils.ImportKey(attr)
+		if slices.Contains(keyCols, col) {
+			continue
+		}
+		num, err := countRows(
+			ctx,
+			db,
+			fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE corpus_id = ? AND `%s` IS NULL", table, col),
+			corpusInfo.Name,
+		)
+		if err != nil {
+			return ans, fmt.Errorf("failed to test NULL values in %s: %w", col, err)
+		}
+		if num > 0 {
+			ans.NullAttrColumns[col] = num
+		}
+	}
+
+	if corpusInfo.GroupedName() != corpusInfo.Name {
+		ans.OrphanedAlignedRows, err = countRows(
+			ctx,
+			db,
+			fmt.Sprintf(
+				"SELECT COUNT(*) FROM %s AS t2 WHERE t2.corpus_id <> ? AND NOT EXISTS "+
+					"(SELECT 1 FROM %s AS t1 WHERE t1.corpus_id = ? AND t1.item_id = t2.item_id)",
+				table, table,
+			),
+			corpusInfo.Name, corpusInfo.Name,
+		)
+		if err != nil {
+			return ans, fmt.Errorf("failed to search for orphaned aligned rows: %w", err)
+		}
+	}
+
+	rows, err := db.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			"SELECT item_id FROM %s WHERE corpus_id = ? AND item_id IS NOT NULL "+
+				"GROUP BY item_id HAVING COUNT(*) > 1",
+			table,
+		),
+		corpusInfo.Name,
+	)
+	if err != nil {
+		return ans, fmt.Errorf("failed to search for duplicate item IDs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var itemID string
+		if err := rows.Scan(&itemID); err != nil {
+			return ans, fmt.Errorf("failed to search for duplicate item IDs: %w", err)
+		}
+		ans.NumDuplicateItemIDs++
+		if len(ans.DuplicateItemIDs) < maxReportedDuplicates {
+			ans.DuplicateItemIDs = append(ans.DuplicateItemIDs, itemID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return ans, fmt.Errorf("failed to search for duplicate item IDs: %w", err)
+	}
+	return ans, nil
+}
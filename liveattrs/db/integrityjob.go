@@ -0,0 +1,130 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"frodo/jobs"
+	"time"
+)
+
+const (
+	IntegrityJobType = "liveattrs-integrity"
+)
+
+// IntegrityJobInfo collects information about liveattrs
+// data integrity validation job
+type IntegrityJobInfo struct {
+	ID          string           `json:"id"`
+	Type        string           `json:"type"`
+	CorpusID    string           `json:"corpusId"`
+	Start       jobs.JSONTime    `json:"start"`
+	Update      jobs.JSONTime    `json:"update"`
+	Finished    bool             `json:"finished"`
+	Error       error            `json:"error,omitempty"`
+	NumRestarts int              `json:"numRestarts"`
+	Result      *IntegrityReport `json:"result"`
+}
+
+func (j IntegrityJobInfo) GetID() string {
+	return j.ID
+}
+
+func (j IntegrityJobInfo) GetType() string {
+	return j.Type
+}
+
+func (j IntegrityJobInfo) GetStartDT() jobs.JSONTime {
+	return j.Start
+}
+
+func (j IntegrityJobInfo) GetNumRestarts() int {
+	return j.NumRestarts
+}
+
+func (j IntegrityJobInfo) GetCorpus() string {
+	return j.CorpusID
+}
+
+func (j IntegrityJobInfo) GetDatasetID() string {
+	return j.CorpusID
+}
+
+func (j IntegrityJobInfo) AsFinished() jobs.GeneralJobInfo {
+	j.Update = jobs.CurrentDatetime()
+	j.Finished = true
+	return j
+}
+
+func (j IntegrityJobInfo) IsFinished() bool {
+	return j.Finished
+}
+
+func (j IntegrityJobInfo) FullInfo() any {
+	return struct {
+		ID          string           `json:"id"`
+		Type        string           `json:"type"`
+		CorpusID    string           `json:"corpusId"`
+		Start       jobs.JSONTime    `json:"start"`
+		Update      jobs.JSONTime    `json:"update"`
+		Finished    bool             `json:"finished"`
+		Error       string           `json:"error,omitempty"`
+		OK          bool             `json:"ok"`
+		NumRestarts int              `json:"numRestarts"`
+		Result      *IntegrityReport `json:"result"`
+	}{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      j.Update,
+		Finished:    j.Finished,
+		Error:       jobs.ErrorToString(j.Error),
+		OK:          j.Error == nil,
+		NumRestarts: j.NumRestarts,
+		Result:      j.Result,
+	}
+}
+
+func (j IntegrityJobInfo) CompactVersion() jobs.JobInfoCompact {
+	return jobs.JobInfoCompact{
+		ID:       j.ID,
+		Type:     j.Type,
+		CorpusID: j.CorpusID,
+		Start:    j.Start,
+		Update:   j.Update,
+		Finished: j.Finished,
+		OK:       j.Error == nil,
+	}
+}
+
+func (j IntegrityJobInfo) GetError() error {
+	return j.Error
+}
+
+func (j IntegrityJobInfo) WithError(err error) jobs.GeneralJobInfo {
+	return &IntegrityJobInfo{
+		ID:          j.ID,
+		Type:        j.Type,
+		CorpusID:    j.CorpusID,
+		Start:       j.Start,
+		Update:      jobs.JSONTime(time.Now()),
+		Finished:    true,
+		Error:       err,
+		Result:      j.Result,
+		NumRestarts: j.NumRestarts,
+	}
+}
@@ -19,6 +19,7 @@ package adhoc
 import (
 	"fmt"
 	"frodo/corpus"
+	"frodo/db/tables"
 	"frodo/liveattrs/request/query"
 	"strings"
 )
@@ -46,8 +47,8 @@ func (ssize *SubcSize) Query() (ansSQL string, whereValues []any) {
 		joinSQL = append(
 			joinSQL,
 			fmt.Sprintf(
-				"JOIN `%s_liveattrs_entry` AS t%d ON t1.item_id = t%d.item_id",
-				ssize.CorpusInfo.GroupedName(), iOffs, iOffs,
+				"JOIN `%s` AS t%d ON t1.item_id = t%d.item_id",
+				tables.Name(ssize.CorpusInfo.GroupedName(), tables.LiveAttrsEntry), iOffs, iOffs,
 			),
 		)
 		whereSQL = append(
@@ -66,8 +67,8 @@ func (ssize *SubcSize) Query() (ansSQL string, whereValues []any) {
 	whereSQL = append(whereSQL, where2)
 	whereValues = append(whereValues, args2...)
 	ansSQL = fmt.Sprintf(
-		"SELECT SUM(t1.poscount) FROM `%s_liveattrs_entry` AS t1 %s WHERE %s",
-		ssize.CorpusInfo.GroupedName(),
+		"SELECT SUM(t1.poscount) FROM `%s` AS t1 %s WHERE %s",
+		tables.Name(ssize.CorpusInfo.GroupedName(), tables.LiveAttrsEntry),
 		strings.Join(joinSQL, " "),
 		strings.Join(whereSQL, " AND "),
 	)
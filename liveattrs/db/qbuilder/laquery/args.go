@@ -21,6 +21,8 @@ import (
 	"frodo/liveattrs/db/qbuilder"
 	"frodo/liveattrs/request/query"
 	"frodo/liveattrs/utils"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -32,6 +34,13 @@ type PredicateArgs struct {
 	bibLabel            string
 	autocompleteAttr    string
 	emptyValPlaceholder string
+	orGroups            query.OrGroups
+}
+
+// predicate is an SQL condition matching a single attribute
+type predicate struct {
+	sql    string
+	values []string
 }
 
 func (args *PredicateArgs) Len() int {
@@ -45,18 +54,57 @@ func (args *PredicateArgs) importValue(value string) string {
 	return value
 }
 
-func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []string) {
-	where := make([]string, 0, 20)
+// isPlainListing tests whether all the values are strings to be
+// matched exactly (i.e. no LIKE patterns and no bib. label values)
+func isPlainListing(values []any) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		tv, ok := v.(string)
+		if !ok || strings.Contains(tv, "%") || strings.HasPrefix(tv, "@") {
+			return false
+		}
+	}
+	return true
+}
+
+// ExportSQL creates a WHERE condition (along with its arguments) matching
+// the attributes. Negated attributes (`!struct.attr`) produce `NOT IN`,
+// `!=` or `NOT LIKE` conditions. In case an attribute name cannot be used
+// as a column name, utils.ErrorUnknownAttr is returned. A malformed
+// interval value is reported as an error too.
+func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []string, error) {
+	preds := make(map[string]predicate, len(args.data))
 	sqlValues := make([]string, 0, 20)
 	for dkey, values := range args.data {
+		if !utils.IsValidAttrName(dkey) {
+			return "", []string{}, fmt.Errorf("%w %s", utils.ErrorUnknownAttr, dkey)
+		}
 		exclude := strings.HasPrefix(dkey, "!")
 		key := utils.ImportKey(dkey)
-		if args.autocompleteAttr == args.bibLabel && key == args.bibID {
+		if utils.ImportKey(args.autocompleteAttr) == args.bibLabel && key == args.bibID {
 			continue
 		}
 		cnfItem := make([]string, 0, 20)
+		valStart := len(sqlValues)
 		switch tValues := values.(type) {
 		case []any:
+			if exclude && isPlainListing(tValues) {
+				placeholders := make([]string, len(tValues))
+				for i, value := range tValues {
+					placeholders[i] = "?"
+					sqlValues = append(sqlValues, args.importValue(value.(string)))
+				}
+				cnfItem = append(
+					cnfItem,
+					fmt.Sprintf(
+						"%s.%s NOT IN (%s)",
+						itemPrefix, key, strings.Join(placeholders, ", "),
+					),
+				)
+				break
+			}
 			for _, value := range tValues {
 				tValue, ok := value.(string)
 				if !ok {
@@ -103,8 +151,33 @@ func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []str
 			}
 			sqlValues = append(sqlValues, args.importValue(tValues))
 		case map[string]any:
+			interval, isInterval, err := args.data.GetIntervalAttrVal(dkey)
+			if err != nil {
+				// skipping the attribute would widen the filter
+				return "", []string{}, fmt.Errorf(
+					"failed to process liveattrs attribute %s (corpus %s): %w", key, corpusID, err)
+			}
 			regexpVal, ok := args.data.GetRegexpAttrVal(dkey)
-			if ok {
+			if isInterval {
+				if exclude {
+					cnfItem = append(
+						cnfItem,
+						fmt.Sprintf("CAST(%s.%s AS DECIMAL(65, 10)) NOT BETWEEN ? AND ?", itemPrefix, key),
+					)
+
+				} else {
+					cnfItem = append(
+						cnfItem,
+						fmt.Sprintf("CAST(%s.%s AS DECIMAL(65, 10)) BETWEEN ? AND ?", itemPrefix, key),
+					)
+				}
+				sqlValues = append(
+					sqlValues,
+					strconv.FormatFloat(interval.From, 'f', -1, 64),
+					strconv.FormatFloat(interval.To, 'f', -1, 64),
+				)
+
+			} else if ok {
 				if exclude {
 					cnfItem = append(cnfItem, fmt.Sprintf("%s.%s NOT REGEXP ?", itemPrefix, key))
 				} else {
@@ -130,16 +203,55 @@ func (args *PredicateArgs) ExportSQL(itemPrefix, corpusID string) (string, []str
 		}
 
 		if len(cnfItem) > 0 {
+			var cond string
 			if exclude {
-				where = append(where, fmt.Sprintf("(%s)", strings.Join(cnfItem, " AND ")))
+				cond = fmt.Sprintf("(%s)", strings.Join(cnfItem, " AND "))
 			} else {
-				where = append(where, fmt.Sprintf("(%s)", strings.Join(cnfItem, " OR ")))
+				cond = fmt.Sprintf("(%s)", strings.Join(cnfItem, " OR "))
 			}
+			preds[dkey] = predicate{sql: cond, values: sqlValues[valStart:]}
 		}
 	}
+	where, sqlValues := args.combinePredicates(preds)
 	where = append(where, fmt.Sprintf("%s.corpus_id = ?", itemPrefix))
 	sqlValues = append(sqlValues, corpusID)
-	return strings.Join(where, " AND "), sqlValues
+	return strings.Join(where, " AND "), sqlValues, nil
+}
+
+// combinePredicates joins predicates of attributes within each OR group.
+// It returns conditions to be combined using AND along with their arguments.
+// OR groups go first, then the attributes without a group (sorted by name).
+func (args *PredicateArgs) combinePredicates(preds map[string]predicate) ([]string, []string) {
+	where := make([]string, 0, len(preds)+1)
+	sqlValues := make([]string, 0, 20)
+	grouped := make(map[string]bool)
+	for _, group := range args.orGroups {
+		items := make([]string, 0, len(group))
+		for _, attr := range group {
+			grouped[attr] = true
+			pred, ok := preds[attr]
+			if !ok {
+				continue
+			}
+			items = append(items, pred.sql)
+			sqlValues = append(sqlValues, pred.values...)
+		}
+		if len(items) > 0 {
+			where = append(where, fmt.Sprintf("(%s)", strings.Join(items, " OR ")))
+		}
+	}
+	attrs := make([]string, 0, len(preds))
+	for attr := range preds {
+		if !grouped[attr] {
+			attrs = append(attrs, attr)
+		}
+	}
+	slices.Sort(attrs)
+	for _, attr := range attrs {
+		where = append(where, preds[attr].sql)
+		sqlValues = append(sqlValues, preds[attr].values...)
+	}
+	return where, sqlValues
 }
 
 type QueryComponents struct {
@@ -147,4 +259,5 @@ type QueryComponents struct {
 	selectedAttrs []string
 	hiddenAttrs   []string
 	whereValues   []string
+	limit         int
 }
@@ -17,23 +17,54 @@
 package laquery
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"frodo/corpus"
+	"frodo/db/tables"
 	"frodo/liveattrs/request/query"
 	"frodo/liveattrs/utils"
+	"slices"
 	"strings"
 
 	"github.com/czcorpus/cnc-gokit/collections"
 )
 
+const (
+	// JoinStrategyNone means no aligned corpora are involved
+	JoinStrategyNone = "none"
+
+	// JoinStrategyItemID means aligned corpora are matched via a self-join
+	// of the grouped (parallel) liveattrs table on the `item_id` column
+	JoinStrategyItemID = "item_id"
+)
+
 type LAFilter struct {
-	CorpusInfo          *corpus.DBInfo
-	AttrMap             query.Attrs
-	SearchAttrs         []string
-	AlignedCorpora      []string
+	CorpusInfo     *corpus.DBInfo
+	AttrMap        query.Attrs
+	AlignedCorpora []string
+
+	// SearchAttrs specifies attributes to be fetched. Attributes
+	// of aligned corpora are prefixed by the corpus ID
+	// (see utils.AlignedAttrSeparator).
+	SearchAttrs []string
+
+	// AutocompleteAttr is an attribute whose value (in AttrMap)
+	// is searched as a pattern. It can be also located in one
+	// of AlignedCorpora (see SearchAttrs for the notation).
 	AutocompleteAttr    string
 	EmptyValPlaceholder string
+
+	// OrGroups (optional) specifies attributes whose conditions
+	// are combined using OR
+	OrGroups query.OrGroups
+
+	// SQLLimit (optional) limits the number of fetched rows.
+	// Please note that with the limit applied, all the counts
+	// (poscount, counts of values) are calculated only from the fetched
+	// rows and thus they are approximate. It should be used only for plain
+	// listings where exact counts are not required. Zero means "no limit".
+	SQLLimit int
 }
 
 func (b *LAFilter) attrToSQL(values []string, prefix string) []string {
@@ -44,17 +75,123 @@ func (b *LAFilter) attrToSQL(values []string, prefix string) []string {
 	return ans
 }
 
-func (b *LAFilter) CreateSQL() QueryComponents {
+// alignedColumn describes a column of an aligned corpus
+// attribute within the joined liveattrs table
+type alignedColumn struct {
+
+	// table is the alias of the joined table (e.g. `t2`)
+	table string
+
+	// column is the attribute column (e.g. `doc_title`)
+	column string
+}
+
+// alias is the name under which the column is selected
+// (so it cannot be confused with the primary corpus column)
+func (ac alignedColumn) alias() string {
+	return ac.table + "_" + ac.column
+}
+
+// alignedColumn resolves an attribute prefixed by an aligned corpus ID
+// into the respective joined table column. For non-prefixed attributes,
+// nil is returned. In case the corpus is not among AlignedCorpora or
+// the attribute name is invalid, utils.ErrorUnknownAttr is returned.
+func (b *LAFilter) alignedColumn(attr string) (*alignedColumn, error) {
+	alignedCorpus, name := utils.SplitAlignedAttr(attr)
+	if alignedCorpus == "" {
+		return nil, nil
+	}
+	idx := slices.Index(b.AlignedCorpora, alignedCorpus)
+	if idx < 0 {
+		return nil, fmt.Errorf(
+			"%w %s (corpus %s not among aligned corpora)", utils.ErrorUnknownAttr, attr, alignedCorpus)
+	}
+	if !utils.IsValidAttrName(name) {
+		return nil, fmt.Errorf("%w %s", utils.ErrorUnknownAttr, attr)
+	}
+	return &alignedColumn{
+		table:  fmt.Sprintf("t%d", idx+2),
+		column: utils.ImportKey(name),
+	}, nil
+}
+
+// ColumnAttrs maps database columns of selected attributes to their
+// structure-qualified names (e.g. `doc_author` => `doc.author`). Unlike
+// utils.ExportKey, this works also for structures with underscores in
+// their names. Columns of aligned corpora attributes are mapped
+// to the prefixed attributes.
+func (b *LAFilter) ColumnAttrs() map[string]string {
+	ans := make(map[string]string, len(b.SearchAttrs)+1)
+	for _, attr := range b.SearchAttrs {
+		if ac, err := b.alignedColumn(attr); ac != nil && err == nil {
+			ans[ac.alias()] = attr
+			continue
+		}
+		ans[utils.ImportKey(attr)] = strings.TrimPrefix(attr, "!")
+	}
+	if b.CorpusInfo.BibIDAttr != "" {
+		ans[utils.ImportKey(b.CorpusInfo.BibIDAttr)] = b.CorpusInfo.BibIDAttr
+	}
+	return ans
+}
+
+// JoinStrategy describes how the filter matches aligned corpora
+// (see JoinStrategy* constants)
+func (b *LAFilter) JoinStrategy() string {
+	if len(b.AlignedCorpora) == 0 {
+		return JoinStrategyNone
+	}
+	return JoinStrategyItemID
+}
+
+// CreateSQL creates an SQL query (and its arguments) for fetching
+// liveattrs entries matching the filter.
+func (b *LAFilter) CreateSQL() (QueryComponents, error) {
 	bibID := utils.ImportKey(b.CorpusInfo.BibIDAttr)
 	bibLabel := utils.ImportKey(b.CorpusInfo.BibLabelAttr)
+	// attributes of aligned corpora are handled separately
+	// as they refer to the joined tables
+	searchAttrs := make([]string, 0, len(b.SearchAttrs))
+	alignedSelect := make([]string, 0, 2)
+	for _, attr := range b.SearchAttrs {
+		ac, err := b.alignedColumn(attr)
+		if err != nil {
+			return QueryComponents{}, err
+		}
+		if ac != nil {
+			alignedSelect = append(
+				alignedSelect, fmt.Sprintf("%s.%s AS %s", ac.table, ac.column, ac.alias()))
+			continue
+		}
+		searchAttrs = append(searchAttrs, attr)
+	}
+	attrMap := b.AttrMap
+	autocompleteAttr := b.AutocompleteAttr
+	acColumn, err := b.alignedColumn(b.AutocompleteAttr)
+	if err != nil {
+		return QueryComponents{}, err
+	}
+	if acColumn != nil {
+		attrMap = make(query.Attrs, len(b.AttrMap))
+		for k, v := range b.AttrMap {
+			if k != b.AutocompleteAttr {
+				attrMap[k] = v
+			}
+		}
+		autocompleteAttr = ""
+	}
 	attrItems := PredicateArgs{
-		data:                b.AttrMap,
+		data:                attrMap,
 		bibID:               bibID,
 		bibLabel:            bibLabel,
-		autocompleteAttr:    b.AutocompleteAttr,
+		autocompleteAttr:    autocompleteAttr,
 		emptyValPlaceholder: b.EmptyValPlaceholder,
+		orGroups:            b.OrGroups,
+	}
+	whereSQL0, whereValues0, err := attrItems.ExportSQL("t1", b.CorpusInfo.Name) // TODO py uses 'info.id' here
+	if err != nil {
+		return QueryComponents{}, err
 	}
-	whereSQL0, whereValues0 := attrItems.ExportSQL("t1", b.CorpusInfo.Name) // TODO py uses 'info.id' here
 	whereSQL := make([]string, 0, 20)
 	whereSQL = append(whereSQL, whereSQL0)
 	whereValues := make([]string, 0, 20+len(whereValues0))
@@ -64,42 +201,75 @@ func (b *LAFilter) CreateSQL() QueryComponents {
 		joinSQL = append(
 			joinSQL,
 			fmt.Sprintf(
-				"JOIN `%s_liveattrs_entry` AS t%d ON t1.item_id = t%d.item_id", b.CorpusInfo.GroupedName(),
+				"JOIN `%s` AS t%d ON t1.item_id = t%d.item_id", tables.Name(b.CorpusInfo.GroupedName(), tables.LiveAttrsEntry),
 				i+2, i+2,
 			),
 		)
 		whereSQL = append(whereSQL, fmt.Sprintf(" AND t%d.corpus_id = ?", i+2))
 		whereValues = append(whereValues, item)
 	}
+	if acColumn != nil {
+		acValue, ok := b.AttrMap[b.AutocompleteAttr].(string)
+		if !ok {
+			return QueryComponents{}, fmt.Errorf(
+				"invalid autocomplete value of aligned attribute %s", b.AutocompleteAttr)
+		}
+		whereSQL = append(whereSQL, fmt.Sprintf(" AND %s.%s LIKE ?", acColumn.table, acColumn.column))
+		whereValues = append(whereValues, acValue)
+	}
 	hiddenAttrs := collections.NewSet[string]()
-	if bibID != "" && !collections.SliceContains(b.SearchAttrs, bibID) {
+	if bibID != "" && !collections.SliceContains(searchAttrs, bibID) {
 		hiddenAttrs.Add(bibID)
 	}
-	selectedAttrs := collections.NewSet(b.SearchAttrs...).Union(*hiddenAttrs)
+	selectedAttrs := collections.NewSet(searchAttrs...).Union(*hiddenAttrs)
+	// note: with no attributes selected (e.g. a corpus without subcorp. attributes
+	// and without bib. ID), we still must produce a valid column list
+	selectSQL := func(cols ...string) string {
+		cols = append(cols, b.attrToSQL(selectedAttrs.ToOrderedSlice(), "t1")...)
+		return strings.Join(append(cols, alignedSelect...), ", ")
+	}
 	var sqlTemplate string
 	if len(whereSQL) > 0 {
 		sqlTemplate = fmt.Sprintf(
-			"SELECT DISTINCT t1.poscount, t1.id, %s FROM `%s_liveattrs_entry` AS t1 %s WHERE %s",
-			strings.Join(b.attrToSQL(selectedAttrs.ToOrderedSlice(), "t1"), ", "),
-			b.CorpusInfo.GroupedName(),
+			"SELECT DISTINCT %s FROM `%s` AS t1 %s WHERE %s",
+			selectSQL("t1.poscount", "t1.id"),
+			tables.Name(b.CorpusInfo.GroupedName(), tables.LiveAttrsEntry),
 			strings.Join(joinSQL, " "),
 			strings.Join(whereSQL, " "),
 		)
 
 	} else {
 		sqlTemplate = fmt.Sprintf(
-			"SELECT DISTINCT t1.poscount, %s FROM `%s_liveattrs_entry` AS t1 %s",
-			strings.Join(b.attrToSQL(selectedAttrs.ToOrderedSlice(), "t1"), ", "),
-			b.CorpusInfo.GroupedName(),
+			"SELECT DISTINCT %s FROM `%s` AS t1 %s",
+			selectSQL("t1.poscount"),
+			tables.Name(b.CorpusInfo.GroupedName(), tables.LiveAttrsEntry),
 			strings.Join(joinSQL, " "),
 		)
 	}
+	if b.SQLLimit > 0 {
+		sqlTemplate += " LIMIT ?"
+	}
 	return QueryComponents{
 		sqlTemplate:   sqlTemplate,
 		selectedAttrs: selectedAttrs.ToOrderedSlice(),
 		hiddenAttrs:   hiddenAttrs.ToOrderedSlice(),
 		whereValues:   whereValues,
+		limit:         b.SQLLimit,
+	}, nil
+}
+
+// CreateCountSQL creates an SQL query (and its arguments) for calculating
+// the total number of positions of liveattrs entries matching the filter.
+// The SQLLimit is not applied here.
+func (b *LAFilter) CreateCountSQL() (string, []string, error) {
+	cb := *b
+	cb.SearchAttrs = []string{}
+	cb.SQLLimit = 0
+	qc, err := cb.CreateSQL()
+	if err != nil {
+		return "", []string{}, err
 	}
+	return fmt.Sprintf("SELECT SUM(tc.poscount) FROM (%s) AS tc", qc.sqlTemplate), qc.whereValues, nil
 }
 
 type ResultRow struct {
@@ -113,13 +283,22 @@ type DataIterator struct {
 	Builder *LAFilter
 }
 
-func (di *DataIterator) Iterate(fn func(row ResultRow) error) error {
-	qc := di.Builder.CreateSQL()
-	args := make([]any, len(qc.whereValues))
+// Iterate runs the filter query and calls fn for each matching row.
+// In case the ctx is cancelled (e.g. by a client closing the connection),
+// the query is aborted and ctx.Err() is returned.
+func (di *DataIterator) Iterate(ctx context.Context, fn func(row ResultRow) error) error {
+	qc, err := di.Builder.CreateSQL()
+	if err != nil {
+		return err
+	}
+	args := make([]any, len(qc.whereValues), len(qc.whereValues)+1)
 	for i, v := range qc.whereValues {
 		args[i] = v
 	}
-	rows, err := di.DB.Query(qc.sqlTemplate, args...)
+	if qc.limit > 0 {
+		args = append(args, qc.limit)
+	}
+	rows, err := di.DB.QueryContext(ctx, qc.sqlTemplate, args...)
 	if err != nil {
 		return err
 	}
@@ -129,6 +308,9 @@ func (di *DataIterator) Iterate(fn func(row ResultRow) error) error {
 	}
 	defer rows.Close()
 	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		pcols := make([]any, len(colnames))
 		ansRow := ResultRow{
 			Attrs: make(map[string]string, len(colnames)-2),
@@ -154,5 +336,23 @@ func (di *DataIterator) Iterate(fn func(row ResultRow) error) error {
 		}
 
 	}
-	return nil
+	return rows.Err()
+}
+
+// TotalPoscount returns the total number of positions of all
+// the entries matching the filter (regardless of SQLLimit).
+func (di *DataIterator) TotalPoscount(ctx context.Context) (int, error) {
+	sqlq, values, err := di.Builder.CreateCountSQL()
+	if err != nil {
+		return 0, err
+	}
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	var ans sql.NullInt64
+	if err := di.DB.QueryRowContext(ctx, sqlq, args...).Scan(&ans); err != nil {
+		return 0, err
+	}
+	return int(ans.Int64), nil
 }
@@ -0,0 +1,411 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package laquery
+
+import (
+	"frodo/corpus"
+	"frodo/liveattrs/request/query"
+	"frodo/liveattrs/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSQLNoSearchAttrs(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+		AttrMap:    query.Attrs{},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id FROM `susanne_liveattrs_entry` AS t1  WHERE t1.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+	assert.Empty(t, qc.selectedAttrs)
+	assert.Equal(t, []string{"susanne"}, qc.whereValues)
+}
+
+func TestCreateSQLWithSearchAttrs(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo:  &corpus.DBInfo{Name: "susanne", BibIDAttr: "doc.id"},
+		AttrMap:     query.Attrs{},
+		SearchAttrs: []string{"doc_genre"},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id, t1.doc_genre, t1.doc_id "+
+			"FROM `susanne_liveattrs_entry` AS t1  WHERE t1.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+}
+
+func TestCreateSQLOverlappingAttrNames(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo:  &corpus.DBInfo{Name: "susanne"},
+		AttrMap:     query.Attrs{"text.author": []any{"Doe"}},
+		SearchAttrs: []string{"doc.author", "text.author"},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id, t1.doc_author, t1.text_author "+
+			"FROM `susanne_liveattrs_entry` AS t1  WHERE (t1.text_author = ?) AND t1.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"Doe", "susanne"}, qc.whereValues)
+}
+
+func TestColumnAttrsOverlappingAttrNames(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo:  &corpus.DBInfo{Name: "susanne", BibIDAttr: "text_block.id"},
+		SearchAttrs: []string{"doc.author", "text.author", "text_block.author"},
+	}
+	assert.Equal(
+		t,
+		map[string]string{
+			"doc_author":        "doc.author",
+			"text_author":       "text.author",
+			"text_block_author": "text_block.author",
+			"text_block_id":     "text_block.id",
+		},
+		filter.ColumnAttrs(),
+	)
+}
+
+func TestCreateSQLInterval(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+		AttrMap: query.Attrs{
+			"doc.year": map[string]any{"interval": []any{1990.0, 2000.0}},
+		},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id FROM `susanne_liveattrs_entry` AS t1  "+
+			"WHERE (CAST(t1.doc_year AS DECIMAL(65, 10)) BETWEEN ? AND ?) AND t1.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"1990", "2000", "susanne"}, qc.whereValues)
+}
+
+func TestCreateSQLIntervalSingleValue(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+		AttrMap: query.Attrs{
+			"!doc.year": map[string]any{"interval": []any{1995.5, 1995.5}},
+		},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id FROM `susanne_liveattrs_entry` AS t1  "+
+			"WHERE (CAST(t1.doc_year AS DECIMAL(65, 10)) NOT BETWEEN ? AND ?) AND t1.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"1995.5", "1995.5", "susanne"}, qc.whereValues)
+}
+
+func TestCreateSQLMalformedInterval(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+		AttrMap: query.Attrs{
+			"doc.author": []any{"Doe"},
+			"doc.year":   map[string]any{"interval": []any{2000.0, 1990.0}},
+		},
+	}
+	_, err := filter.CreateSQL()
+	assert.ErrorContains(t, err, "doc_year")
+}
+
+func TestValidateIntervals(t *testing.T) {
+	assert.NoError(t, query.Attrs{
+		"doc.year":  map[string]any{"interval": []any{2000.0, 2000.0}},
+		"doc.genre": []any{"fiction"},
+	}.ValidateIntervals())
+	assert.Error(t, query.Attrs{
+		"doc.year": map[string]any{"interval": []any{2000.0, 1990.0}},
+	}.ValidateIntervals())
+	assert.Error(t, query.Attrs{
+		"doc.year": map[string]any{"interval": []any{"1990", 2000.0}},
+	}.ValidateIntervals())
+	assert.Error(t, query.Attrs{
+		"doc.year": map[string]any{"interval": []any{1990.0}},
+	}.ValidateIntervals())
+}
+
+func TestCreateSQLNegatedListing(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+		AttrMap:    query.Attrs{"!doc.author": []any{"Doe", "Smith", "Novak"}},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id FROM `susanne_liveattrs_entry` AS t1  "+
+			"WHERE (t1.doc_author NOT IN (?, ?, ?)) AND t1.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"Doe", "Smith", "Novak", "susanne"}, qc.whereValues)
+}
+
+func TestCreateSQLNegatedPattern(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+		AttrMap:    query.Attrs{"!doc.author": []any{"Do%", "Smith"}},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id FROM `susanne_liveattrs_entry` AS t1  "+
+			"WHERE (t1.doc_author NOT LIKE ? AND t1.doc_author != ?) AND t1.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"Do%", "Smith", "susanne"}, qc.whereValues)
+}
+
+func TestCreateSQLNegatedWithAlignedAndBibID(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{
+			Name:           "intercorp_v13_cs",
+			ParallelCorpus: "intercorp_v13",
+			BibIDAttr:      "doc.id",
+			BibLabelAttr:   "doc.title",
+		},
+		AttrMap:        query.Attrs{"!doc.id": []any{"d1", "d2"}},
+		SearchAttrs:    []string{"doc.title"},
+		AlignedCorpora: []string{"intercorp_v13_en"},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id, t1.doc_title, t1.doc_id "+
+			"FROM `intercorp_v13_liveattrs_entry` AS t1 "+
+			"JOIN `intercorp_v13_liveattrs_entry` AS t2 ON t1.item_id = t2.item_id "+
+			"WHERE (t1.doc_id NOT IN (?, ?)) AND t1.corpus_id = ?  AND t2.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"d1", "d2", "intercorp_v13_cs", "intercorp_v13_en"}, qc.whereValues)
+	assert.Equal(t, []string{"doc_id"}, qc.hiddenAttrs)
+}
+
+func TestJoinStrategy(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "intercorp_v13_cs", ParallelCorpus: "intercorp_v13"},
+	}
+	assert.Equal(t, JoinStrategyNone, filter.JoinStrategy())
+	filter.AlignedCorpora = []string{"intercorp_v13_en"}
+	assert.Equal(t, JoinStrategyItemID, filter.JoinStrategy())
+}
+
+func TestCreateSQLRejectsInvalidAttrNames(t *testing.T) {
+	for _, attr := range []string{
+		"!doc.author) OR (1=1",
+		"doc.author = doc.author OR doc.id",
+		"doc.`author`",
+		"doc.author;--",
+		"author",
+	} {
+		filter := &LAFilter{
+			CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+			AttrMap:    query.Attrs{attr: []any{"Doe"}},
+		}
+		_, err := filter.CreateSQL()
+		assert.ErrorIs(t, err, utils.ErrorUnknownAttr, attr)
+	}
+}
+
+func TestCreateSQLOrGroup(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+		AttrMap: query.Attrs{
+			"doc.author":     []any{"Doe"},
+			"doc.translator": []any{"Doe"},
+			"doc.genre":      []any{"fiction", "poetry"},
+		},
+		OrGroups: query.OrGroups{{"doc.author", "doc.translator"}},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id FROM `susanne_liveattrs_entry` AS t1  "+
+			"WHERE ((t1.doc_author = ?) OR (t1.doc_translator = ?)) AND "+
+			"(t1.doc_genre = ? OR t1.doc_genre = ?) AND t1.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"Doe", "Doe", "fiction", "poetry", "susanne"}, qc.whereValues)
+}
+
+func TestCreateSQLWithoutOrGroups(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+		AttrMap: query.Attrs{
+			"doc.translator": []any{"Doe"},
+			"doc.author":     []any{"Doe"},
+		},
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id FROM `susanne_liveattrs_entry` AS t1  "+
+			"WHERE (t1.doc_author = ?) AND (t1.doc_translator = ?) AND t1.corpus_id = ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"Doe", "Doe", "susanne"}, qc.whereValues)
+}
+
+func TestValidateOrGroups(t *testing.T) {
+	attrs := query.Attrs{
+		"doc.author":     []any{"Doe"},
+		"doc.translator": []any{"Doe"},
+	}
+	assert.NoError(t, query.OrGroups{{"doc.author", "doc.translator"}}.Validate(attrs))
+	assert.NoError(t, query.OrGroups(nil).Validate(attrs))
+	assert.Error(t, query.OrGroups{{"doc.author"}}.Validate(attrs))
+	assert.Error(t, query.OrGroups{{"doc.author", "doc.title"}}.Validate(attrs))
+	assert.Error(t, query.OrGroups{
+		{"doc.author", "doc.translator"}, {"doc.translator", "doc.author"}}.Validate(attrs))
+}
+
+func TestValidateConditions(t *testing.T) {
+	attrs := query.Attrs{
+		"doc.author": []any{"Doe"},
+		"doc.year":   map[string]any{"interval": []any{1990.0, 2000.0}},
+	}
+	assert.NoError(t, query.Payload{
+		Attrs: attrs, OrGroups: query.OrGroups{{"doc.author", "doc.year"}}}.ValidateConditions())
+	assert.Error(t, query.Payload{
+		Attrs: attrs, OrGroups: query.OrGroups{{"doc.author", "doc.title"}}}.ValidateConditions())
+	assert.Error(t, query.Payload{
+		Attrs: query.Attrs{"doc.year": map[string]any{"interval": []any{2000.0, 1990.0}}}}.ValidateConditions())
+}
+
+func TestCreateSQLWithLimit(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{Name: "susanne"},
+		AttrMap:    query.Attrs{"doc.author": []any{"Doe"}},
+		SQLLimit:   1000,
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id FROM `susanne_liveattrs_entry` AS t1  "+
+			"WHERE (t1.doc_author = ?) AND t1.corpus_id = ? LIMIT ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"Doe", "susanne"}, qc.whereValues)
+	assert.Equal(t, 1000, qc.limit)
+}
+
+func TestCreateCountSQL(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo:  &corpus.DBInfo{Name: "susanne", BibIDAttr: "doc.id"},
+		AttrMap:     query.Attrs{"doc.author": []any{"Doe"}},
+		SearchAttrs: []string{"doc.genre"},
+		SQLLimit:    1000,
+	}
+	sqlq, values, err := filter.CreateCountSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT SUM(tc.poscount) FROM (SELECT DISTINCT t1.poscount, t1.id, t1.doc_id "+
+			"FROM `susanne_liveattrs_entry` AS t1  WHERE (t1.doc_author = ?) AND t1.corpus_id = ?) AS tc",
+		sqlq,
+	)
+	assert.Equal(t, []string{"Doe", "susanne"}, values)
+	assert.Equal(t, []string{"doc.genre"}, filter.SearchAttrs)
+}
+
+func TestCreateSQLCustomEmptyValPlaceholder(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo:          &corpus.DBInfo{Name: "susanne"},
+		AttrMap:             query.Attrs{"doc.author": []any{"Doe", "N/A", "?"}},
+		EmptyValPlaceholder: "N/A",
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Doe", "", "?", "susanne"}, qc.whereValues)
+}
+
+func TestCreateSQLAlignedAutocomplete(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo: &corpus.DBInfo{
+			Name:           "intercorp_v13_cs",
+			ParallelCorpus: "intercorp_v13",
+			BibIDAttr:      "doc.id",
+		},
+		AttrMap: query.Attrs{
+			"doc.lang":                   []any{"cs"},
+			"intercorp_v13_en:doc.title": "%Hobbit%",
+		},
+		SearchAttrs:      []string{"doc.title", "intercorp_v13_en:doc.title"},
+		AlignedCorpora:   []string{"intercorp_v13_en"},
+		AutocompleteAttr: "intercorp_v13_en:doc.title",
+	}
+	qc, err := filter.CreateSQL()
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"SELECT DISTINCT t1.poscount, t1.id, t1.doc_title, t1.doc_id, t2.doc_title AS t2_doc_title "+
+			"FROM `intercorp_v13_liveattrs_entry` AS t1 "+
+			"JOIN `intercorp_v13_liveattrs_entry` AS t2 ON t1.item_id = t2.item_id "+
+			"WHERE (t1.doc_lang = ?) AND t1.corpus_id = ?  AND t2.corpus_id = ?  AND t2.doc_title LIKE ?",
+		qc.sqlTemplate,
+	)
+	assert.Equal(t, []string{"cs", "intercorp_v13_cs", "intercorp_v13_en", "%Hobbit%"}, qc.whereValues)
+	assert.Equal(
+		t,
+		map[string]string{
+			"doc_title":    "doc.title",
+			"t2_doc_title": "intercorp_v13_en:doc.title",
+			"doc_id":       "doc.id",
+		},
+		filter.ColumnAttrs(),
+	)
+
+	// the aligned column is not selected when counting positions
+	sql, values, err := filter.CreateCountSQL()
+	assert.NoError(t, err)
+	assert.NotContains(t, sql, "AS t2_doc_title")
+	assert.Contains(t, sql, "AND t2.doc_title LIKE ?")
+	assert.Equal(t, []string{"cs", "intercorp_v13_cs", "intercorp_v13_en", "%Hobbit%"}, values)
+}
+
+func TestCreateSQLAlignedAutocompleteUnknownCorpus(t *testing.T) {
+	filter := &LAFilter{
+		CorpusInfo:       &corpus.DBInfo{Name: "intercorp_v13_cs", ParallelCorpus: "intercorp_v13"},
+		AttrMap:          query.Attrs{"intercorp_v13_de:doc.title": "%Hobbit%"},
+		SearchAttrs:      []string{"intercorp_v13_de:doc.title"},
+		AlignedCorpora:   []string{"intercorp_v13_en"},
+		AutocompleteAttr: "intercorp_v13_de:doc.title",
+	}
+	_, err := filter.CreateSQL()
+	assert.ErrorIs(t, err, utils.ErrorUnknownAttr)
+}
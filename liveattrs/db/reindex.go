@@ -0,0 +1,170 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"frodo/corpus"
+	"frodo/db/tables"
+	"frodo/liveattrs/utils"
+	"slices"
+)
+
+const (
+	// reindexSuffix is appended to names of indexes created by
+	// the reindexing job. It differs from the `_autoindex` suffix
+	// so UpdateIndexes never drops the indexes.
+	reindexSuffix = "_idx"
+)
+
+// ReindexResult describes state of a reindexing job
+type ReindexResult struct {
+
+	// Columns are all the columns which should be indexed
+	Columns []string `json:"columns"`
+
+	// CreatedIndexes are names of the indexes created so far
+	CreatedIndexes []string `json:"createdIndexes"`
+
+	// SkippedColumns are columns which already have an index
+	SkippedColumns []string `json:"skippedColumns"`
+
+	// NumProcessed is the number of already processed columns
+	NumProcessed int `json:"numProcessed"`
+}
+
+// MostUsedColumns returns liveattrs columns most used in queries
+// as recorded by StructAttrUsage. The selection is shared by
+// UpdateIndexes and the reindexing job.
+func MostUsedColumns(ctx context.Context, laDB *sql.DB, corpusID string, maxColumns int) ([]string, error) {
+	rows, err := laDB.QueryContext(
+		ctx,
+		"SELECT structattr_name "+
+			"FROM `usage` "+
+			"WHERE corpus_id = ? AND num_used > 0 ORDER BY num_used DESC LIMIT ?",
+		corpusID, maxColumns,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get most used columns: %w", err)
+	}
+	defer rows.Close()
+	ans := make([]string, 0, maxColumns)
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, fmt.Errorf("failed to get most used columns: %w", err)
+		}
+		ans = append(ans, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get most used columns: %w", err)
+	}
+	return ans, nil
+}
+
+// loadColumnNames returns names of columns listed in information_schema
+// for the table. In case leadingIndexCols is true, only columns
+// which are first columns of some index are returned.
+func loadColumnNames(ctx context.Context, laDB *sql.DB, table string, leadingIndexCols bool) ([]string, error) {
+	sqlq := "SELECT COLUMN_NAME FROM information_schema.columns " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?"
+	if leadingIndexCols {
+		sqlq = "SELECT COLUMN_NAME FROM information_schema.statistics " +
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND SEQ_IN_INDEX = 1"
+	}
+	rows, err := laDB.QueryContext(ctx, sqlq, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ans := make([]string, 0, 30)
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, err
+		}
+		ans = append(ans, col)
+	}
+	return ans, rows.Err()
+}
+
+// Reindex creates missing indexes on the `*_liveattrs_entry` table
+// of a corpus. Columns can be specified either as structural
+// attributes (`doc.title`) or as table columns (`doc_title`).
+// Columns already having an index are skipped. Indexes are created
+// one by one without locking the table for reading and writing
+// so the function can be used with a running service.
+// The onProgress callback (if not nil) is called after each
+// processed column.
+func Reindex(
+	ctx context.Context,
+	laDB *sql.DB,
+	corpusInfo *corpus.DBInfo,
+	columns []string,
+	onProgress func(ReindexResult),
+) (ReindexResult, error) {
+	table := tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry)
+	ans := ReindexResult{
+		Columns:        make([]string, 0, len(columns)),
+		CreatedIndexes: []string{},
+		SkippedColumns: []string{},
+	}
+	for _, c := range columns {
+		col := utils.ImportKey(c)
+		if !slices.Contains(ans.Columns, col) {
+			ans.Columns = append(ans.Columns, col)
+		}
+	}
+	tableCols, err := loadColumnNames(ctx, laDB, table, false)
+	if err != nil {
+		return ans, fmt.Errorf("failed to load columns of %s: %w", table, err)
+	}
+	for _, col := range ans.Columns {
+		if !slices.Contains(tableCols, col) {
+			return ans, fmt.Errorf("column %s not found in %s", col, table)
+		}
+	}
+	indexedCols, err := loadColumnNames(ctx, laDB, table, true)
+	if err != nil {
+		return ans, fmt.Errorf("failed to load indexes of %s: %w", table, err)
+	}
+	// for grouped (parallel) corpora, the corpus_id is always
+	// part of the query so it makes sense to include it in the index
+	sqlTemplate := "ALTER TABLE `%s` ADD INDEX `%s` (`%s`), ALGORITHM=INPLACE, LOCK=NONE"
+	if corpusInfo.GroupedName() != corpusInfo.Name {
+		sqlTemplate = "ALTER TABLE `%s` ADD INDEX `%s` (`%s`, `corpus_id`), ALGORITHM=INPLACE, LOCK=NONE"
+	}
+	for _, col := range ans.Columns {
+		if slices.Contains(indexedCols, col) {
+			ans.SkippedColumns = append(ans.SkippedColumns, col)
+
+		} else {
+			idxName := col + reindexSuffix
+			if _, err := laDB.ExecContext(ctx, fmt.Sprintf(sqlTemplate, table, idxName, col)); err != nil {
+				return ans, fmt.Errorf("failed to create index for %s: %w", col, err)
+			}
+			ans.CreatedIndexes = append(ans.CreatedIndexes, idxName)
+		}
+		ans.NumProcessed++
+		if onProgress != nil {
+			onProgress(ans)
+		}
+	}
+	return ans, nil
+}
@@ -20,10 +20,12 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"frodo/corpus"
+	"frodo/db/tables"
 	"frodo/liveattrs/request/query"
 	"frodo/liveattrs/utils"
 	"strings"
@@ -140,46 +142,31 @@ func (res *updIdxResult) MarshalJSON() ([]byte, error) {
 // --
 
 func UpdateIndexes(laDB *sql.DB, corpusInfo *corpus.DBInfo, maxColumns int) updIdxResult {
-	// get most used columns
-	rows, err := laDB.Query(
-		"SELECT structattr_name "+
-			"FROM `usage` "+
-			"WHERE corpus_id = ? AND num_used > 0 ORDER BY num_used DESC LIMIT ?",
-		corpusInfo.Name, maxColumns,
-	)
-	if err != nil && err != sql.ErrNoRows {
+	columns, err := MostUsedColumns(context.Background(), laDB, corpusInfo.Name, maxColumns)
+	if err != nil {
 		return updIdxResult{Error: err}
 	}
-	defer rows.Close()
-	columns := make([]string, 0, maxColumns)
-	for rows.Next() {
-		var structattrName string
-		if err := rows.Scan(&structattrName); err != nil {
-			return updIdxResult{Error: err}
-		}
-		columns = append(columns, structattrName)
-	}
 
 	// create indexes if necessary with `_autoindex` appendix
 	var sqlTemplate string
 	if corpusInfo.GroupedName() == corpusInfo.Name {
-		sqlTemplate = "CREATE INDEX IF NOT EXISTS `%s` ON `%s_liveattrs_entry` (`%s`)"
+		sqlTemplate = "CREATE INDEX IF NOT EXISTS `%s` ON `%s` (`%s`)"
 	} else {
-		sqlTemplate = "CREATE INDEX IF NOT EXISTS `%s` ON `%s_liveattrs_entry` (`%s`, `corpus_id`)"
+		sqlTemplate = "CREATE INDEX IF NOT EXISTS `%s` ON `%s` (`%s`, `corpus_id`)"
 	}
 	usedIndexes := make([]any, len(columns))
-	context, err := laDB.Begin()
+	tx, err := laDB.Begin()
 	if err != nil {
 		return updIdxResult{Error: err}
 	}
 	for i, column := range columns {
 		usedIndexes[i] = fmt.Sprintf("%s_autoindex", column)
-		_, err := context.Exec(fmt.Sprintf(sqlTemplate, usedIndexes[i], corpusInfo.GroupedName(), column))
+		_, err := tx.Exec(fmt.Sprintf(sqlTemplate, usedIndexes[i], tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry), column))
 		if err != nil {
 			return updIdxResult{Error: err}
 		}
 	}
-	context.Commit()
+	tx.Commit()
 
 	// get remaining unused indexes with `_autoindex` appendix
 	valuesPlaceholders := make([]string, len(usedIndexes))
@@ -190,8 +177,8 @@ func UpdateIndexes(laDB *sql.DB, corpusInfo *corpus.DBInfo, maxColumns int) updI
 		"SELECT INDEX_NAME FROM information_schema.statistics where TABLE_NAME = ? AND INDEX_NAME LIKE '%%_autoindex' AND INDEX_NAME NOT IN (%s)",
 		strings.Join(valuesPlaceholders, ", "),
 	)
-	values := append([]any{fmt.Sprintf("`%s_liveattrs_entry`", corpusInfo.GroupedName())}, usedIndexes...)
-	rows, err = laDB.Query(sqlTemplate, values...)
+	values := append([]any{fmt.Sprintf("`%s`", tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry))}, usedIndexes...)
+	rows, err := laDB.Query(sqlTemplate, values...)
 	if err != nil && err != sql.ErrNoRows {
 		return updIdxResult{Error: err}
 	}
@@ -206,18 +193,18 @@ func UpdateIndexes(laDB *sql.DB, corpusInfo *corpus.DBInfo, maxColumns int) updI
 	}
 
 	// drop unused indexes
-	sqlTemplate = "DROP INDEX %s ON `%s_liveattrs_entry`"
-	context, err = laDB.Begin()
+	sqlTemplate = "DROP INDEX %s ON `%s`"
+	tx, err = laDB.Begin()
 	if err != nil {
 		return updIdxResult{Error: err}
 	}
 	for _, index := range unusedIndexes {
-		_, err := context.Exec(fmt.Sprintf(sqlTemplate, index, corpusInfo.GroupedName()))
+		_, err := tx.Exec(fmt.Sprintf(sqlTemplate, index, tables.Name(corpusInfo.GroupedName(), tables.LiveAttrsEntry)))
 		if err != nil {
 			return updIdxResult{Error: err}
 		}
 	}
-	context.Commit()
+	tx.Commit()
 
 	ans := updIdxResult{
 		UsedIndexes:    make([]string, len(usedIndexes)),
@@ -0,0 +1,141 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package laconf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"frodo/liveattrs/utils"
+	"os"
+	"path/filepath"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	vtedb "github.com/czcorpus/vert-tagextract/v3/db"
+)
+
+// NamedBibView is a bibliography view identified by its name.
+// A corpus may provide alternative bibliography groupings
+// (e.g. by edition vs. by author) - each of them is represented
+// by a separate named view.
+// note: when used within PatchArgs, the IDAttr uses dot notation
+// (e.g. "doc.id") and Cols are generated automatically.
+type NamedBibView struct {
+	Name string `json:"name"`
+	vtedb.BibViewConf
+}
+
+// createBibViews creates bib views based on views specified in
+// PatchArgs. All the views share the same columns.
+func createBibViews(args []NamedBibView, cols []string) ([]NamedBibView, error) {
+	ans := make([]NamedBibView, 0, len(args))
+	names := make(map[string]bool)
+	for i, bv := range args {
+		if bv.Name == "" {
+			return nil, fmt.Errorf("%w: missing name of bib view %d", ErrorInvalidBibView, i)
+		}
+		if names[bv.Name] {
+			return nil, fmt.Errorf("%w: duplicate bib view name %s", ErrorInvalidBibView, bv.Name)
+		}
+		if bv.BibViewConf.IDAttr == "" {
+			return nil, fmt.Errorf("%w: missing idAttr of bib view %s", ErrorInvalidBibView, bv.Name)
+		}
+		names[bv.Name] = true
+		viewCols := make([]string, len(cols))
+		copy(viewCols, cols)
+		ans = append(ans, NamedBibView{
+			Name: bv.Name,
+			BibViewConf: vtedb.BibViewConf{
+				IDAttr: utils.ImportKey(bv.BibViewConf.IDAttr),
+				Cols:   viewCols,
+			},
+		})
+	}
+	return ans, nil
+}
+
+func (lcache *LiveAttrsBuildConfProvider) bibViewsPath(corpusID string) string {
+	return filepath.Join(lcache.confDirPath, corpusID+".bibviews.json")
+}
+
+// backupBibViews keeps the current bib views file of a corpus as the backup
+// file so it can be put back in by Restore along with the configuration
+// it belongs to. With move set to true, the current file is removed.
+// In case there is no current file, a possible previous backup is removed
+// so it cannot be restored along with a wrong configuration.
+func (lcache *LiveAttrsBuildConfProvider) backupBibViews(corpusID string, move bool) error {
+	viewsPath := lcache.bibViewsPath(corpusID)
+	bakPath := viewsPath + backupFileSuffix
+	isFile, err := fs.IsFile(viewsPath)
+	if err != nil {
+		return err
+	}
+	if !isFile {
+		if err := os.Remove(bakPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if move {
+		return os.Rename(viewsPath, bakPath)
+	}
+	rawData, err := os.ReadFile(viewsPath)
+	if err != nil {
+		return err
+	}
+	return lcache.writeConfAtomically(bakPath, rawData)
+}
+
+// SaveBibViews stores all the bib views of a corpus. As vert-tagextract
+// configuration supports only a single bib view, the views are stored
+// in a separate file (corpname.bibviews.json). Saving an empty list
+// removes the file. The previous version of the file is backed up
+// by Save which is expected to be called before SaveBibViews.
+func (lcache *LiveAttrsBuildConfProvider) SaveBibViews(corpusID string, views []NamedBibView) error {
+	viewsPath := lcache.bibViewsPath(corpusID)
+	if len(views) == 0 {
+		if err := os.Remove(viewsPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to save bib views: %w", err)
+		}
+		return nil
+	}
+	rawData, err := json.MarshalIndent(views, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save bib views: %w", err)
+	}
+	if err := lcache.writeConfAtomically(viewsPath, rawData); err != nil {
+		return fmt.Errorf("failed to save bib views: %w", err)
+	}
+	return nil
+}
+
+// GetBibViews returns all the stored bib views of a corpus.
+// In case there are no stored views, an empty list is returned.
+func (lcache *LiveAttrsBuildConfProvider) GetBibViews(corpusID string) ([]NamedBibView, error) {
+	rawData, err := os.ReadFile(lcache.bibViewsPath(corpusID))
+	if errors.Is(err, os.ErrNotExist) {
+		return []NamedBibView{}, nil
+
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load bib views: %w", err)
+	}
+	var ans []NamedBibView
+	if err := json.Unmarshal(rawData, &ans); err != nil {
+		return nil, fmt.Errorf("failed to load bib views: %w", err)
+	}
+	return ans, nil
+}
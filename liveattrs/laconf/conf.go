@@ -17,9 +17,12 @@
 package laconf
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 
 	vteconf "github.com/czcorpus/vert-tagextract/v3/cnf"
+	"gopkg.in/yaml.v3"
 )
 
 func GetSubcorpAttrs(vteConf *vteconf.VTEConf) []string {
@@ -35,3 +38,45 @@ func GetSubcorpAttrs(vteConf *vteconf.VTEConf) []string {
 func LoadConf(path string) (*vteconf.VTEConf, error) {
 	return vteconf.LoadConf(path)
 }
+
+// LoadYAMLConf loads a YAML version of a vte configuration.
+// The YAML keys are expected to match the JSON ones
+// (i.e. vteconf JSON struct tags are used).
+func LoadYAMLConf(path string) (*vteconf.VTEConf, error) {
+	rawData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load YAML vte conf: %w", err)
+	}
+	var tmp any
+	if err := yaml.Unmarshal(rawData, &tmp); err != nil {
+		return nil, fmt.Errorf("failed to load YAML vte conf: %w", err)
+	}
+	jsonData, err := json.Marshal(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load YAML vte conf: %w", err)
+	}
+	var ans vteconf.VTEConf
+	if err := json.Unmarshal(jsonData, &ans); err != nil {
+		return nil, fmt.Errorf("failed to load YAML vte conf: %w", err)
+	}
+	return &ans, nil
+}
+
+// ConfToYAML exports a vte configuration to YAML. To keep
+// the keys consistent with the JSON format, the configuration
+// is converted via its JSON representation.
+func ConfToYAML(conf *vteconf.VTEConf) ([]byte, error) {
+	jsonData, err := json.Marshal(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export vte conf to YAML: %w", err)
+	}
+	var tmp any
+	if err := json.Unmarshal(jsonData, &tmp); err != nil {
+		return nil, fmt.Errorf("failed to export vte conf to YAML: %w", err)
+	}
+	ans, err := yaml.Marshal(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export vte conf to YAML: %w", err)
+	}
+	return ans, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package laconf
+
+import (
+	"reflect"
+	"sort"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v3/cnf"
+)
+
+// StringChange describes a change of a single string value
+type StringChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// NgramsChange describes a change of n-gram settings
+type NgramsChange struct {
+	Old vteconf.NgramConf `json:"old"`
+	New vteconf.NgramConf `json:"new"`
+}
+
+// ConfDiff is a structural difference between two liveattrs
+// configurations. Structural attributes are in the dot notation
+// (e.g. "doc.author").
+type ConfDiff struct {
+	HasChanges         bool          `json:"hasChanges"`
+	AddedStructures    []string      `json:"addedStructures"`
+	RemovedStructures  []string      `json:"removedStructures"`
+	AddedStructAttrs   []string      `json:"addedStructAttrs"`
+	RemovedStructAttrs []string      `json:"removedStructAttrs"`
+	AtomStructure      *StringChange `json:"atomStructure,omitempty"`
+	Ngrams             *NgramsChange `json:"ngrams,omitempty"`
+}
+
+func structAttrSet(conf *vteconf.VTEConf) map[string]bool {
+	ans := make(map[string]bool)
+	for _, v := range GetSubcorpAttrs(conf) {
+		ans[v] = true
+	}
+	return ans
+}
+
+// missingKeys returns sorted keys of `a` not present in `b`
+func missingKeys[T any](a, b map[string]T) []string {
+	ans := make([]string, 0, len(a))
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			ans = append(ans, k)
+		}
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// Diff compares the current configuration with a candidate one.
+// The current configuration may be nil (= no configuration exists yet).
+func Diff(curr, candidate *vteconf.VTEConf) ConfDiff {
+	if curr == nil {
+		curr = &vteconf.VTEConf{}
+	}
+	ans := ConfDiff{
+		AddedStructures:    missingKeys(candidate.Structures, curr.Structures),
+		RemovedStructures:  missingKeys(curr.Structures, candidate.Structures),
+		AddedStructAttrs:   missingKeys(structAttrSet(candidate), structAttrSet(curr)),
+		RemovedStructAttrs: missingKeys(structAttrSet(curr), structAttrSet(candidate)),
+	}
+	if curr.AtomStructure != candidate.AtomStructure {
+		ans.AtomStructure = &StringChange{Old: curr.AtomStructure, New: candidate.AtomStructure}
+	}
+	if !reflect.DeepEqual(curr.Ngrams, candidate.Ngrams) {
+		ans.Ngrams = &NgramsChange{Old: curr.Ngrams, New: candidate.Ngrams}
+	}
+	ans.HasChanges = len(ans.AddedStructures) > 0 || len(ans.RemovedStructures) > 0 ||
+		len(ans.AddedStructAttrs) > 0 || len(ans.RemovedStructAttrs) > 0 ||
+		ans.AtomStructure != nil || ans.Ngrams != nil
+	return ans
+}
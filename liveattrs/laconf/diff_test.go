@@ -0,0 +1,45 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package laconf
+
+import (
+	"testing"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v3/cnf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	curr := vteconf.VTEConf{
+		AtomStructure: "doc",
+		Structures:    map[string][]string{"doc": {"id", "title"}, "p": {"id"}},
+	}
+	candidate := vteconf.VTEConf{
+		AtomStructure: "text",
+		Structures:    map[string][]string{"doc": {"id", "author"}, "text": {"id"}},
+	}
+	ans := Diff(&curr, &candidate)
+	assert.True(t, ans.HasChanges)
+	assert.Equal(t, []string{"text"}, ans.AddedStructures)
+	assert.Equal(t, []string{"p"}, ans.RemovedStructures)
+	assert.Equal(t, []string{"doc.author", "text.id"}, ans.AddedStructAttrs)
+	assert.Equal(t, []string{"doc.title", "p.id"}, ans.RemovedStructAttrs)
+	assert.Equal(t, &StringChange{Old: "doc", New: "text"}, ans.AtomStructure)
+	assert.Nil(t, ans.Ngrams)
+
+	assert.False(t, Diff(&curr, &curr).HasChanges)
+}
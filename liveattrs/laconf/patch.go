@@ -52,6 +52,7 @@ type PatchArgs struct {
 	AtomStructure           *string               `json:"atomStructure"`
 	SelfJoin                *vteDb.SelfJoinConf   `json:"selfJoin"`
 	BibView                 *vteDb.BibViewConf    `json:"bibView"`
+	BibViews                []NamedBibView        `json:"bibViews"`
 	Ngrams                  *vteCnf.NgramConf     `json:"ngrams"`
 	TagsetAttr              *string               `json:"tagsetAttr"`
 	TagsetName              *corp.SupportedTagset `json:"tagsetName"`
@@ -124,3 +125,47 @@ func (la *PatchArgs) GetTagsetName() corp.SupportedTagset {
 	}
 	return *la.TagsetName
 }
+
+// WithOverrides returns a copy of the PatchArgs where all the non-nil
+// items of `other` replace respective items of the original.
+// In case `other` is nil, a plain copy is returned.
+func (la *PatchArgs) WithOverrides(other *PatchArgs) *PatchArgs {
+	ans := *la
+	if other == nil {
+		return &ans
+	}
+	if other.VerticalFiles != nil {
+		ans.VerticalFiles = other.VerticalFiles
+	}
+	if other.DateAttr != nil {
+		ans.DateAttr = other.DateAttr
+	}
+	if other.RemoveEntriesBeforeDate != nil {
+		ans.RemoveEntriesBeforeDate = other.RemoveEntriesBeforeDate
+	}
+	if other.MaxNumErrors != nil {
+		ans.MaxNumErrors = other.MaxNumErrors
+	}
+	if other.AtomStructure != nil {
+		ans.AtomStructure = other.AtomStructure
+	}
+	if other.SelfJoin != nil {
+		ans.SelfJoin = other.SelfJoin
+	}
+	if other.BibView != nil {
+		ans.BibView = other.BibView
+	}
+	if other.BibViews != nil {
+		ans.BibViews = other.BibViews
+	}
+	if other.Ngrams != nil {
+		ans.Ngrams = other.Ngrams
+	}
+	if other.TagsetAttr != nil {
+		ans.TagsetAttr = other.TagsetAttr
+	}
+	if other.TagsetName != nil {
+		ans.TagsetName = other.TagsetName
+	}
+	return &ans
+}
@@ -26,7 +26,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
-	"strings"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 
@@ -36,12 +36,77 @@ import (
 	vtedb "github.com/czcorpus/vert-tagextract/v3/db"
 )
 
+const (
+	backupFileSuffix = ".bak"
+)
+
 var (
 	ErrorNoSuchConfig = errors.New("no such configuration (corpus not installed)")
+	ErrorNoSuchBackup = errors.New("no backup of the configuration found")
+
+	// ErrorMissingAtomStructure is returned by Create in case no atom
+	// structure is specified and the corpus has no structures to infer
+	// the value from
+	ErrorMissingAtomStructure = errors.New(
+		"no atomStructure specified and the value cannot be inferred as there are no involved structures")
+
+	// ErrorAmbiguousAtomStructure is returned by Create in case no atom
+	// structure is specified and the value cannot be inferred
+	ErrorAmbiguousAtomStructure = errors.New(
+		"no atomStructure specified and the value cannot be inferred due to multiple involved structures")
+
+	// ErrorInvalidSelfJoin is returned by Create in case self-join
+	// columns are not in the struct.attr format
+	ErrorInvalidSelfJoin = errors.New("invalid mergeAttr")
+
+	// ErrorInvalidBibView is returned by Create in case a bib view
+	// definition is incomplete or ambiguous
+	ErrorInvalidBibView = errors.New("invalid bib view")
+
+	// ErrorInvalidNgramSize is returned in case n-gram columns are
+	// specified via PatchArgs without a positive n-gram size
+	ErrorInvalidNgramSize = errors.New("invalid n-gram size")
+
+	// ErrorMissingNgramColumns is returned in case an n-gram size
+	// is configured without columns to extract n-grams from
+	ErrorMissingNgramColumns = errors.New("missing columns to extract n-grams from")
+
+	// ErrorUnsupportedBackend is returned by Create in case the liveattrs
+	// database is not supported (which is a service configuration problem)
+	ErrorUnsupportedBackend = errors.New("Frodo service does not provide support for SQLite backend")
 )
 
+// IsInvalidArgsError tests whether an error returned by Create
+// (or CreateWithBibViews) is caused by invalid arguments (i.e. it is
+// a client's problem) as opposed to a server-side failure.
+func IsInvalidArgsError(err error) bool {
+	var verrs ValidationErrors
+	return errors.Is(err, ErrorMissingAtomStructure) ||
+		errors.Is(err, ErrorAmbiguousAtomStructure) ||
+		errors.Is(err, ErrorInvalidSelfJoin) ||
+		errors.Is(err, ErrorInvalidBibView) ||
+		errors.Is(err, ErrorInvalidNgramSize) ||
+		errors.Is(err, ErrorMissingNgramColumns) ||
+		errors.As(err, &verrs)
+}
+
+// addStructAttr adds a structural attribute to structures
+// in case it is not already present
+func addStructAttr(structures map[string][]string, stru, attr string) {
+	tmp, ok := structures[stru]
+	if ok {
+		if !collections.SliceContains(tmp, attr) {
+			structures[stru] = append(structures[stru], attr)
+		}
+
+	} else {
+		structures[stru] = []string{attr}
+	}
+}
+
 // Create creates a new live attributes extraction configuration based
-// on provided args.
+// on provided args. Errors caused by invalid args can be detected
+// via IsInvalidArgsError.
 // note: bibIdAttr and mergeAttrs use dot notation (e.g. "doc.author")
 func Create(
 	conf *liveattrs.Conf,
@@ -49,6 +114,22 @@ func Create(
 	corpusDBInfo *corpus.DBInfo,
 	jsonArgs *PatchArgs,
 ) (*vteconf.VTEConf, error) {
+	ans, _, err := CreateWithBibViews(conf, corpusInfo, corpusDBInfo, jsonArgs)
+	return ans, err
+}
+
+// CreateWithBibViews is a variant of Create which also returns
+// all the bib views defined via `jsonArgs.BibViews`. As vert-tagextract
+// supports only a single bib view, the views are expected to be stored
+// separately (see LiveAttrsBuildConfProvider.SaveBibViews).
+// In case `jsonArgs.BibView` is not specified, the first of `BibViews`
+// becomes the bib view of the returned configuration.
+func CreateWithBibViews(
+	conf *liveattrs.Conf,
+	corpusInfo *corpus.Info,
+	corpusDBInfo *corpus.DBInfo,
+	jsonArgs *PatchArgs,
+) (*vteconf.VTEConf, []NamedBibView, error) {
 	maxNumErr := conf.VertMaxNumErrors
 	if jsonArgs.MaxNumErrors != nil {
 		maxNumErr = *jsonArgs.MaxNumErrors
@@ -65,25 +146,30 @@ func Create(
 	}
 
 	newConf.Structures = corpusInfo.RegistryConf.SubcorpAttrs
+	bibViewCols := make([]string, 0, len(newConf.Structures)*3)
+	for stru, attrs := range corpusInfo.RegistryConf.SubcorpAttrs {
+		for _, attr := range attrs {
+			bibViewCols = append(bibViewCols, fmt.Sprintf("%s_%s", stru, attr))
+		}
+	}
+	bibViews, err := createBibViews(jsonArgs.BibViews, bibViewCols)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, bv := range jsonArgs.BibViews {
+		bibIdStruct, bibIdAttr := bv.BibViewConf.IDAttrElements()
+		addStructAttr(newConf.Structures, bibIdStruct, bibIdAttr)
+	}
 	if jsonArgs.BibView != nil {
-		bibView := vtedb.BibViewConf{}
-		bibView.IDAttr = utils.ImportKey(jsonArgs.BibView.IDAttr)
-		for stru, attrs := range corpusInfo.RegistryConf.SubcorpAttrs {
-			for _, attr := range attrs {
-				bibView.Cols = append(bibView.Cols, fmt.Sprintf("%s_%s", stru, attr))
-			}
+		newConf.BibView = vtedb.BibViewConf{
+			IDAttr: utils.ImportKey(jsonArgs.BibView.IDAttr),
+			Cols:   bibViewCols,
 		}
-		newConf.BibView = bibView
 		bibIdStruct, bibIdAttr := jsonArgs.BibView.IDAttrElements()
-		tmp, ok := newConf.Structures[bibIdStruct]
-		if ok {
-			if !collections.SliceContains(tmp, bibIdAttr) {
-				newConf.Structures[bibIdStruct] = append(newConf.Structures[bibIdStruct], bibIdAttr)
-			}
+		addStructAttr(newConf.Structures, bibIdStruct, bibIdAttr)
 
-		} else {
-			newConf.Structures[bibIdStruct] = []string{bibIdAttr}
-		}
+	} else if len(bibViews) > 0 {
+		newConf.BibView = bibViews[0].BibViewConf
 	}
 	if jsonArgs.AtomStructure == nil {
 		if len(newConf.Structures) == 1 {
@@ -93,41 +179,26 @@ func Create(
 			}
 			log.Info().Msgf("no atomStructure, inferred value: %s", newConf.AtomStructure)
 
+		} else if len(newConf.Structures) == 0 {
+			return nil, nil, ErrorMissingAtomStructure
+
 		} else {
-			return nil, fmt.Errorf("no atomStructure specified and the value cannot be inferred due to multiple involved structures")
+			return nil, nil, ErrorAmbiguousAtomStructure
 		}
 
 	} else {
 		newConf.AtomStructure = jsonArgs.GetAtomStructure()
 	}
-	atomExists := false
-	for _, st := range corpusInfo.IndexedStructs {
-		if st == newConf.AtomStructure {
-			atomExists = true
-			break
-		}
-	}
-	if !atomExists {
-		return nil, fmt.Errorf("atom structure '%s' does not exist in corpus %s", newConf.AtomStructure, corpusInfo.ID)
-	}
 
 	if jsonArgs.SelfJoin != nil {
 		newConf.SelfJoin.ArgColumns = make([]string, len(jsonArgs.SelfJoin.ArgColumns))
 		for i, argCol := range jsonArgs.SelfJoin.ArgColumns {
-			tmp := strings.Split(argCol, "_")
-			if len(tmp) != 2 {
-				return nil, fmt.Errorf("invalid mergeAttr format (must be struct_attr): %s", argCol)
-			}
-			newConf.SelfJoin.ArgColumns[i] = tmp[0] + "_" + tmp[1]
-			_, ok := newConf.Structures[tmp[0]]
-			if ok {
-				if !collections.SliceContains(newConf.Structures[tmp[0]], tmp[1]) {
-					newConf.Structures[tmp[0]] = append(newConf.Structures[tmp[0]], tmp[1])
-				}
-
-			} else {
-				newConf.Structures[tmp[0]] = []string{tmp[1]}
+			stru, attr, err := splitStructAttr(argCol)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: %w", ErrorInvalidSelfJoin, err)
 			}
+			newConf.SelfJoin.ArgColumns[i] = stru + "_" + attr
+			addStructAttr(newConf.Structures, stru, attr)
 		}
 		newConf.SelfJoin.GeneratorFn = jsonArgs.SelfJoin.GeneratorFn
 	}
@@ -147,9 +218,12 @@ func Create(
 		}
 
 	} else {
-		return nil, fmt.Errorf("Frodo service does not provide support for SQLite backend")
+		return nil, nil, ErrorUnsupportedBackend
+	}
+	if verrs := validateConf(&newConf, corpusInfo.IndexedStructs); verrs != nil {
+		return nil, nil, verrs
 	}
-	return &newConf, nil
+	return &newConf, bibViews, nil
 }
 
 // LiveAttrsBuildConfProvider is a loader and a cache for
@@ -161,28 +235,96 @@ func Create(
 // So at least in theory - the stored vte config files should not
 // deprecate.
 type LiveAttrsBuildConfProvider struct {
-	confDirPath  string
-	globalDBConf *vtedb.Conf
-	data         map[string]*vteconf.VTEConf
+	confDirPath string
+	corpSetup   *corpus.CorporaSetup
+	data        map[string]*vteconf.VTEConf
+
+	// globalDBConf can be replaced while the service is running
+	// (see SetGlobalDBConf) so it must be accessed via globalDBConfLock
+	globalDBConf     *vtedb.Conf
+	globalDBConfLock sync.RWMutex
+
+	// versions contains a per-corpus counter incremented
+	// each time a respective config is saved or cleared.
+	// Please note that the counter lives only in memory
+	// so it starts from zero after each service restart.
+	versions     map[string]int
+	versionsLock sync.RWMutex
+
+	// confChangeHandlers are called each time a corpus configuration
+	// is saved, cleared, restored or removed from cache (e.g. to invalidate
+	// caches depending on the configuration). The handlers are injected
+	// to avoid import cycles.
+	confChangeHandlers []func(corpusID string)
+}
+
+// OnConfChange registers a function called each time
+// a corpus configuration changes (Save, Clear, Restore, Uncache).
+func (lcache *LiveAttrsBuildConfProvider) OnConfChange(fn func(corpusID string)) {
+	lcache.confChangeHandlers = append(lcache.confChangeHandlers, fn)
 }
 
+func (lcache *LiveAttrsBuildConfProvider) notifyConfChange(corpusID string) {
+	for _, fn := range lcache.confChangeHandlers {
+		fn(corpusID)
+	}
+}
+
+func (lcache *LiveAttrsBuildConfProvider) incVersion(corpusID string) {
+	lcache.versionsLock.Lock()
+	lcache.versions[corpusID]++
+	lcache.versionsLock.Unlock()
+}
+
+// Version returns the current version of a corpus configuration.
+// The value changes each time the configuration is saved or cleared
+// so clients can use it to find out whether they need to re-fetch
+// the configuration.
+func (lcache *LiveAttrsBuildConfProvider) Version(corpusID string) int {
+	lcache.versionsLock.RLock()
+	defer lcache.versionsLock.RUnlock()
+	return lcache.versions[corpusID]
+}
+
+// applyGlobalDBConf rewrites database access configuration
+// of the provided vte conf with the current global one
+// (in case the global one is a MySQL configuration)
+func (lcache *LiveAttrsBuildConfProvider) applyGlobalDBConf(conf *vteconf.VTEConf) {
+	lcache.globalDBConfLock.RLock()
+	defer lcache.globalDBConfLock.RUnlock()
+	if lcache.globalDBConf != nil && lcache.globalDBConf.Type == "mysql" {
+		conf.DB = *lcache.globalDBConf
+	}
+}
+
+// loadFromFile loads a configuration from its JSON file. In case
+// the JSON file does not exist, a hand-written YAML variant
+// (corpname.yaml) is tried. Please note that JSON remains
+// the canonical format - i.e. Save always writes JSON.
 func (lcache *LiveAttrsBuildConfProvider) loadFromFile(corpname string, storeToCache bool) (*vteconf.VTEConf, error) {
 	confPath := path.Join(lcache.confDirPath, corpname+".json")
 	isFile, err := fs.IsFile(confPath)
 	if err != nil {
 		return nil, err
 	}
+	loadFn := LoadConf
+	if !isFile {
+		confPath = path.Join(lcache.confDirPath, corpname+".yaml")
+		isFile, err = fs.IsFile(confPath)
+		if err != nil {
+			return nil, err
+		}
+		loadFn = LoadYAMLConf
+	}
 	if isFile {
-		v, err := LoadConf(confPath)
+		v, err := loadFn(confPath)
 		if err != nil {
 			return nil, err
 		}
 		if storeToCache {
 			lcache.data[corpname] = v
 		}
-		if lcache.globalDBConf.Type == "mysql" {
-			v.DB = *lcache.globalDBConf
-		}
+		lcache.applyGlobalDBConf(v)
 		return v, nil
 	}
 	return nil, ErrorNoSuchConfig
@@ -192,9 +334,12 @@ func (lcache *LiveAttrsBuildConfProvider) loadFromFile(corpname string, storeToC
 // file does not exist the method will not create it for you (as it
 // requires additional arguments to determine specific properties).
 // In case there is no other error but the configuration does not exist,
-// the method returns ErrorNoSuchConfig error
+// the method returns ErrorNoSuchConfig error.
+// Cached entries always receive the current global database configuration
+// so a change of credentials does not require a service restart.
 func (lcache *LiveAttrsBuildConfProvider) Get(corpname string) (*vteconf.VTEConf, error) {
 	if v, ok := lcache.data[corpname]; ok {
+		lcache.applyGlobalDBConf(v)
 		return v, nil
 	}
 	return lcache.loadFromFile(corpname, true)
@@ -223,50 +368,194 @@ func (lcache *LiveAttrsBuildConfProvider) GetUncachedWithoutPasswords(corpname s
 	return &ans, nil
 }
 
-// Save saves a provided configuration to a file for later use
+func (lcache *LiveAttrsBuildConfProvider) confPath(corpusID string) string {
+	return filepath.Join(lcache.confDirPath, corpusID+".json")
+}
+
+// backupConf keeps the current version of a configuration file
+// (if any) along with its bib views as the backup files
+func (lcache *LiveAttrsBuildConfProvider) backupConf(corpusID string) error {
+	confPath := lcache.confPath(corpusID)
+	isFile, err := fs.IsFile(confPath)
+	if err != nil {
+		return err
+	}
+	if !isFile {
+		return nil
+	}
+	if err := os.Rename(confPath, confPath+backupFileSuffix); err != nil {
+		return err
+	}
+	return lcache.backupBibViews(corpusID, true)
+}
+
+// swapWithBackup swaps a file with its backup. In case one of the two
+// files does not exist, the other one is just renamed.
+func swapWithBackup(filePath string) error {
+	bakPath := filePath + backupFileSuffix
+	isFile, err := fs.IsFile(filePath)
+	if err != nil {
+		return err
+	}
+	isBakFile, err := fs.IsFile(bakPath)
+	if err != nil {
+		return err
+	}
+	if isFile && isBakFile {
+		swapPath := filePath + ".swp"
+		if err := os.Rename(filePath, swapPath); err != nil {
+			return err
+		}
+		if err := os.Rename(bakPath, filePath); err != nil {
+			os.Rename(swapPath, filePath)
+			return err
+		}
+		return os.Rename(swapPath, bakPath)
+
+	} else if isFile {
+		return os.Rename(filePath, bakPath)
+
+	} else if isBakFile {
+		return os.Rename(bakPath, filePath)
+	}
+	return nil
+}
+
+// writeConfAtomically writes data to a temporary file first and then
+// replaces the target file so a failure during writing
+// cannot corrupt the existing configuration.
+func (lcache *LiveAttrsBuildConfProvider) writeConfAtomically(confPath string, rawData []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(confPath), filepath.Base(confPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(rawData)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if cErr := tmpFile.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpFile.Name(), 0777)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), confPath)
+}
+
+// GetAsYAML returns a configuration in YAML format (e.g. for hand-editing).
+// Passwords and similar stuff are removed.
+func (lcache *LiveAttrsBuildConfProvider) GetAsYAML(corpname string) ([]byte, error) {
+	conf, err := lcache.GetWithoutPasswords(corpname)
+	if err != nil {
+		return nil, err
+	}
+	return ConfToYAML(conf)
+}
+
+// Save saves a provided configuration to a file for later use.
+// The previous version of the file (if any) is kept as a backup
+// which can be put back in via Restore.
 func (lcache *LiveAttrsBuildConfProvider) Save(data *vteconf.VTEConf) error {
 	rawData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to save vte conf file: %w", err)
 	}
-	confPath := filepath.Join(lcache.confDirPath, data.Corpus+".json")
-	err = os.WriteFile(confPath, rawData, 0777)
+	confPath := lcache.confPath(data.Corpus)
+	prevData, err := os.ReadFile(confPath)
+	if err == nil {
+		err = lcache.writeConfAtomically(confPath+backupFileSuffix, prevData)
+		if err == nil {
+			err = lcache.backupBibViews(data.Corpus, false)
+		}
+
+	} else if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to backup vte conf file: %w", err)
+	}
+	err = lcache.writeConfAtomically(confPath, rawData)
 	if err != nil {
 		return fmt.Errorf("failed to save vte conf file: %w", err)
 	}
 	lcache.data[data.Corpus] = data
+	lcache.incVersion(data.Corpus)
+	lcache.notifyConfChange(data.Corpus)
 	if data.DB.Type == "mysql" {
-		data.DB = *lcache.globalDBConf
+		lcache.applyGlobalDBConf(data)
 	}
 	return nil
 }
 
+// SetGlobalDBConf replaces the global database configuration
+// applied to all the provided configurations (e.g. in case
+// of a credentials rotation). The change is picked up by
+// all subsequent calls of Get, including cached entries.
+func (lcache *LiveAttrsBuildConfProvider) SetGlobalDBConf(conf *vtedb.Conf) {
+	lcache.globalDBConfLock.Lock()
+	lcache.globalDBConf = conf
+	lcache.globalDBConfLock.Unlock()
+}
+
 // Uncache removes item corpusID from cache and returns true if the item
 // was present. Otherwise does nothing and returns false.
 func (lcache *LiveAttrsBuildConfProvider) Uncache(corpusID string) bool {
 	_, ok := lcache.data[corpusID]
 	delete(lcache.data, corpusID)
+	lcache.notifyConfChange(corpusID)
 	return ok
 }
 
-// Clear removes a configuration from memory and from filesystem
+// Clear removes a configuration from memory and from filesystem.
+// The removed file (along with the corpus bib views) is kept as a backup
+// which can be put back in via Restore.
 func (lcache *LiveAttrsBuildConfProvider) Clear(corpusID string) error {
 	delete(lcache.data, corpusID)
-	confPath := path.Join(lcache.confDirPath, corpusID+".json")
-	isFile, err := fs.IsFile(confPath)
+	lcache.incVersion(corpusID)
+	lcache.notifyConfChange(corpusID)
+	return lcache.backupConf(corpusID)
+}
+
+// Restore swaps the backup of a configuration (along with the corpus
+// bib views) with the current configuration file. In case there is no
+// current configuration, the backup is just put back in. Calling Restore
+// twice therefore returns to the original state. If there is no backup,
+// ErrorNoSuchBackup is returned.
+func (lcache *LiveAttrsBuildConfProvider) Restore(corpusID string) error {
+	confPath := lcache.confPath(corpusID)
+	isFile, err := fs.IsFile(confPath + backupFileSuffix)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to restore vte conf file: %w", err)
 	}
-	if isFile {
-		return os.Remove(confPath)
+	if !isFile {
+		return ErrorNoSuchBackup
 	}
+	if err := swapWithBackup(confPath); err != nil {
+		return fmt.Errorf("failed to restore vte conf file: %w", err)
+	}
+	if err := swapWithBackup(lcache.bibViewsPath(corpusID)); err != nil {
+		return fmt.Errorf("failed to restore bib views file: %w", err)
+	}
+	delete(lcache.data, corpusID)
+	lcache.incVersion(corpusID)
+	lcache.notifyConfChange(corpusID)
 	return nil
 }
 
-func NewLiveAttrsBuildConfProvider(confDirPath string, globalDBConf *vtedb.Conf) *LiveAttrsBuildConfProvider {
+func NewLiveAttrsBuildConfProvider(
+	confDirPath string,
+	globalDBConf *vtedb.Conf,
+	corpSetup *corpus.CorporaSetup,
+) *LiveAttrsBuildConfProvider {
 	return &LiveAttrsBuildConfProvider{
 		confDirPath:  confDirPath,
 		globalDBConf: globalDBConf,
+		corpSetup:    corpSetup,
 		data:         make(map[string]*vteconf.VTEConf),
+		versions:     make(map[string]int),
 	}
 }
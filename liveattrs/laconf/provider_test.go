@@ -0,0 +1,153 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package laconf
+
+import (
+	"fmt"
+	"frodo/liveattrs/cache"
+	"frodo/liveattrs/request/query"
+	"frodo/liveattrs/request/response"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v3/cnf"
+	vtedb "github.com/czcorpus/vert-tagextract/v3/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveKeepsBackupAndRestoreSwaps(t *testing.T) {
+	dir := t.TempDir()
+	provider := NewLiveAttrsBuildConfProvider(dir, nil, nil)
+	assert.NoError(t, provider.Save(&vteconf.VTEConf{Corpus: "susanne", AtomStructure: "doc"}))
+	assert.NoFileExists(t, filepath.Join(dir, "susanne.json.bak"))
+	assert.ErrorIs(t, provider.Restore("susanne"), ErrorNoSuchBackup)
+
+	assert.NoError(t, provider.Save(&vteconf.VTEConf{Corpus: "susanne", AtomStructure: "text"}))
+	assert.FileExists(t, filepath.Join(dir, "susanne.json.bak"))
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2) // no temporary files left
+
+	assert.NoError(t, provider.Restore("susanne"))
+	conf, err := provider.Get("susanne")
+	assert.NoError(t, err)
+	assert.Equal(t, "doc", conf.AtomStructure)
+
+	assert.NoError(t, provider.Restore("susanne"))
+	conf, err = provider.Get("susanne")
+	assert.NoError(t, err)
+	assert.Equal(t, "text", conf.AtomStructure)
+}
+
+func saveConfWithBibViews(t *testing.T, provider *LiveAttrsBuildConfProvider, atomStruct string, views ...string) {
+	assert.NoError(t, provider.Save(&vteconf.VTEConf{Corpus: "susanne", AtomStructure: atomStruct}))
+	bibViews := make([]NamedBibView, len(views))
+	for i, v := range views {
+		bibViews[i] = NamedBibView{Name: v, BibViewConf: vtedb.BibViewConf{IDAttr: "doc_id"}}
+	}
+	assert.NoError(t, provider.SaveBibViews("susanne", bibViews))
+}
+
+func bibViewNames(t *testing.T, provider *LiveAttrsBuildConfProvider) []string {
+	views, err := provider.GetBibViews("susanne")
+	assert.NoError(t, err)
+	ans := make([]string, len(views))
+	for i, v := range views {
+		ans[i] = v.Name
+	}
+	return ans
+}
+
+func TestRestoreSwapsBibViews(t *testing.T) {
+	provider := NewLiveAttrsBuildConfProvider(t.TempDir(), nil, nil)
+	saveConfWithBibViews(t, provider, "doc", "byAuthor")
+	saveConfWithBibViews(t, provider, "text", "byEdition")
+
+	assert.NoError(t, provider.Restore("susanne"))
+	assert.Equal(t, []string{"byAuthor"}, bibViewNames(t, provider))
+	assert.NoError(t, provider.Restore("susanne"))
+	assert.Equal(t, []string{"byEdition"}, bibViewNames(t, provider))
+}
+
+func TestRestoreRemovesBibViewsMissingInBackup(t *testing.T) {
+	provider := NewLiveAttrsBuildConfProvider(t.TempDir(), nil, nil)
+	saveConfWithBibViews(t, provider, "doc", "byAuthor")
+	saveConfWithBibViews(t, provider, "text")
+	saveConfWithBibViews(t, provider, "p", "byEdition")
+
+	assert.NoError(t, provider.Restore("susanne"))
+	assert.Empty(t, bibViewNames(t, provider))
+	assert.NoError(t, provider.Restore("susanne"))
+	assert.Equal(t, []string{"byEdition"}, bibViewNames(t, provider))
+}
+
+func TestClearKeepsBibViewsBackup(t *testing.T) {
+	dir := t.TempDir()
+	provider := NewLiveAttrsBuildConfProvider(dir, nil, nil)
+	saveConfWithBibViews(t, provider, "doc", "byAuthor")
+
+	assert.NoError(t, provider.Clear("susanne"))
+	assert.Empty(t, bibViewNames(t, provider))
+	assert.FileExists(t, filepath.Join(dir, "susanne.bibviews.json.bak"))
+
+	assert.NoError(t, provider.Restore("susanne"))
+	assert.Equal(t, []string{"byAuthor"}, bibViewNames(t, provider))
+	conf, err := provider.Get("susanne")
+	assert.NoError(t, err)
+	assert.Equal(t, "doc", conf.AtomStructure)
+}
+
+func TestSetGlobalDBConfAppliesToCachedConf(t *testing.T) {
+	dir := t.TempDir()
+	provider := NewLiveAttrsBuildConfProvider(
+		dir, &vtedb.Conf{Type: "mysql", User: "frodo", Password: "old"}, nil)
+	assert.NoError(t, provider.Save(&vteconf.VTEConf{
+		Corpus: "susanne", AtomStructure: "doc", DB: vtedb.Conf{Type: "mysql"}}))
+	conf, err := provider.Get("susanne")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", conf.DB.Password)
+
+	provider.SetGlobalDBConf(&vtedb.Conf{Type: "mysql", User: "frodo", Password: "new"})
+	conf, err = provider.Get("susanne")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", conf.DB.Password)
+}
+
+func TestSavePrunesEmptyQueryCache(t *testing.T) {
+	eqCache := cache.NewEmptyQueryCache(0, 0)
+	qry := query.Payload{Aligned: []string{"corp2"}}
+	eqCache.Set("corp1", qry, &response.QueryAns{AlignedCorpora: []string{"corp2"}})
+	eqCache.Set("corp3", query.Payload{}, &response.QueryAns{})
+
+	provider := NewLiveAttrsBuildConfProvider(t.TempDir(), nil, nil)
+	provider.OnConfChange(eqCache.Del)
+	assert.NoError(t, provider.Save(&vteconf.VTEConf{Corpus: "corp2", AtomStructure: "doc"}))
+	assert.Nil(t, eqCache.Get("corp1", qry))
+	assert.NotNil(t, eqCache.Get("corp3", query.Payload{}))
+}
+
+func TestIsInvalidArgsError(t *testing.T) {
+	_, err := createBibViews([]NamedBibView{{Name: "view1"}}, []string{"doc_title"})
+	assert.ErrorIs(t, err, ErrorInvalidBibView)
+	assert.True(t, IsInvalidArgsError(err))
+	assert.True(t, IsInvalidArgsError(ErrorAmbiguousAtomStructure))
+	assert.True(t, IsInvalidArgsError(fmt.Errorf("failed to create conf: %w", ErrorMissingNgramColumns)))
+	assert.True(t, IsInvalidArgsError(ValidationErrors{{Field: "atomStructure", Message: "not found"}}))
+	assert.False(t, IsInvalidArgsError(ErrorUnsupportedBackend))
+	assert.False(t, IsInvalidArgsError(os.ErrNotExist))
+}
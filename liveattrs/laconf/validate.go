@@ -0,0 +1,150 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package laconf
+
+import (
+	"fmt"
+	"frodo/corpus"
+	"strings"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v3/cnf"
+)
+
+// ValidationError describes a single problem found in a liveattrs
+// configuration. The Field refers to the JSON path of the problematic
+// configuration item.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (verr ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", verr.Field, verr.Message)
+}
+
+// ValidationErrors is a list of all the problems found
+// in a liveattrs configuration.
+type ValidationErrors []ValidationError
+
+func (verrs ValidationErrors) Error() string {
+	msgs := make([]string, len(verrs))
+	for i, verr := range verrs {
+		msgs[i] = verr.Error()
+	}
+	return "invalid liveattrs configuration: " + strings.Join(msgs, "; ")
+}
+
+// splitStructAttr splits a column name in the struct_attr format
+// (e.g. "doc_author") into the structure and the attribute
+func splitStructAttr(col string) (string, string, error) {
+	tmp := strings.Split(col, "_")
+	if len(tmp) != 2 || tmp[0] == "" || tmp[1] == "" {
+		return "", "", fmt.Errorf("invalid column format (must be struct_attr): %s", col)
+	}
+	return tmp[0], tmp[1], nil
+}
+
+// validateConf checks structural consistency of a liveattrs configuration:
+//   - the atom structure must exist in the corpus (i.e. in indexedStructs)
+//   - self-join columns must use the struct_attr format and refer
+//     to configured structural attributes
+//   - bib view ID attribute and columns must refer to configured
+//     structural attributes
+func validateConf(conf *vteconf.VTEConf, indexedStructs []string) ValidationErrors {
+	ans := make(ValidationErrors, 0, 5)
+	if conf.AtomStructure == "" {
+		ans = append(ans, ValidationError{
+			Field: "atomStructure", Message: "no atom structure specified"})
+
+	} else {
+		atomExists := false
+		for _, st := range indexedStructs {
+			if st == conf.AtomStructure {
+				atomExists = true
+				break
+			}
+		}
+		if !atomExists {
+			ans = append(ans, ValidationError{
+				Field: "atomStructure",
+				Message: fmt.Sprintf(
+					"atom structure '%s' does not exist in corpus %s", conf.AtomStructure, conf.Corpus),
+			})
+		}
+	}
+
+	knownCols := make(map[string]bool)
+	for stru, attrs := range conf.Structures {
+		for _, attr := range attrs {
+			knownCols[stru+"_"+attr] = true
+		}
+	}
+
+	for i, argCol := range conf.SelfJoin.ArgColumns {
+		field := fmt.Sprintf("selfJoin.argColumns[%d]", i)
+		if _, _, err := splitStructAttr(argCol); err != nil {
+			ans = append(ans, ValidationError{Field: field, Message: err.Error()})
+
+		} else if !knownCols[argCol] {
+			ans = append(ans, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("column %s is not among configured structures", argCol),
+			})
+		}
+	}
+
+	if conf.BibView.IDAttr != "" || len(conf.BibView.Cols) > 0 {
+		if conf.BibView.IDAttr == "" {
+			ans = append(ans, ValidationError{
+				Field: "bibView.idAttr", Message: "no bib view ID attribute specified"})
+
+		} else if !knownCols[conf.BibView.IDAttr] {
+			ans = append(ans, ValidationError{
+				Field: "bibView.idAttr",
+				Message: fmt.Sprintf(
+					"ID attribute %s is not among configured structures", conf.BibView.IDAttr),
+			})
+		}
+		for i, col := range conf.BibView.Cols {
+			if !knownCols[col] {
+				ans = append(ans, ValidationError{
+					Field:   fmt.Sprintf("bibView.cols[%d]", i),
+					Message: fmt.Sprintf("column %s is not among configured structures", col),
+				})
+			}
+		}
+	}
+
+	if len(ans) > 0 {
+		return ans
+	}
+	return nil
+}
+
+// Validate checks a liveattrs configuration without saving it.
+// In case the configuration is invalid, ValidationErrors is returned.
+// Other errors (e.g. inaccessible corpus registry) are returned as they are.
+func (lcache *LiveAttrsBuildConfProvider) Validate(conf *vteconf.VTEConf) error {
+	corpusInfo, err := corpus.GetCorpusInfo(conf.Corpus, lcache.corpSetup, false)
+	if err != nil {
+		return fmt.Errorf("failed to validate liveattrs conf: %w", err)
+	}
+	if verrs := validateConf(conf, corpusInfo.IndexedStructs); verrs != nil {
+		return verrs
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package laconf
+
+import (
+	"testing"
+
+	vteconf "github.com/czcorpus/vert-tagextract/v3/cnf"
+	vtedb "github.com/czcorpus/vert-tagextract/v3/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfValid(t *testing.T) {
+	conf := vteconf.VTEConf{
+		Corpus:        "susanne",
+		AtomStructure: "doc",
+		Structures:    map[string][]string{"doc": {"id", "author"}},
+		BibView:       vtedb.BibViewConf{IDAttr: "doc_id", Cols: []string{"doc_id", "doc_author"}},
+	}
+	assert.Nil(t, validateConf(&conf, []string{"doc", "p"}))
+}
+
+func TestValidateConfReportsAllErrors(t *testing.T) {
+	conf := vteconf.VTEConf{
+		Corpus:        "susanne",
+		AtomStructure: "text",
+		Structures:    map[string][]string{"doc": {"id"}},
+		SelfJoin:      vtedb.SelfJoinConf{ArgColumns: []string{"doc_id", "docid"}},
+		BibView:       vtedb.BibViewConf{IDAttr: "doc_id", Cols: []string{"doc_title"}},
+	}
+	verrs := validateConf(&conf, []string{"doc", "p"})
+	fields := make([]string, len(verrs))
+	for i, verr := range verrs {
+		fields[i] = verr.Field
+	}
+	assert.Equal(t, []string{"atomStructure", "selfJoin.argColumns[1]", "bibView.cols[0]"}, fields)
+}
@@ -17,6 +17,7 @@
 package liveattrs
 
 import (
+	"fmt"
 	"frodo/jobs"
 	"time"
 
@@ -25,15 +26,56 @@ import (
 )
 
 const (
-	JobType = "liveattrs"
+	JobType       = "liveattrs"
+	UpdateJobType = "liveattrs-update"
 )
 
+// IncrementalUpdateArgs specifies documents affected by an incremental
+// liveattrs update. Documents are identified by their bibliographic ID.
+type IncrementalUpdateArgs struct {
+
+	// RemovedDocs are documents to be removed from liveattrs data
+	RemovedDocs []string `json:"removedDocs"`
+
+	// UpdatedDocs are changed or added documents. Their current rows
+	// (if any) are removed and new rows are extracted from VerticalFiles.
+	UpdatedDocs []string `json:"updatedDocs"`
+
+	// VerticalFiles should contain just the updated documents
+	// (i.e. not the whole corpus).
+	VerticalFiles []string `json:"verticalFiles"`
+}
+
+func (args IncrementalUpdateArgs) Validate() error {
+	if len(args.RemovedDocs) == 0 && len(args.UpdatedDocs) == 0 {
+		return fmt.Errorf("no documents to remove or update")
+	}
+	if len(args.UpdatedDocs) > 0 && len(args.VerticalFiles) == 0 {
+		return fmt.Errorf("updated documents require vertical files")
+	}
+	if len(args.UpdatedDocs) == 0 && len(args.VerticalFiles) > 0 {
+		return fmt.Errorf("vertical files specified but no updated documents")
+	}
+	return nil
+}
+
+// AffectedDocs returns all the documents whose current rows
+// must be removed.
+func (args IncrementalUpdateArgs) AffectedDocs() []string {
+	ans := make([]string, 0, len(args.RemovedDocs)+len(args.UpdatedDocs))
+	ans = append(ans, args.RemovedDocs...)
+	return append(ans, args.UpdatedDocs...)
+}
+
 type JobInfoArgs struct {
 	Append           bool                 `json:"append"`
 	VteConf          vteCnf.VTEConf       `json:"vteConf"`
 	NoCorpusDBUpdate bool                 `json:"noCorpusDbUpdate"`
 	TagsetAttr       string               `json:"tagsetAttr"`
 	TagsetName       corp.SupportedTagset `json:"tagsetName"`
+
+	// Incremental is set in case of an incremental update job
+	Incremental *IncrementalUpdateArgs `json:"incremental,omitempty"`
 }
 
 func (jargs JobInfoArgs) WithoutPasswords() JobInfoArgs {
@@ -55,6 +97,7 @@ type LiveAttrsJobInfo struct {
 	ProcessedAtoms  int           `json:"processedAtoms"`
 	ProcessedLines  int           `json:"processedLines"`
 	ProcessedTokens int           `json:"processedTokens"`
+	NumRemovedRows  int           `json:"numRemovedRows"`
 	NumRestarts     int           `json:"numRestarts"`
 	Args            JobInfoArgs   `json:"args"`
 }
@@ -110,6 +153,7 @@ func (j LiveAttrsJobInfo) FullInfo() any {
 		ProcessedAtoms  int           `json:"processedAtoms"`
 		ProcessedLines  int           `json:"processedLines"`
 		ProcessedTokens int           `json:"processedTokens"`
+		NumRemovedRows  int           `json:"numRemovedRows"`
 		NumRestarts     int           `json:"numRestarts"`
 		Args            JobInfoArgs   `json:"args"`
 	}{
@@ -124,6 +168,7 @@ func (j LiveAttrsJobInfo) FullInfo() any {
 		ProcessedAtoms:  j.ProcessedAtoms,
 		ProcessedLines:  j.ProcessedLines,
 		ProcessedTokens: j.ProcessedTokens,
+		NumRemovedRows:  j.NumRemovedRows,
 		NumRestarts:     j.NumRestarts,
 		Args:            j.Args.WithoutPasswords(),
 	}
@@ -151,14 +196,19 @@ func (j LiveAttrsJobInfo) GetError() error {
 // WithError creates a new instance of LiveAttrsJobInfo with
 // the Error property set to the value of 'err'.
 func (j LiveAttrsJobInfo) WithError(err error) jobs.GeneralJobInfo {
+	jobType := j.Type
+	if jobType == "" {
+		jobType = JobType
+	}
 	return LiveAttrsJobInfo{
 		ID:              j.ID,
-		Type:            JobType,
+		Type:            jobType,
 		CorpusID:        j.CorpusID,
 		AliasedCorpusID: j.AliasedCorpusID,
 		Start:           j.Start,
 		Update:          jobs.JSONTime(time.Now()),
 		Error:           err,
+		NumRemovedRows:  j.NumRemovedRows,
 		NumRestarts:     j.NumRestarts,
 		Args:            j.Args,
 		Finished:        true,
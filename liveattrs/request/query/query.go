@@ -18,12 +18,23 @@ package query
 
 import (
 	"fmt"
+	"sort"
 )
 
 // Attrs represents a user selection of text types
 // The values can be of different types. To handle them
 // in a more convenient way, the type contains helper methods
-// (GetRegexpAttrVal, GetListingOf).
+// (GetRegexpAttrVal, GetIntervalAttrVal, GetListingOf).
+//
+// Supported values are:
+//   - a list of strings (`["Doe", "Smith"]`) - any of the values matches
+//   - a string (`"Do%"`) - a LIKE pattern
+//   - a regular expression (`{"regexp": "^Do.+"}`)
+//   - a numeric interval (`{"interval": [1990, 2000]}`)
+//
+// An attribute name prefixed with `!` (e.g. `!doc.author`) negates
+// the condition - i.e. `{"!doc.author": ["Doe", "Smith"]}` matches all
+// the authors except for the two listed ones.
 type Attrs map[string]any
 
 // GetRegexpAttrVal tries to extract value of a regular
@@ -50,6 +61,60 @@ func (q Attrs) GetRegexpAttrVal(attr string) (string, bool) {
 	return "", false
 }
 
+// Interval defines a closed numeric interval [From, To] used
+// to search numeric attributes (e.g. `{"interval": [1990, 2000]}`).
+type Interval struct {
+	From float64
+	To   float64
+}
+
+// GetIntervalAttrVal tries to extract a numeric interval from
+// Attrs under the 'attr' key. In case there is no interval value
+// stored in q[attr], false is returned as the second value.
+// In case the interval is malformed, an error is returned.
+func (q Attrs) GetIntervalAttrVal(attr string) (Interval, bool, error) {
+	v, ok := q[attr]
+	if !ok {
+		v, ok = q["!"+attr]
+		if !ok {
+			return Interval{}, false, nil
+		}
+	}
+	tm, ok := v.(map[string]any)
+	if !ok {
+		return Interval{}, false, nil
+	}
+	iv, ok := tm["interval"]
+	if !ok {
+		return Interval{}, false, nil
+	}
+	bounds, ok := iv.([]any)
+	if !ok || len(bounds) != 2 {
+		return Interval{}, true, fmt.Errorf("interval of attribute %s must be a pair of numbers", attr)
+	}
+	from, ok1 := bounds[0].(float64)
+	to, ok2 := bounds[1].(float64)
+	if !ok1 || !ok2 {
+		return Interval{}, true, fmt.Errorf("interval of attribute %s must be a pair of numbers", attr)
+	}
+	if from > to {
+		return Interval{}, true, fmt.Errorf(
+			"invalid interval of attribute %s: lower bound is greater than upper bound", attr)
+	}
+	return Interval{From: from, To: to}, true, nil
+}
+
+// ValidateIntervals tests that all the interval values
+// are well-formed.
+func (q Attrs) ValidateIntervals() error {
+	for attr := range q {
+		if _, _, err := q.GetIntervalAttrVal(attr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetListingOf returns a list of strings (= selected values) for
 // a specified attribute. In case the attribute is not represented
 // by a value listing (like e.g. in case of range values), the function
@@ -85,15 +150,242 @@ func (q Attrs) GetListingOf(attr string) ([]string, error) {
 	return ans, nil
 }
 
+// OrGroups defines groups of attributes (keys of Attrs) whose
+// conditions are combined using OR. E.g. the group `["doc.author", "doc.translator"]`
+// matches items where either the author or the translator match.
+// Conditions of different groups and of attributes without a group are
+// combined using AND.
+type OrGroups [][]string
+
+// Validate tests that each group contains at least two attributes,
+// all the attributes are present in attrs and that each attribute
+// belongs to at most one group.
+func (g OrGroups) Validate(attrs Attrs) error {
+	used := make(map[string]bool)
+	for _, group := range g {
+		if len(group) < 2 {
+			return fmt.Errorf("OR group must contain at least two attributes")
+		}
+		for _, attr := range group {
+			if _, ok := attrs[attr]; !ok {
+				return fmt.Errorf("OR group attribute %s not found in attrs", attr)
+			}
+			if used[attr] {
+				return fmt.Errorf("attribute %s is used in multiple OR groups", attr)
+			}
+			used[attr] = true
+		}
+	}
+	return nil
+}
+
+// NumBucket defines a closed numeric interval [From, To]
+// used to group values of numeric attributes (e.g. years into decades).
+type NumBucket struct {
+	Label string  `json:"label"`
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+}
+
+// GetLabel returns either a defined label or a label
+// generated from the interval bounds
+func (b NumBucket) GetLabel() string {
+	if b.Label != "" {
+		return b.Label
+	}
+	return fmt.Sprintf("%v-%v", b.From, b.To)
+}
+
+// Contains tests whether the value is within the bucket
+// (including both bounds)
+func (b NumBucket) Contains(v float64) bool {
+	return v >= b.From && v <= b.To
+}
+
+// Buckets maps attributes to their bucket definitions
+type Buckets map[string][]NumBucket
+
+// Validate tests that all the buckets are non-empty intervals
+// and that buckets of a single attribute do not overlap.
+func (b Buckets) Validate() error {
+	for attr, buckets := range b {
+		if len(buckets) == 0 {
+			return fmt.Errorf("no buckets defined for attribute %s", attr)
+		}
+		sorted := make([]NumBucket, len(buckets))
+		copy(sorted, buckets)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+		for i, bucket := range sorted {
+			if bucket.From > bucket.To {
+				return fmt.Errorf(
+					"invalid bucket %s of attribute %s: lower bound is greater than upper bound",
+					bucket.GetLabel(), attr,
+				)
+			}
+			if i > 0 && sorted[i-1].To >= bucket.From {
+				return fmt.Errorf(
+					"buckets %s and %s of attribute %s overlap",
+					sorted[i-1].GetLabel(), bucket.GetLabel(), attr,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+const (
+	SortKeyLabel = "label"
+	SortKeyCount = "count"
+	SortKeyID    = "id"
+)
+
+// AttrSort specifies how values of an attribute are sorted
+// in a response.
+type AttrSort struct {
+
+	// Key is one of "label" (default), "count", "id"
+	Key string `json:"key"`
+
+	// Desc, if true, reverses the order
+	Desc bool `json:"desc"`
+}
+
+// Validate tests whether the sort key is supported
+func (as AttrSort) Validate() error {
+	switch as.Key {
+	case "", SortKeyLabel, SortKeyCount, SortKeyID:
+		return nil
+	default:
+		return fmt.Errorf("unsupported sort key %s", as.Key)
+	}
+}
+
+// Supported values of Payload.SortBy
+const (
+	SortByLabel     = "label"
+	SortByCount     = "count"
+	SortByCountDesc = "-count"
+)
+
+// ParseSortBy converts a Payload.SortBy value into a sorting
+// specification. An empty value means sorting by label.
+func ParseSortBy(v string) (AttrSort, error) {
+	switch v {
+	case "", SortByLabel:
+		return AttrSort{Key: SortKeyLabel}, nil
+	case SortByCount:
+		return AttrSort{Key: SortKeyCount}, nil
+	case SortByCountDesc:
+		return AttrSort{Key: SortKeyCount, Desc: true}, nil
+	default:
+		return AttrSort{}, fmt.Errorf("unsupported sortBy value %s", v)
+	}
+}
+
+// SortSpec maps attributes to their sorting specification
+type SortSpec map[string]AttrSort
+
+// Get returns a sorting specification for an attribute.
+// For attributes without a specification, the default one
+// (ascending by label) is returned.
+func (ss SortSpec) Get(attr string) AttrSort {
+	if v, ok := ss[attr]; ok {
+		return v
+	}
+	return AttrSort{Key: SortKeyLabel}
+}
+
+// GetOrDefault returns a sorting specification for an attribute.
+// For attributes without a specification, dflt is returned.
+func (ss SortSpec) GetOrDefault(attr string, dflt AttrSort) AttrSort {
+	if v, ok := ss[attr]; ok {
+		return v
+	}
+	return dflt
+}
+
+// Validate tests all the attribute sorting specifications
+func (ss SortSpec) Validate() error {
+	for attr, v := range ss {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("invalid sort of attribute %s: %w", attr, err)
+		}
+	}
+	return nil
+}
+
 // Payload represents a query arguments as required by an HTTP API endpoint
 type Payload struct {
-	Aligned          []string `json:"aligned"`
-	Attrs            Attrs    `json:"attrs"`
-	AutocompleteAttr string   `json:"autocompleteAttr"`
-	MaxAttrListSize  int      `json:"maxAttrListSize"`
+	Aligned []string `json:"aligned"`
+	Attrs   Attrs    `json:"attrs"`
+
+	// AutocompleteAttr specifies an attribute whose value (in Attrs) is
+	// to be autocompleted. An attribute of an aligned corpus (which must
+	// be listed in Aligned) can be specified using the corpus ID prefix
+	// (e.g. `intercorp_v16ud_en:doc.title`). The same form must be used
+	// for the respective key in Attrs.
+	AutocompleteAttr string `json:"autocompleteAttr"`
+	MaxAttrListSize  int    `json:"maxAttrListSize"`
 
 	// ApplyCutoff, if set true, then in case a result returns more than MaxAttrListSize,
 	// the list is cut to the MaxAttrListSize and the response is behaving like there
 	// is no problem with too much matching items
 	ApplyCutoff bool `json:"applyCutoff"`
+
+	// IncludeEmptyAttrs, if set true, makes sure all the queried attributes
+	// are present in the result (with possibly empty value lists) along with
+	// their total number of matching positions (possibly zero). This allows
+	// clients to distinguish between "not queried" and "no matches".
+	IncludeEmptyAttrs bool `json:"includeEmptyAttrs"`
+
+	// Buckets (optional) specifies numeric attributes whose values should
+	// be grouped into provided intervals with summed counts. Values out
+	// of all the intervals are omitted.
+	Buckets Buckets `json:"buckets"`
+
+	// Sort (optional) specifies per-attribute sorting of returned values.
+	// Attributes without a specification are sorted by their labels
+	// in ascending order.
+	Sort SortSpec `json:"sort"`
+
+	// SortBy (optional) specifies sorting of all the attributes
+	// without a specific sorting in Sort. Supported values are
+	// "label" (default), "count" and "-count" (descending). Values with
+	// equal counts are sorted by their labels (using the collator locale).
+	SortBy string `json:"sortBy"`
+
+	// MinCount (optional) specifies a minimum number of positions
+	// of a listed value. Values with lower counts are omitted.
+	// The filter is applied before the list size limits (MaxAttrListSize,
+	// ApplyCutoff) are evaluated. Attributes with Buckets are not
+	// filtered. Zero value means "no filter".
+	MinCount int `json:"minCount"`
+
+	// OrGroups (optional) specifies attributes whose conditions
+	// are combined using OR (instead of the default AND).
+	OrGroups OrGroups `json:"orGroups"`
+
+	// ApproxCounts, if set true along with ApplyCutoff, allows for limiting
+	// the number of database rows read while processing the query
+	// (see liveattrs.Conf.ApproxQueryRowLimit). Once the limit is reached,
+	// all the counts are computed just from the rows read so far (i.e. they
+	// are lower bounds of the real values) and the answer is marked as approximate.
+	// The option is ignored for queries with Buckets, MinCount or IncludeEmptyAttrs
+	// as they require exact counts.
+	ApproxCounts bool `json:"approxCounts"`
+
+	// ExactPoscount, if set true, makes sure the total number of positions
+	// is calculated by an additional aggregate query over all the matching
+	// items. This way, the value is exact even if the number of read rows
+	// has been limited (see ApproxCounts).
+	ExactPoscount bool `json:"exactPoscount"`
+}
+
+// ValidateConditions tests that the attribute conditions
+// (including interval values and OR groups) are well-formed.
+func (p Payload) ValidateConditions() error {
+	if err := p.Attrs.ValidateIntervals(); err != nil {
+		return err
+	}
+	return p.OrGroups.Validate(p.Attrs)
 }
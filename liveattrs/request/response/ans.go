@@ -17,20 +17,64 @@
 package response
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"frodo/liveattrs/request/query"
+	"frodo/liveattrs/utils"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/czcorpus/cnc-gokit/collections"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
 )
 
+var supportedCollations = language.NewMatcher(collate.Supported())
+
+// ParseCollatorLocale parses provided locale (e.g. `cs_CZ`, `en-US`)
+// and makes sure there is a collation available for it.
+func ParseCollatorLocale(locale string) (language.Tag, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return language.Und, fmt.Errorf("invalid locale %s: %w", locale, err)
+	}
+	if _, _, conf := supportedCollations.Match(tag); conf == language.No {
+		return language.Und, fmt.Errorf("unsupported locale %s", locale)
+	}
+	return tag, nil
+}
+
+// labelComparator returns a function comparing two labels
+// based on provided locale. In case the locale is empty or
+// not usable, plain byte-wise comparison is used.
+func labelComparator(collatorLocale string) func(a, b string) int {
+	if collatorLocale == "" {
+		return strings.Compare
+	}
+	tag, err := ParseCollatorLocale(collatorLocale)
+	if err != nil {
+		return strings.Compare
+	}
+	coll := collate.New(tag)
+	return coll.CompareString
+}
+
 type ListedValue struct {
 	ID         string
 	Label      string
 	ShortLabel string
 	Count      int
 	Grouping   int
+
+	// IsGroup is true for bib. items merged from multiple items
+	// sharing the same label (the ID is then "@" + label)
+	IsGroup bool
+
+	// bucket is set for values created by ApplyBuckets so
+	// they can be sorted by their bounds instead of labels
+	bucket *query.NumBucket
 }
 
 type SummarizedValue struct {
@@ -42,6 +86,61 @@ type QueryAns struct {
 	AttrValues     map[string]any
 	AlignedCorpora []string
 	AppliedCutoff  int
+
+	// JoinStrategy describes how aligned corpora were matched
+	// (see laquery.JoinStrategy* constants)
+	JoinStrategy string
+
+	// IgnoredAlignedCorpora contains requested aligned corpora
+	// which are not part of the queried corpus' parallel group
+	// and thus were not used in the query
+	IgnoredAlignedCorpora []string
+
+	// AttrTotals contains total numbers of positions for
+	// (queried) attributes. It is filled in only on request.
+	AttrTotals map[string]int
+
+	// Partial is true if reading of the data failed in the middle
+	// and the answer contains just the values accumulated so far
+	// (this must be explicitly allowed by a client).
+	Partial bool
+
+	// Approximate is true if the number of read rows has been
+	// limited and thus all the counts are just lower bounds
+	// (this must be explicitly allowed by a client).
+	Approximate bool
+
+	// DistinctCounts contains numbers of distinct values of listed
+	// attributes as found in the data (i.e. before any cutoff is applied).
+	// Attributes switched to the count-only mode are not included.
+	DistinctCounts map[string]int
+
+	// NilColumns contains numbers of NULL values encountered
+	// in respective database columns while reading the data.
+	// It is not exported (see WithDebugWarnings).
+	NilColumns map[string]int
+
+	// Warnings contains non-fatal problems encountered while
+	// reading the data. It is filled in only on request
+	// (see WithDebugWarnings).
+	Warnings []string
+}
+
+// WithDebugWarnings returns a shallow copy of the answer with
+// Warnings describing encountered problems (e.g. NULL columns
+// which typically mean a misconfigured liveattrs structure).
+// The original answer (which may be cached) is not modified.
+func (qa *QueryAns) WithDebugWarnings() *QueryAns {
+	ans := *qa
+	ans.Warnings = make([]string, 0, len(qa.NilColumns))
+	for col, num := range qa.NilColumns {
+		ans.Warnings = append(
+			ans.Warnings,
+			fmt.Sprintf("column %s contains NULL values (%d occurrences)", col, num),
+		)
+	}
+	sort.Strings(ans.Warnings)
+	return &ans
 }
 
 func (qa *QueryAns) MarshalJSON() ([]byte, error) {
@@ -50,16 +149,17 @@ func (qa *QueryAns) MarshalJSON() ([]byte, error) {
 		var attrValues any
 		tv, ok := v.([]*ListedValue)
 		if ok {
-			tAttrValues := make([][5]any, 0, len(qa.AttrValues))
+			tAttrValues := make([][6]any, 0, len(qa.AttrValues))
 			for _, item := range tv {
 				tAttrValues = append(
 					tAttrValues,
-					[5]any{
+					[6]any{
 						item.ShortLabel,
 						item.ID,
 						item.Label,
 						item.Grouping,
 						item.Count,
+						item.IsGroup,
 					},
 				)
 			}
@@ -72,18 +172,49 @@ func (qa *QueryAns) MarshalJSON() ([]byte, error) {
 
 	}
 	return json.Marshal(&struct {
-		Poscount       int            `json:"poscount"`
-		AttrValues     map[string]any `json:"attr_values"`
-		AlignedCorpora []string       `json:"aligned"`
-		AppliedCutoff  int            `json:"applied_cutoff,omitempty"`
+		Poscount              int            `json:"poscount"`
+		AttrValues            map[string]any `json:"attr_values"`
+		AlignedCorpora        []string       `json:"aligned"`
+		JoinStrategy          string         `json:"join_strategy,omitempty"`
+		IgnoredAlignedCorpora []string       `json:"ignored_aligned,omitempty"`
+		AppliedCutoff         int            `json:"applied_cutoff,omitempty"`
+		AttrTotals            map[string]int `json:"attr_totals,omitempty"`
+		Partial               bool           `json:"partial,omitempty"`
+		Approximate           bool           `json:"approximate,omitempty"`
+		DistinctCounts        map[string]int `json:"distinct_counts,omitempty"`
+		Warnings              []string       `json:"warnings,omitempty"`
 	}{
-		Poscount:       qa.Poscount,
-		AttrValues:     expAllAttrValues,
-		AlignedCorpora: qa.AlignedCorpora,
-		AppliedCutoff:  qa.AppliedCutoff,
+		Poscount:              qa.Poscount,
+		AttrValues:            expAllAttrValues,
+		AlignedCorpora:        qa.AlignedCorpora,
+		JoinStrategy:          qa.JoinStrategy,
+		IgnoredAlignedCorpora: qa.IgnoredAlignedCorpora,
+		AppliedCutoff:         qa.AppliedCutoff,
+		AttrTotals:            qa.AttrTotals,
+		Partial:               qa.Partial,
+		Approximate:           qa.Approximate,
+		DistinctCounts:        qa.DistinctCounts,
+		Warnings:              qa.Warnings,
 	})
 }
 
+// RawQueryAns contains accumulated attribute value counts before
+// any export transformations are applied. I.e. bibliographic items
+// are not grouped, no cutoff or buckets are applied and values are
+// identified by their IDs (which differ from labels only in case
+// of the bib. label attribute).
+type RawQueryAns struct {
+	Poscount int `json:"poscount"`
+
+	// AttrValues contains {attr: {value_id: num_positions,...},...}
+	AttrValues map[string]map[string]int `json:"attr_values"`
+
+	// AttrTotals contains total numbers of positions for attributes
+	// exceeding max. allowed number of distinct values (such attributes
+	// are not listed in AttrValues)
+	AttrTotals map[string]int `json:"attr_totals"`
+}
+
 func (qa *QueryAns) AddListedValue(attr string, v *ListedValue) error {
 	entry, ok := qa.AttrValues[attr]
 	if !ok {
@@ -97,6 +228,127 @@ func (qa *QueryAns) AddListedValue(attr string, v *ListedValue) error {
 	return nil
 }
 
+// RecordDistinctCounts stores current lengths of listed attribute values
+// into DistinctCounts. It is expected to be called once all the values
+// are added and before any cutoff or grouping is applied.
+func (qa *QueryAns) RecordDistinctCounts() {
+	qa.DistinctCounts = make(map[string]int)
+	for attr, items := range qa.AttrValues {
+		if tEntry, ok := items.([]*ListedValue); ok {
+			qa.DistinctCounts[attr] = len(tEntry)
+		}
+	}
+}
+
+// EnsureAttrsWithTotals makes sure all the provided attributes are present
+// in AttrValues (in case of a missing one, an empty list is inserted)
+// and calculates their total number of positions into AttrTotals.
+func (qa *QueryAns) EnsureAttrsWithTotals(attrs []string) {
+	if qa.AttrTotals == nil {
+		qa.AttrTotals = make(map[string]int)
+	}
+	for _, attr := range attrs {
+		entry, ok := qa.AttrValues[attr]
+		if !ok {
+			qa.AttrValues[attr] = []*ListedValue{}
+			qa.AttrTotals[attr] = 0
+			continue
+		}
+		switch tEntry := entry.(type) {
+		case []*ListedValue:
+			var total int
+			for _, v := range tEntry {
+				total += v.Count
+			}
+			qa.AttrTotals[attr] = total
+		case int:
+			qa.AttrTotals[attr] = tEntry
+		}
+	}
+}
+
+// ApplyBuckets replaces listed values of a numeric attribute with provided
+// buckets (intervals) containing summed counts of respective values.
+// In case the attribute contains a non-numeric value, an error is returned.
+// Empty values are ignored.
+func (qa *QueryAns) ApplyBuckets(attr string, buckets []query.NumBucket) error {
+	entry, ok := qa.AttrValues[attr]
+	if !ok {
+		return nil
+	}
+	tEntry, ok := entry.([]*ListedValue)
+	if !ok {
+		return fmt.Errorf("failed to apply buckets: attribute %s not a list type", attr)
+	}
+	ans := make([]*ListedValue, len(buckets))
+	for i, b := range buckets {
+		ans[i] = &ListedValue{
+			ID:         b.GetLabel(),
+			Label:      b.GetLabel(),
+			ShortLabel: b.GetLabel(),
+			Grouping:   0,
+			bucket:     &buckets[i],
+		}
+	}
+	for _, item := range tEntry {
+		if item.Label == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(item.Label), 64)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to apply buckets: %w %s (value: %s)", utils.ErrorNonNumericAttr, attr, item.Label)
+		}
+		for i, b := range buckets {
+			if b.Contains(v) {
+				ans[i].Count += item.Count
+				ans[i].Grouping++
+				break
+			}
+		}
+	}
+	qa.AttrValues[attr] = ans
+	return nil
+}
+
+// FilterMinCount removes listed values with Count lower than minCount.
+// Attributes listed in skipAttrs are left untouched.
+func (qa *QueryAns) FilterMinCount(minCount int, skipAttrs query.Buckets) {
+	if minCount <= 0 {
+		return
+	}
+	for attr, items := range qa.AttrValues {
+		if _, ok := skipAttrs[attr]; ok {
+			continue
+		}
+		tEntry, ok := items.([]*ListedValue)
+		if !ok {
+			continue
+		}
+		filtered := make([]*ListedValue, 0, len(tEntry))
+		for _, item := range tEntry {
+			if item.Count >= minCount {
+				filtered = append(filtered, item)
+			}
+		}
+		qa.AttrValues[attr] = filtered
+	}
+}
+
+// SortValues sorts listed values of all the attributes according
+// to the provided specification (see ExportAttrValues). It is expected
+// to be called before CutoffValues so the cutoff keeps the top values.
+func (qa *QueryAns) SortValues(collatorLocale string, sortSpec query.SortSpec, dfltSort query.AttrSort) {
+	cmpLabels := labelComparator(collatorLocale)
+	for attr, items := range qa.AttrValues {
+		if tEntry, ok := items.([]*ListedValue); ok {
+			sortListedValues(tEntry, sortSpec.GetOrDefault(attr, dfltSort), cmpLabels)
+		}
+	}
+}
+
+// CutoffValues keeps at most `cutoff` first values of each listed attribute.
+// To keep the top values, SortValues must be called first.
 func (qa *QueryAns) CutoffValues(cutoff int) {
 	var cutoffApplied bool
 	for attr, items := range qa.AttrValues {
@@ -111,25 +363,58 @@ func (qa *QueryAns) CutoffValues(cutoff int) {
 	}
 }
 
+// sortListedValues sorts values according to the provided specification.
+// Values with equal keys are always sorted by their labels in ascending
+// order so the result is stable across requests.
+func sortListedValues(
+	values []*ListedValue,
+	srt query.AttrSort,
+	cmpLabels func(a, b string) int,
+) {
+	sort.Slice(
+		values,
+		func(i, j int) bool {
+			var c int
+			switch {
+			case srt.Key == query.SortKeyCount:
+				c = values[i].Count - values[j].Count
+			case values[i].bucket != nil && values[j].bucket != nil:
+				// labels of buckets are not suitable for sorting
+				// (e.g. "100-200" would precede "20-40")
+				c = cmp.Compare(values[i].bucket.From, values[j].bucket.From)
+			case srt.Key == query.SortKeyID:
+				c = strings.Compare(values[i].ID, values[j].ID)
+			default:
+				c = cmpLabels(values[i].Label, values[j].Label)
+			}
+			if c == 0 {
+				return cmpLabels(values[i].Label, values[j].Label) < 0
+			}
+			if srt.Desc {
+				return c > 0
+			}
+			return c < 0
+		},
+	)
+}
+
 func ExportAttrValues(
 	data *QueryAns,
 	alignedCorpora []string,
 	expandAttrs []string,
 	collatorLocale string,
 	maxAttrListSize int,
+	sortSpec query.SortSpec,
+	dfltSort query.AttrSort,
 ) {
 	values := make(map[string]any)
+	cmpLabels := labelComparator(collatorLocale)
 	for k, v := range data.AttrValues {
 		switch tVal := v.(type) {
 		case []*ListedValue:
 			if maxAttrListSize == 0 || len(tVal) <= maxAttrListSize ||
 				collections.SliceContains(expandAttrs, k) {
-				sort.Slice(
-					tVal,
-					func(i, j int) bool {
-						return strings.Compare(tVal[i].Label, tVal[j].Label) == -1
-					},
-				)
+				sortListedValues(tVal, sortSpec.GetOrDefault(k, dfltSort), cmpLabels)
 				values[k] = tVal
 
 			} else {
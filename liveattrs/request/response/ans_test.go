@@ -0,0 +1,285 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"frodo/liveattrs/request/query"
+	"frodo/liveattrs/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterMinCount(t *testing.T) {
+	ans := QueryAns{
+		AttrValues: map[string]any{
+			"doc.author": []*ListedValue{
+				{ID: "a1", Label: "a1", Count: 1},
+				{ID: "a2", Label: "a2", Count: 50},
+				{ID: "a3", Label: "a3", Count: 2},
+				{ID: "a4", Label: "a4", Count: 10},
+			},
+			"doc.year": []*ListedValue{
+				{ID: "1990-2000", Label: "1990-2000", Count: 1},
+			},
+			"doc.title": 120,
+		},
+	}
+	ans.FilterMinCount(10, query.Buckets{"doc.year": nil})
+	labels := make([]string, 0, 2)
+	for _, v := range ans.AttrValues["doc.author"].([]*ListedValue) {
+		labels = append(labels, v.Label)
+	}
+	assert.Equal(t, []string{"a2", "a4"}, labels)
+	assert.Len(t, ans.AttrValues["doc.year"], 1)
+	assert.Equal(t, 120, ans.AttrValues["doc.title"])
+
+	// the list size limit is evaluated on the filtered values
+	// (i.e. 2 values instead of 4 => no summarization)
+	ExportAttrValues(&ans, []string{}, []string{}, "", 2, query.SortSpec{}, query.AttrSort{})
+	assert.IsType(t, []*ListedValue{}, ans.AttrValues["doc.author"])
+	assert.Len(t, ans.AttrValues["doc.author"], 2)
+}
+
+func exportedLabels(ans *QueryAns, attr string) []string {
+	ans2 := make([]string, 0, 5)
+	for _, v := range ans.AttrValues[attr].([]*ListedValue) {
+		ans2 = append(ans2, v.Label)
+	}
+	return ans2
+}
+
+func TestExportAttrValuesSortBy(t *testing.T) {
+	mkAns := func() *QueryAns {
+		return &QueryAns{
+			AttrValues: map[string]any{
+				"doc.author": []*ListedValue{
+					{Label: "Čapek", Count: 10},
+					{Label: "Dyk", Count: 5},
+					{Label: "Hašek", Count: 10},
+					{Label: "Cimrman", Count: 10},
+				},
+				"doc.genre": []*ListedValue{
+					{Label: "poetry", Count: 1},
+					{Label: "fiction", Count: 5},
+				},
+			},
+		}
+	}
+	countDesc, err := query.ParseSortBy("-count")
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ { // the order must be stable
+		ans := mkAns()
+		ExportAttrValues(
+			ans, []string{}, []string{}, "cs_CZ", 0,
+			query.SortSpec{"doc.genre": {Key: query.SortKeyLabel}}, countDesc)
+		assert.Equal(t, []string{"Cimrman", "Čapek", "Hašek", "Dyk"}, exportedLabels(ans, "doc.author"))
+		assert.Equal(t, []string{"fiction", "poetry"}, exportedLabels(ans, "doc.genre"))
+	}
+
+	countAsc, err := query.ParseSortBy("count")
+	assert.NoError(t, err)
+	ans := mkAns()
+	ExportAttrValues(ans, []string{}, []string{}, "cs_CZ", 0, query.SortSpec{}, countAsc)
+	assert.Equal(t, []string{"Dyk", "Cimrman", "Čapek", "Hašek"}, exportedLabels(ans, "doc.author"))
+
+	_, err = query.ParseSortBy("-label")
+	assert.Error(t, err)
+}
+
+func TestMarshalGroupedValues(t *testing.T) {
+	ans := &QueryAns{
+		AttrValues: map[string]any{
+			"doc.title": []*ListedValue{
+				{ID: "@Babička", Label: "Babička", ShortLabel: "Babička", Count: 7, Grouping: 2, IsGroup: true},
+				{ID: "b2", Label: "Bylo nás pět", ShortLabel: "Bylo nás pět", Count: 3, Grouping: 1},
+			},
+		},
+	}
+	ExportAttrValues(ans, []string{}, []string{}, "cs_CZ", 0, query.SortSpec{}, query.AttrSort{})
+	data, err := json.Marshal(ans)
+	assert.NoError(t, err)
+	var exported struct {
+		AttrValues map[string][][6]any `json:"attr_values"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &exported))
+	values := exported.AttrValues["doc.title"]
+	if assert.Len(t, values, 2) {
+		assert.Equal(t, "@Babička", values[0][1])
+		assert.Equal(t, true, values[0][5])
+		assert.Equal(t, false, values[1][5])
+	}
+}
+
+func TestWithDebugWarnings(t *testing.T) {
+	ans := &QueryAns{
+		AttrValues: map[string]any{},
+		NilColumns: map[string]int{"doc_title": 3, "doc_author": 1},
+	}
+	data, err := json.Marshal(ans)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "warnings")
+
+	dbgAns := ans.WithDebugWarnings()
+	assert.Nil(t, ans.Warnings)
+	assert.Equal(
+		t,
+		[]string{
+			"column doc_author contains NULL values (1 occurrences)",
+			"column doc_title contains NULL values (3 occurrences)",
+		},
+		dbgAns.Warnings,
+	)
+	data, err = json.Marshal(dbgAns)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"warnings":["column doc_author`)
+}
+
+func TestDistinctCountsSurviveCutoff(t *testing.T) {
+	mkValues := func(n int) []*ListedValue {
+		ans := make([]*ListedValue, n)
+		for i := range ans {
+			ans[i] = &ListedValue{ID: fmt.Sprint(i), Label: fmt.Sprint(i), Count: i + 1}
+		}
+		return ans
+	}
+	ans := &QueryAns{
+		AttrValues: map[string]any{
+			"doc.author":  mkValues(12),
+			"doc.title":   mkValues(30),
+			"doc.genre":   mkValues(3),
+			"doc.pubyear": 1500,
+		},
+	}
+	ans.RecordDistinctCounts()
+	ans.CutoffValues(5)
+	ExportAttrValues(ans, []string{}, []string{"doc.title"}, "", 5, query.SortSpec{}, query.AttrSort{})
+
+	assert.Equal(
+		t,
+		map[string]int{"doc.author": 12, "doc.title": 30, "doc.genre": 3},
+		ans.DistinctCounts,
+	)
+	assert.Len(t, ans.AttrValues["doc.title"], 5)
+	assert.Len(t, ans.AttrValues["doc.genre"], 3)
+
+	data, err := json.Marshal(ans)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"distinct_counts":{"doc.author":12,"doc.genre":3,"doc.title":30}`)
+}
+
+func TestSortValuesBeforeCutoffKeepsTopValues(t *testing.T) {
+	ans := &QueryAns{
+		AttrValues: map[string]any{
+			"doc.author": []*ListedValue{
+				{ID: "a1", Label: "a1", Count: 1},
+				{ID: "a2", Label: "a2", Count: 50},
+				{ID: "a3", Label: "a3", Count: 2},
+				{ID: "a4", Label: "a4", Count: 10},
+			},
+		},
+	}
+	countDesc := query.AttrSort{Key: query.SortKeyCount, Desc: true}
+	ans.SortValues("", query.SortSpec{}, countDesc)
+	ans.CutoffValues(2)
+	ExportAttrValues(ans, []string{}, []string{}, "", 2, query.SortSpec{}, countDesc)
+	values := ans.AttrValues["doc.author"].([]*ListedValue)
+	assert.Len(t, values, 2)
+	assert.Equal(t, "a2", values[0].ID)
+	assert.Equal(t, "a4", values[1].ID)
+	assert.Equal(t, 2, ans.AppliedCutoff)
+}
+
+func TestPerAttrSortBeforeCutoff(t *testing.T) {
+	mkValues := func() []*ListedValue {
+		return []*ListedValue{
+			{ID: "b", Label: "b", Count: 1},
+			{ID: "d", Label: "d", Count: 50},
+			{ID: "a", Label: "a", Count: 2},
+			{ID: "c", Label: "c", Count: 10},
+		}
+	}
+	ans := &QueryAns{
+		AttrValues: map[string]any{
+			"doc.author": mkValues(),
+			"doc.title":  mkValues(),
+		},
+	}
+	sortSpec := query.SortSpec{
+		"doc.author": {Key: query.SortKeyCount, Desc: true},
+	}
+	ans.SortValues("", sortSpec, query.AttrSort{Key: query.SortKeyLabel})
+	ans.CutoffValues(2)
+	ExportAttrValues(ans, []string{}, []string{}, "", 2, sortSpec, query.AttrSort{Key: query.SortKeyLabel})
+	authors := ans.AttrValues["doc.author"].([]*ListedValue)
+	assert.Equal(t, []string{"d", "c"}, []string{authors[0].ID, authors[1].ID})
+	titles := ans.AttrValues["doc.title"].([]*ListedValue)
+	assert.Equal(t, []string{"a", "b"}, []string{titles[0].ID, titles[1].ID})
+}
+
+func TestApplyBucketsSortsByBounds(t *testing.T) {
+	ans := QueryAns{
+		AttrValues: map[string]any{
+			"doc.year": []*ListedValue{
+				{ID: "25", Label: "25", Count: 1},
+				{ID: "150", Label: "150", Count: 2},
+				{ID: "30", Label: "30", Count: 4},
+				{ID: "", Label: "", Count: 8},
+			},
+		},
+	}
+	err := ans.ApplyBuckets(
+		"doc.year",
+		[]query.NumBucket{{From: 100, To: 200}, {From: 20, To: 40}},
+	)
+	assert.NoError(t, err)
+	ExportAttrValues(&ans, []string{}, []string{}, "", 0, query.SortSpec{}, query.AttrSort{})
+	assert.Equal(t, []string{"20-40", "100-200"}, exportedLabels(&ans, "doc.year"))
+	values := ans.AttrValues["doc.year"].([]*ListedValue)
+	assert.Equal(t, 5, values[0].Count)
+	assert.Equal(t, 2, values[1].Count)
+}
+
+func TestApplyBucketsNonNumericValue(t *testing.T) {
+	ans := QueryAns{
+		AttrValues: map[string]any{
+			"doc.year": []*ListedValue{{ID: "unknown", Label: "unknown", Count: 1}},
+		},
+	}
+	err := ans.ApplyBuckets("doc.year", []query.NumBucket{{From: 1990, To: 2000}})
+	assert.ErrorIs(t, err, utils.ErrorNonNumericAttr)
+}
+
+func TestParseCollatorLocale(t *testing.T) {
+	for _, locale := range []string{"cs_CZ", "cs-CZ", "en", "de_AT"} {
+		_, err := ParseCollatorLocale(locale)
+		assert.NoError(t, err, locale)
+	}
+	_, err := ParseCollatorLocale("c$")
+	assert.Error(t, err)
+}
+
+func TestLabelComparator(t *testing.T) {
+	assert.Equal(t, 1, labelComparator("cs_CZ")("chata", "hrad"))
+	assert.Equal(t, -1, labelComparator("en_US")("chata", "hrad"))
+	assert.Equal(t, -1, labelComparator("cs_CZ")("čaj", "dům"))
+	// no (or an invalid) locale means byte-wise comparison
+	assert.Equal(t, 1, labelComparator("")("čaj", "dům"))
+	assert.Equal(t, 1, labelComparator("c$")("čaj", "dům"))
+}
@@ -0,0 +1,35 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package response
+
+// StreamedRow is a single line of a streamed (NDJSON) liveattrs
+// query answer. It represents one liveattrs database row, i.e. values
+// are not aggregated across rows.
+type StreamedRow struct {
+	Poscount int               `json:"poscount"`
+	Attrs    map[string]string `json:"attrs"`
+}
+
+// StreamSummary is the last line of a streamed liveattrs query answer.
+// In case the data iteration fails in the middle, the Error is set and
+// the numbers reflect only the rows written so far.
+type StreamSummary struct {
+	Done     bool   `json:"done"`
+	Poscount int    `json:"poscount"`
+	NumRows  int    `json:"numRows"`
+	Error    string `json:"error,omitempty"`
+}
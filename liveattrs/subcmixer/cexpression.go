@@ -22,7 +22,10 @@ import (
 )
 
 var (
-	operators = map[string]string{"==": "<>", "<>": "==", "<=": ">=", ">=": "<="}
+	// operators maps supported operators to their negations
+	operators = map[string]string{
+		"==": "<>", "<>": "==", "<=": ">", ">": "<=", ">=": "<", "<": ">=",
+	}
 )
 
 type CategoryExpression struct {
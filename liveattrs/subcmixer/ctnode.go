@@ -24,20 +24,56 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// NodeBounds contains size limits of a category tree node
+// as computed by the CategoryTree initialization.
+type NodeBounds struct {
+
+	// Available is the size (in positions) of all the data
+	// matching the node's metadata condition
+	Available int
+
+	// Upper is the maximum size of the node's data in the mixed
+	// corpus so the required ratios of the node and its siblings
+	// can be met. It is the right side of the node's LP constraint.
+	Upper int
+
+	// ConstraintIdx is the index of the node's row in the LP
+	// constraint matrix (see MetadataModel). For the root node,
+	// which has no constraint, the value is -1.
+	ConstraintIdx int
+}
+
+func (nb NodeBounds) HasConstraint() bool {
+	return nb.ConstraintIdx >= 0
+}
+
+func (nb NodeBounds) String() string {
+	return fmt.Sprintf(
+		"NodeBounds(available: %d, upper: %d, constraint: %d)",
+		nb.Available, nb.Upper, nb.ConstraintIdx,
+	)
+}
+
 type CategoryTreeNode struct {
 	NodeID            int
 	ParentID          common.Maybe[int]
 	Ratio             float64
 	MetadataCondition []AbstractExpression
 	Size              int
-	ComputedBounds    any // TODO type
-	Children          []*CategoryTreeNode
+
+	// ComputedBounds is nil until the tree bounds are initialized
+	ComputedBounds *NodeBounds
+	Children       []*CategoryTreeNode
 }
 
 func (ctn *CategoryTreeNode) String() string {
+	bounds := "<nil>"
+	if ctn.ComputedBounds != nil {
+		bounds = ctn.ComputedBounds.String()
+	}
 	return fmt.Sprintf(
-		"CategoryTreeNode(id: %d, parent: %v, ratio: %01.3f, metadata: %v, size: %d, num children: %d)",
-		ctn.NodeID, ctn.ParentID, ctn.Ratio, ctn.MetadataCondition, ctn.Size, len(ctn.Children),
+		"CategoryTreeNode(id: %d, parent: %v, ratio: %01.3f, metadata: %v, size: %d, bounds: %s, num children: %d)",
+		ctn.NodeID, ctn.ParentID, ctn.Ratio, ctn.MetadataCondition, ctn.Size, bounds, len(ctn.Children),
 	)
 }
 
@@ -71,40 +107,42 @@ func (ej *ExpressionJoin) IsComposed() bool {
 	return true
 }
 
-func collectAtomsRecursive(current any) []any {
+// collectAtomsRecursive collects all the atomic expressions
+// of a (possibly nested) expression
+func collectAtomsRecursive(current AbstractExpression) []AbstractAtomicExpression {
 	switch tCurrent := current.(type) {
 	case *ExpressionJoin:
+		var ans []AbstractAtomicExpression
 		for _, item := range tCurrent.Items {
-			var ans []any
 			ans = append(ans, collectAtomsRecursive(item)...)
-			return ans
 		}
+		return ans
 	case *CategoryExpression:
-		return []any{&tCurrent}
+		return []AbstractAtomicExpression{tCurrent}
 	}
 	log.Debug().Msg("possibly invalid expression encoutered")
-	return []any{}
+	return []AbstractAtomicExpression{}
 }
 
 func (ej *ExpressionJoin) GetAtoms() []AbstractAtomicExpression {
-	tmp := collectAtomsRecursive(ej)
-	ans := make([]AbstractAtomicExpression, len(tmp))
-	for i, v := range tmp {
-		t, ok := v.(*CategoryExpression)
-		if ok {
-			ans[i] = t
-
-		} else {
-			log.Debug().Msg("possibly invalid expression")
-		}
-	}
-	return ans
+	return collectAtomsRecursive(ej)
 }
 
 func (ej *ExpressionJoin) IsEmpty() bool {
 	return len(ej.Items) == 0 && ej.Op == ""
 }
 
+func (ej *ExpressionJoin) String() string {
+	items := make([]string, len(ej.Items))
+	for i, item := range ej.Items {
+		items[i] = fmt.Sprintf("%v", item)
+	}
+	return "(" + strings.Join(items, " "+ej.Op+" ") + ")"
+}
+
+// Negate creates a new expression according to De Morgan's laws,
+// i.e. the operator is flipped and all the items are negated
+// (recursively).
 func (ej *ExpressionJoin) Negate() AbstractExpression {
 	var newOp string
 	if ej.Op == "OR" {
@@ -117,7 +155,9 @@ func (ej *ExpressionJoin) Negate() AbstractExpression {
 		Op:    newOp,
 		Items: make([]AbstractExpression, len(ej.Items)),
 	}
-	copy(expr.Items, ej.Items)
+	for i, item := range ej.Items {
+		expr.Items[i] = item.Negate()
+	}
 	return expr
 }
 
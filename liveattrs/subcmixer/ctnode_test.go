@@ -0,0 +1,151 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subcmixer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mkExpr(t *testing.T, attr, op, value string) *CategoryExpression {
+	expr, err := NewCategoryExpression(attr, op, value)
+	assert.NoError(t, err)
+	return expr
+}
+
+func atomsToStrings(atoms []AbstractAtomicExpression) []string {
+	ans := make([]string, len(atoms))
+	for i, v := range atoms {
+		ans[i] = v.(*CategoryExpression).String()
+	}
+	return ans
+}
+
+func TestExpressionJoinGetAtoms(t *testing.T) {
+	join := &ExpressionJoin{Op: "AND"}
+	join.Add(mkExpr(t, "div.txtype", "==", "fiction"))
+	join.Add(mkExpr(t, "div.year", ">=", "2000"))
+	join.Add(mkExpr(t, "div.medium", "<>", "web"))
+
+	atoms := join.GetAtoms()
+	assert.Equal(
+		t,
+		[]string{"div_txtype == 'fiction'", "div_year >= '2000'", "div_medium <> 'web'"},
+		atomsToStrings(atoms),
+	)
+	for _, atom := range atoms {
+		assert.NotNil(t, atom)
+	}
+}
+
+func TestExpressionJoinGetAtomsNested(t *testing.T) {
+	inner := &ExpressionJoin{Op: "OR"}
+	inner.Add(mkExpr(t, "div.year", "==", "2000"))
+	inner.Add(mkExpr(t, "div.year", "==", "2001"))
+	join := &ExpressionJoin{Op: "AND"}
+	join.Add(mkExpr(t, "div.txtype", "==", "fiction"))
+	join.Add(inner)
+
+	assert.Equal(
+		t,
+		[]string{"div_txtype == 'fiction'", "div_year == '2000'", "div_year == '2001'"},
+		atomsToStrings(join.GetAtoms()),
+	)
+	assert.Empty(t, (&ExpressionJoin{}).GetAtoms())
+}
+
+// evalExpr evaluates an expression against a document
+// with provided attribute values
+func evalExpr(t *testing.T, expr AbstractExpression, doc map[string]string) bool {
+	switch tExpr := expr.(type) {
+	case *ExpressionJoin:
+		ans := tExpr.Op == "AND"
+		for _, item := range tExpr.Items {
+			if tExpr.Op == "AND" {
+				ans = ans && evalExpr(t, item, doc)
+
+			} else {
+				ans = ans || evalExpr(t, item, doc)
+			}
+		}
+		return ans
+	case *CategoryExpression:
+		v := doc[tExpr.Attr()]
+		switch tExpr.OpSQL() {
+		case "=":
+			return v == tExpr.Value()
+		case "<>":
+			return v != tExpr.Value()
+		case "<":
+			return v < tExpr.Value()
+		case "<=":
+			return v <= tExpr.Value()
+		case ">":
+			return v > tExpr.Value()
+		case ">=":
+			return v >= tExpr.Value()
+		}
+	}
+	t.Fatalf("unsupported expression %v", expr)
+	return false
+}
+
+func TestExpressionJoinNegate(t *testing.T) {
+	inner := &ExpressionJoin{Op: "OR"}
+	inner.Add(mkExpr(t, "div.year", "<=", "2000"))
+	inner.Add(mkExpr(t, "div.medium", "==", "web"))
+	join := &ExpressionJoin{Op: "AND"}
+	join.Add(mkExpr(t, "div.txtype", "==", "fiction"))
+	join.Add(inner)
+
+	negated := join.Negate()
+	expected := &ExpressionJoin{
+		Op: "OR",
+		Items: []AbstractExpression{
+			mkExpr(t, "div.txtype", "<>", "fiction"),
+			&ExpressionJoin{
+				Op: "AND",
+				Items: []AbstractExpression{
+					mkExpr(t, "div.year", ">", "2000"),
+					mkExpr(t, "div.medium", "<>", "web"),
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected.String(), negated.(*ExpressionJoin).String())
+	assert.Equal(
+		t,
+		"(div_txtype <> 'fiction' OR (div_year > '2000' AND div_medium <> 'web'))",
+		negated.(*ExpressionJoin).String(),
+	)
+	// the original expression must not be modified
+	assert.Equal(
+		t,
+		"(div_txtype == 'fiction' AND (div_year <= '2000' OR div_medium == 'web'))",
+		join.String(),
+	)
+
+	for _, txtype := range []string{"fiction", "poetry"} {
+		for _, year := range []string{"1999", "2000", "2001"} {
+			for _, medium := range []string{"web", "book"} {
+				doc := map[string]string{"div_txtype": txtype, "div_year": year, "div_medium": medium}
+				assert.Equal(t, !evalExpr(t, join, doc), evalExpr(t, negated, doc), "doc: %v", doc)
+			}
+		}
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"frodo/common"
 	"math"
 	"strings"
+
+	"github.com/rs/zerolog/log"
 )
 
 type AbstractExpression interface {
@@ -205,43 +207,79 @@ func (ct *CategoryTree) computeSizes(node *CategoryTreeNode) error {
 //
 //	to this category
 func (ct *CategoryTree) getCategorySize(mc []AbstractExpression) (int, error) {
+	sqlq, args := ct.categorySizeSQL(mc)
+	row := ct.DB.QueryRow(sqlq, args...)
+	var csize int
+	err := row.Scan(&csize)
+	if err == sql.ErrNoRows {
+		return 0, nil
+
+	} else if err != nil {
+		return -1, err
+	}
+	return csize, nil
+}
+
+// categorySizeSQL creates an SQL query (along with its arguments)
+// for getCategorySize
+func (ct *CategoryTree) categorySizeSQL(mc []AbstractExpression) (string, []any) {
 	var sqle strings.Builder
 	sqle.WriteString(fmt.Sprintf(
 		"SELECT SUM(m1.poscount) FROM %s as m1", ct.TableName),
 	)
 	var args []any
-	ct.appendAlignedCorpSQL(sqle, &args)
-	whereSQL := []string{}
-	for _, subl := range mc {
-		for _, expr := range subl.GetAtoms() {
-			whereSQL = append(
-				whereSQL,
-				fmt.Sprintf("m1.%s %s ?", expr.Attr(), expr.OpSQL()),
-			)
-		}
-	}
+	ct.appendAlignedCorpSQL(&sqle, &args)
 	sqle.WriteString(
 		fmt.Sprintf(
 			" WHERE %s AND m1.corpus_id = ?",
-			strings.Join(whereSQL, " AND "),
+			conditionsToSQL(mc, &args),
 		),
 	)
-	for _, subl := range mc {
-		for _, expr := range subl.GetAtoms() {
-			args = append(args, expr.Value())
+	args = append(args, ct.CorpusID)
+	return sqle.String(), args
+}
+
+// conditionsToSQL renders a list of metadata conditions (which must
+// all be satisfied) into an SQL WHERE expression. The values are
+// appended to args in the same order as their placeholders.
+func conditionsToSQL(mc []AbstractExpression, args *[]any) string {
+	items := make([]string, 0, len(mc))
+	for _, expr := range mc {
+		if sqlExpr := expressionToSQL(expr, args); sqlExpr != "" {
+			items = append(items, sqlExpr)
 		}
 	}
-	args = append(args, ct.CorpusID)
-	row := ct.DB.QueryRow(sqle.String(), args...)
-	var csize int
-	err := row.Scan(&csize)
-	if err == sql.ErrNoRows {
-		return 0, nil
+	if len(items) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(items, " AND ")
+}
 
-	} else if err != nil {
-		return -1, err
+// expressionToSQL renders an expression tree into SQL, respecting
+// the operators of the composed expressions (e.g. a negated category
+// created by De Morgan's laws becomes an OR of negated atoms).
+func expressionToSQL(expr AbstractExpression, args *[]any) string {
+	switch tExpr := expr.(type) {
+	case *CategoryExpression:
+		*args = append(*args, tExpr.Value())
+		return fmt.Sprintf("m1.%s %s ?", tExpr.Attr(), tExpr.OpSQL())
+	case *ExpressionJoin:
+		items := make([]string, 0, len(tExpr.Items))
+		for _, item := range tExpr.Items {
+			if sqlItem := expressionToSQL(item, args); sqlItem != "" {
+				items = append(items, sqlItem)
+			}
+		}
+		if len(items) == 0 {
+			return ""
+		}
+		if len(items) == 1 {
+			return items[0]
+		}
+		return "(" + strings.Join(items, " "+tExpr.Op+" ") + ")"
 	}
-	return csize, nil
+	log.Debug().Msg("possibly invalid expression encoutered")
+	return ""
 }
 
 // appendAlignedCorpSQL adds one or more JOINs attaching
@@ -265,11 +303,11 @@ func (ct *CategoryTree) getCategorySize(mc []AbstractExpression) (int, error) {
 
 // returns:
 // a 2-tuple (extended SQL string, extended args list)
-func (ct *CategoryTree) appendAlignedCorpSQL(sqle strings.Builder, args *[]any) {
+func (ct *CategoryTree) appendAlignedCorpSQL(sqle *strings.Builder, args *[]any) {
 	for i, ac := range ct.AlignedCorpora {
 		sqle.WriteString(
 			fmt.Sprintf(
-				"JOIN %s AS %d ON m%d.item_id = m%d.item_id AND m%d.corpus_id = ?",
+				" JOIN %s AS m%d ON m%d.item_id = m%d.item_id AND m%d.corpus_id = ?",
 				ct.TableName, i+2, i+1, i+2, i+2,
 			),
 		)
@@ -277,14 +315,30 @@ func (ct *CategoryTree) appendAlignedCorpSQL(sqle strings.Builder, args *[]any)
 	}
 }
 
+// setUpperBounds copies the computed node sizes into
+// the nodes' bounds
+func (ct *CategoryTree) setUpperBounds(node *CategoryTreeNode) {
+	node.ComputedBounds.Upper = node.Size
+	for _, child := range node.Children {
+		ct.setUpperBounds(child)
+	}
+}
+
 func (ct *CategoryTree) initializeBounds() error {
 	for i := 1; i < len(ct.CategoryList); i++ {
 		node := ct.getNodeByID(ct.RootNode, i)
+		if node == nil {
+			return fmt.Errorf("failed to initialize bounds: category %d not found in the tree", i)
+		}
 		var err error
 		node.Size, err = ct.getCategorySize(node.MetadataCondition)
 		if err != nil {
 			return err
 		}
+		node.ComputedBounds = &NodeBounds{
+			Available:     node.Size,
+			ConstraintIdx: node.NodeID - 1,
+		}
 	}
 	var sqle strings.Builder
 	sqle.WriteString(
@@ -294,7 +348,7 @@ func (ct *CategoryTree) initializeBounds() error {
 		),
 	)
 	args := []any{}
-	ct.appendAlignedCorpSQL(sqle, &args)
+	ct.appendAlignedCorpSQL(&sqle, &args)
 	sqle.WriteString(" WHERE m1.corpus_id = ?")
 	args = append(args, ct.CorpusID)
 	row := ct.DB.QueryRow(sqle.String(), args...)
@@ -304,7 +358,14 @@ func (ct *CategoryTree) initializeBounds() error {
 		return fmt.Errorf("failed to initialize bounds: %s", err)
 	}
 	ct.RootNode.Size = common.Min(ct.CorpusMaxSize, maxAvailable)
-	ct.computeSizes(ct.RootNode)
+	ct.RootNode.ComputedBounds = &NodeBounds{
+		Available:     maxAvailable,
+		ConstraintIdx: -1,
+	}
+	if err := ct.computeSizes(ct.RootNode); err != nil {
+		return fmt.Errorf("failed to initialize bounds: %w", err)
+	}
+	ct.setUpperBounds(ct.RootNode)
 	return nil
 }
 
@@ -0,0 +1,283 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subcmixer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"frodo/common"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sizesDriver is a fake database driver answering category size
+// queries. Queries with the corpus ID as the only argument are
+// answered with the total size, others with the size of the value
+// provided as the first argument.
+type sizesDriver struct {
+	total int64
+	sizes map[string]int64
+}
+
+func (d *sizesDriver) Open(name string) (driver.Conn, error) {
+	return &sizesConn{d}, nil
+}
+
+func (d *sizesDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *sizesDriver) Driver() driver.Driver {
+	return d
+}
+
+type sizesConn struct {
+	drv *sizesDriver
+}
+
+func (c *sizesConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements not supported")
+}
+
+func (c *sizesConn) Close() error {
+	return nil
+}
+
+func (c *sizesConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c *sizesConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	size := c.drv.total
+	if len(args) > 1 {
+		size = c.drv.sizes[args[0].Value.(string)]
+	}
+	return &sizesRows{data: []driver.Value{size}}, nil
+}
+
+type sizesRows struct {
+	data []driver.Value
+	pos  int
+}
+
+func (r *sizesRows) Columns() []string {
+	return []string{"size"}
+}
+
+func (r *sizesRows) Close() error {
+	return nil
+}
+
+func (r *sizesRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	dest[0] = r.data[r.pos]
+	r.pos++
+	return nil
+}
+
+func mkTaskArgs(t *testing.T, nodeID, parentID int, ratio float64, attr, value string) TaskArgs {
+	expr, err := NewCategoryExpression(attr, "==", value)
+	assert.NoError(t, err)
+	return TaskArgs{
+		NodeID:     nodeID,
+		ParentID:   common.NewMaybe(parentID),
+		Ratio:      ratio,
+		Expression: expr,
+	}
+}
+
+func mkRootTaskArgs() TaskArgs {
+	return TaskArgs{
+		NodeID:     0,
+		ParentID:   common.NewEmptyMaybe[int](),
+		Ratio:      1,
+		Expression: &CategoryExpression{},
+	}
+}
+
+func TestCategoryTreeBuild(t *testing.T) {
+	ct := &CategoryTree{
+		CategoryList: []TaskArgs{
+			mkRootTaskArgs(),
+			mkTaskArgs(t, 1, 0, 0.7, "div.txtype", "fiction"),
+			mkTaskArgs(t, 2, 0, 0.3, "div.txtype", "journalism"),
+			mkTaskArgs(t, 3, 1, 0.5, "div.year", "2000"),
+			mkTaskArgs(t, 4, 1, 0.5, "div.year", "2001"),
+		},
+		RootNode: &CategoryTreeNode{
+			NodeID:            0,
+			ParentID:          common.NewEmptyMaybe[int](),
+			Ratio:             1,
+			MetadataCondition: []AbstractExpression{},
+		},
+	}
+	ct.addVirtualCats()
+	ct.build()
+
+	// a virtual category (= none of the siblings) is added for the nested level
+	assert.Equal(t, 6, ct.NumCategories())
+	virtual, ok := ct.CategoryList[5].Expression.(*ExpressionJoin)
+	assert.True(t, ok)
+	assert.Equal(t, "AND", virtual.Op)
+	assert.Len(t, virtual.Items, 2)
+
+	assert.Len(t, ct.RootNode.Children, 2)
+	fiction := ct.getNodeByID(ct.RootNode, 1)
+	assert.Len(t, fiction.Children, 3)
+	n3 := ct.getNodeByID(ct.RootNode, 3)
+	assert.Len(t, n3.MetadataCondition, 2)
+	assert.Equal(t, "div_year == '2000'", n3.MetadataCondition[0].(*CategoryExpression).String())
+	assert.Equal(t, "div_txtype == 'fiction'", n3.MetadataCondition[1].(*CategoryExpression).String())
+	assert.Nil(t, n3.ComputedBounds)
+	assert.Nil(t, ct.getNodeByID(ct.RootNode, 10))
+}
+
+func TestNewCategoryTreeBounds(t *testing.T) {
+	db := sql.OpenDB(&sizesDriver{
+		total: 1000,
+		sizes: map[string]int64{"fiction": 600, "journalism": 400},
+	})
+	defer db.Close()
+
+	ct, err := NewCategoryTree(
+		[]TaskArgs{
+			mkRootTaskArgs(),
+			mkTaskArgs(t, 1, 0, 0.7, "div.txtype", "fiction"),
+			mkTaskArgs(t, 2, 0, 0.3, "div.txtype", "journalism"),
+		},
+		db,
+		"syn2020",
+		[]string{},
+		"syn2020_liveattrs_entry",
+		500000,
+	)
+	assert.NoError(t, err)
+
+	// fiction is the limiting category: 600 / 0.7 ~ 857
+	assert.Equal(t, NodeBounds{Available: 1000, Upper: 857, ConstraintIdx: -1}, *ct.RootNode.ComputedBounds)
+	assert.False(t, ct.RootNode.ComputedBounds.HasConstraint())
+	fiction := ct.getNodeByID(ct.RootNode, 1)
+	assert.Equal(t, NodeBounds{Available: 600, Upper: 600, ConstraintIdx: 0}, *fiction.ComputedBounds)
+	journalism := ct.getNodeByID(ct.RootNode, 2)
+	assert.Equal(t, NodeBounds{Available: 400, Upper: 257, ConstraintIdx: 1}, *journalism.ComputedBounds)
+	assert.Contains(t, journalism.String(), "bounds: NodeBounds(available: 400, upper: 257, constraint: 1)")
+}
+
+func TestNewCategoryTreeCorpusMaxSize(t *testing.T) {
+	db := sql.OpenDB(&sizesDriver{
+		total: 1000,
+		sizes: map[string]int64{"fiction": 600, "journalism": 400},
+	})
+	defer db.Close()
+
+	ct, err := NewCategoryTree(
+		[]TaskArgs{
+			mkRootTaskArgs(),
+			mkTaskArgs(t, 1, 0, 0.5, "div.txtype", "fiction"),
+			mkTaskArgs(t, 2, 0, 0.5, "div.txtype", "journalism"),
+		},
+		db,
+		"syn2020",
+		[]string{},
+		"syn2020_liveattrs_entry",
+		200,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, ct.RootNode.ComputedBounds.Available)
+	assert.Equal(t, 200, ct.RootNode.ComputedBounds.Upper)
+	assert.Equal(t, 100, ct.getNodeByID(ct.RootNode, 1).ComputedBounds.Upper)
+	assert.Equal(t, 100, ct.getNodeByID(ct.RootNode, 2).ComputedBounds.Upper)
+}
+
+func TestCategorySizeSQLWithAlignedCorpus(t *testing.T) {
+	ct := &CategoryTree{
+		CorpusID:       "intercorp_v13_cs",
+		AlignedCorpora: []string{"intercorp_v13_en"},
+		TableName:      "intercorp_v13_liveattrs_entry",
+	}
+	expr, err := NewCategoryExpression("div.txtype", "==", "fiction")
+	assert.NoError(t, err)
+	sqlq, args := ct.categorySizeSQL([]AbstractExpression{expr})
+	assert.Equal(
+		t,
+		"SELECT SUM(m1.poscount) FROM intercorp_v13_liveattrs_entry as m1 "+
+			"JOIN intercorp_v13_liveattrs_entry AS m2 ON m1.item_id = m2.item_id AND m2.corpus_id = ? "+
+			"WHERE m1.div_txtype = ? AND m1.corpus_id = ?",
+		sqlq,
+	)
+	assert.Equal(t, []any{"intercorp_v13_en", "fiction", "intercorp_v13_cs"}, args)
+}
+
+func TestCategorySizeSQLNegatedComposite(t *testing.T) {
+	ct := &CategoryTree{
+		CorpusID:  "syn2020",
+		TableName: "syn2020_liveattrs_entry",
+	}
+	expr1, err := NewCategoryExpression("div.txtype", "==", "fiction")
+	assert.NoError(t, err)
+	expr2, err := NewCategoryExpression("doc.pubyear", "<=", "2000")
+	assert.NoError(t, err)
+	composite := &ExpressionJoin{Op: "AND"}
+	composite.Add(expr1)
+	composite.Add(expr2)
+	sqlq, args := ct.categorySizeSQL([]AbstractExpression{composite.Negate()})
+	assert.Equal(
+		t,
+		"SELECT SUM(m1.poscount) FROM syn2020_liveattrs_entry as m1 "+
+			"WHERE (m1.div_txtype <> ? OR m1.doc_pubyear > ?) AND m1.corpus_id = ?",
+		sqlq,
+	)
+	assert.Equal(t, []any{"fiction", "2000", "syn2020"}, args)
+}
+
+func TestCategorySizeSQLNestedComposite(t *testing.T) {
+	ct := &CategoryTree{
+		CorpusID:  "syn2020",
+		TableName: "syn2020_liveattrs_entry",
+	}
+	expr1, err := NewCategoryExpression("div.txtype", "==", "fiction")
+	assert.NoError(t, err)
+	expr2, err := NewCategoryExpression("div.txtype", "==", "poetry")
+	assert.NoError(t, err)
+	expr3, err := NewCategoryExpression("doc.lang", "==", "cs")
+	assert.NoError(t, err)
+	inner := &ExpressionJoin{Op: "OR"}
+	inner.Add(expr1)
+	inner.Add(expr2)
+	outer := &ExpressionJoin{Op: "AND"}
+	outer.Add(inner)
+	outer.Add(expr3)
+	sqlq, args := ct.categorySizeSQL([]AbstractExpression{outer.Negate()})
+	assert.Equal(
+		t,
+		"SELECT SUM(m1.poscount) FROM syn2020_liveattrs_entry as m1 "+
+			"WHERE ((m1.div_txtype <> ? AND m1.div_txtype <> ?) OR m1.doc_lang <> ?) "+
+			"AND m1.corpus_id = ?",
+		sqlq,
+	)
+	assert.Equal(t, []any{"fiction", "poetry", "cs", "syn2020"}, args)
+}
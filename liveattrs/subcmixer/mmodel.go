@@ -66,12 +66,10 @@ type MetadataModel struct {
 	a         [][]float64
 }
 
-func (mm *MetadataModel) getAllConditions(node *CategoryTreeNode) [][2]string {
-	sqlArgs := [][2]string{}
+func (mm *MetadataModel) getAllConditions(node *CategoryTreeNode) []AbstractAtomicExpression {
+	sqlArgs := []AbstractAtomicExpression{}
 	for _, subl := range node.MetadataCondition {
-		for _, mc := range subl.GetAtoms() {
-			sqlArgs = append(sqlArgs, [2]string{mc.Attr(), mc.Value()})
-		}
+		sqlArgs = append(sqlArgs, subl.GetAtoms()...)
 	}
 	for _, child := range node.Children {
 		sqlArgs = append(sqlArgs, mm.getAllConditions(child)...)
@@ -79,6 +77,19 @@ func (mm *MetadataModel) getAllConditions(node *CategoryTreeNode) [][2]string {
 	return sqlArgs
 }
 
+// getNodeConditionsSQL renders the metadata condition of each node
+// in the (sub)tree into SQL. Nodes without a condition are skipped.
+func (mm *MetadataModel) getNodeConditionsSQL(node *CategoryTreeNode, args *[]any) []string {
+	ans := []string{}
+	if len(node.MetadataCondition) > 0 {
+		ans = append(ans, "("+conditionsToSQL(node.MetadataCondition, args)+")")
+	}
+	for _, child := range node.Children {
+		ans = append(ans, mm.getNodeConditionsSQL(child, args)...)
+	}
+	return ans
+}
+
 // List all the texts matching main corpus. This will be the
 // base for the 'A' matrix in the optimization problem.
 // In case we work with aligned corpora we still want
@@ -93,13 +104,8 @@ func (mm *MetadataModel) getAllConditions(node *CategoryTreeNode) [][2]string {
 // result a record has a different index then in
 // all the records list).
 func (mm *MetadataModel) getTextSizes() ([]int, map[string]int, error) {
-	allCond := mm.getAllConditions(mm.cTree.RootNode)
-	allCondSQL := make([]string, len(allCond))
-	allCondArgsSQL := make([]any, len(allCond))
-	for i, v := range allCond {
-		allCondSQL[i] = fmt.Sprintf("%s = ?", v[0])
-		allCondArgsSQL[i] = v[1]
-	}
+	var allCondArgsSQL []any
+	allCondSQL := mm.getNodeConditionsSQL(mm.cTree.RootNode, &allCondArgsSQL)
 	var sqle strings.Builder
 	sqle.WriteString(fmt.Sprintf(
 		"SELECT MIN(m1.id) AS db_id, SUM(poscount) FROM %s AS m1 ",
@@ -159,33 +165,22 @@ func (mm *MetadataModel) PrintA(m [][]float64) {
 }
 
 func (mm *MetadataModel) initAB(node *CategoryTreeNode, usedIDs *collections.Set[string]) error {
-	if len(node.MetadataCondition) > 0 {
-		sqlItems := []string{}
-		for _, subl := range node.MetadataCondition {
-			for _, mc := range subl.GetAtoms() {
-				sqlItems = append(
-					sqlItems,
-					fmt.Sprintf("m1.%s %s ?", mc.Attr(), mc.OpSQL()),
-				)
-			}
-		}
+	if node.ComputedBounds == nil {
+		return fmt.Errorf("failed to initialize LP model: node %d has no computed bounds", node.NodeID)
+	}
+	if len(node.MetadataCondition) > 0 && node.ComputedBounds.HasConstraint() {
 		sqlArgs := []any{}
 		var sqle strings.Builder
 		sqle.WriteString(fmt.Sprintf(
 			"SELECT m1.id AS db_id, SUM(m1.poscount) FROM %s AS m1 ",
 			mm.tableName,
 		))
-		mm.cTree.appendAlignedCorpSQL(sqle, &sqlArgs)
+		mm.cTree.appendAlignedCorpSQL(&sqle, &sqlArgs)
 		sqle.WriteString(fmt.Sprintf(
-			"WHERE %s AND m1.corpus_id = ? GROUP BY %s ORDER BY db_id",
-			strings.Join(sqlItems, " AND "), utils.ImportKey(mm.idAttr),
+			" WHERE %s AND m1.corpus_id = ? GROUP BY %s ORDER BY db_id",
+			conditionsToSQL(node.MetadataCondition, &sqlArgs),
+			utils.ImportKey(mm.idAttr),
 		))
-		// mc.value for subl in node.metadata_condition for mc in subl
-		for _, subl := range node.MetadataCondition {
-			for _, mc := range subl.GetAtoms() {
-				sqlArgs = append(sqlArgs, mc.Value())
-			}
-		}
 		sqlArgs = append(sqlArgs, mm.cTree.CorpusID)
 		rows, err := mm.db.Query(sqle.String(), sqlArgs...)
 		if err != nil {
@@ -200,14 +195,14 @@ func (mm *MetadataModel) initAB(node *CategoryTreeNode, usedIDs *collections.Set
 				return err
 			}
 			mcf := float64(minCount)
-			mm.a[node.NodeID-1][mm.idMap[docID]] = mcf
+			mm.a[node.ComputedBounds.ConstraintIdx][mm.idMap[docID]] = mcf
 			usedIDs.Add(docID)
 		}
-		mm.b[node.NodeID-1] = float64(node.Size)
+		mm.b[node.ComputedBounds.ConstraintIdx] = float64(node.ComputedBounds.Upper)
 	}
-	if len(node.Children) > 0 {
-		for _, child := range node.Children {
-			mm.initAB(child, usedIDs)
+	for _, child := range node.Children {
+		if err := mm.initAB(child, usedIDs); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -334,13 +329,18 @@ func (mm *MetadataModel) Solve() *CorpusComposition {
 				if total > 0 {
 					ratio = v / total
 				}
+				op := allCond[i].OpSQL()
+				if op == "=" {
+					op = "=="
+				}
 				return CategorySize{
 					Total: int(v),
 					Ratio: ratio,
 					Expression: fmt.Sprintf(
-						"%s == '%s'",
-						utils.ExportKey(allCond[i][0]),
-						utils.ExportKey(allCond[i][1]),
+						"%s %s '%s'",
+						utils.ExportKey(allCond[i].Attr()),
+						op,
+						utils.ExportKey(allCond[i].Value()),
 					),
 				}
 			},
@@ -373,7 +373,9 @@ func NewMetadataModel(
 	for i := 0; i < len(ans.a); i++ {
 		ans.a[i] = make([]float64, ans.numTexts)
 	}
-	ans.initAB(cTree.RootNode, usedIDs)
+	if err := ans.initAB(cTree.RootNode, usedIDs); err != nil {
+		return nil, err
+	}
 	// for items without aligned counterparts we create
 	// conditions fulfillable only for x[i] = 0
 	ans.initABNonalign(usedIDs)
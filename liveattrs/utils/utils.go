@@ -17,7 +17,24 @@
 
 package utils
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrorAmbiguousAttr  = errors.New("ambiguous attribute")
+	ErrorUnknownAttr    = errors.New("unknown attribute")
+	ErrorNonNumericAttr = errors.New("non-numeric attribute")
+)
+
+var validAttrName = regexp.MustCompile(`^[a-zA-Z0-9_]+\.[a-zA-Z0-9_]+$`)
+
+// AlignedAttrSeparator separates an aligned corpus ID from
+// an attribute located in the aligned corpus (e.g. `intercorp_v16ud_en:doc.title`)
+const AlignedAttrSeparator = ":"
 
 func ShortenVal(v string, maxLength int) string {
 	if len(v) <= maxLength {
@@ -45,3 +62,67 @@ func ImportKey(k string) string {
 func ExportKey(k string) string {
 	return strings.Replace(k, "_", ".", 1)
 }
+
+// QualifyAttr makes sure the attribute is qualified by its structure
+// (e.g. `author` => `doc.author`). Already qualified attributes are returned
+// as they are. For unqualified ones, the function searches the provided
+// known (qualified) attributes and in case there is exactly one attribute
+// of the name, it is returned. Otherwise ErrorUnknownAttr or ErrorAmbiguousAttr
+// is returned. Attributes in the database column form (e.g. `doc_author`)
+// are accepted too. A possible negation prefix (`!`) is preserved.
+// Qualified attributes with characters not allowed in database
+// column names are reported as unknown.
+func QualifyAttr(attr string, known []string) (string, error) {
+	neg := strings.HasPrefix(attr, "!")
+	name := strings.TrimPrefix(attr, "!")
+	if strings.Contains(name, ".") {
+		if !IsValidAttrName(name) {
+			return "", fmt.Errorf("%w %s", ErrorUnknownAttr, name)
+		}
+		return attr, nil
+	}
+	var ans string
+	for _, k := range known {
+		// the attribute may be also in the "imported" (db column) form
+		if ImportKey(k) == name {
+			ans = k
+			break
+		}
+	}
+	if ans == "" {
+		for _, k := range known {
+			if strings.HasSuffix(k, "."+name) {
+				if ans != "" {
+					return "", fmt.Errorf(
+						"%w %s (matches both %s and %s)", ErrorAmbiguousAttr, name, ans, k)
+				}
+				ans = k
+			}
+		}
+	}
+	if ans == "" {
+		return "", fmt.Errorf("%w %s", ErrorUnknownAttr, name)
+	}
+	if neg {
+		return "!" + ans, nil
+	}
+	return ans, nil
+}
+
+// IsValidAttrName tests whether the attribute is in the `struct.attr`
+// form with both parts usable as a part of a database column name.
+// A possible negation prefix (`!`) is ignored.
+func IsValidAttrName(attr string) bool {
+	return validAttrName.MatchString(strings.TrimPrefix(attr, "!"))
+}
+
+// SplitAlignedAttr splits an attribute prefixed by an aligned corpus ID
+// (see AlignedAttrSeparator) into the corpus ID and the attribute.
+// For non-prefixed attributes, an empty corpus ID is returned.
+func SplitAlignedAttr(attr string) (string, string) {
+	corpusID, name, ok := strings.Cut(attr, AlignedAttrSeparator)
+	if !ok {
+		return "", attr
+	}
+	return corpusID, name
+}
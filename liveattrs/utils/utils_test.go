@@ -0,0 +1,78 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var overlappingAttrs = []string{"doc.author", "doc.title", "text.author", "text_block.id"}
+
+func TestQualifyAttrKeepsQualified(t *testing.T) {
+	v, err := QualifyAttr("text.author", overlappingAttrs)
+	assert.NoError(t, err)
+	assert.Equal(t, "text.author", v)
+	v, err = QualifyAttr("!doc.author", overlappingAttrs)
+	assert.NoError(t, err)
+	assert.Equal(t, "!doc.author", v)
+}
+
+func TestQualifyAttrUnique(t *testing.T) {
+	v, err := QualifyAttr("title", overlappingAttrs)
+	assert.NoError(t, err)
+	assert.Equal(t, "doc.title", v)
+	v, err = QualifyAttr("!title", overlappingAttrs)
+	assert.NoError(t, err)
+	assert.Equal(t, "!doc.title", v)
+}
+
+func TestQualifyAttrAmbiguous(t *testing.T) {
+	_, err := QualifyAttr("author", overlappingAttrs)
+	assert.ErrorIs(t, err, ErrorAmbiguousAttr)
+}
+
+func TestQualifyAttrUnknown(t *testing.T) {
+	_, err := QualifyAttr("publisher", overlappingAttrs)
+	assert.ErrorIs(t, err, ErrorUnknownAttr)
+}
+
+func TestQualifyAttrInvalidQualified(t *testing.T) {
+	_, err := QualifyAttr("doc.author) OR (1=1", overlappingAttrs)
+	assert.ErrorIs(t, err, ErrorUnknownAttr)
+	_, err = QualifyAttr("!doc.author;--", overlappingAttrs)
+	assert.ErrorIs(t, err, ErrorUnknownAttr)
+}
+
+func TestQualifyAttrColumnForm(t *testing.T) {
+	v, err := QualifyAttr("text_author", overlappingAttrs)
+	assert.NoError(t, err)
+	assert.Equal(t, "text.author", v)
+	v, err = QualifyAttr("text_block_id", overlappingAttrs)
+	assert.NoError(t, err)
+	assert.Equal(t, "text_block.id", v)
+}
+
+func TestSplitAlignedAttr(t *testing.T) {
+	corpusID, attr := SplitAlignedAttr("intercorp_v16ud_en:doc.title")
+	assert.Equal(t, "intercorp_v16ud_en", corpusID)
+	assert.Equal(t, "doc.title", attr)
+	corpusID, attr = SplitAlignedAttr("doc.title")
+	assert.Equal(t, "", corpusID)
+	assert.Equal(t, "doc.title", attr)
+}
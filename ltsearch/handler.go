@@ -19,6 +19,7 @@ package ltsearch
 import (
 	"errors"
 	"fmt"
+	"frodo/corpus"
 	"frodo/db/mysql"
 	"frodo/liveattrs/laconf"
 	"net/http"
@@ -37,9 +38,9 @@ type inputArgs struct {
 }
 
 type Actions struct {
-	db          *mysql.Adapter
-	laConfCache *laconf.LiveAttrsBuildConfProvider
-	registryDir string
+	db           *mysql.Adapter
+	laConfCache  *laconf.LiveAttrsBuildConfProvider
+	registryDirs []string
 }
 
 // Query provides the core "live tokens" functionality for filtering
@@ -187,7 +188,12 @@ func (a *Actions) AutoConf(ctx *gin.Context) {
 		profileConf[i].VertIdx = -1
 	}
 
-	if err := AutoConf(filepath.Join(a.registryDir, corpusID), profileConf); err != nil {
+	regPath, err := corpus.FindRegistryFile(a.registryDirs, corpusID)
+	if err != nil {
+		uniresp.RespondWithErrorJSON(ctx, err, http.StatusNotFound)
+		return
+	}
+	if err := AutoConf(regPath, profileConf); err != nil {
 		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
 		return
 	}
@@ -222,29 +228,41 @@ func (a *Actions) AutoConfWithProfile(ctx *gin.Context) {
 		uniresp.RespondWithErrorJSON(ctx, errors.New("profile not found"), http.StatusNotFound)
 		return
 	}
-	regFiles, err := os.ReadDir(a.registryDir)
-	if err != nil {
-		uniresp.RespondWithErrorJSON(ctx, errors.New("failed to read registry directory"), http.StatusInternalServerError)
-		return
+	// the same corpus may be present in multiple registry directories,
+	// in such case the first directory wins (see corpus.FindRegistryFile)
+	regPaths := make(map[string]string)
+	regNames := make([]string, 0, 60)
+	for _, regDir := range a.registryDirs {
+		regFiles, err := os.ReadDir(regDir)
+		if err != nil {
+			uniresp.RespondWithErrorJSON(
+				ctx, fmt.Errorf("failed to read registry directory %s", regDir), http.StatusInternalServerError)
+			return
+		}
+		for _, f := range regFiles {
+			if f.IsDir() || !strings.HasPrefix(f.Name(), profileID+"_") {
+				continue
+			}
+			if _, ok := regPaths[f.Name()]; !ok {
+				regPaths[f.Name()] = filepath.Join(regDir, f.Name())
+				regNames = append(regNames, f.Name())
+			}
+		}
 	}
 
 	var resp acwpResponse
-	resp.Items = make([]acwp, 0, 60)
-	for _, f := range regFiles {
-		if !strings.HasPrefix(f.Name(), profileID+"_") {
-			continue
-		}
+	resp.Items = make([]acwp, 0, len(regNames))
+	for _, regName := range regNames {
 		pConfNew := make(livetokens.AttrList, len(pConf))
 		copy(pConfNew, pConf)
 
-		status := acwp{Corpus: f.Name()}
-		vteConf, err := a.laConfCache.Get(f.Name())
+		status := acwp{Corpus: regName}
+		vteConf, err := a.laConfCache.Get(regName)
 		if err != nil {
 			status.Error = err.Error()
 
 		} else {
-			regPath := filepath.Join(a.registryDir, f.Name())
-			if err := AutoConf(regPath, pConfNew); err != nil {
+			if err := AutoConf(regPaths[regName], pConfNew); err != nil {
 				status.Error = err.Error()
 
 			} else {
@@ -263,10 +281,10 @@ func (a *Actions) AutoConfWithProfile(ctx *gin.Context) {
 }
 
 // NewActions creates an http action handler for the "live tokens" endpoint.
-func NewActions(db *mysql.Adapter, laConfCache *laconf.LiveAttrsBuildConfProvider, registryDir string) *Actions {
+func NewActions(db *mysql.Adapter, laConfCache *laconf.LiveAttrsBuildConfProvider, registryDirs []string) *Actions {
 	return &Actions{
-		db:          db,
-		laConfCache: laConfCache,
-		registryDir: registryDir,
+		db:           db,
+		laConfCache:  laConfCache,
+		registryDirs: registryDirs,
 	}
 }
@@ -27,8 +27,60 @@ var (
 	NotFoundMsgPlaceholder = "??"
 )
 
+// Placeholders available in notification body templates
+const (
+	PlaceholderSubject   = "{subject}"
+	PlaceholderJobType   = "{jobType}"
+	PlaceholderJobID     = "{jobId}"
+	PlaceholderStatus    = "{status}"
+	PlaceholderLink      = "{link}"
+	PlaceholderSignature = "{signature}"
+)
+
 type EmailNotification struct {
 	cncmail.NotificationConf
+
+	// BodyTemplate defines multi-language notification body where each
+	// item represents a paragraph. Paragraphs may contain placeholders
+	// (e.g. {jobId}, see Placeholder* constants).
+	BodyTemplate map[string][]string `json:"bodyTemplate"`
+
+	// JobLinkBaseURL is a public URL of the service used to create
+	// the {link} placeholder value. If empty, the placeholder is
+	// replaced with an empty string.
+	JobLinkBaseURL string `json:"jobLinkBaseURL"`
+}
+
+// LocalizedBodyTemplate returns a notification body template based on
+// configuration and provided language (with the same matching rules as
+// in LocalizedSignature). The second returned value is false in case
+// no matching template is configured.
+func (enConf EmailNotification) LocalizedBodyTemplate(lang string) ([]string, bool) {
+	if tpl, ok := enConf.BodyTemplate[lang]; ok {
+		return tpl, true
+	}
+	lang2 := strings.Split(lang, "-")[0]
+	for k, tpl := range enConf.BodyTemplate {
+		if strings.Split(k, "-")[0] == lang2 {
+			return tpl, true
+		}
+	}
+	return nil, false
+}
+
+// RenderParagraphs replaces placeholders in all the template paragraphs
+// with the provided values. Unknown placeholders are kept as they are.
+func RenderParagraphs(tpl []string, values map[string]string) []string {
+	repl := make([]string, 0, 2*len(values))
+	for k, v := range values {
+		repl = append(repl, k, v)
+	}
+	replacer := strings.NewReplacer(repl...)
+	ans := make([]string, len(tpl))
+	for i, p := range tpl {
+		ans[i] = replacer.Replace(p)
+	}
+	return ans
 }
 
 // LocalizedSignature returns a mail signature based on configuration
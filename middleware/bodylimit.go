@@ -0,0 +1,65 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// MaxRequestBodySize limits size of request bodies to maxBytes.
+// Reading more data from the body produces *http.MaxBytesError
+// (see RequestBodyErrorStatus). For a non-positive maxBytes,
+// the function returns nil (= no limit).
+func MaxRequestBodySize(maxBytes int64) gin.HandlerFunc {
+	if maxBytes <= 0 {
+		return nil
+	}
+	return func(ctx *gin.Context) {
+		if ctx.Request.ContentLength > maxBytes {
+			uniresp.RespondWithErrorJSON(
+				ctx,
+				fmt.Errorf("request body too large (limit: %d bytes)", maxBytes),
+				http.StatusRequestEntityTooLarge,
+			)
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes)
+		ctx.Next()
+	}
+}
+
+// IsRequestBodyTooLarge tests whether the error has been caused
+// by exceeding the limit set by MaxRequestBodySize
+func IsRequestBodyTooLarge(err error) bool {
+	var mbErr *http.MaxBytesError
+	return errors.As(err, &mbErr)
+}
+
+// RequestBodyErrorStatus returns a proper HTTP status for an error
+// encountered while reading/decoding request body.
+func RequestBodyErrorStatus(err error) int {
+	if IsRequestBodyTooLarge(err) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	errFormatJSON = "json"
+	errFormatText = "text"
+	errFormatHTML = "html"
+)
+
+// errResponse covers both the single error and the multi-error
+// JSON responses as written by the uniresp package
+type errResponse struct {
+	Code    int      `json:"code"`
+	Error   *string  `json:"error"`
+	Details []string `json:"details"`
+	Errors  []string `json:"errors"`
+}
+
+func (er errResponse) messages() []string {
+	ans := make([]string, 0, len(er.Errors)+1)
+	if er.Error != nil {
+		ans = append(ans, *er.Error)
+	}
+	return append(ans, er.Errors...)
+}
+
+// negotiateErrFormat selects the best supported error format based
+// on the Accept header. In case of no match or equal preference,
+// JSON is used.
+func negotiateErrFormat(accept string) string {
+	ans := errFormatJSON
+	bestQ := 0.0
+	for _, item := range strings.Split(accept, ",") {
+		parts := strings.Split(item, ";")
+		q := 1.0
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		var format string
+		switch strings.TrimSpace(parts[0]) {
+		case "application/json":
+			format = errFormatJSON
+		case "text/plain":
+			format = errFormatText
+		case "text/html":
+			format = errFormatHTML
+		default:
+			continue
+		}
+		if q > bestQ || q == bestQ && format == errFormatJSON {
+			ans = format
+			bestQ = q
+		}
+	}
+	return ans
+}
+
+// negotiatingWriter buffers error responses (status >= 400)
+// so they can be re-rendered in a different format
+type negotiatingWriter struct {
+	gin.ResponseWriter
+	status       int
+	intercepting bool
+	buff         bytes.Buffer
+}
+
+func (w *negotiatingWriter) WriteHeader(code int) {
+	if code >= http.StatusBadRequest {
+		w.intercepting = true
+		w.status = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *negotiatingWriter) Write(data []byte) (int, error) {
+	if w.intercepting {
+		return w.buff.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *negotiatingWriter) WriteString(s string) (int, error) {
+	if w.intercepting {
+		return w.buff.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *negotiatingWriter) flush(format string) {
+	var resp errResponse
+	if err := json.Unmarshal(w.buff.Bytes(), &resp); err != nil {
+		msg := strings.TrimSpace(w.buff.String())
+		resp = errResponse{Error: &msg}
+	}
+	if resp.Code == 0 {
+		resp.Code = w.status
+	}
+	var out bytes.Buffer
+	switch format {
+	case errFormatText:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(&out, "Error %d (%s)\n", resp.Code, http.StatusText(resp.Code))
+		for _, msg := range resp.messages() {
+			fmt.Fprintf(&out, "\n%s\n", msg)
+		}
+		for _, d := range resp.Details {
+			fmt.Fprintf(&out, "  - %s\n", d)
+		}
+	case errFormatHTML:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		title := fmt.Sprintf("Error %d (%s)", resp.Code, http.StatusText(resp.Code))
+		fmt.Fprintf(
+			&out,
+			"<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>%s</title></head>\n<body>\n<h1>%s</h1>\n",
+			html.EscapeString(title), html.EscapeString(title),
+		)
+		for _, msg := range resp.messages() {
+			fmt.Fprintf(&out, "<p>%s</p>\n", html.EscapeString(msg))
+		}
+		if len(resp.Details) > 0 {
+			out.WriteString("<ul>\n")
+			for _, d := range resp.Details {
+				fmt.Fprintf(&out, "<li>%s</li>\n", html.EscapeString(d))
+			}
+			out.WriteString("</ul>\n")
+		}
+		out.WriteString("</body>\n</html>\n")
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(out.Bytes())
+}
+
+// ErrorContentNegotiation is a middleware which renders error
+// responses (originally written as JSON) as plain text or as a simple
+// HTML page in case the client prefers such formats via the Accept
+// header. JSON remains the default format. The middleware must
+// be registered after any middleware setting the content type.
+func ErrorContentNegotiation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := negotiateErrFormat(c.GetHeader("Accept"))
+		if format == errFormatJSON {
+			c.Next()
+			return
+		}
+		w := &negotiatingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		if w.intercepting {
+			w.flush(format)
+		}
+	}
+}
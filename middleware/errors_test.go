@@ -0,0 +1,98 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateErrFormat(t *testing.T) {
+	assert.Equal(t, errFormatJSON, negotiateErrFormat(""))
+	assert.Equal(t, errFormatJSON, negotiateErrFormat("*/*"))
+	assert.Equal(t, errFormatJSON, negotiateErrFormat("application/json"))
+	assert.Equal(t, errFormatText, negotiateErrFormat("text/plain"))
+	assert.Equal(t, errFormatHTML, negotiateErrFormat("text/html,application/xhtml+xml,*/*;q=0.8"))
+	assert.Equal(t, errFormatText, negotiateErrFormat("application/json;q=0.5, text/plain"))
+	assert.Equal(t, errFormatJSON, negotiateErrFormat("text/html;q=0.9, application/json;q=0.9"))
+	assert.Equal(t, errFormatJSON, negotiateErrFormat("image/png"))
+}
+
+func newErrorNegotiationTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ErrorContentNegotiation())
+	engine.GET("/fail", func(ctx *gin.Context) {
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionErrorFrom(errors.New("corpus <syn2020> not found")),
+			http.StatusNotFound,
+		)
+	})
+	engine.GET("/ok", func(ctx *gin.Context) {
+		uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+	})
+	return engine
+}
+
+func serveWithAccept(engine *gin.Engine, path, accept string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestErrorContentNegotiationJSON(t *testing.T) {
+	engine := newErrorNegotiationTestEngine()
+	w := serveWithAccept(engine, "/fail", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"code": 404, "error": "corpus <syn2020> not found", "details": null}`, w.Body.String())
+}
+
+func TestErrorContentNegotiationText(t *testing.T) {
+	engine := newErrorNegotiationTestEngine()
+	w := serveWithAccept(engine, "/fail", "text/plain")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Error 404 (Not Found)\n\ncorpus <syn2020> not found\n", w.Body.String())
+}
+
+func TestErrorContentNegotiationHTML(t *testing.T) {
+	engine := newErrorNegotiationTestEngine()
+	w := serveWithAccept(engine, "/fail", "text/html")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<title>Error 404 (Not Found)</title>")
+	assert.Contains(t, w.Body.String(), "<p>corpus &lt;syn2020&gt; not found</p>")
+}
+
+func TestErrorContentNegotiationKeepsSuccess(t *testing.T) {
+	engine := newErrorNegotiationTestEngine()
+	w := serveWithAccept(engine, "/ok", "text/html")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"ok": true}`, w.Body.String())
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	NDJSONContentType = "application/x-ndjson"
+)
+
+// WantsNDJSON tests whether the client requested a response
+// as a stream of newline-delimited JSON objects (either via
+// the `Accept` header or via the `format=ndjson` URL argument).
+func WantsNDJSON(ctx *gin.Context) bool {
+	if ctx.Query("format") == "ndjson" {
+		return true
+	}
+	return strings.Contains(ctx.GetHeader("Accept"), NDJSONContentType)
+}
+
+// DisableWriteDeadline removes the server's write timeout for
+// the current response. This is required for streamed responses
+// which may take much longer than a regular request.
+func DisableWriteDeadline(ctx *gin.Context) {
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn().Err(err).Str("path", ctx.Request.URL.Path).Msg("failed to disable write deadline")
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamPastWriteDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/stream", func(ctx *gin.Context) {
+		ctx.Writer.Header().Set("Content-Type", NDJSONContentType)
+		DisableWriteDeadline(ctx)
+		ctx.Writer.WriteHeader(http.StatusOK)
+		enc := json.NewEncoder(ctx.Writer)
+		for i := 0; i < 6; i++ {
+			time.Sleep(100 * time.Millisecond)
+			assert.NoError(t, enc.Encode(map[string]int{"row": i}))
+			ctx.Writer.Flush()
+		}
+	})
+	srv := httptest.NewUnstartedServer(engine)
+	srv.Config.WriteTimeout = 300 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/stream")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(body)), "\n"), 6)
+}
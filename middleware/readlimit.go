@@ -0,0 +1,55 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// ConcurrencyLimit limits the number of concurrently processed requests
+// to maxConcurrent. Requests exceeding the limit are not queued - they
+// are immediately rejected with 503 and the `Retry-After` header set
+// to retryAfterSecs. This is intended for synchronous read actions
+// so that traffic spikes cannot exhaust database connections.
+// All the handlers using the returned middleware share the limit.
+// For a non-positive maxConcurrent, the function returns nil (= no limit).
+func ConcurrencyLimit(maxConcurrent int, retryAfterSecs int) gin.HandlerFunc {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	sem := make(chan struct{}, maxConcurrent)
+	return func(ctx *gin.Context) {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+			ctx.Next()
+		default:
+			ctx.Header("Retry-After", strconv.Itoa(retryAfterSecs))
+			uniresp.RespondWithErrorJSON(
+				ctx,
+				fmt.Errorf("too many concurrent requests (limit: %d), please try again later", maxConcurrent),
+				http.StatusServiceUnavailable,
+			)
+			ctx.Abort()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimitRejectsWhenSaturated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	engine.GET("/slow", ConcurrencyLimit(1, 3), func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.Status(http.StatusOK)
+	})
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		done <- w.Code
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Retry-After"))
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+}
+
+func TestConcurrencyLimitDisabled(t *testing.T) {
+	assert.Nil(t, ConcurrencyLimit(0, 1))
+}
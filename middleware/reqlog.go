@@ -0,0 +1,185 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dfltMaxLoggedBodySize = 4096
+	redactedValue         = "*****"
+)
+
+var (
+	dfltRedactedKeys = []string{"password", "passwd", "pass", "secret", "token", "apiKey", "auth"}
+)
+
+// RequestLoggingConf configures the optional request/response
+// logging middleware. The logging is off by default.
+type RequestLoggingConf struct {
+	Enabled bool `json:"enabled"`
+
+	// LogBodies enables logging of (truncated) request and response
+	// bodies.
+	LogBodies bool `json:"logBodies"`
+
+	// MaxBodySize specifies a max. number of bytes logged for
+	// each of request and response bodies
+	MaxBodySize int `json:"maxBodySize"`
+
+	// RedactedKeys is a list of JSON keys and URL query arguments
+	// whose values are replaced with asterisks in logs. If not
+	// specified, a default list of typical credentials-related keys
+	// is used.
+	RedactedKeys []string `json:"redactedKeys"`
+}
+
+// boundedBuffer stores at most `limit` bytes and remembers whether
+// there was more data written
+type boundedBuffer struct {
+	buff      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (bb *boundedBuffer) Write(data []byte) (int, error) {
+	rest := bb.limit - bb.buff.Len()
+	if rest < len(data) {
+		bb.truncated = true
+		if rest > 0 {
+			bb.buff.Write(data[:rest])
+		}
+		return len(data), nil
+	}
+	return bb.buff.Write(data)
+}
+
+func (bb *boundedBuffer) String() string {
+	if bb.truncated {
+		return bb.buff.String() + "...[truncated]"
+	}
+	return bb.buff.String()
+}
+
+type loggingWriter struct {
+	gin.ResponseWriter
+	body *boundedBuffer
+}
+
+func (w *loggingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *loggingWriter) WriteString(s string) (int, error) {
+	w.body.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// teeReadCloser copies everything read from the original body
+// to a bounded buffer
+type teeReadCloser struct {
+	io.Reader
+	orig io.Closer
+}
+
+func (t *teeReadCloser) Close() error {
+	return t.orig.Close()
+}
+
+// redactor replaces values of sensitive keys in JSON data
+// and URL query strings
+type redactor struct {
+	jsonRegexp  *regexp.Regexp
+	queryRegexp *regexp.Regexp
+}
+
+func (r *redactor) apply(s string) string {
+	s = r.jsonRegexp.ReplaceAllString(s, fmt.Sprintf(`${1}"%s"`, redactedValue))
+	return r.queryRegexp.ReplaceAllString(s, "${1}"+redactedValue)
+}
+
+func newRedactor(keys []string) *redactor {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = regexp.QuoteMeta(k)
+	}
+	alt := strings.Join(quoted, "|")
+	return &redactor{
+		jsonRegexp:  regexp.MustCompile(`(?i)("(?:` + alt + `)"\s*:\s*)"(?:[^"\\]|\\.)*"?`),
+		queryRegexp: regexp.MustCompile(`(?i)((?:^|&)(?:` + alt + `)=)[^&]*`),
+	}
+}
+
+// RequestLogging creates a middleware logging (at the debug level)
+// method, path, status and duration of each request and optionally
+// also request and response bodies. In case the logging is disabled,
+// nil is returned.
+func RequestLogging(conf *RequestLoggingConf) gin.HandlerFunc {
+	if conf == nil || !conf.Enabled {
+		return nil
+	}
+	maxBodySize := conf.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = dfltMaxLoggedBodySize
+	}
+	redactedKeys := conf.RedactedKeys
+	if len(redactedKeys) == 0 {
+		redactedKeys = dfltRedactedKeys
+	}
+	red := newRedactor(redactedKeys)
+
+	return func(c *gin.Context) {
+		t0 := time.Now()
+		var reqBody, respBody *boundedBuffer
+		if conf.LogBodies {
+			reqBody = &boundedBuffer{limit: maxBodySize}
+			if c.Request.Body != nil {
+				c.Request.Body = &teeReadCloser{
+					Reader: io.TeeReader(c.Request.Body, reqBody),
+					orig:   c.Request.Body,
+				}
+			}
+			respBody = &boundedBuffer{limit: maxBodySize}
+			c.Writer = &loggingWriter{ResponseWriter: c.Writer, body: respBody}
+		}
+
+		c.Next()
+
+		evt := log.Debug().
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Str("query", red.apply(c.Request.URL.RawQuery)).
+			Int("status", c.Writer.Status()).
+			Float64("durationSecs", time.Since(t0).Seconds())
+		if conf.LogBodies {
+			evt.
+				Str("requestBody", red.apply(reqBody.String())).
+				Str("responseBody", red.apply(respBody.String()))
+		}
+		evt.Msg("request processed")
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoundedBuffer(t *testing.T) {
+	bb := &boundedBuffer{limit: 5}
+	n, err := bb.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "abc", bb.String())
+	n, err = bb.Write([]byte("defgh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, "abcde...[truncated]", bb.String())
+}
+
+func TestRedactor(t *testing.T) {
+	red := newRedactor(dfltRedactedKeys)
+	assert.Equal(
+		t,
+		`{"user": "joe", "password": "*****", "Token":"*****"}`,
+		red.apply(`{"user": "joe", "password": "se\"cr3t", "Token":"abc"}`),
+	)
+	assert.Equal(t, "corpus=syn2020&apiKey=*****&passes=2", red.apply("corpus=syn2020&apiKey=xyz&passes=2"))
+}
+
+func TestRequestLoggingDisabled(t *testing.T) {
+	assert.Nil(t, RequestLogging(nil))
+	assert.Nil(t, RequestLogging(&RequestLoggingConf{}))
+}
+
+// captureLog redirects the global logger to the returned buffer
+// for the rest of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buff bytes.Buffer
+	orig := log.Logger
+	log.Logger = zerolog.New(&buff).Level(zerolog.DebugLevel)
+	t.Cleanup(func() { log.Logger = orig })
+	return &buff
+}
+
+func TestRequestLoggingBodies(t *testing.T) {
+	logged := captureLog(t)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RequestLogging(&RequestLoggingConf{Enabled: true, LogBodies: true, MaxBodySize: 40}))
+	engine.POST("/jobs", func(ctx *gin.Context) {
+		body, err := io.ReadAll(ctx.Request.Body)
+		assert.NoError(t, err)
+		// the handler must still see the whole body
+		assert.Equal(t, `{"secret": "abc", "args": "`+strings.Repeat("x", 50)+`"}`, string(body))
+		ctx.String(http.StatusAccepted, "created")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(
+		w,
+		httptest.NewRequest(
+			http.MethodPost,
+			"/jobs?token=abc&force=1",
+			strings.NewReader(`{"secret": "abc", "args": "`+strings.Repeat("x", 50)+`"}`),
+		),
+	)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "created", w.Body.String())
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(logged.Bytes(), &entry))
+	assert.Equal(t, "debug", entry["level"])
+	assert.Equal(t, "POST", entry["method"])
+	assert.Equal(t, "/jobs", entry["path"])
+	assert.Equal(t, "token=*****&force=1", entry["query"])
+	assert.Equal(t, float64(http.StatusAccepted), entry["status"])
+	assert.Equal(t, `{"secret": "*****", "args": "`+strings.Repeat("x", 13)+`...[truncated]`, entry["requestBody"])
+	assert.Equal(t, "created", entry["responseBody"])
+}
+
+func TestRequestLoggingWithoutBodies(t *testing.T) {
+	logged := captureLog(t)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RequestLogging(&RequestLoggingConf{Enabled: true}))
+	engine.GET("/jobs", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "[]")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(logged.Bytes(), &entry))
+	assert.Equal(t, float64(http.StatusOK), entry["status"])
+	assert.NotContains(t, entry, "requestBody")
+	assert.NotContains(t, entry, "responseBody")
+}
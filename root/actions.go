@@ -18,6 +18,7 @@
 package root
 
 import (
+	"database/sql"
 	"encoding/json"
 	"frodo/cnf"
 	"frodo/general"
@@ -31,6 +32,12 @@ import (
 type Actions struct {
 	Version general.VersionInfo
 	Conf    *cnf.Conf
+
+	// LADB, CNCDB and Jobs are checked by the Health action.
+	// A nil value means the respective check is skipped.
+	LADB  *sql.DB
+	CNCDB *sql.DB
+	Jobs  JobScheduler
 }
 
 // RootAction is just an information action about the service
@@ -0,0 +1,107 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	healthCheckTimeout = 3 * time.Second
+)
+
+// JobScheduler provides liveness information about
+// the job scheduler (see jobs.Actions)
+type JobScheduler interface {
+	SchedulerAlive() (bool, time.Time)
+}
+
+// HealthCheck is a result of a single readiness check
+type HealthCheck struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+// HealthResponse contains results of all the readiness checks
+type HealthResponse struct {
+	OK     bool                   `json:"ok"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+func pingDB(ctx context.Context, db *sql.DB) HealthCheck {
+	if db == nil {
+		return HealthCheck{OK: true, Skipped: true}
+	}
+	pctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	if err := db.PingContext(pctx); err != nil {
+		return HealthCheck{Error: err.Error()}
+	}
+	return HealthCheck{OK: true}
+}
+
+func checkJobScheduler(scheduler JobScheduler) HealthCheck {
+	if scheduler == nil {
+		return HealthCheck{OK: true, Skipped: true}
+	}
+	alive, lastBeat := scheduler.SchedulerAlive()
+	if !alive {
+		if lastBeat.IsZero() {
+			return HealthCheck{Error: "job scheduler has not started"}
+		}
+		return HealthCheck{
+			Error: fmt.Sprintf("job scheduler inactive since %s", lastBeat.Format(time.RFC3339)),
+		}
+	}
+	return HealthCheck{OK: true}
+}
+
+// Health godoc
+// @Summary      Readiness probe
+// @Description  Check availability of the liveattrs database, the CNC database (if configured) and the job scheduler. Unlike `/jobs/utilization`, the action fails with 503 once any of the checks fails.
+// @Produce      json
+// @Success      200 {object} HealthResponse
+// @Failure      503 {object} HealthResponse
+// @Router       /health [get]
+func (a *Actions) Health(ctx *gin.Context) {
+	ans := HealthResponse{
+		OK: true,
+		Checks: map[string]HealthCheck{
+			"laDB":         pingDB(ctx.Request.Context(), a.LADB),
+			"cncDB":        pingDB(ctx.Request.Context(), a.CNCDB),
+			"jobScheduler": checkJobScheduler(a.Jobs),
+		},
+	}
+	for _, check := range ans.Checks {
+		if !check.OK {
+			ans.OK = false
+		}
+	}
+	if !ans.OK {
+		uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusServiceUnavailable, ans)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
@@ -39,38 +39,50 @@ func init() {
 }
 
 var messageKeyToIndex = map[string]int{
+	"%d jobs finished":                               12,
 	"Job ID: %s":                                     1,
-	"Job finished with error: %s":                    7,
-	"Job finished without errors":                    6,
+	"Job cancelled":                                  10,
+	"Job finished with error: %s":                    11,
+	"Job finished without errors":                    9,
 	"Job of type \"%s\" finished":                    0,
 	"Live attributes data extraction and generation": 3,
+	"Live attributes data incremental update":        4,
+	"Live attributes data integrity validation":      5,
+	"Live attributes tables reindexing":              6,
 	"N-grams and query suggestion data generation":   2,
-	"Testing and debugging empty job":                4,
-	"Unknown job":                                    5,
+	"Testing and debugging empty job":                7,
+	"Unknown job":                                    8,
 }
 
-var csIndex = []uint32{ // 9 elements
+var csIndex = []uint32{ // 14 elements
 	0x00000000, 0x00000024, 0x00000035, 0x00000063,
-	0x0000008a, 0x000000b1, 0x000000c2, 0x000000dc,
-	0x000000fd,
-} // Size: 60 bytes
+	0x0000008a, 0x000000be, 0x000000e9, 0x00000116,
+	0x0000013d, 0x0000014e, 0x00000168, 0x0000017d,
+	0x0000019e, 0x000001c0,
+} // Size: 80 bytes
 
-const csData string = "" + // Size: 253 bytes
+const csData string = "" + // Size: 448 bytes
 	"\x02Úloha typu \x22%[1]s\x22 byla dokončena\x02ID úlohy: %[1]s\x02Genero" +
 	"vání n-gramů a dat pro našeptávač\x02vygenerování dat pro Live attribute" +
+	"s\x02Inkrementální aktualizace dat pro Live attributes\x02Kontrola integ" +
+	"rity dat pro Live attributes\x02Přeindexování tabulek pro Live attribute" +
 	"s\x02Prázdný testovací a debugovací job\x02Neznámá úloha\x02Úloha skonči" +
-	"la bez chyb\x02Úloha skončila s chybou: %[1]s"
+	"la bez chyb\x02Úloha byla zrušena\x02Úloha skončila s chybou: %[1]s\x02P" +
+	"očet dokončených úloh: %[1]d"
 
-var enIndex = []uint32{ // 9 elements
+var enIndex = []uint32{ // 14 elements
 	0x00000000, 0x0000001d, 0x0000002b, 0x00000058,
-	0x00000087, 0x000000a7, 0x000000b3, 0x000000cf,
-	0x000000ee,
-} // Size: 60 bytes
+	0x00000087, 0x000000af, 0x000000d9, 0x000000fb,
+	0x0000011b, 0x00000127, 0x00000143, 0x00000151,
+	0x00000170, 0x00000184,
+} // Size: 80 bytes
 
-const enData string = "" + // Size: 238 bytes
+const enData string = "" + // Size: 388 bytes
 	"\x02Job of type \x22%[1]s\x22 finished\x02Job ID: %[1]s\x02N-grams and q" +
 	"uery suggestion data generation\x02Live attributes data extraction and g" +
-	"eneration\x02Testing and debugging empty job\x02Unknown job\x02Job finis" +
-	"hed without errors\x02Job finished with error: %[1]s"
+	"eneration\x02Live attributes data incremental update\x02Live attributes " +
+	"data integrity validation\x02Live attributes tables reindexing\x02Testin" +
+	"g and debugging empty job\x02Unknown job\x02Job finished without errors\x02" +
+	"Job cancelled\x02Job finished with error: %[1]s\x02%[1]d jobs finished"
 
-	// Total table size 611 bytes (0KiB); checksum: 1EE06547
+	// Total table size 996 bytes (0KiB); checksum: 2BB1D5DA